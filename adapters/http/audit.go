@@ -0,0 +1,68 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	"github.com/ucladevx/BPool/interfaces"
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+type (
+	// AuditService handles the use cases for audit events
+	AuditService interface {
+		GetForEntity(entity, entityID string, page pagination.Page, user *auth.UserClaims) ([]*models.AuditEvent, *pagination.Cursor, error)
+	}
+
+	// AuditController is the controller for browsing audit events
+	AuditController struct {
+		service AuditService
+		cursors *pagination.Codec
+		logger  interfaces.Logger
+	}
+)
+
+// NewAuditController creates a new audit controller
+func NewAuditController(a AuditService, cursors *pagination.Codec, l interfaces.Logger) *AuditController {
+	return &AuditController{
+		service: a,
+		cursors: cursors,
+		logger:  l,
+	}
+}
+
+// MountRoutes mounts the audit routes, they are only for admins
+func (a *AuditController) MountRoutes(c *echo.Group) {
+	c.Use(auth.NewAuthMiddleware(services.AdminLevel, a.logger))
+	c.GET("/audit", a.list)
+}
+
+func (a *AuditController) list(c echo.Context) error {
+	entity := c.QueryParam("entity")
+	id := c.QueryParam("id")
+
+	if entity == "" || id == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "entity and id are required")
+	}
+
+	page, err := pageParams(c, a.cursors)
+	if err != nil {
+		return err
+	}
+
+	user := userClaimsFromContext(c)
+
+	events, next, err := a.service.GetForEntity(entity, id, page, user)
+	if err != nil {
+		if err == services.ErrUnknownAuditEntity {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		return listError(err)
+	}
+
+	return listPage(c, a.cursors, events, next)
+}
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	"github.com/ucladevx/BPool/interfaces"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/cache"
+)
+
+type (
+	// CacheStats reports the hits and misses of the store caches by name
+	CacheStats interface {
+		Stats() map[string]cache.Stats
+	}
+
+	// CacheController is the controller for monitoring the store caches
+	CacheController struct {
+		caches CacheStats
+		logger interfaces.Logger
+	}
+)
+
+// NewCacheController creates a new cache controller
+func NewCacheController(caches CacheStats, l interfaces.Logger) *CacheController {
+	return &CacheController{
+		caches: caches,
+		logger: l,
+	}
+}
+
+// MountRoutes mounts the cache routes, they are only for admins
+func (cc *CacheController) MountRoutes(c *echo.Group) {
+	c.Use(auth.NewAuthMiddleware(services.AdminLevel, cc.logger))
+	c.GET("/cache/stats", cc.stats)
+}
+
+func (cc *CacheController) stats(c echo.Context) error {
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": cc.caches.Stats(),
+	})
+}
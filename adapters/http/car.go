@@ -2,44 +2,48 @@ package http
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/labstack/echo"
 
 	"github.com/ucladevx/BPool/interfaces"
 	"github.com/ucladevx/BPool/models"
 	"github.com/ucladevx/BPool/services"
-	"github.com/ucladevx/BPool/stores/postgres"
+	"github.com/ucladevx/BPool/stores"
 	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
 type (
 	// CarService is used to handle all car CRUD operations
 	CarService interface {
-		GetAll(lastID string, limit, authLevel int) ([]*models.Car, error)
+		GetAll(page pagination.Page, authLevel int) ([]*models.Car, *pagination.Cursor, error)
 		GetCar(id string) (*models.Car, error)
 		AddCar(body models.Car, userID string) (*models.Car, error)
 		DeleteCar(id, userID string) error
+		RestoreCar(id string, authLevel int) (*models.Car, error)
 	}
 
 	// CarController http adapter
 	CarController struct {
 		logger  interfaces.Logger
 		service CarService
+		cursors *pagination.Codec
 	}
 )
 
 // NewCarController creates a new car controller
-func NewCarController(c CarService, l interfaces.Logger) *CarController {
+func NewCarController(c CarService, cursors *pagination.Codec, l interfaces.Logger) *CarController {
 	return &CarController{
 		logger:  l,
 		service: c,
+		cursors: cursors,
 	}
 }
 
 // MountRoutes mounts the car routes
 func (cc *CarController) MountRoutes(c *echo.Group) {
 	c.GET("/cars", cc.list, auth.NewAuthMiddleware(services.AdminLevel, cc.logger))
+	c.POST("/cars/:id/restore", cc.restore, auth.NewAuthMiddleware(services.AdminLevel, cc.logger))
 
 	c.Use(auth.NewAuthMiddleware(services.UserLevel, cc.logger))
 
@@ -50,30 +54,18 @@ func (cc *CarController) MountRoutes(c *echo.Group) {
 
 func (cc *CarController) list(c echo.Context) error {
 	user := userClaimsFromContext(c)
-	limitStr := c.QueryParam("limit")
-	limit, err := strconv.Atoi(limitStr)
 
-	if limitStr == "" {
-		limit = 15
-	} else if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "limit must be an integer greater than 0")
+	page, err := pageParams(c, cc.cursors)
+	if err != nil {
+		return err
 	}
 
-	lastID := c.QueryParam("last")
-
-	cars, err := cc.service.GetAll(lastID, limit, user.AuthLevel)
-
+	cars, next, err := cc.service.GetAll(page, user.AuthLevel)
 	if err != nil {
-		if err == services.ErrNotAllowed {
-			return echo.NewHTTPError(http.StatusForbidden, err)
-		}
-
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return listError(err)
 	}
 
-	return c.JSON(http.StatusOK, echo.Map{
-		"data": cars,
-	})
+	return listPage(c, cc.cursors, cars, next)
 }
 
 func (cc *CarController) show(c echo.Context) error {
@@ -82,7 +74,7 @@ func (cc *CarController) show(c echo.Context) error {
 	car, err := cc.service.GetCar(id)
 
 	if err != nil {
-		if err == postgres.ErrNoCarFound {
+		if err == stores.ErrNoCarFound {
 			return echo.NewHTTPError(http.StatusNotFound, err.Error())
 		}
 
@@ -129,3 +121,26 @@ func (cc *CarController) remove(c echo.Context) error {
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+func (cc *CarController) restore(c echo.Context) error {
+	id := c.Param("id")
+
+	userClaims := userClaimsFromContext(c)
+
+	car, err := cc.service.RestoreCar(id, userClaims.AuthLevel)
+
+	if err != nil {
+		switch err {
+		case services.ErrNotAllowed:
+			return ErrNotAllowed
+		case stores.ErrNoCarFound:
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": car,
+	})
+}
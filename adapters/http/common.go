@@ -2,14 +2,21 @@ package http
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/labstack/echo"
+	"github.com/ucladevx/BPool/services"
 	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
 var (
 	// ErrNotAllowed occurs when the user does not have sufficient auth level
 	ErrNotAllowed = echo.NewHTTPError(http.StatusForbidden, "user not allowed")
+
+	// ErrInvalidIfMatch occurs when the If-Match header is not a version
+	ErrInvalidIfMatch = echo.NewHTTPError(http.StatusBadRequest, "If-Match must be the version being updated")
 )
 
 func userClaimsFromContext(c echo.Context) *auth.UserClaims {
@@ -22,3 +29,102 @@ func userClaimsFromContext(c echo.Context) *auth.UserClaims {
 
 	return &user
 }
+
+// ifMatchVersion reads the version from the If-Match header, which may be quoted
+// like an ETag, nil is returned when there is no header
+func ifMatchVersion(c echo.Context) (*int, error) {
+	header := c.Request().Header.Get("If-Match")
+	if header == "" {
+		return nil, nil
+	}
+
+	header = strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+
+	version, err := strconv.Atoi(header)
+	if err != nil {
+		return nil, ErrInvalidIfMatch
+	}
+
+	return &version, nil
+}
+
+// statusParam reads the comma separated statuses of the status query param, nil
+// is returned when there are none
+func statusParam(c echo.Context) []string {
+	value := c.QueryParam("status")
+	if value == "" {
+		return nil
+	}
+
+	return strings.Split(value, ",")
+}
+
+// setETag sets the ETag header to the version so it can be sent back in If-Match
+func setETag(c echo.Context, version int) {
+	c.Response().Header().Set("ETag", `"`+strconv.Itoa(version)+`"`)
+}
+
+// pageParams reads the page requested by the limit, sort and cursor query params,
+// the service fills in the sort and limit when they are not given
+func pageParams(c echo.Context, cursors *pagination.Codec) (pagination.Page, error) {
+	page := pagination.Page{}
+
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return page, echo.NewHTTPError(http.StatusBadRequest, "limit must be an integer greater than 0")
+		}
+
+		page.Limit = limit
+	}
+
+	if sort := c.QueryParam("sort"); sort != "" {
+		page.Sort = pagination.ParseSort(sort)
+	}
+
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		page.After = &pagination.Cursor{}
+		if err := cursors.Decode(cursor, page.After); err != nil {
+			return page, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	return page, nil
+}
+
+// listError maps the errors of listing a page to http errors
+func listError(err error) error {
+	switch err {
+	case services.ErrNotAllowed:
+		return ErrNotAllowed
+	case pagination.ErrInvalidSort, pagination.ErrSortMismatch:
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+}
+
+// listPage responds with a page of data and the encoded cursor of the next page
+func listPage(c echo.Context, cursors *pagination.Codec, data interface{}, next *pagination.Cursor) error {
+	nextCursor := ""
+	if next != nil {
+		encoded, err := cursors.Encode(next)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		nextCursor = encoded
+	}
+
+	return listJSON(c, data, nextCursor)
+}
+
+// listJSON responds with a list of data, nextCursor is empty when there are no more
+// pages, lists that are not paged are sent with an empty nextCursor
+func listJSON(c echo.Context, data interface{}, nextCursor string) error {
+	return c.JSON(http.StatusOK, echo.Map{
+		"data":        data,
+		"next_cursor": nextCursor,
+		"has_more":    nextCursor != "",
+	})
+}
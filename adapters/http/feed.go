@@ -8,26 +8,30 @@ import (
 	"github.com/ucladevx/BPool/models"
 	"github.com/ucladevx/BPool/services"
 	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
 type (
 	// FeedService handles the use cases for the feed
 	FeedService interface {
-		GetUserRides(userID string) ([]*models.Ride, error)
+		GetUserRides(userID string, statuses []string) ([]*models.Ride, error)
+		GetUpcomingRides(page pagination.Page) ([]*models.Ride, *pagination.Cursor, error)
 	}
 
 	// FeedController is the controller for the feed
 	FeedController struct {
 		logger      interfaces.Logger
 		feedService FeedService
+		cursors     *pagination.Codec
 	}
 )
 
 // NewFeedController creates a new feed controller
-func NewFeedController(f FeedService, l interfaces.Logger) *FeedController {
+func NewFeedController(f FeedService, cursors *pagination.Codec, l interfaces.Logger) *FeedController {
 	return &FeedController{
 		logger:      l,
 		feedService: f,
+		cursors:     cursors,
 	}
 }
 
@@ -35,17 +39,30 @@ func NewFeedController(f FeedService, l interfaces.Logger) *FeedController {
 func (f *FeedController) MountRoutes(c *echo.Group) {
 	c.Use(auth.NewAuthMiddleware(services.UserLevel, f.logger))
 	c.GET("/feed", f.getFeed)
+	c.GET("/feed/upcoming", f.getUpcoming)
 }
 
 func (f *FeedController) getFeed(c echo.Context) error {
 	user := userClaimsFromContext(c)
 
-	rides, err := f.feedService.GetUserRides(user.ID)
+	rides, err := f.feedService.GetUserRides(user.ID, statusParam(c))
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	return c.JSON(http.StatusOK, echo.Map{
-		"data": rides,
-	})
+	return listJSON(c, rides, "")
+}
+
+func (f *FeedController) getUpcoming(c echo.Context) error {
+	page, err := pageParams(c, f.cursors)
+	if err != nil {
+		return err
+	}
+
+	rides, next, err := f.feedService.GetUpcomingRides(page)
+	if err != nil {
+		return listError(err)
+	}
+
+	return listPage(c, f.cursors, rides, next)
 }
@@ -1,20 +1,33 @@
 package http
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo"
 	"github.com/ucladevx/BPool/interfaces"
 )
 
+// readyTimeout is how long the readiness check waits for the db
+const readyTimeout = 2 * time.Second
+
+// Pinger checks that a connection can be made, like *sql.DB
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
 // PagesController is the controller for pages
 type PagesController struct {
+	db     Pinger
 	logger interfaces.Logger
 }
 
-// NewPagesController creates a new pages controller
-func NewPagesController(l interfaces.Logger) *PagesController {
+// NewPagesController creates a new pages controller, db is nil when there is no
+// db to check for readiness
+func NewPagesController(db Pinger, l interfaces.Logger) *PagesController {
 	return &PagesController{
+		db:     db,
 		logger: l,
 	}
 }
@@ -23,6 +36,7 @@ func NewPagesController(l interfaces.Logger) *PagesController {
 func (p *PagesController) MountRoutes(c *echo.Group) {
 	c.GET("/", p.index)
 	c.GET("/health", p.health)
+	c.GET("/ready", p.ready)
 }
 
 func (p *PagesController) index(c echo.Context) error {
@@ -34,3 +48,22 @@ func (p *PagesController) health(c echo.Context) error {
 		"health": "OK",
 	})
 }
+
+// ready reports whether the app can serve requests, which needs the db to be reachable
+func (p *PagesController) ready(c echo.Context) error {
+	if p.db != nil {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), readyTimeout)
+		defer cancel()
+
+		if err := p.db.PingContext(ctx); err != nil {
+			p.logger.Error("READY", "error", err.Error())
+			return c.JSON(http.StatusServiceUnavailable, echo.Map{
+				"ready": "DB UNAVAILABLE",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"ready": "OK",
+	})
+}
@@ -2,14 +2,15 @@ package http
 
 import (
 	"net/http"
-	"strconv"
 	"strings"
 
 	"github.com/labstack/echo"
 	"github.com/ucladevx/BPool/interfaces"
 	"github.com/ucladevx/BPool/models"
 	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/stores"
 	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
 type (
@@ -18,34 +19,42 @@ type (
 		Create(*models.Passenger, *auth.UserClaims) error
 		Get(id string, user *auth.UserClaims) (*models.Passenger, error)
 		Update(updates *models.PassengerChangeSet, passengerID string, user *auth.UserClaims) (*models.Passenger, error)
-		GetAll(lastID string, limit, userAuthLevel int) ([]*models.Passenger, error)
+		GetAll(page pagination.Page, userAuthLevel int) ([]*models.Passenger, *pagination.Cursor, error)
 		GetAllByRideID(rideID string, user *auth.UserClaims) ([]*models.Passenger, error)
 		Delete(id string, user *auth.UserClaims) error
+		Restore(id string, user *auth.UserClaims) (*models.Passenger, error)
+		ConfirmSeat(id string, user *auth.UserClaims) (*models.Passenger, error)
+		Withdraw(id string, user *auth.UserClaims) (*models.Passenger, error)
 	}
 
 	// PassengerController http adapter
 	PassengerController struct {
 		logger  interfaces.Logger
 		service PassengerService
+		cursors *pagination.Codec
 	}
 )
 
 // NewPassengerController creates a new passenger controller
-func NewPassengerController(r PassengerService, l interfaces.Logger) *PassengerController {
+func NewPassengerController(r PassengerService, cursors *pagination.Codec, l interfaces.Logger) *PassengerController {
 	return &PassengerController{
 		logger:  l,
 		service: r,
+		cursors: cursors,
 	}
 }
 
 // MountRoutes mounts the auth routes
 func (p *PassengerController) MountRoutes(c *echo.Group) {
 	c.GET("/passengers", p.list, auth.NewAuthMiddleware(services.AdminLevel, p.logger))
+	c.POST("/passengers/:id/restore", p.restore, auth.NewAuthMiddleware(services.AdminLevel, p.logger))
 	c.GET("/passengers/:id", p.show)
 	c.Use(auth.NewAuthMiddleware(services.UserLevel, p.logger))
 	c.POST("/passengers", p.create)
 	c.DELETE("/passengers/:id", p.delete)
 	c.PUT("/passengers/:id", p.update)
+	c.POST("/passengers/:id/confirm", p.confirmSeat)
+	c.POST("/passengers/:id/withdraw", p.withdraw)
 }
 
 func (p *PassengerController) create(c echo.Context) error {
@@ -80,31 +89,18 @@ func (p *PassengerController) create(c echo.Context) error {
 
 func (p *PassengerController) list(c echo.Context) error {
 	user := userClaimsFromContext(c)
-	limitStr := c.QueryParam("limit")
-	limit, err := strconv.Atoi(limitStr)
 
-	if limitStr == "" {
-		limit = 15
-	} else if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "limit must be an integer greater than 0")
+	page, err := pageParams(c, p.cursors)
+	if err != nil {
+		return err
 	}
 
-	lastID := c.QueryParam("last")
-
-	passengers, err := p.service.GetAll(lastID, limit, user.AuthLevel)
-
+	passengers, next, err := p.service.GetAll(page, user.AuthLevel)
 	if err != nil {
-		status := http.StatusInternalServerError
-		if err == services.ErrNotAllowed {
-			status = http.StatusForbidden
-		}
-
-		return echo.NewHTTPError(status, err.Error())
+		return listError(err)
 	}
 
-	return c.JSON(http.StatusOK, echo.Map{
-		"data": passengers,
-	})
+	return listPage(c, p.cursors, passengers, next)
 }
 
 func (p *PassengerController) show(c echo.Context) error {
@@ -117,6 +113,8 @@ func (p *PassengerController) show(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	setETag(c, passenger.Version)
+
 	return c.JSON(http.StatusOK, echo.Map{
 		"data": passenger,
 	})
@@ -139,6 +137,15 @@ func (p *PassengerController) update(c echo.Context) error {
 	data.RideID = nil
 	data.PassengerID = nil
 
+	// the header takes precedence over a version in the body
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+	if version != nil {
+		data.Version = version
+	}
+
 	user := userClaimsFromContext(c)
 
 	passenger, err := p.service.Update(&data, id, user)
@@ -147,11 +154,45 @@ func (p *PassengerController) update(c echo.Context) error {
 		status := http.StatusBadRequest
 		if err == services.ErrForbidden {
 			status = http.StatusForbidden
+		} else if err == stores.ErrVersionConflict {
+			status = http.StatusConflict
 		}
 
 		return echo.NewHTTPError(status, err.Error())
 	}
 
+	setETag(c, passenger.Version)
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": passenger,
+	})
+}
+
+func (p *PassengerController) confirmSeat(c echo.Context) error {
+	user := userClaimsFromContext(c)
+
+	passenger, err := p.service.ConfirmSeat(c.Param("id"), user)
+	if err != nil {
+		return echo.NewHTTPError(waitlistErrorStatus(err), err.Error())
+	}
+
+	setETag(c, passenger.Version)
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": passenger,
+	})
+}
+
+func (p *PassengerController) withdraw(c echo.Context) error {
+	user := userClaimsFromContext(c)
+
+	passenger, err := p.service.Withdraw(c.Param("id"), user)
+	if err != nil {
+		return echo.NewHTTPError(waitlistErrorStatus(err), err.Error())
+	}
+
+	setETag(c, passenger.Version)
+
 	return c.JSON(http.StatusOK, echo.Map{
 		"data": passenger,
 	})
@@ -177,3 +218,43 @@ func (p *PassengerController) delete(c echo.Context) error {
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+func (p *PassengerController) restore(c echo.Context) error {
+	id := c.Param("id")
+
+	user := userClaimsFromContext(c)
+
+	passenger, err := p.service.Restore(id, user)
+
+	if err != nil {
+		switch err {
+		case services.ErrNotAllowed:
+			return ErrNotAllowed
+		case stores.ErrNoPassengerFound:
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case stores.ErrAlreadyPassenger:
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": passenger,
+	})
+}
+
+// waitlistErrorStatus is the response status of an error confirming a seat or withdrawing
+func waitlistErrorStatus(err error) int {
+	switch err {
+	case services.ErrForbidden:
+		return http.StatusForbidden
+	case stores.ErrNoPassengerFound:
+		return http.StatusNotFound
+	case services.ErrNoSeatOffered, services.ErrSeatOfferExpired, services.ErrCannotWithdraw, services.ErrNoMoreSeats,
+		services.ErrRideNotScheduled, stores.ErrVersionConflict:
+		return http.StatusConflict
+	}
+
+	return http.StatusBadRequest
+}
@@ -4,12 +4,16 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/labstack/echo"
 	"github.com/ucladevx/BPool/interfaces"
 	"github.com/ucladevx/BPool/models"
 	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/stores"
 	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
 type (
@@ -18,8 +22,14 @@ type (
 		Create(*models.Ride, *auth.UserClaims) error
 		Get(id string) (*models.Ride, error)
 		Update(updates *models.RideChangeSet, rideID string, user *auth.UserClaims) (*models.Ride, error)
-		GetAll(lastID string, limit, userAuthLevel int) ([]*models.Ride, error)
+		GetAll(page pagination.Page, userAuthLevel int) ([]*models.Ride, *pagination.Cursor, error)
 		Delete(id string, user *auth.UserClaims) error
+		Restore(id string, user *auth.UserClaims) (*models.Ride, error)
+		Search(search *models.RideSearch) ([]*models.Ride, *models.RideSearchCursor, error)
+		Start(id string, user *auth.UserClaims) (*models.Ride, error)
+		Complete(id string, user *auth.UserClaims) (*models.Ride, error)
+		Cancel(id, reason string, user *auth.UserClaims) (*models.Ride, error)
+		Reliability(driverID string) (*models.DriverReliability, error)
 	}
 
 	// RideController http adapter
@@ -27,27 +37,39 @@ type (
 		logger           interfaces.Logger
 		service          RideService
 		passengerService PassengerService
+		cursors          *pagination.Codec
+	}
+
+	rideCancelRequest struct {
+		Reason string `json:"reason"`
 	}
 )
 
 // NewRideController creates a new auth controller
-func NewRideController(r RideService, p PassengerService, l interfaces.Logger) *RideController {
+func NewRideController(r RideService, p PassengerService, cursors *pagination.Codec, l interfaces.Logger) *RideController {
 	return &RideController{
 		logger:           l,
 		service:          r,
 		passengerService: p,
+		cursors:          cursors,
 	}
 }
 
 // MountRoutes mounts the auth routes
 func (r *RideController) MountRoutes(c *echo.Group) {
 	c.GET("/rides", r.list, auth.NewAuthMiddleware(services.AdminLevel, r.logger))
+	c.POST("/rides/:id/restore", r.restore, auth.NewAuthMiddleware(services.AdminLevel, r.logger))
+	c.GET("/rides/search", r.search)
 	c.GET("/rides/:id", r.show)
+	c.GET("/users/:id/reliability", r.reliability)
 	c.Use(auth.NewAuthMiddleware(services.UserLevel, r.logger))
 	c.GET("/rides/:id/passengers", r.listPassengers)
 	c.POST("/rides", r.create)
 	c.DELETE("/rides/:id", r.delete)
 	c.PUT("/rides/:id", r.update)
+	c.POST("/rides/:id/start", r.transition(r.service.Start))
+	c.POST("/rides/:id/complete", r.transition(r.service.Complete))
+	c.POST("/rides/:id/cancel", r.cancel)
 }
 
 func (r *RideController) create(c echo.Context) error {
@@ -80,30 +102,157 @@ func (r *RideController) create(c echo.Context) error {
 
 func (r *RideController) list(c echo.Context) error {
 	user := userClaimsFromContext(c)
-	limitStr := c.QueryParam("limit")
-	limit, err := strconv.Atoi(limitStr)
 
-	if limitStr == "" {
-		limit = 15
-	} else if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "limit must be an integer greater than 0")
+	page, err := pageParams(c, r.cursors)
+	if err != nil {
+		return err
 	}
 
-	lastID := c.QueryParam("last")
+	rides, next, err := r.service.GetAll(page, user.AuthLevel)
+	if err != nil {
+		return listError(err)
+	}
+
+	return listPage(c, r.cursors, rides, next)
+}
 
-	rides, err := r.service.GetAll(lastID, limit, user.AuthLevel)
+func (r *RideController) search(c echo.Context) error {
+	search := models.RideSearch{
+		Query:     strings.TrimSpace(c.QueryParam("q")),
+		StartCity: c.QueryParam("start_city"),
+		EndCity:   c.QueryParam("end_city"),
+		Sort:      c.QueryParam("sort"),
+		Statuses:  statusParam(c),
+	}
 
+	near, err := searchNear(c)
 	if err != nil {
-		if err == services.ErrNotAllowed {
-			return ErrNotAllowed
+		return err
+	}
+	search.Near = near
+
+	dates := []struct {
+		name  string
+		value *time.Time
+	}{
+		{"date_from", &search.StartAfter},
+		{"date_to", &search.StartBefore},
+	}
+
+	for _, date := range dates {
+		if value := c.QueryParam(date.name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, date.name+" must be an RFC 3339 date")
+			}
+
+			*date.value = parsed
 		}
+	}
 
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	if seatsStr := c.QueryParam("seats"); seatsStr != "" {
+		seats, err := strconv.Atoi(seatsStr)
+		if err != nil || seats < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "seats must be an integer of at least 0")
+		}
+
+		search.MinSeats = seats
 	}
 
-	return c.JSON(http.StatusOK, echo.Map{
-		"data": rides,
-	})
+	if priceStr := c.QueryParam("max_price"); priceStr != "" {
+		price, err := strconv.ParseFloat(priceStr, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "max_price must be a number")
+		}
+
+		search.MaxPrice = &price
+	}
+
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be an integer greater than 0")
+		}
+
+		search.Limit = limit
+	}
+
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		search.After = &models.RideSearchCursor{}
+		if err := r.cursors.Decode(cursor, search.After); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	rides, next, err := r.service.Search(&search)
+	if err != nil {
+		return searchError(err)
+	}
+
+	nextCursor := ""
+	if next != nil {
+		if nextCursor, err = r.cursors.Encode(next); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return listJSON(c, rides, nextCursor)
+}
+
+// searchError maps the errors of searching rides to http errors, only an invalid
+// search is the client's fault
+func searchError(err error) error {
+	switch err {
+	case models.ErrSortNeedsNear, models.ErrSortNeedsQuery, models.ErrCursorSortMismatch, models.ErrUnknownRideStatus:
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if _, ok := err.(validation.Errors); ok {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+}
+
+// searchNear reads the points and radius to search near, they are optional but
+// must all be given together
+func searchNear(c echo.Context) (*models.RideSearchNear, error) {
+	near := models.RideSearchNear{}
+
+	params := []struct {
+		name  string
+		value *float64
+	}{
+		{"from_lat", &near.FromLat},
+		{"from_lon", &near.FromLon},
+		{"to_lat", &near.ToLat},
+		{"to_lon", &near.ToLon},
+		{"radius_km", &near.FromRadiusKm},
+	}
+
+	given := 0
+	for _, param := range params {
+		if c.QueryParam(param.name) != "" {
+			given++
+		}
+	}
+
+	if given == 0 {
+		return nil, nil
+	}
+
+	for _, param := range params {
+		value, err := strconv.ParseFloat(c.QueryParam(param.name), 64)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, param.name+" must be a number, from_lat, from_lon, to_lat, to_lon and radius_km must be given together")
+		}
+
+		*param.value = value
+	}
+
+	near.ToRadiusKm = near.FromRadiusKm
+
+	return &near, nil
 }
 
 func (r *RideController) show(c echo.Context) error {
@@ -115,6 +264,8 @@ func (r *RideController) show(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	setETag(c, ride.Version)
+
 	return c.JSON(http.StatusOK, echo.Map{
 		"data": ride,
 	})
@@ -134,9 +285,7 @@ func (r *RideController) listPassengers(c echo.Context) error {
 		return echo.NewHTTPError(status, err.Error())
 	}
 
-	return c.JSON(http.StatusOK, echo.Map{
-		"data": passengers,
-	})
+	return listJSON(c, passengers, "")
 }
 
 func (r *RideController) update(c echo.Context) error {
@@ -152,6 +301,15 @@ func (r *RideController) update(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, msg)
 	}
 
+	// the header takes precedence over a version in the body
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+	if version != nil {
+		data.Version = version
+	}
+
 	user := userClaimsFromContext(c)
 
 	ride, err := r.service.Update(&data, id, user)
@@ -160,16 +318,75 @@ func (r *RideController) update(c echo.Context) error {
 		status := http.StatusBadRequest
 		if err == services.ErrForbidden {
 			status = http.StatusForbidden
+		} else if err == stores.ErrVersionConflict {
+			status = http.StatusConflict
 		}
 
 		return echo.NewHTTPError(status, err.Error())
 	}
 
+	setETag(c, ride.Version)
+
 	return c.JSON(http.StatusOK, echo.Map{
 		"data": ride,
 	})
 }
 
+// transition handles changing the status of a ride with the service method given
+func (r *RideController) transition(change func(id string, user *auth.UserClaims) (*models.Ride, error)) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := userClaimsFromContext(c)
+
+		ride, err := change(c.Param("id"), user)
+		if err != nil {
+			status := http.StatusBadRequest
+			switch err {
+			case services.ErrForbidden:
+				status = http.StatusForbidden
+			case stores.ErrNoRideFound:
+				status = http.StatusNotFound
+			case services.ErrInvalidRideTransition, stores.ErrVersionConflict:
+				status = http.StatusConflict
+			}
+
+			return echo.NewHTTPError(status, err.Error())
+		}
+
+		setETag(c, ride.Version)
+
+		return c.JSON(http.StatusOK, echo.Map{
+			"data": ride,
+		})
+	}
+}
+
+func (r *RideController) cancel(c echo.Context) error {
+	data := rideCancelRequest{}
+	if err := c.Bind(&data); err != nil {
+		msg := err.Error()
+		if strings.HasPrefix(err.Error(), "code=400, message=Syntax error") {
+			msg = "The JSON was invalid"
+		}
+
+		return echo.NewHTTPError(http.StatusBadRequest, msg)
+	}
+
+	return r.transition(func(id string, user *auth.UserClaims) (*models.Ride, error) {
+		return r.service.Cancel(id, data.Reason, user)
+	})(c)
+}
+
+func (r *RideController) reliability(c echo.Context) error {
+	reliability, err := r.service.Reliability(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": reliability,
+	})
+}
+
 func (r *RideController) delete(c echo.Context) error {
 	id := c.Param("id")
 
@@ -190,3 +407,26 @@ func (r *RideController) delete(c echo.Context) error {
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+func (r *RideController) restore(c echo.Context) error {
+	id := c.Param("id")
+
+	user := userClaimsFromContext(c)
+
+	ride, err := r.service.Restore(id, user)
+
+	if err != nil {
+		switch err {
+		case services.ErrNotAllowed:
+			return ErrNotAllowed
+		case stores.ErrNoRideFound:
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": ride,
+	})
+}
@@ -0,0 +1,247 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/ucladevx/BPool/interfaces"
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+type (
+	// RideRequestService is used to handle the ride request use cases
+	RideRequestService interface {
+		Create(*models.RideRequest, *auth.UserClaims) error
+		Get(id string, user *auth.UserClaims) (*models.RideRequest, error)
+		GetOpen(page pagination.Page) ([]*models.RideRequest, *pagination.Cursor, error)
+		GetAllByPassenger(page pagination.Page, user *auth.UserClaims) ([]*models.RideRequest, *pagination.Cursor, error)
+		Update(updates *models.RideRequestChangeSet, id string, user *auth.UserClaims) (*models.RideRequest, error)
+		Delete(id string, user *auth.UserClaims) error
+		Offer(id, rideID string, user *auth.UserClaims) (*models.Passenger, error)
+		GetOffers(id string, user *auth.UserClaims) ([]*models.Passenger, error)
+		Confirm(id, passengerID string, user *auth.UserClaims) (*models.Passenger, error)
+	}
+
+	// RideRequestController http adapter
+	RideRequestController struct {
+		logger  interfaces.Logger
+		service RideRequestService
+		cursors *pagination.Codec
+	}
+
+	// rideOfferRequest is the body of a driver offering a ride to a ride request
+	rideOfferRequest struct {
+		RideID string `json:"ride_id"`
+	}
+)
+
+// NewRideRequestController creates a new ride request controller
+func NewRideRequestController(s RideRequestService, cursors *pagination.Codec, l interfaces.Logger) *RideRequestController {
+	return &RideRequestController{
+		logger:  l,
+		service: s,
+		cursors: cursors,
+	}
+}
+
+// MountRoutes mounts the ride request routes
+func (r *RideRequestController) MountRoutes(c *echo.Group) {
+	c.GET("/ride-requests", r.listOpen)
+	c.Use(auth.NewAuthMiddleware(services.UserLevel, r.logger))
+	c.GET("/ride-requests/mine", r.listMine)
+	c.GET("/ride-requests/:id", r.show)
+	c.POST("/ride-requests", r.create)
+	c.PUT("/ride-requests/:id", r.update)
+	c.DELETE("/ride-requests/:id", r.delete)
+	c.GET("/ride-requests/:id/offers", r.listOffers)
+	c.POST("/ride-requests/:id/offers", r.offer)
+	c.POST("/ride-requests/:id/offers/:passenger_id/confirm", r.confirm)
+}
+
+func (r *RideRequestController) create(c echo.Context) error {
+	data := models.RideRequestChangeSet{}
+	if err := c.Bind(&data); err != nil {
+		msg := err.Error()
+		if strings.HasPrefix(err.Error(), "code=400, message=Syntax error") {
+			msg = "The JSON was invalid"
+		}
+
+		return echo.NewHTTPError(http.StatusBadRequest, msg)
+	}
+
+	user := userClaimsFromContext(c)
+	data.PassengerID = &user.ID
+
+	request, err := models.NewRideRequest(&data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := r.service.Create(request, user); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": request,
+	})
+}
+
+func (r *RideRequestController) listOpen(c echo.Context) error {
+	page, err := pageParams(c, r.cursors)
+	if err != nil {
+		return err
+	}
+
+	requests, next, err := r.service.GetOpen(page)
+	if err != nil {
+		return listError(err)
+	}
+
+	return listPage(c, r.cursors, requests, next)
+}
+
+func (r *RideRequestController) listMine(c echo.Context) error {
+	user := userClaimsFromContext(c)
+
+	page, err := pageParams(c, r.cursors)
+	if err != nil {
+		return err
+	}
+
+	requests, next, err := r.service.GetAllByPassenger(page, user)
+	if err != nil {
+		return listError(err)
+	}
+
+	return listPage(c, r.cursors, requests, next)
+}
+
+func (r *RideRequestController) show(c echo.Context) error {
+	user := userClaimsFromContext(c)
+
+	request, err := r.service.Get(c.Param("id"), user)
+	if err != nil {
+		return echo.NewHTTPError(rideRequestErrorStatus(err), err.Error())
+	}
+
+	setETag(c, request.Version)
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": request,
+	})
+}
+
+func (r *RideRequestController) update(c echo.Context) error {
+	id := c.Param("id")
+
+	data := models.RideRequestChangeSet{}
+	if err := c.Bind(&data); err != nil {
+		msg := err.Error()
+		if strings.HasPrefix(err.Error(), "code=400, message=Syntax error") {
+			msg = "The JSON was invalid"
+		}
+
+		return echo.NewHTTPError(http.StatusBadRequest, msg)
+	}
+
+	// the header takes precedence over a version in the body
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+	if version != nil {
+		data.Version = version
+	}
+
+	user := userClaimsFromContext(c)
+
+	request, err := r.service.Update(&data, id, user)
+	if err != nil {
+		return echo.NewHTTPError(rideRequestErrorStatus(err), err.Error())
+	}
+
+	setETag(c, request.Version)
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": request,
+	})
+}
+
+func (r *RideRequestController) delete(c echo.Context) error {
+	user := userClaimsFromContext(c)
+
+	if err := r.service.Delete(c.Param("id"), user); err != nil {
+		return echo.NewHTTPError(rideRequestErrorStatus(err), err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (r *RideRequestController) listOffers(c echo.Context) error {
+	user := userClaimsFromContext(c)
+
+	offers, err := r.service.GetOffers(c.Param("id"), user)
+	if err != nil {
+		return echo.NewHTTPError(rideRequestErrorStatus(err), err.Error())
+	}
+
+	return listJSON(c, offers, "")
+}
+
+func (r *RideRequestController) offer(c echo.Context) error {
+	data := rideOfferRequest{}
+	if err := c.Bind(&data); err != nil {
+		msg := err.Error()
+		if strings.HasPrefix(err.Error(), "code=400, message=Syntax error") {
+			msg = "The JSON was invalid"
+		}
+
+		return echo.NewHTTPError(http.StatusBadRequest, msg)
+	}
+
+	user := userClaimsFromContext(c)
+
+	passenger, err := r.service.Offer(c.Param("id"), data.RideID, user)
+	if err != nil {
+		return echo.NewHTTPError(rideRequestErrorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": passenger,
+	})
+}
+
+func (r *RideRequestController) confirm(c echo.Context) error {
+	user := userClaimsFromContext(c)
+
+	passenger, err := r.service.Confirm(c.Param("id"), c.Param("passenger_id"), user)
+	if err != nil {
+		return echo.NewHTTPError(rideRequestErrorStatus(err), err.Error())
+	}
+
+	setETag(c, passenger.Version)
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": passenger,
+	})
+}
+
+// rideRequestErrorStatus is the response status of a ride request use case error
+func rideRequestErrorStatus(err error) int {
+	switch err {
+	case services.ErrForbidden:
+		return http.StatusForbidden
+	case stores.ErrNoRideRequestFound, stores.ErrNoRideFound, stores.ErrNoPassengerFound:
+		return http.StatusNotFound
+	case services.ErrRideRequestClosed, services.ErrOfferNotPending, services.ErrNoMoreSeats,
+		services.ErrRideNotScheduled, stores.ErrAlreadyPassenger, stores.ErrVersionConflict:
+		return http.StatusConflict
+	}
+
+	return http.StatusBadRequest
+}
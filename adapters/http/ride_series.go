@@ -0,0 +1,185 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/ucladevx/BPool/interfaces"
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+type (
+	// RideSeriesService is used to handle the ride series use cases
+	RideSeriesService interface {
+		Create(*models.RideSeries, *auth.UserClaims) error
+		Get(id string) (*models.RideSeries, error)
+		GetAllByDriver(page pagination.Page, user *auth.UserClaims) ([]*models.RideSeries, *pagination.Cursor, error)
+		GetRides(id string) ([]*models.Ride, error)
+		Update(updates *models.RideSeriesChangeSet, id string, user *auth.UserClaims) (*models.RideSeries, error)
+		Delete(id string, user *auth.UserClaims) error
+	}
+
+	// RideSeriesController http adapter, single rides of a series are edited and
+	// cancelled through the RideController
+	RideSeriesController struct {
+		logger  interfaces.Logger
+		service RideSeriesService
+		cursors *pagination.Codec
+	}
+)
+
+// NewRideSeriesController creates a new ride series controller
+func NewRideSeriesController(s RideSeriesService, cursors *pagination.Codec, l interfaces.Logger) *RideSeriesController {
+	return &RideSeriesController{
+		logger:  l,
+		service: s,
+		cursors: cursors,
+	}
+}
+
+// MountRoutes mounts the ride series routes
+func (r *RideSeriesController) MountRoutes(c *echo.Group) {
+	c.GET("/ride-series/:id", r.show)
+	c.GET("/ride-series/:id/rides", r.listRides)
+	c.Use(auth.NewAuthMiddleware(services.UserLevel, r.logger))
+	c.GET("/ride-series", r.list)
+	c.POST("/ride-series", r.create)
+	c.PUT("/ride-series/:id", r.update)
+	c.DELETE("/ride-series/:id", r.delete)
+}
+
+func (r *RideSeriesController) create(c echo.Context) error {
+	data := models.RideSeriesChangeSet{}
+	if err := c.Bind(&data); err != nil {
+		msg := err.Error()
+		if strings.HasPrefix(err.Error(), "code=400, message=Syntax error") {
+			msg = "The JSON was invalid"
+		}
+
+		return echo.NewHTTPError(http.StatusBadRequest, msg)
+	}
+
+	user := userClaimsFromContext(c)
+	data.DriverID = &user.ID
+
+	series, err := models.NewRideSeries(&data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := r.service.Create(series, user); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": series,
+	})
+}
+
+func (r *RideSeriesController) list(c echo.Context) error {
+	user := userClaimsFromContext(c)
+
+	page, err := pageParams(c, r.cursors)
+	if err != nil {
+		return err
+	}
+
+	series, next, err := r.service.GetAllByDriver(page, user)
+	if err != nil {
+		return listError(err)
+	}
+
+	return listPage(c, r.cursors, series, next)
+}
+
+func (r *RideSeriesController) show(c echo.Context) error {
+	series, err := r.service.Get(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+
+	setETag(c, series.Version)
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": series,
+	})
+}
+
+func (r *RideSeriesController) listRides(c echo.Context) error {
+	rides, err := r.service.GetRides(c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == stores.ErrNoRideSeriesFound {
+			status = http.StatusNotFound
+		}
+
+		return echo.NewHTTPError(status, err.Error())
+	}
+
+	return listJSON(c, rides, "")
+}
+
+func (r *RideSeriesController) update(c echo.Context) error {
+	id := c.Param("id")
+
+	data := models.RideSeriesChangeSet{}
+	if err := c.Bind(&data); err != nil {
+		msg := err.Error()
+		if strings.HasPrefix(err.Error(), "code=400, message=Syntax error") {
+			msg = "The JSON was invalid"
+		}
+
+		return echo.NewHTTPError(http.StatusBadRequest, msg)
+	}
+
+	// the header takes precedence over a version in the body
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+	if version != nil {
+		data.Version = version
+	}
+
+	user := userClaimsFromContext(c)
+
+	series, err := r.service.Update(&data, id, user)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err == services.ErrForbidden {
+			status = http.StatusForbidden
+		} else if err == stores.ErrVersionConflict || err == services.ErrFewerSeatsThanTaken {
+			status = http.StatusConflict
+		}
+
+		return echo.NewHTTPError(status, err.Error())
+	}
+
+	setETag(c, series.Version)
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": series,
+	})
+}
+
+func (r *RideSeriesController) delete(c echo.Context) error {
+	user := userClaimsFromContext(c)
+
+	if err := r.service.Delete(c.Param("id"), user); err != nil {
+		status := http.StatusBadRequest
+		if err == services.ErrForbidden {
+			status = http.StatusForbidden
+		} else if err == stores.ErrNoRideSeriesFound {
+			status = http.StatusNotFound
+		}
+
+		return echo.NewHTTPError(status, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -2,7 +2,6 @@ package http
 
 import (
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/labstack/echo"
@@ -10,6 +9,7 @@ import (
 	"github.com/ucladevx/BPool/models"
 	"github.com/ucladevx/BPool/services"
 	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
 type (
@@ -17,7 +17,7 @@ type (
 	UserService interface {
 		Login(googleToken string) (string, error)
 		Get(id string) (*models.User, error)
-		GetAll(lastID string, limit, userAuthLevel int) ([]*models.User, error)
+		GetAll(page pagination.Page, userAuthLevel int) ([]*models.User, *pagination.Cursor, error)
 	}
 
 	authCookieInfo struct {
@@ -30,6 +30,7 @@ type (
 		logger     interfaces.Logger
 		service    UserService
 		authCookie authCookieInfo
+		cursors    *pagination.Codec
 	}
 
 	userLoginRequest struct {
@@ -38,13 +39,14 @@ type (
 )
 
 // NewUserController creates a new auth controller
-func NewUserController(u UserService, daysTokenValidFor int, cookieName string, l interfaces.Logger) *UserController {
+func NewUserController(u UserService, daysTokenValidFor int, cookieName string, cursors *pagination.Codec, l interfaces.Logger) *UserController {
 	a := authCookieInfo{daysTokenValidFor, cookieName}
 
 	return &UserController{
 		logger:     l,
 		service:    u,
 		authCookie: a,
+		cursors:    cursors,
 	}
 }
 
@@ -83,30 +85,18 @@ func (u *UserController) login(c echo.Context) error {
 
 func (u *UserController) list(c echo.Context) error {
 	user := userClaimsFromContext(c)
-	limitStr := c.QueryParam("limit")
-	limit, err := strconv.Atoi(limitStr)
 
-	if limitStr == "" {
-		limit = 15
-	} else if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "limit must be an integer greater than 0")
+	page, err := pageParams(c, u.cursors)
+	if err != nil {
+		return err
 	}
 
-	lastID := c.QueryParam("last")
-
-	users, err := u.service.GetAll(lastID, limit, user.AuthLevel)
-
+	users, next, err := u.service.GetAll(page, user.AuthLevel)
 	if err != nil {
-		if err == services.ErrNotAllowed {
-			return ErrNotAllowed
-		}
-
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return listError(err)
 	}
 
-	return c.JSON(http.StatusOK, echo.Map{
-		"data": users,
-	})
+	return listPage(c, u.cursors, users, next)
 }
 
 func (u *UserController) show(c echo.Context) error {
@@ -3,13 +3,22 @@ package bpool
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/ucladevx/BPool/adapters/http"
+	"github.com/ucladevx/BPool/models"
 	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/stores/cached"
+	"github.com/ucladevx/BPool/stores/memory"
+	"github.com/ucladevx/BPool/stores/migrate"
 	"github.com/ucladevx/BPool/stores/postgres"
+	"github.com/ucladevx/BPool/stores/sqlite"
 	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/cache"
+	"github.com/ucladevx/BPool/utils/pagination"
 
 	"github.com/codyleyhan/config-loader"
+	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
 	"go.uber.org/zap"
@@ -24,19 +33,30 @@ const logo = `
    \ \_______\ \__\    \ \_______\ \_______\ \_______\
     \|_______|\|__|     \|_______|\|_______|\|_______|`
 
+// storeSet is every store the services need
+type storeSet struct {
+	users      services.UserStore
+	cars       services.CarStore
+	rides      services.RideStore
+	series     services.RideSeriesStore
+	requests   services.RideRequestStore
+	passengers services.PassengerStore
+	audit      services.AuditStore
+	outbox     services.OutboxStore
+	transactor services.Transactor
+
+	// caches are the caches the stores read through, empty if caching is off
+	caches cached.Caches
+
+	// db is nil for the memory backend
+	db *sqlx.DB
+}
+
 // Start starts the server
 func Start() {
 	fmt.Println(logo)
 
-	env := os.Getenv("ENV")
-
-	var conf config.LoadedData
-
-	if env != "PROD" {
-		conf = config.LoadConfig("./config", "config")
-	} else {
-		conf = config.LoadConfig("/config", "config")
-	}
+	conf, env := loadConfig()
 
 	// create logger
 	loggerUnsugared, err := zap.NewDevelopment()
@@ -57,44 +77,66 @@ func Start() {
 	)
 
 	// connect to db
-	db := postgres.NewConnection(
-		conf.Get("db.user"),
-		conf.Get("db.password"),
-		conf.Get("db.name"),
-		conf.Get("db.port"),
-		conf.Get("db.host"),
+	s, err := newStores(conf, logger)
+	if err != nil {
+		logger.Error("DB SETUP FAILED", "error", err.Error())
+		return
+	}
+
+	if err := s.useCaches(conf, logger); err != nil {
+		logger.Error("CACHE SETUP FAILED", "error", err.Error())
+		return
+	}
+
+	userService := services.NewUserService(s.users, tokenizer, logger)
+	carService := services.NewCarService(s.cars, logger)
+	rideService := services.NewRideService(s.rides, carService, s.transactor, int(conf.GetInt("rides.late_cancellation_hours")), int(conf.GetInt("rides.seat_offer_hours")), logger)
+	passengerService := services.NewPassengerService(s.passengers, rideService, s.transactor, logger)
+	rideRequestService := services.NewRideRequestService(s.requests, passengerService, s.transactor, logger)
+	rideSeriesService := services.NewRideSeriesService(s.series, s.rides, carService, s.transactor, int(conf.GetInt("series.horizon_days")), int(conf.GetInt("rides.late_cancellation_hours")), int(conf.GetInt("rides.seat_offer_hours")), logger)
+	feedService := services.NewFeedService(s.rides, logger)
+	auditService := services.NewAuditService(s.audit, logger)
+
+	// passengers are purged before the rides and cars they belong to, delivered
+	// outbox events are purged with them
+	purgeService := services.NewPurgeService(
+		purgeRetention(conf),
 		logger,
+		s.passengers,
+		s.rides,
+		s.series,
+		s.requests,
+		s.cars,
+		s.outbox,
 	)
+	go purgeService.Run(purgeInterval(conf), nil)
 
-	userStore := postgres.NewUserStore(db)
-	carStore := postgres.NewCarStore(db)
-	rideStore := postgres.NewRideStore(db)
-	passengerStore := postgres.NewPassengerStore(db)
+	go rideSeriesService.Run(seriesInterval(conf), nil)
 
-	postgres.CreateTables(
-		userStore,
-		carStore,
-		rideStore,
-		passengerStore,
-	)
+	go passengerService.Run(waitlistInterval(conf), nil)
 
-	userService := services.NewUserService(userStore, tokenizer, logger)
-	carService := services.NewCarService(carStore, logger)
-	rideService := services.NewRideService(rideStore, carService, logger)
-	passengerService := services.NewPassengerService(passengerStore, rideService, logger)
-	feedService := services.NewFeedService(rideStore, logger)
+	outboxDispatcher := services.NewOutboxDispatcher(s.outbox, logger)
+	outboxDispatcher.Handle(services.AllOutboxEvents, logOutboxEvent(logger))
+	go outboxDispatcher.Run(outboxInterval(conf), nil)
+
+	cursors := pagination.NewCodec(cursorSecret(conf))
 
 	userController := http.NewUserController(
 		userService,
 		int(conf.GetInt("jwt.num_days_valid")),
 		conf.Get("jwt.cookie"),
+		cursors,
 		logger,
 	)
-	rideController := http.NewRideController(rideService, passengerService, logger)
-	pagesController := http.NewPagesController(logger)
-	carController := http.NewCarController(carService, logger)
-	passengersController := http.NewPassengerController(passengerService, logger)
-	feedController := http.NewFeedController(feedService, logger)
+	rideController := http.NewRideController(rideService, passengerService, cursors, logger)
+	rideSeriesController := http.NewRideSeriesController(rideSeriesService, cursors, logger)
+	rideRequestController := http.NewRideRequestController(rideRequestService, cursors, logger)
+	pagesController := http.NewPagesController(pinger(s.db), logger)
+	carController := http.NewCarController(carService, cursors, logger)
+	passengersController := http.NewPassengerController(passengerService, cursors, logger)
+	feedController := http.NewFeedController(feedService, cursors, logger)
+	auditController := http.NewAuditController(auditService, cursors, logger)
+	cacheController := http.NewCacheController(s.caches, logger)
 
 	app := echo.New()
 	app.HTTPErrorHandler = handleError(logger)
@@ -123,9 +165,13 @@ func Start() {
 
 	userController.MountRoutes(app.Group("/api/v1"))
 	rideController.MountRoutes(app.Group("/api/v1"))
+	rideSeriesController.MountRoutes(app.Group("/api/v1"))
+	rideRequestController.MountRoutes(app.Group("/api/v1"))
 	carController.MountRoutes(app.Group("/api/v1"))
 	passengersController.MountRoutes(app.Group("/api/v1"))
 	feedController.MountRoutes(app.Group("/api/v1"))
+	auditController.MountRoutes(app.Group("/api/v1"))
+	cacheController.MountRoutes(app.Group("/api/v1"))
 
 	logger.Info("CONFIG", "env", env)
 	port := ":" + conf.Get("port")
@@ -133,6 +179,242 @@ func Start() {
 	app.Logger.Fatal(app.Start(port))
 }
 
+func loadConfig() (config.LoadedData, string) {
+	env := os.Getenv("ENV")
+
+	if env != "PROD" {
+		return config.LoadConfig("./config", "config"), env
+	}
+
+	return config.LoadConfig("/config", "config"), env
+}
+
+// newStores creates the stores for the backend chosen by the db.driver config
+func newStores(conf config.LoadedData, l *Logger) (*storeSet, error) {
+	driver := conf.Get("db.driver")
+	l.Info("CONFIG", "db.driver", driver)
+
+	if driver == "memory" {
+		db := memory.NewDB()
+
+		return &storeSet{
+			users:      memory.NewUserStore(db),
+			cars:       memory.NewCarStore(db),
+			rides:      memory.NewRideStore(db),
+			series:     memory.NewRideSeriesStore(db),
+			requests:   memory.NewRideRequestStore(db),
+			passengers: memory.NewPassengerStore(db),
+			audit:      memory.NewAuditStore(db),
+			outbox:     memory.NewOutboxStore(db),
+			transactor: memory.NewTransactor(db),
+		}, nil
+	}
+
+	db, migrator, err := connectMigrator(conf, l)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := migrator.Up(); err != nil {
+		return nil, err
+	}
+
+	if driver == "sqlite" {
+		return &storeSet{
+			users:      sqlite.NewUserStore(db),
+			cars:       sqlite.NewCarStore(db),
+			rides:      sqlite.NewRideStore(db),
+			series:     sqlite.NewRideSeriesStore(db),
+			requests:   sqlite.NewRideRequestStore(db),
+			passengers: sqlite.NewPassengerStore(db),
+			audit:      sqlite.NewAuditStore(db),
+			outbox:     sqlite.NewOutboxStore(db),
+			transactor: sqlite.NewTransactor(db),
+			db:         db,
+		}, nil
+	}
+
+	return &storeSet{
+		users:      postgres.NewUserStore(db),
+		cars:       postgres.NewCarStore(db),
+		rides:      postgres.NewRideStore(db),
+		series:     postgres.NewRideSeriesStore(db),
+		requests:   postgres.NewRideRequestStore(db),
+		passengers: postgres.NewPassengerStore(db),
+		audit:      postgres.NewAuditStore(db),
+		outbox:     postgres.NewOutboxStore(db),
+		transactor: postgres.NewTransactor(db),
+		db:         db,
+	}, nil
+}
+
+// useCaches wraps the stores with the read-through caches chosen by the cache.driver
+// config, none (default) or lru, cache.size defaults to 1000 records per cache and
+// cache.ttl_seconds to 30 seconds. The lru caches are in process and other instances
+// do not invalidate them, so they only suit a single instance
+func (s *storeSet) useCaches(conf config.LoadedData, l *Logger) error {
+	driver := conf.Get("cache.driver")
+	l.Info("CONFIG", "cache.driver", driver)
+
+	switch driver {
+	case "", "none":
+		return nil
+	case "lru":
+	default:
+		return fmt.Errorf("unknown cache.driver %q", driver)
+	}
+
+	size := conf.GetInt("cache.size")
+	if size <= 0 {
+		size = 1000
+	}
+
+	ttl := conf.GetInt("cache.ttl_seconds")
+	if ttl <= 0 {
+		ttl = 30
+	}
+
+	s.caches = cached.Caches{
+		Users: cache.NewLRU(int(size), time.Duration(ttl)*time.Second),
+		Cars:  cache.NewLRU(int(size), time.Duration(ttl)*time.Second),
+		Rides: cache.NewLRU(int(size), time.Duration(ttl)*time.Second),
+	}
+
+	s.users = cached.NewUserStore(s.users, s.caches.Users)
+	s.cars = cached.NewCarStore(s.cars, s.caches.Cars, s.caches.Rides)
+	s.rides = cached.NewRideStore(s.rides, s.caches.Rides)
+	s.series = cached.NewRideSeriesStore(s.series, s.caches.Rides)
+	s.passengers = cached.NewPassengerStore(s.passengers, s.caches.Rides)
+	s.transactor = cached.NewTransactor(s.transactor, s.caches.Rides)
+
+	return nil
+}
+
+// connectMigrator connects to the SQL db chosen by the db.driver config and
+// creates the migrator for its dialect
+func connectMigrator(conf config.LoadedData, l *Logger) (*sqlx.DB, *migrate.Migrator, error) {
+	switch driver := conf.Get("db.driver"); driver {
+	case "memory":
+		return nil, nil, ErrNothingToMigrate
+	case "sqlite":
+		db := sqlite.NewConnection(conf.Get("db.path"), l)
+		return db, sqlite.NewMigrator(db, l), nil
+	case "", "postgres":
+		db, err := postgres.NewConnection(postgresConfig(conf), l)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return db, postgres.NewMigrator(db, l), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown db.driver %q", driver)
+	}
+}
+
+// purgeRetention is how long soft deleted records are kept, purge.retention_days
+// defaults to 30 days
+func purgeRetention(conf config.LoadedData) time.Duration {
+	days := conf.GetInt("purge.retention_days")
+	if days <= 0 {
+		days = 30
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// purgeInterval is how often the purge job runs, purge.interval_minutes defaults to an hour
+func purgeInterval(conf config.LoadedData) time.Duration {
+	minutes := conf.GetInt("purge.interval_minutes")
+	if minutes <= 0 {
+		minutes = 60
+	}
+
+	return time.Duration(minutes) * time.Minute
+}
+
+// outboxInterval is how often due outbox events are dispatched, outbox.interval_seconds
+// defaults to 5 seconds
+func outboxInterval(conf config.LoadedData) time.Duration {
+	seconds := conf.GetInt("outbox.interval_seconds")
+	if seconds <= 0 {
+		seconds = 5
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// seriesInterval is how often the rides of ride series are materialized,
+// series.materialize_interval_minutes defaults to an hour
+func seriesInterval(conf config.LoadedData) time.Duration {
+	minutes := conf.GetInt("series.materialize_interval_minutes")
+	if minutes <= 0 {
+		minutes = 60
+	}
+
+	return time.Duration(minutes) * time.Minute
+}
+
+// waitlistInterval is how often lapsed seat offers are passed on down the waitlist,
+// rides.waitlist_interval_minutes defaults to 5 minutes
+func waitlistInterval(conf config.LoadedData) time.Duration {
+	minutes := conf.GetInt("rides.waitlist_interval_minutes")
+	if minutes <= 0 {
+		minutes = 5
+	}
+
+	return time.Duration(minutes) * time.Minute
+}
+
+// logOutboxEvent logs every delivered outbox event
+func logOutboxEvent(l *Logger) services.OutboxHandler {
+	return func(event *models.OutboxEvent) error {
+		l.Info("OUTBOX EVENT", "id", event.ID, "type", event.Type, "entity_id", event.EntityID)
+		return nil
+	}
+}
+
+// cursorSecret signs pagination cursors, pagination.cursor_secret defaults to the
+// jwt secret
+func cursorSecret(conf config.LoadedData) string {
+	if secret := conf.Get("pagination.cursor_secret"); secret != "" {
+		return secret
+	}
+
+	return conf.Get("jwt.secret")
+}
+
+// postgresConfig reads the postgres connection and pool settings, db.connect_timeout_seconds
+// defaults to 30 seconds
+func postgresConfig(conf config.LoadedData) postgres.Config {
+	connectTimeout := conf.GetInt("db.connect_timeout_seconds")
+	if connectTimeout <= 0 {
+		connectTimeout = 30
+	}
+
+	return postgres.Config{
+		User:             conf.Get("db.user"),
+		Password:         conf.Get("db.password"),
+		Name:             conf.Get("db.name"),
+		Port:             conf.Get("db.port"),
+		Host:             conf.Get("db.host"),
+		SSLMode:          conf.Get("db.sslmode"),
+		MaxOpenConns:     int(conf.GetInt("db.max_open_conns")),
+		MaxIdleConns:     int(conf.GetInt("db.max_idle_conns")),
+		ConnMaxLifetime:  time.Duration(conf.GetInt("db.conn_max_lifetime_minutes")) * time.Minute,
+		StatementTimeout: time.Duration(conf.GetInt("db.statement_timeout_ms")) * time.Millisecond,
+		ConnectDeadline:  time.Duration(connectTimeout) * time.Second,
+	}
+}
+
+// pinger is the db checked by the readiness endpoint, nil when there is no db
+func pinger(db *sqlx.DB) http.Pinger {
+	if db == nil {
+		return nil
+	}
+
+	return db
+}
+
 func handleError(l *Logger) echo.HTTPErrorHandler {
 	return func(err error, c echo.Context) {
 		code := 500
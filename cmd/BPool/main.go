@@ -1,9 +1,49 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/ucladevx/BPool"
 )
 
+const usage = `usage: BPool [command]
+
+commands:
+  serve             starts the server (default)
+  migrate up        applies all pending migrations
+  migrate down N    rolls back the last N migrations
+  migrate status    lists migrations and whether they are applied
+  export [--out F]  writes users, cars, rides and passengers to F as JSON lines (default stdout)
+  import [--in F]   reads a dump written by export from F (default stdin)
+  seed [flags]      fills the db with made up data, see --seed, --users, --cars, --rides, --passengers`
+
 func main() {
-	bpool.Start()
+	args := os.Args[1:]
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	var err error
+
+	switch args[0] {
+	case "serve":
+		bpool.Start()
+	case "migrate":
+		err = bpool.Migrate(args[1:])
+	case "export":
+		err = bpool.Export(args[1:])
+	case "import":
+		err = bpool.Import(args[1:])
+	case "seed":
+		err = bpool.Seed(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
 }
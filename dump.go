@@ -0,0 +1,121 @@
+package bpool
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/ucladevx/BPool/services"
+
+	"github.com/codyleyhan/config-loader"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrExportUsage occurs when the export command is given bad arguments
+	ErrExportUsage = errors.New("usage: export [--out dump.jsonl]")
+
+	// ErrImportUsage occurs when the import command is given bad arguments
+	ErrImportUsage = errors.New("usage: import [--in dump.jsonl]")
+)
+
+// Export runs the export command, writing every user, car, ride series, ride, ride request
+// and passenger to the --out file, or stdout if it is not given
+func Export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	out := flags.String("out", "-", "the file to write the dump to")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return ErrExportUsage
+	}
+
+	return withStores(func(conf config.LoadedData, s *storeSet, l *Logger) error {
+		dump := services.NewDumpService(s.users, s.cars, s.series, s.rides, s.requests, s.passengers, l)
+		w := io.Writer(os.Stdout)
+
+		if *out != "-" {
+			file, err := os.Create(*out)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			w = file
+		}
+
+		counts, err := dump.Export(w)
+		fmt.Fprintf(os.Stderr, "exported %s\n", formatDumpCounts(counts))
+
+		return err
+	})
+}
+
+// Import runs the import command, persisting the records of the --in file, or
+// stdin if it is not given, with their ids and timestamps
+func Import(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	in := flags.String("in", "-", "the file to read the dump from")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return ErrImportUsage
+	}
+
+	return withStores(func(conf config.LoadedData, s *storeSet, l *Logger) error {
+		dump := services.NewDumpService(s.users, s.cars, s.series, s.rides, s.requests, s.passengers, l)
+		r := io.Reader(os.Stdin)
+
+		if *in != "-" {
+			file, err := os.Open(*in)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			r = file
+		}
+
+		counts, err := dump.Import(r)
+		fmt.Fprintf(os.Stderr, "imported %s\n", formatDumpCounts(counts))
+
+		return err
+	})
+}
+
+// withStores connects to the configured backend, applying any pending migrations,
+// and runs fn with the config and the backend's stores
+func withStores(fn func(conf config.LoadedData, s *storeSet, l *Logger) error) error {
+	conf, _ := loadConfig()
+
+	loggerUnsugared, err := zap.NewDevelopment()
+	if err != nil {
+		return err
+	}
+	defer loggerUnsugared.Sync()
+
+	logger := NewBPoolLogger(loggerUnsugared.Sugar())
+
+	s, err := newStores(conf, logger)
+	if err != nil {
+		return err
+	}
+	if s.db != nil {
+		defer s.db.Close()
+	}
+
+	return fn(conf, s, logger)
+}
+
+func formatDumpCounts(counts services.DumpCounts) string {
+	parts := []string{}
+	for _, kind := range services.DumpKinds {
+		parts = append(parts, fmt.Sprintf("%d %s(s)", counts[kind], kind))
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,84 @@
+package bpool
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrMigrateUsage occurs when the migrate command is given bad arguments
+	ErrMigrateUsage = errors.New("usage: migrate up | migrate down N | migrate status")
+
+	// ErrNothingToMigrate occurs when the configured backend has no schema
+	ErrNothingToMigrate = errors.New("the memory backend has no schema to migrate")
+)
+
+// Migrate runs the migrate command, args are one of: up, down N, status
+func Migrate(args []string) error {
+	if len(args) == 0 {
+		return ErrMigrateUsage
+	}
+
+	conf, _ := loadConfig()
+
+	loggerUnsugared, err := zap.NewDevelopment()
+	if err != nil {
+		return err
+	}
+	defer loggerUnsugared.Sync()
+
+	logger := NewBPoolLogger(loggerUnsugared.Sugar())
+
+	db, migrator, err := connectMigrator(conf, logger)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		count, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("applied %d migration(s)\n", count)
+	case "down":
+		if len(args) != 2 {
+			return ErrMigrateUsage
+		}
+
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return ErrMigrateUsage
+		}
+
+		count, err := migrator.Down(n)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("rolled back %d migration(s)\n", count)
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.Applied {
+				appliedAt = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, appliedAt)
+		}
+	default:
+		return ErrMigrateUsage
+	}
+
+	return nil
+}
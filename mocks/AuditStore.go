@@ -0,0 +1,49 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+import models "github.com/ucladevx/BPool/models"
+
+import stores "github.com/ucladevx/BPool/stores"
+
+// AuditStore is an autogenerated mock type for the AuditStore type
+type AuditStore struct {
+	mock.Mock
+}
+
+// Insert provides a mock function with given fields: event
+func (_m *AuditStore) Insert(event *models.AuditEvent) error {
+	ret := _m.Called(event)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.AuditEvent) error); ok {
+		r0 = rf(event)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WhereMany provides a mock function with given fields: clauses
+func (_m *AuditStore) WhereMany(clauses []stores.QueryModifier) ([]*models.AuditEvent, error) {
+	ret := _m.Called(clauses)
+
+	var r0 []*models.AuditEvent
+	if rf, ok := ret.Get(0).(func([]stores.QueryModifier) []*models.AuditEvent); ok {
+		r0 = rf(clauses)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.AuditEvent)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]stores.QueryModifier) error); ok {
+		r1 = rf(clauses)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
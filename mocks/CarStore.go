@@ -2,22 +2,24 @@
 package mocks
 
 import mock "github.com/stretchr/testify/mock"
+import time "time"
 import models "github.com/ucladevx/BPool/models"
 
 import stores "github.com/ucladevx/BPool/stores"
+import pagination "github.com/ucladevx/BPool/utils/pagination"
 
 // CarStore is an autogenerated mock type for the CarStore type
 type CarStore struct {
 	mock.Mock
 }
 
-// GetAll provides a mock function with given fields: lastID, limit
-func (_m *CarStore) GetAll(lastID string, limit int) ([]*models.Car, error) {
-	ret := _m.Called(lastID, limit)
+// GetAll provides a mock function with given fields: page
+func (_m *CarStore) GetAll(page pagination.Page) ([]*models.Car, error) {
+	ret := _m.Called(page)
 
 	var r0 []*models.Car
-	if rf, ok := ret.Get(0).(func(string, int) []*models.Car); ok {
-		r0 = rf(lastID, limit)
+	if rf, ok := ret.Get(0).(func(pagination.Page) []*models.Car); ok {
+		r0 = rf(page)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*models.Car)
@@ -25,8 +27,8 @@ func (_m *CarStore) GetAll(lastID string, limit int) ([]*models.Car, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, int) error); ok {
-		r1 = rf(lastID, limit)
+	if rf, ok := ret.Get(1).(func(pagination.Page) error); ok {
+		r1 = rf(page)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -78,6 +80,20 @@ func (_m *CarStore) GetCount(queryModifiers []stores.QueryModifier) (int, error)
 	return r0, r1
 }
 
+// Import provides a mock function with given fields: car
+func (_m *CarStore) Import(car *models.Car) error {
+	ret := _m.Called(car)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.Car) error); ok {
+		r0 = rf(car)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Insert provides a mock function with given fields: car
 func (_m *CarStore) Insert(car *models.Car) error {
 	ret := _m.Called(car)
@@ -105,3 +121,38 @@ func (_m *CarStore) Remove(id string) error {
 
 	return r0
 }
+
+// Purge provides a mock function with given fields: before
+func (_m *CarStore) Purge(before time.Time) (int, error) {
+	ret := _m.Called(before)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(time.Time) int); ok {
+		r0 = rf(before)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: id
+func (_m *CarStore) Restore(id string) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,107 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+import models "github.com/ucladevx/BPool/models"
+
+import time "time"
+
+// OutboxStore is an autogenerated mock type for the OutboxStore type
+type OutboxStore struct {
+	mock.Mock
+}
+
+// Claim provides a mock function with given fields: now, until, limit
+func (_m *OutboxStore) Claim(now time.Time, until time.Time, limit int) ([]*models.OutboxEvent, error) {
+	ret := _m.Called(now, until, limit)
+
+	var r0 []*models.OutboxEvent
+	if rf, ok := ret.Get(0).(func(time.Time, time.Time, int) []*models.OutboxEvent); ok {
+		r0 = rf(now, until, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.OutboxEvent)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time, time.Time, int) error); ok {
+		r1 = rf(now, until, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDue provides a mock function with given fields: now, limit
+func (_m *OutboxStore) GetDue(now time.Time, limit int) ([]*models.OutboxEvent, error) {
+	ret := _m.Called(now, limit)
+
+	var r0 []*models.OutboxEvent
+	if rf, ok := ret.Get(0).(func(time.Time, int) []*models.OutboxEvent); ok {
+		r0 = rf(now, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.OutboxEvent)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time, int) error); ok {
+		r1 = rf(now, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Insert provides a mock function with given fields: event
+func (_m *OutboxStore) Insert(event *models.OutboxEvent) error {
+	ret := _m.Called(event)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.OutboxEvent) error); ok {
+		r0 = rf(event)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Purge provides a mock function with given fields: before
+func (_m *OutboxStore) Purge(before time.Time) (int, error) {
+	ret := _m.Called(before)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(time.Time) int); ok {
+		r0 = rf(before)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: event
+func (_m *OutboxStore) Update(event *models.OutboxEvent) error {
+	ret := _m.Called(event)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.OutboxEvent) error); ok {
+		r0 = rf(event)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
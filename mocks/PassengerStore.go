@@ -2,9 +2,11 @@
 package mocks
 
 import mock "github.com/stretchr/testify/mock"
+import time "time"
 import models "github.com/ucladevx/BPool/models"
 
 import stores "github.com/ucladevx/BPool/stores"
+import pagination "github.com/ucladevx/BPool/utils/pagination"
 
 // PassengerStore is an autogenerated mock type for the PassengerStore type
 type PassengerStore struct {
@@ -46,13 +48,13 @@ func (_m *PassengerStore) Delete(id string) error {
 	return r0
 }
 
-// GetAll provides a mock function with given fields: lastID, limit
-func (_m *PassengerStore) GetAll(lastID string, limit int) ([]*models.Passenger, error) {
-	ret := _m.Called(lastID, limit)
+// GetAll provides a mock function with given fields: page
+func (_m *PassengerStore) GetAll(page pagination.Page) ([]*models.Passenger, error) {
+	ret := _m.Called(page)
 
 	var r0 []*models.Passenger
-	if rf, ok := ret.Get(0).(func(string, int) []*models.Passenger); ok {
-		r0 = rf(lastID, limit)
+	if rf, ok := ret.Get(0).(func(pagination.Page) []*models.Passenger); ok {
+		r0 = rf(page)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*models.Passenger)
@@ -60,8 +62,8 @@ func (_m *PassengerStore) GetAll(lastID string, limit int) ([]*models.Passenger,
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, int) error); ok {
-		r1 = rf(lastID, limit)
+	if rf, ok := ret.Get(1).(func(pagination.Page) error); ok {
+		r1 = rf(page)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -92,6 +94,20 @@ func (_m *PassengerStore) GetByID(id string) (*models.Passenger, error) {
 	return r0, r1
 }
 
+// Import provides a mock function with given fields: passenger
+func (_m *PassengerStore) Import(passenger *models.Passenger) error {
+	ret := _m.Called(passenger)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.Passenger) error); ok {
+		r0 = rf(passenger)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Insert provides a mock function with given fields: ride
 func (_m *PassengerStore) Insert(ride *models.Passenger) error {
 	ret := _m.Called(ride)
@@ -106,6 +122,27 @@ func (_m *PassengerStore) Insert(ride *models.Passenger) error {
 	return r0
 }
 
+// SumSeats provides a mock function with given fields: clauses
+func (_m *PassengerStore) SumSeats(clauses []stores.QueryModifier) (int, error) {
+	ret := _m.Called(clauses)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func([]stores.QueryModifier) int); ok {
+		r0 = rf(clauses)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]stores.QueryModifier) error); ok {
+		r1 = rf(clauses)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: ride
 func (_m *PassengerStore) Update(ride *models.Passenger) error {
 	ret := _m.Called(ride)
@@ -142,3 +179,38 @@ func (_m *PassengerStore) WhereMany(clauses []stores.QueryModifier) ([]*models.P
 
 	return r0, r1
 }
+
+// Purge provides a mock function with given fields: before
+func (_m *PassengerStore) Purge(before time.Time) (int, error) {
+	ret := _m.Called(before)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(time.Time) int); ok {
+		r0 = rf(before)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: id
+func (_m *PassengerStore) Restore(id string) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
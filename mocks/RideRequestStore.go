@@ -0,0 +1,159 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+import models "github.com/ucladevx/BPool/models"
+import pagination "github.com/ucladevx/BPool/utils/pagination"
+import stores "github.com/ucladevx/BPool/stores"
+import time "time"
+
+// RideRequestStore is an autogenerated mock type for the RideRequestStore type
+type RideRequestStore struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields: id
+func (_m *RideRequestStore) Delete(id string) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAll provides a mock function with given fields: page
+func (_m *RideRequestStore) GetAll(page pagination.Page) ([]*models.RideRequest, error) {
+	ret := _m.Called(page)
+
+	var r0 []*models.RideRequest
+	if rf, ok := ret.Get(0).(func(pagination.Page) []*models.RideRequest); ok {
+		r0 = rf(page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.RideRequest)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(pagination.Page) error); ok {
+		r1 = rf(page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByID provides a mock function with given fields: id
+func (_m *RideRequestStore) GetByID(id string) (*models.RideRequest, error) {
+	ret := _m.Called(id)
+
+	var r0 *models.RideRequest
+	if rf, ok := ret.Get(0).(func(string) *models.RideRequest); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.RideRequest)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Import provides a mock function with given fields: request
+func (_m *RideRequestStore) Import(request *models.RideRequest) error {
+	ret := _m.Called(request)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.RideRequest) error); ok {
+		r0 = rf(request)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Insert provides a mock function with given fields: request
+func (_m *RideRequestStore) Insert(request *models.RideRequest) error {
+	ret := _m.Called(request)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.RideRequest) error); ok {
+		r0 = rf(request)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Purge provides a mock function with given fields: before
+func (_m *RideRequestStore) Purge(before time.Time) (int, error) {
+	ret := _m.Called(before)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(time.Time) int); ok {
+		r0 = rf(before)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: request
+func (_m *RideRequestStore) Update(request *models.RideRequest) error {
+	ret := _m.Called(request)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.RideRequest) error); ok {
+		r0 = rf(request)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WhereMany provides a mock function with given fields: clauses
+func (_m *RideRequestStore) WhereMany(clauses []stores.QueryModifier) ([]*models.RideRequest, error) {
+	ret := _m.Called(clauses)
+
+	var r0 []*models.RideRequest
+	if rf, ok := ret.Get(0).(func([]stores.QueryModifier) []*models.RideRequest); ok {
+		r0 = rf(clauses)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.RideRequest)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]stores.QueryModifier) error); ok {
+		r1 = rf(clauses)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
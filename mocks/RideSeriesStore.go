@@ -0,0 +1,196 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+import models "github.com/ucladevx/BPool/models"
+import pagination "github.com/ucladevx/BPool/utils/pagination"
+import stores "github.com/ucladevx/BPool/stores"
+import time "time"
+
+// RideSeriesStore is an autogenerated mock type for the RideSeriesStore type
+type RideSeriesStore struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields: id
+func (_m *RideSeriesStore) Delete(id string) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetActive provides a mock function with given fields:
+func (_m *RideSeriesStore) GetActive() ([]*models.RideSeries, error) {
+	ret := _m.Called()
+
+	var r0 []*models.RideSeries
+	if rf, ok := ret.Get(0).(func() []*models.RideSeries); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.RideSeries)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAll provides a mock function with given fields: page
+func (_m *RideSeriesStore) GetAll(page pagination.Page) ([]*models.RideSeries, error) {
+	ret := _m.Called(page)
+
+	var r0 []*models.RideSeries
+	if rf, ok := ret.Get(0).(func(pagination.Page) []*models.RideSeries); ok {
+		r0 = rf(page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.RideSeries)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(pagination.Page) error); ok {
+		r1 = rf(page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByID provides a mock function with given fields: id
+func (_m *RideSeriesStore) GetByID(id string) (*models.RideSeries, error) {
+	ret := _m.Called(id)
+
+	var r0 *models.RideSeries
+	if rf, ok := ret.Get(0).(func(string) *models.RideSeries); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.RideSeries)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Import provides a mock function with given fields: series
+func (_m *RideSeriesStore) Import(series *models.RideSeries) error {
+	ret := _m.Called(series)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.RideSeries) error); ok {
+		r0 = rf(series)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Insert provides a mock function with given fields: series
+func (_m *RideSeriesStore) Insert(series *models.RideSeries) error {
+	ret := _m.Called(series)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.RideSeries) error); ok {
+		r0 = rf(series)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Purge provides a mock function with given fields: before
+func (_m *RideSeriesStore) Purge(before time.Time) (int, error) {
+	ret := _m.Called(before)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(time.Time) int); ok {
+		r0 = rf(before)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetMaterializedThrough provides a mock function with given fields: id, date
+func (_m *RideSeriesStore) SetMaterializedThrough(id string, date *string) error {
+	ret := _m.Called(id, date)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, *string) error); ok {
+		r0 = rf(id, date)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: series
+func (_m *RideSeriesStore) Update(series *models.RideSeries) error {
+	ret := _m.Called(series)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.RideSeries) error); ok {
+		r0 = rf(series)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WhereMany provides a mock function with given fields: clauses
+func (_m *RideSeriesStore) WhereMany(clauses []stores.QueryModifier) ([]*models.RideSeries, error) {
+	ret := _m.Called(clauses)
+
+	var r0 []*models.RideSeries
+	if rf, ok := ret.Get(0).(func([]stores.QueryModifier) []*models.RideSeries); ok {
+		r0 = rf(clauses)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.RideSeries)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]stores.QueryModifier) error); ok {
+		r1 = rf(clauses)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -2,15 +2,38 @@
 package mocks
 
 import mock "github.com/stretchr/testify/mock"
+import time "time"
 import models "github.com/ucladevx/BPool/models"
 
 import stores "github.com/ucladevx/BPool/stores"
+import pagination "github.com/ucladevx/BPool/utils/pagination"
 
 // RideStore is an autogenerated mock type for the RideStore type
 type RideStore struct {
 	mock.Mock
 }
 
+// Count provides a mock function with given fields: clauses
+func (_m *RideStore) Count(clauses []stores.QueryModifier) (int, error) {
+	ret := _m.Called(clauses)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func([]stores.QueryModifier) int); ok {
+		r0 = rf(clauses)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]stores.QueryModifier) error); ok {
+		r1 = rf(clauses)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Delete provides a mock function with given fields: id
 func (_m *RideStore) Delete(id string) error {
 	ret := _m.Called(id)
@@ -25,13 +48,13 @@ func (_m *RideStore) Delete(id string) error {
 	return r0
 }
 
-// GetAll provides a mock function with given fields: lastID, limit
-func (_m *RideStore) GetAll(lastID string, limit int) ([]*models.Ride, error) {
-	ret := _m.Called(lastID, limit)
+// GetAll provides a mock function with given fields: page
+func (_m *RideStore) GetAll(page pagination.Page) ([]*models.Ride, error) {
+	ret := _m.Called(page)
 
 	var r0 []*models.Ride
-	if rf, ok := ret.Get(0).(func(string, int) []*models.Ride); ok {
-		r0 = rf(lastID, limit)
+	if rf, ok := ret.Get(0).(func(pagination.Page) []*models.Ride); ok {
+		r0 = rf(page)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*models.Ride)
@@ -39,8 +62,8 @@ func (_m *RideStore) GetAll(lastID string, limit int) ([]*models.Ride, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, int) error); ok {
-		r1 = rf(lastID, limit)
+	if rf, ok := ret.Get(1).(func(pagination.Page) error); ok {
+		r1 = rf(page)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -94,6 +117,43 @@ func (_m *RideStore) GetByID(id string) (*models.Ride, error) {
 	return r0, r1
 }
 
+// GetByIDForUpdate provides a mock function with given fields: id
+func (_m *RideStore) GetByIDForUpdate(id string) (*models.Ride, error) {
+	ret := _m.Called(id)
+
+	var r0 *models.Ride
+	if rf, ok := ret.Get(0).(func(string) *models.Ride); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Ride)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Import provides a mock function with given fields: ride
+func (_m *RideStore) Import(ride *models.Ride) error {
+	ret := _m.Called(ride)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.Ride) error); ok {
+		r0 = rf(ride)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Insert provides a mock function with given fields: ride
 func (_m *RideStore) Insert(ride *models.Ride) error {
 	ret := _m.Called(ride)
@@ -144,3 +204,61 @@ func (_m *RideStore) WhereMany(clauses []stores.QueryModifier) ([]*models.Ride,
 
 	return r0, r1
 }
+
+// Purge provides a mock function with given fields: before
+func (_m *RideStore) Purge(before time.Time) (int, error) {
+	ret := _m.Called(before)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(time.Time) int); ok {
+		r0 = rf(before)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: id
+func (_m *RideStore) Restore(id string) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Search provides a mock function with given fields: search
+func (_m *RideStore) Search(search *models.RideSearch) ([]*models.Ride, error) {
+	ret := _m.Called(search)
+
+	var r0 []*models.Ride
+	if rf, ok := ret.Get(0).(func(*models.RideSearch) []*models.Ride); ok {
+		r0 = rf(search)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Ride)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*models.RideSearch) error); ok {
+		r1 = rf(search)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
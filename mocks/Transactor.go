@@ -0,0 +1,48 @@
+package mocks
+
+import services "github.com/ucladevx/BPool/services"
+
+// Transactor is a mock transactor that runs units of work directly against mocked stores
+type Transactor struct {
+	RideStore        *RideStore
+	RideSeriesStore  *RideSeriesStore
+	RideRequestStore *RideRequestStore
+	PassengerStore   *PassengerStore
+	AuditStore       *AuditStore
+	OutboxStore      *OutboxStore
+}
+
+// WithTx runs fn with the mocked stores, there is nothing to commit or roll back
+func (t *Transactor) WithTx(fn func(tx services.Tx) error) error {
+	return fn(t)
+}
+
+// Rides returns the mocked ride store
+func (t *Transactor) Rides() services.RideStore {
+	return t.RideStore
+}
+
+// RideSeries returns the mocked ride series store
+func (t *Transactor) RideSeries() services.RideSeriesStore {
+	return t.RideSeriesStore
+}
+
+// RideRequests returns the mocked ride request store
+func (t *Transactor) RideRequests() services.RideRequestStore {
+	return t.RideRequestStore
+}
+
+// Passengers returns the mocked passenger store
+func (t *Transactor) Passengers() services.PassengerStore {
+	return t.PassengerStore
+}
+
+// Audit returns the mocked audit store
+func (t *Transactor) Audit() services.AuditStore {
+	return t.AuditStore
+}
+
+// Outbox returns the mocked outbox store
+func (t *Transactor) Outbox() services.OutboxStore {
+	return t.OutboxStore
+}
@@ -3,19 +3,20 @@ package mocks
 
 import mock "github.com/stretchr/testify/mock"
 import models "github.com/ucladevx/BPool/models"
+import pagination "github.com/ucladevx/BPool/utils/pagination"
 
 // UserStore is an autogenerated mock type for the UserStore type
 type UserStore struct {
 	mock.Mock
 }
 
-// GetAll provides a mock function with given fields: lastID, limit
-func (_m *UserStore) GetAll(lastID string, limit int) ([]*models.User, error) {
-	ret := _m.Called(lastID, limit)
+// GetAll provides a mock function with given fields: page
+func (_m *UserStore) GetAll(page pagination.Page) ([]*models.User, error) {
+	ret := _m.Called(page)
 
 	var r0 []*models.User
-	if rf, ok := ret.Get(0).(func(string, int) []*models.User); ok {
-		r0 = rf(lastID, limit)
+	if rf, ok := ret.Get(0).(func(pagination.Page) []*models.User); ok {
+		r0 = rf(page)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*models.User)
@@ -23,8 +24,8 @@ func (_m *UserStore) GetAll(lastID string, limit int) ([]*models.User, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, int) error); ok {
-		r1 = rf(lastID, limit)
+	if rf, ok := ret.Get(1).(func(pagination.Page) error); ok {
+		r1 = rf(page)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -78,6 +79,20 @@ func (_m *UserStore) GetByID(id string) (*models.User, error) {
 	return r0, r1
 }
 
+// Import provides a mock function with given fields: user
+func (_m *UserStore) Import(user *models.User) error {
+	ret := _m.Called(user)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.User) error); ok {
+		r0 = rf(user)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Insert provides a mock function with given fields: user
 func (_m *UserStore) Insert(user *models.User) error {
 	ret := _m.Called(user)
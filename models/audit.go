@@ -0,0 +1,97 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+const (
+	// AuditEntityRide is the entity name of audit events for rides
+	AuditEntityRide = "ride"
+	// AuditEntityPassenger is the entity name of audit events for passengers
+	AuditEntityPassenger = "passenger"
+
+	// AuditActionUpdate is the action of audit events for updates
+	AuditActionUpdate = "update"
+	// AuditActionDelete is the action of audit events for soft deletes
+	AuditActionDelete = "delete"
+	// AuditActionRestore is the action of audit events for undoing soft deletes
+	AuditActionRestore = "restore"
+)
+
+// AuditEvent is an append-only record of a change made to an entity, Before and
+// After hold only the fields that changed
+type AuditEvent struct {
+	ID        string         `json:"id" db:"id"`
+	ActorID   string         `json:"actor_id" db:"actor_id"`
+	Entity    string         `json:"entity" db:"entity"`
+	EntityID  string         `json:"entity_id" db:"entity_id"`
+	Action    string         `json:"action" db:"action"`
+	Before    types.JSONText `json:"before" db:"before"`
+	After     types.JSONText `json:"after" db:"after"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+// NewAuditEvent creates an audit event from the JSON of an entity before and after
+// a change, updated_at is left out since it changes with every update
+func NewAuditEvent(actorID, entity, entityID, action string, before, after interface{}) (*AuditEvent, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	for field, value := range afterFields {
+		if old, ok := beforeFields[field]; ok && string(old) == string(value) {
+			delete(beforeFields, field)
+			delete(afterFields, field)
+		}
+	}
+
+	delete(beforeFields, "updated_at")
+	delete(afterFields, "updated_at")
+
+	beforeJSON, err := json.Marshal(beforeFields)
+	if err != nil {
+		return nil, err
+	}
+
+	afterJSON, err := json.Marshal(afterFields)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditEvent{
+		ActorID:  actorID,
+		Entity:   entity,
+		EntityID: entityID,
+		Action:   action,
+		Before:   types.JSONText(beforeJSON),
+		After:    types.JSONText(afterJSON),
+	}, nil
+}
+
+// jsonFields is the JSON of every field of v, a nil v has no fields
+func jsonFields(v interface{}) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if v == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,38 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ucladevx/BPool/models"
+)
+
+func TestNewAuditEvent(t *testing.T) {
+	assert := assert.New(t)
+
+	before := models.Passenger{
+		ID:        "abc",
+		RideID:    "xyz",
+		Status:    models.PassengerInterested,
+		UpdatedAt: time.Now(),
+	}
+
+	after := before
+	after.Status = models.PassengerAccepted
+	after.UpdatedAt = before.UpdatedAt.Add(time.Minute)
+
+	event, err := models.NewAuditEvent("123", models.AuditEntityPassenger, after.ID, models.AuditActionUpdate, before, after)
+	assert.Nil(err)
+	assert.Equal("123", event.ActorID)
+	assert.Equal(models.AuditEntityPassenger, event.Entity)
+	assert.Equal("abc", event.EntityID)
+	assert.Equal(models.AuditActionUpdate, event.Action)
+	assert.JSONEq(`{"status": "interested"}`, event.Before.String(), "only changed fields should be kept")
+	assert.JSONEq(`{"status": "accepted"}`, event.After.String(), "only changed fields should be kept")
+
+	event, err = models.NewAuditEvent("123", models.AuditEntityPassenger, after.ID, models.AuditActionUpdate, before, before)
+	assert.Nil(err)
+	assert.JSONEq(`{}`, event.Before.String(), "there should be no changes")
+	assert.JSONEq(`{}`, event.After.String(), "there should be no changes")
+}
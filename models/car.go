@@ -8,14 +8,15 @@ import (
 
 // Car model instance
 type Car struct {
-	ID        string    `json:"id"`
-	Make      string    `json:"make"`
-	Model     string    `json:"model"`
-	Year      int       `json:"year"`
-	Color     string    `json:"color"`
-	UserID    string    `json:"user_id" db:"user_id"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID        string     `json:"id"`
+	Make      string     `json:"make"`
+	Model     string     `json:"model"`
+	Year      int        `json:"year"`
+	Color     string     `json:"color"`
+	UserID    string     `json:"user_id" db:"user_id"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
 // CarChangeSet is an object for updates
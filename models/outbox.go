@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+const (
+	// EventRideCreated is the type of outbox events for new rides
+	EventRideCreated = "ride.created"
+	// EventRideUpdated is the type of outbox events for changed rides
+	EventRideUpdated = "ride.updated"
+	// EventRideDeleted is the type of outbox events for deleted rides
+	EventRideDeleted = "ride.deleted"
+	// EventRideDeparted is the type of outbox events for rides the driver has started
+	EventRideDeparted = "ride.departed"
+	// EventRideCompleted is the type of outbox events for rides that have arrived
+	EventRideCompleted = "ride.completed"
+	// EventRideCancelled is the type of outbox events for rides the driver called off
+	EventRideCancelled = "ride.cancelled"
+	// EventRideRestored is the type of outbox events for deleted rides an admin brought back
+	EventRideRestored = "ride.restored"
+
+	// EventPassengerRequested is the type of outbox events for users asking to join a ride
+	EventPassengerRequested = "passenger.requested"
+	// EventPassengerAccepted is the type of outbox events for passengers accepted by the driver
+	EventPassengerAccepted = "passenger.accepted"
+	// EventPassengerRejected is the type of outbox events for passengers rejected by the driver
+	EventPassengerRejected = "passenger.rejected"
+	// EventPassengerCancelled is the type of outbox events for passengers whose ride the driver cancelled
+	EventPassengerCancelled = "passenger.cancelled_by_driver"
+	// EventPassengerOffered is the type of outbox events for drivers offering a ride to a ride request
+	EventPassengerOffered = "passenger.offered"
+	// EventPassengerWaitlisted is the type of outbox events for passengers accepted into a full ride
+	EventPassengerWaitlisted = "passenger.waitlisted"
+	// EventPassengerSeatOffered is the type of outbox events for waitlisted passengers offered a seat that freed up
+	EventPassengerSeatOffered = "passenger.seat_offered"
+	// EventPassengerSeatOfferExpired is the type of outbox events for passengers who did not confirm a seat offered to them in time
+	EventPassengerSeatOfferExpired = "passenger.seat_offer_expired"
+	// EventPassengerWithdrawn is the type of outbox events for passengers who left a ride
+	EventPassengerWithdrawn = "passenger.withdrawn"
+	// EventPassengerDeleted is the type of outbox events for passengers the driver removed
+	EventPassengerDeleted = "passenger.deleted"
+	// EventPassengerRestored is the type of outbox events for deleted passengers an admin brought back
+	EventPassengerRestored = "passenger.restored"
+
+	// EventRideRequestFulfilled is the type of outbox events for ride requests whose passenger confirmed an offer
+	EventRideRequestFulfilled = "ride_request.fulfilled"
+)
+
+// OutboxEvent is a domain event written in the same transaction as the change it
+// describes, it is due while NextAttemptAt is set and delivered once DeliveredAt is,
+// an event that is neither due nor delivered has run out of attempts
+type OutboxEvent struct {
+	ID            string         `json:"id" db:"id"`
+	Type          string         `json:"type" db:"type"`
+	EntityID      string         `json:"entity_id" db:"entity_id"`
+	Payload       types.JSONText `json:"payload" db:"payload"`
+	Attempts      int            `json:"attempts" db:"attempts"`
+	LastError     *string        `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt *time.Time     `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	DeliveredAt   *time.Time     `json:"delivered_at,omitempty" db:"delivered_at"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+}
+
+// NewOutboxEvent creates an outbox event holding the JSON of the entity it is about
+func NewOutboxEvent(eventType, entityID string, payload interface{}) (*OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxEvent{
+		Type:     eventType,
+		EntityID: entityID,
+		Payload:  types.JSONText(data),
+	}, nil
+}
@@ -14,31 +14,47 @@ const (
 	PassengerInterested = "interested"
 	// PassengerRejected means the passenger cannot join the ride
 	PassengerRejected = "rejected"
+	// PassengerCancelledByDriver means the driver cancelled the ride the passenger was in or interested in
+	PassengerCancelledByDriver = "cancelled_by_driver"
+	// PassengerWaitlisted means the driver accepted the passenger but the ride was full
+	PassengerWaitlisted = "waitlisted"
+	// PassengerSeatOffered means a seat freed up for the passenger, which is held until they confirm it or ConfirmBy passes
+	PassengerSeatOffered = "seat_offered"
+	// PassengerWithdrawn means the passenger left the ride or did not confirm a seat offered to them in time
+	PassengerWithdrawn = "withdrawn"
 )
 
 type (
 	// Passenger is the entity for the ride passenger relation
 	Passenger struct {
-		ID          string    `json:"id" db:"id"`
-		DriverID    string    `json:"driver_id" db:"driver_id"`
-		PassengerID string    `json:"passenger_id" db:"passenger_id"`
-		RideID      string    `json:"ride_id" db:"ride_id"`
-		Status      string    `json:"status" db:"status"`
-		CreatedAt   time.Time `json:"created_at" db:"created_at"`
-		UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+		ID             string     `json:"id" db:"id"`
+		DriverID       string     `json:"driver_id" db:"driver_id"`
+		PassengerID    string     `json:"passenger_id" db:"passenger_id"`
+		RideID         string     `json:"ride_id" db:"ride_id"`
+		Status         string     `json:"status" db:"status"`
+		SeatsRequested int        `json:"seats_requested" db:"seats_requested"` // the size of the passenger's party, the seats they take once accepted
+		RequestID      *string    `json:"request_id,omitempty" db:"request_id"` // the ride request the driver offered the ride to
+		ConfirmBy      *time.Time `json:"confirm_by,omitempty" db:"confirm_by"` // when a seat offered from the waitlist lapses
+		CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+		UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+		DeletedAt      *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+		Version        int        `json:"version" db:"version"`
 	}
 
 	// PassengerChangeSet is what is allowed to be changed
 	PassengerChangeSet struct {
-		PassengerID *string `json:"passenger_id"`
-		RideID      *string `json:"ride_id"`
-		Status      *string `json:"status"`
+		PassengerID    *string `json:"passenger_id"`
+		RideID         *string `json:"ride_id"`
+		Status         *string `json:"status"`
+		SeatsRequested *int    `json:"seats_requested"`
+		Version        *int    `json:"version"`
 	}
 )
 
-// NewPassenger creates and validates a passenger from a changeset
+// NewPassenger creates and validates a passenger from a changeset, for one seat unless
+// the changeset asks for more
 func NewPassenger(p *PassengerChangeSet) (*Passenger, error) {
-	passenger := Passenger{}
+	passenger := Passenger{SeatsRequested: 1}
 
 	if err := passenger.ApplyUpdates(p); err != nil {
 		return nil, err
@@ -56,7 +72,11 @@ func (p *Passenger) Validate() error {
 	return validation.ValidateStruct(p,
 		validation.Field(&p.RideID, validation.Required),
 		validation.Field(&p.PassengerID, validation.Required),
-		validation.Field(&p.Status, validation.Required, validation.In(PassengerAccepted, PassengerInterested, PassengerRejected)),
+		validation.Field(&p.SeatsRequested, validation.Required, validation.Min(1)),
+		validation.Field(&p.Status, validation.Required, validation.In(
+			PassengerAccepted, PassengerInterested, PassengerRejected, PassengerCancelledByDriver,
+			PassengerWaitlisted, PassengerSeatOffered, PassengerWithdrawn,
+		)),
 	)
 }
 
@@ -74,6 +94,17 @@ func (p *Passenger) ApplyUpdates(o *PassengerChangeSet) error {
 
 	if o.Status != nil {
 		newPassenger.Status = *o.Status
+		if newPassenger.Status != PassengerSeatOffered {
+			newPassenger.ConfirmBy = nil
+		}
+	}
+
+	if o.SeatsRequested != nil {
+		newPassenger.SeatsRequested = *o.SeatsRequested
+	}
+
+	if o.Version != nil {
+		newPassenger.Version = *o.Version
 	}
 
 	if err := newPassenger.Validate(); err != nil {
@@ -84,3 +115,9 @@ func (p *Passenger) ApplyUpdates(o *PassengerChangeSet) error {
 
 	return nil
 }
+
+// HoldsSeat tells if the passenger takes up one of the ride's seats, accepted
+// passengers and those offered a seat from the waitlist do
+func (p *Passenger) HoldsSeat() bool {
+	return p.Status == PassengerAccepted || p.Status == PassengerSeatOffered
+}
@@ -0,0 +1,28 @@
+package models
+
+// DriverReliability is how often a driver has seen their rides through, a late
+// cancellation is one made shortly before the ride was due to depart
+type DriverReliability struct {
+	DriverID          string  `json:"driver_id"`
+	Completed         int     `json:"completed"`
+	Cancelled         int     `json:"cancelled"`
+	LateCancellations int     `json:"late_cancellations"`
+	Score             float64 `json:"score"`
+}
+
+// NewDriverReliability scores a driver by the share of their finished rides that
+// were not cancelled late, drivers without finished rides score 1
+func NewDriverReliability(driverID string, completed, cancelled, late int) *DriverReliability {
+	score := 1.0
+	if total := completed + cancelled; total > 0 {
+		score = float64(total-late) / float64(total)
+	}
+
+	return &DriverReliability{
+		DriverID:          driverID,
+		Completed:         completed,
+		Cancelled:         cancelled,
+		LateCancellations: late,
+		Score:             score,
+	}
+}
@@ -1,32 +1,70 @@
 package models
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 )
 
+const (
+	// RideScheduled means the ride has not departed yet and can be joined
+	RideScheduled = "scheduled"
+	// RideDeparted means the driver has started the ride
+	RideDeparted = "departed"
+	// RideCompleted means the ride has arrived
+	RideCompleted = "completed"
+	// RideCancelled means the driver called the ride off before it departed
+	RideCancelled = "cancelled"
+)
+
+// MaxCancelReasonLength is the longest reason a ride can be cancelled for
+const MaxCancelReasonLength = 500
+
+// RideStatuses are all the statuses a ride can have
+var RideStatuses = []string{RideScheduled, RideDeparted, RideCompleted, RideCancelled}
+
+// ErrUnknownRideStatus occurs when rides are filtered by a status rides cannot have
+var ErrUnknownRideStatus = errors.New("unknown ride status")
+
+// rideTransitions are the statuses a ride can change to from each status
+var rideTransitions = map[string][]string{
+	RideScheduled: {RideDeparted, RideCancelled},
+	RideDeparted:  {RideCompleted},
+}
+
 type (
 	// Ride is a ride entity
 	Ride struct {
-		ID              string    `json:"id" db:"id"`
-		DriverID        string    `json:"driver_id" db:"driver_id"`
-		CarID           string    `json:"car_id" db:"car_id"`
-		Seats           int       `json:"seats" db:"seats"`
-		SeatsTaken      *int      `json:"seats_taken,omitempty" db:"seats_taken"` //extra detail field
-		StartCity       string    `json:"start_city" db:"start_city"`
-		EndCity         string    `json:"end_city" db:"end_city"`
-		StartLat        float64   `json:"start_dest_lat" db:"start_dest_lat"`
-		StartLon        float64   `json:"start_dest_lon" db:"start_dest_lon"`
-		EndLat          float64   `json:"end_dest_lat" db:"end_dest_lat"`
-		EndLon          float64   `json:"end_dest_lon" db:"end_dest_lon"`
-		PricePerSeat    float64   `json:"price_per_seat" db:"price_per_seat"`
-		Info            string    `json:"info" db:"info"`
-		PassengerStatus *string   `json:"passenger_status,omitempty" db:"passenger_status"` // extra detail field
-		StartDate       time.Time `json:"start_date" db:"start_date"`
-		CreatedAt       time.Time `json:"created_at" db:"created_at"`
-		UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+		ID               string     `json:"id" db:"id"`
+		DriverID         string     `json:"driver_id" db:"driver_id"`
+		CarID            string     `json:"car_id" db:"car_id"`
+		Seats            int        `json:"seats" db:"seats"`
+		SeatsTaken       *int       `json:"seats_taken,omitempty" db:"seats_taken"` //extra detail field
+		StartCity        string     `json:"start_city" db:"start_city"`
+		EndCity          string     `json:"end_city" db:"end_city"`
+		StartLat         float64    `json:"start_dest_lat" db:"start_dest_lat"`
+		StartLon         float64    `json:"start_dest_lon" db:"start_dest_lon"`
+		EndLat           float64    `json:"end_dest_lat" db:"end_dest_lat"`
+		EndLon           float64    `json:"end_dest_lon" db:"end_dest_lon"`
+		PricePerSeat     float64    `json:"price_per_seat" db:"price_per_seat"`
+		Info             string     `json:"info" db:"info"`
+		PassengerStatus  *string    `json:"passenger_status,omitempty" db:"passenger_status"` // extra detail field
+		Distance         *float64   `json:"distance_km,omitempty" db:"distance_km"`           // extra detail field
+		Rank             *float64   `json:"rank,omitempty" db:"rank"`                         // extra detail field
+		SeriesID         *string    `json:"series_id,omitempty" db:"series_id"`
+		OccurrenceDate   *string    `json:"occurrence_date,omitempty" db:"occurrence_date"`
+		Status           string     `json:"status" db:"status"`
+		CancelledAt      *time.Time `json:"cancelled_at,omitempty" db:"cancelled_at"`
+		CancelledBy      *string    `json:"cancelled_by,omitempty" db:"cancelled_by"`
+		CancelReason     *string    `json:"cancel_reason,omitempty" db:"cancel_reason"`
+		LateCancellation bool       `json:"late_cancellation" db:"late_cancellation"`
+		StartDate        time.Time  `json:"start_date" db:"start_date"`
+		CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+		UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+		DeletedAt        *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+		Version          int        `json:"version" db:"version"`
 	}
 
 	// RideChangeSet is the fields that are modifiable in the ride
@@ -43,12 +81,13 @@ type (
 		PricePerSeat *float64  `json:"price_per_seat"`
 		Info         *string   `json:"info"`
 		StartDate    time.Time `json:"start_date"`
+		Version      *int      `json:"version"`
 	}
 )
 
 // NewRide returns a Ride with the change set fields applied
 func NewRide(r *RideChangeSet) (*Ride, error) {
-	ride := Ride{}
+	ride := Ride{Status: RideScheduled}
 
 	if err := ride.ApplyUpdates(r); err != nil {
 		return nil, err
@@ -57,9 +96,13 @@ func NewRide(r *RideChangeSet) (*Ride, error) {
 	return &ride, nil
 }
 
-// Validate validates a ride
+// Validate validates a ride, only scheduled rides must start in the future so that
+// rides that have departed, arrived or been cancelled can still be edited
 func (r *Ride) Validate() error {
-	now := time.Now()
+	startDate := []validation.Rule{validation.Required}
+	if r.Status == RideScheduled {
+		startDate = append(startDate, validation.Min(time.Now()))
+	}
 
 	return validation.ValidateStruct(r,
 		validation.Field(&r.DriverID, validation.Required),
@@ -72,10 +115,53 @@ func (r *Ride) Validate() error {
 		validation.Field(&r.StartLon, validation.Required, validation.Min(-180.0), validation.Max(180.0)),
 		validation.Field(&r.EndLon, validation.Required, validation.Min(-180.0), validation.Max(180.0)),
 		validation.Field(&r.PricePerSeat, validation.Min(0.0), validation.Max(100000000.00)),
-		validation.Field(&r.StartDate, validation.Required, validation.Min(now)),
+		validation.Field(&r.Status, validation.Required, validation.In(RideScheduled, RideDeparted, RideCompleted, RideCancelled)),
+		validation.Field(&r.StartDate, startDate...),
 	)
 }
 
+// CanTransition reports whether the ride's status can change to the status given
+func (r *Ride) CanTransition(status string) bool {
+	for _, next := range rideTransitions[r.Status] {
+		if next == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Cancel marks the ride as cancelled by the user at the time given for the reason
+// given, it is a late cancellation if it is within lateWindow of the start date
+func (r *Ride) Cancel(userID, reason string, at time.Time, lateWindow time.Duration) {
+	r.Status = RideCancelled
+	r.CancelledAt = &at
+	r.CancelledBy = &userID
+	r.CancelReason = &reason
+	r.LateCancellation = r.StartDate.Sub(at) < lateWindow
+}
+
+// ValidateCancelReason validates the reason a ride is cancelled for
+func ValidateCancelReason(reason string) error {
+	return validation.Validate(reason, validation.Required, validation.Length(1, MaxCancelReasonLength))
+}
+
+// ValidateRideStatuses returns ErrUnknownRideStatus if any of the statuses is not one of RideStatuses
+func ValidateRideStatuses(statuses []string) error {
+	for _, status := range statuses {
+		known := false
+		for _, rideStatus := range RideStatuses {
+			known = known || status == rideStatus
+		}
+
+		if !known {
+			return ErrUnknownRideStatus
+		}
+	}
+
+	return nil
+}
+
 // String returns a string representation of a ride
 func (r *Ride) String() string {
 	return fmt.Sprintf("<Ride id:%s driver:%s car:%s>", r.ID, r.DriverID, r.CarID)
@@ -133,6 +219,10 @@ func (r *Ride) ApplyUpdates(o *RideChangeSet) error {
 		newRide.StartDate = o.StartDate
 	}
 
+	if o.Version != nil {
+		newRide.Version = *o.Version
+	}
+
 	err := newRide.Validate()
 	if err != nil {
 		return err
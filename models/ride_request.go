@@ -0,0 +1,190 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	// RideRequestOpen means the passenger is still looking for a ride
+	RideRequestOpen = "open"
+	// RideRequestFulfilled means the passenger confirmed a driver's offer
+	RideRequestFulfilled = "fulfilled"
+)
+
+// ErrRideRequestWindow occurs when a request's latest start is before its earliest start
+var ErrRideRequestWindow = errors.New("must be on or after earliest_start")
+
+type (
+	// RideRequest is a ride a passenger is looking for, drivers can offer one of their
+	// rides that fits it
+	RideRequest struct {
+		ID          string  `json:"id" db:"id"`
+		PassengerID string  `json:"passenger_id" db:"passenger_id"`
+		StartCity   string  `json:"start_city" db:"start_city"`
+		EndCity     string  `json:"end_city" db:"end_city"`
+		StartLat    float64 `json:"start_dest_lat" db:"start_dest_lat"`
+		StartLon    float64 `json:"start_dest_lon" db:"start_dest_lon"`
+		EndLat      float64 `json:"end_dest_lat" db:"end_dest_lat"`
+		EndLon      float64 `json:"end_dest_lon" db:"end_dest_lon"`
+		// EarliestStart and LatestStart are when an offered ride can start
+		EarliestStart time.Time `json:"earliest_start" db:"earliest_start"`
+		LatestStart   time.Time `json:"latest_start" db:"latest_start"`
+		Seats         int       `json:"seats" db:"seats"`
+		// MaxPrice is the most the passenger will pay per seat, any price if it is nil
+		MaxPrice  *float64   `json:"max_price,omitempty" db:"max_price"`
+		Info      string     `json:"info" db:"info"`
+		Status    string     `json:"status" db:"status"`
+		CreatedAt time.Time  `json:"created_at" db:"created_at"`
+		UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+		DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+		Version   int        `json:"version" db:"version"`
+	}
+
+	// RideRequestChangeSet is the fields that are modifiable in the ride request
+	RideRequestChangeSet struct {
+		PassengerID   *string    `json:"-"`
+		StartCity     *string    `json:"start_city"`
+		EndCity       *string    `json:"end_city"`
+		StartLat      *float64   `json:"start_dest_lat"`
+		StartLon      *float64   `json:"start_dest_lon"`
+		EndLat        *float64   `json:"end_dest_lat"`
+		EndLon        *float64   `json:"end_dest_lon"`
+		EarliestStart *time.Time `json:"earliest_start"`
+		LatestStart   *time.Time `json:"latest_start"`
+		Seats         *int       `json:"seats"`
+		MaxPrice      *float64   `json:"max_price"`
+		Info          *string    `json:"info"`
+		Version       *int       `json:"version"`
+	}
+)
+
+// NewRideRequest returns an open RideRequest for one seat with the change set fields applied
+func NewRideRequest(r *RideRequestChangeSet) (*RideRequest, error) {
+	request := RideRequest{Seats: 1, Status: RideRequestOpen}
+
+	if err := request.ApplyUpdates(r); err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+// Validate validates a ride request, only open requests must end in the future
+func (r *RideRequest) Validate() error {
+	latestStart := []validation.Rule{validation.Required, validation.By(r.validLatestStart)}
+	if r.Status == RideRequestOpen {
+		latestStart = append(latestStart, validation.Min(time.Now()))
+	}
+
+	return validation.ValidateStruct(r,
+		validation.Field(&r.PassengerID, validation.Required),
+		validation.Field(&r.StartCity, validation.Required),
+		validation.Field(&r.EndCity, validation.Required),
+		validation.Field(&r.StartLat, validation.Required, validation.Min(-90.0), validation.Max(90.0)),
+		validation.Field(&r.EndLat, validation.Required, validation.Min(-90.0), validation.Max(90.0)),
+		validation.Field(&r.StartLon, validation.Required, validation.Min(-180.0), validation.Max(180.0)),
+		validation.Field(&r.EndLon, validation.Required, validation.Min(-180.0), validation.Max(180.0)),
+		validation.Field(&r.EarliestStart, validation.Required),
+		validation.Field(&r.LatestStart, latestStart...),
+		validation.Field(&r.Seats, validation.Required, validation.Min(1)),
+		validation.Field(&r.MaxPrice, validation.Min(0.0), validation.Max(100000000.00)),
+		validation.Field(&r.Status, validation.Required, validation.In(RideRequestOpen, RideRequestFulfilled)),
+	)
+}
+
+// String returns a string representation of a ride request
+func (r *RideRequest) String() string {
+	return fmt.Sprintf("<RideRequest id:%s passenger:%s status:%s>", r.ID, r.PassengerID, r.Status)
+}
+
+// ApplyUpdates attempts to update the ride request and validates the updates
+func (r *RideRequest) ApplyUpdates(o *RideRequestChangeSet) error {
+	newRequest := *r
+
+	if o.PassengerID != nil {
+		newRequest.PassengerID = *o.PassengerID
+	}
+
+	if o.StartCity != nil {
+		newRequest.StartCity = *o.StartCity
+	}
+
+	if o.EndCity != nil {
+		newRequest.EndCity = *o.EndCity
+	}
+
+	if o.StartLat != nil {
+		newRequest.StartLat = *o.StartLat
+	}
+
+	if o.StartLon != nil {
+		newRequest.StartLon = *o.StartLon
+	}
+
+	if o.EndLat != nil {
+		newRequest.EndLat = *o.EndLat
+	}
+
+	if o.EndLon != nil {
+		newRequest.EndLon = *o.EndLon
+	}
+
+	if o.EarliestStart != nil {
+		newRequest.EarliestStart = *o.EarliestStart
+	}
+
+	if o.LatestStart != nil {
+		newRequest.LatestStart = *o.LatestStart
+	}
+
+	if o.Seats != nil {
+		newRequest.Seats = *o.Seats
+	}
+
+	if o.MaxPrice != nil {
+		maxPrice := *o.MaxPrice
+		newRequest.MaxPrice = &maxPrice
+	}
+
+	if o.Info != nil {
+		newRequest.Info = *o.Info
+	}
+
+	if o.Version != nil {
+		newRequest.Version = *o.Version
+	}
+
+	if err := newRequest.Validate(); err != nil {
+		return err
+	}
+
+	*r = newRequest
+
+	return nil
+}
+
+// Fits reports whether the ride can fulfil the request: it starts within the
+// request's window, costs at most its max price and has seats for it free
+func (r *RideRequest) Fits(ride *Ride, seatsFree int) bool {
+	if ride.StartDate.Before(r.EarliestStart) || ride.StartDate.After(r.LatestStart) {
+		return false
+	}
+
+	if r.MaxPrice != nil && ride.PricePerSeat > *r.MaxPrice {
+		return false
+	}
+
+	return seatsFree >= r.Seats
+}
+
+func (r *RideRequest) validLatestStart(value interface{}) error {
+	if !r.EarliestStart.IsZero() && r.LatestStart.Before(r.EarliestStart) {
+		return ErrRideRequestWindow
+	}
+
+	return nil
+}
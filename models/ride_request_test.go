@@ -0,0 +1,87 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ucladevx/BPool/models"
+)
+
+func newRequestChangeSet() *models.RideRequestChangeSet {
+	passengerID := "123"
+	startCity, endCity := "Los Angeles", "San Francisco"
+	startLat, startLon, endLat, endLon := 34.068921, -118.445181, 37.774929, -122.419416
+	earliest := time.Now().Add(24 * time.Hour)
+	latest := earliest.Add(6 * time.Hour)
+
+	return &models.RideRequestChangeSet{
+		PassengerID:   &passengerID,
+		StartCity:     &startCity,
+		EndCity:       &endCity,
+		StartLat:      &startLat,
+		StartLon:      &startLon,
+		EndLat:        &endLat,
+		EndLon:        &endLon,
+		EarliestStart: &earliest,
+		LatestStart:   &latest,
+	}
+}
+
+func TestNewRideRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	request, err := models.NewRideRequest(newRequestChangeSet())
+	assert.Nil(err)
+	assert.Equal(models.RideRequestOpen, request.Status)
+	assert.Equal(1, request.Seats, "requests should be for one seat by default")
+	assert.Nil(request.MaxPrice)
+
+	invalid := []func(cs *models.RideRequestChangeSet){
+		func(cs *models.RideRequestChangeSet) {
+			latest := cs.EarliestStart.Add(-time.Hour)
+			cs.LatestStart = &latest
+		},
+		func(cs *models.RideRequestChangeSet) { latest := time.Now().Add(-time.Hour); cs.LatestStart = &latest },
+		func(cs *models.RideRequestChangeSet) { seats := 0; cs.Seats = &seats },
+		func(cs *models.RideRequestChangeSet) { maxPrice := -1.0; cs.MaxPrice = &maxPrice },
+		func(cs *models.RideRequestChangeSet) { city := ""; cs.EndCity = &city },
+	}
+
+	for i, change := range invalid {
+		cs := newRequestChangeSet()
+		change(cs)
+
+		_, err := models.NewRideRequest(cs)
+		assert.NotNil(err, "change %d should be invalid", i)
+	}
+}
+
+func TestRideRequestFits(t *testing.T) {
+	assert := assert.New(t)
+
+	request, err := models.NewRideRequest(newRequestChangeSet())
+	assert.Nil(err)
+	maxPrice := 20.0
+	request.MaxPrice = &maxPrice
+	request.Seats = 2
+
+	ride := models.Ride{StartDate: request.EarliestStart.Add(time.Hour), PricePerSeat: 20}
+	assert.True(request.Fits(&ride, 2))
+	assert.False(request.Fits(&ride, 1), "the ride should have a seat for everyone in the request")
+
+	early := ride
+	early.StartDate = request.EarliestStart.Add(-time.Minute)
+	assert.False(request.Fits(&early, 2), "the ride should start within the window")
+
+	late := ride
+	late.StartDate = request.LatestStart.Add(time.Minute)
+	assert.False(request.Fits(&late, 2), "the ride should start within the window")
+
+	pricey := ride
+	pricey.PricePerSeat = 25
+	assert.False(request.Fits(&pricey, 2), "the ride should cost at most the max price")
+
+	request.MaxPrice = nil
+	assert.True(request.Fits(&pricey, 2), "any price should do without a max price")
+}
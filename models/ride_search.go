@@ -0,0 +1,259 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	// earthRadiusKm is the mean radius of the earth
+	earthRadiusKm = 6371.0088
+
+	// KmPerDegreeLatitude is the distance between degrees of latitude along a meridian
+	KmPerDegreeLatitude = earthRadiusKm * math.Pi / 180
+
+	// MaxSearchRadiusKm is the largest radius rides can be searched within
+	MaxSearchRadiusKm = 500.0
+
+	// RideSortDate sorts searched rides by start date, soonest first
+	RideSortDate = "date"
+	// RideSortPrice sorts searched rides by price per seat, cheapest first
+	RideSortPrice = "price"
+	// RideSortDistance sorts searched rides by distance, nearest first
+	RideSortDistance = "distance"
+	// RideSortRelevance sorts searched rides by how well they match the query, best first
+	RideSortRelevance = "relevance"
+
+	// MaxSearchQueryLength is the longest query rides can be searched for
+	MaxSearchQueryLength = 200
+)
+
+var (
+	// ErrSortNeedsNear occurs when searched rides are sorted by distance without searching near points
+	ErrSortNeedsNear = errors.New("rides can only be sorted by distance when searching near points")
+	// ErrSortNeedsQuery occurs when searched rides are sorted by relevance without a query
+	ErrSortNeedsQuery = errors.New("rides can only be sorted by relevance when searching for a query")
+	// ErrCursorSortMismatch occurs when a cursor from a search with a different sort is used
+	ErrCursorSortMismatch = errors.New("the cursor is for a search with a different sort")
+)
+
+type (
+	// RideSearch finds the rides matching every filter that is set, sorted by Sort,
+	// ties are broken by id so that the After cursor can continue where a page ended
+	RideSearch struct {
+		Query       string
+		Near        *RideSearchNear
+		StartCity   string
+		EndCity     string
+		StartAfter  time.Time
+		StartBefore time.Time
+		MinSeats    int
+		MaxPrice    *float64
+		Statuses    []string
+		Sort        string
+		After       *RideSearchCursor
+		Limit       int
+	}
+
+	// RideSearchNear finds rides that start within FromRadiusKm of the from point
+	// and end within ToRadiusKm of the to point
+	RideSearchNear struct {
+		FromLat      float64
+		FromLon      float64
+		ToLat        float64
+		ToLon        float64
+		FromRadiusKm float64
+		ToRadiusKm   float64
+	}
+
+	// RideSearchCursor is the position of the last ride of a page of searched rides
+	RideSearchCursor struct {
+		Sort      string    `json:"sort"`
+		StartDate time.Time `json:"start_date"`
+		Price     float64   `json:"price"`
+		Distance  float64   `json:"distance"`
+		Rank      float64   `json:"rank"`
+		ID        string    `json:"id"`
+	}
+)
+
+// Validate validates a ride search
+func (s *RideSearch) Validate() error {
+	err := validation.ValidateStruct(s,
+		validation.Field(&s.Query, validation.Length(0, MaxSearchQueryLength)),
+		validation.Field(&s.Near),
+		validation.Field(&s.MinSeats, validation.Min(0)),
+		validation.Field(&s.MaxPrice, validation.Min(0.0)),
+		validation.Field(&s.Sort, validation.Required, validation.In(RideSortDate, RideSortPrice, RideSortDistance, RideSortRelevance)),
+		validation.Field(&s.Limit, validation.Min(1)),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := ValidateRideStatuses(s.Statuses); err != nil {
+		return err
+	}
+
+	if s.Sort == RideSortDistance && s.Near == nil {
+		return ErrSortNeedsNear
+	}
+
+	if s.Sort == RideSortRelevance && s.Query == "" {
+		return ErrSortNeedsQuery
+	}
+
+	if s.After != nil && s.After.Sort != s.Sort {
+		return ErrCursorSortMismatch
+	}
+
+	return nil
+}
+
+// Matches reports whether the ride matches every filter of the search, rides
+// matching a near search have their Distance set and rides matching a query have
+// their Rank set, SeatsTaken must be set
+func (s *RideSearch) Matches(ride *Ride) bool {
+	if s.Query != "" {
+		rank, ok := TextRank(s.Query, ride)
+		if !ok {
+			return false
+		}
+
+		ride.Rank = &rank
+	}
+
+	if s.Near != nil {
+		distance, ok := s.Near.Distance(ride)
+		if !ok {
+			return false
+		}
+
+		ride.Distance = &distance
+	}
+
+	seatsTaken := 0
+	if ride.SeatsTaken != nil {
+		seatsTaken = *ride.SeatsTaken
+	}
+
+	hasStatus := len(s.Statuses) == 0
+	for _, status := range s.Statuses {
+		hasStatus = hasStatus || ride.Status == status
+	}
+
+	return (s.StartCity == "" || strings.EqualFold(s.StartCity, ride.StartCity)) &&
+		(s.EndCity == "" || strings.EqualFold(s.EndCity, ride.EndCity)) &&
+		(s.StartAfter.IsZero() || !ride.StartDate.Before(s.StartAfter)) &&
+		(s.StartBefore.IsZero() || !ride.StartDate.After(s.StartBefore)) &&
+		ride.Seats-seatsTaken >= s.MinSeats &&
+		(s.MaxPrice == nil || ride.PricePerSeat <= *s.MaxPrice) &&
+		hasStatus &&
+		(s.After == nil || s.After.Before(ride))
+}
+
+// Less reports whether ride a comes before ride b in the search's sort order
+func (s *RideSearch) Less(a, b *Ride) bool {
+	return NewRideSearchCursor(s.Sort, a).Before(b)
+}
+
+// Validate validates the near points and radiuses
+func (n RideSearchNear) Validate() error {
+	return validation.ValidateStruct(&n,
+		validation.Field(&n.FromLat, validation.Min(-90.0), validation.Max(90.0)),
+		validation.Field(&n.FromLon, validation.Min(-180.0), validation.Max(180.0)),
+		validation.Field(&n.ToLat, validation.Min(-90.0), validation.Max(90.0)),
+		validation.Field(&n.ToLon, validation.Min(-180.0), validation.Max(180.0)),
+		validation.Field(&n.FromRadiusKm, validation.Required, validation.Min(0.0), validation.Max(MaxSearchRadiusKm)),
+		validation.Field(&n.ToRadiusKm, validation.Required, validation.Min(0.0), validation.Max(MaxSearchRadiusKm)),
+	)
+}
+
+// Distance is how far in km the ride starts from the from point plus how far it
+// ends from the to point, ok is false if either is outside its radius
+func (n *RideSearchNear) Distance(ride *Ride) (distance float64, ok bool) {
+	start := DistanceKm(n.FromLat, n.FromLon, ride.StartLat, ride.StartLon)
+	end := DistanceKm(n.ToLat, n.ToLon, ride.EndLat, ride.EndLon)
+
+	return start + end, start <= n.FromRadiusKm && end <= n.ToRadiusKm
+}
+
+// NewRideSearchCursor creates a cursor positioned at the ride for the sort given
+func NewRideSearchCursor(sort string, ride *Ride) *RideSearchCursor {
+	cursor := &RideSearchCursor{
+		Sort:      sort,
+		StartDate: ride.StartDate,
+		Price:     ride.PricePerSeat,
+		ID:        ride.ID,
+	}
+
+	if ride.Distance != nil {
+		cursor.Distance = *ride.Distance
+	}
+
+	if ride.Rank != nil {
+		cursor.Rank = *ride.Rank
+	}
+
+	return cursor
+}
+
+// Value is the value of the sorted field at the cursor, relevance is sorted
+// ascending by the negative rank so that the best matches are first
+func (c *RideSearchCursor) Value() interface{} {
+	switch c.Sort {
+	case RideSortRelevance:
+		return -c.Rank
+	case RideSortPrice:
+		return c.Price
+	case RideSortDistance:
+		return c.Distance
+	default:
+		return c.StartDate
+	}
+}
+
+// Before reports whether the cursor is before the ride in the cursor's sort order
+func (c *RideSearchCursor) Before(ride *Ride) bool {
+	next := NewRideSearchCursor(c.Sort, ride)
+
+	switch c.Sort {
+	case RideSortPrice:
+		if c.Price != next.Price {
+			return c.Price < next.Price
+		}
+	case RideSortDistance:
+		if c.Distance != next.Distance {
+			return c.Distance < next.Distance
+		}
+	case RideSortRelevance:
+		if c.Rank != next.Rank {
+			return c.Rank > next.Rank
+		}
+	default:
+		if !c.StartDate.Equal(next.StartDate) {
+			return c.StartDate.Before(next.StartDate)
+		}
+	}
+
+	return c.ID < next.ID
+}
+
+// DistanceKm is the great circle distance in km between two points
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := radians(lat2 - lat1)
+	dLon := radians(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(radians(lat1))*math.Cos(radians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
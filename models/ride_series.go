@@ -0,0 +1,366 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	// DateLayout is the layout of the calendar dates rides are scheduled on
+	DateLayout = "2006-01-02"
+
+	// DefaultSeriesTimeZone is the time zone ride series depart in when none is given
+	DefaultSeriesTimeZone = "America/Los_Angeles"
+
+	// MaxSeriesDays is how long a ride series can run for
+	MaxSeriesDays = 366
+)
+
+var (
+	// SeriesDays are the RRULE BYDAY names of the days of the week, indexed by time.Weekday
+	SeriesDays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+	// ErrSeriesDays occurs when a series' days are not a list of distinct SeriesDays
+	ErrSeriesDays = errors.New("must be a comma separated list of distinct days like MO,WE,FR")
+	// ErrSeriesTimeZone occurs when a series' time zone is not a known IANA zone
+	ErrSeriesTimeZone = errors.New("must be a time zone like America/Los_Angeles")
+	// ErrSeriesEndsOn occurs when a series ends before it starts or runs too long
+	ErrSeriesEndsOn = fmt.Errorf("must be on or after starts_on and within %d days of it", MaxSeriesDays)
+
+	departureTimePattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+)
+
+type (
+	// RideSeries is a template for a ride the driver gives every week on the same
+	// days and at the same time until it ends, its rides are materialized ahead of
+	// time as occurrences linked back to the series
+	RideSeries struct {
+		ID           string  `json:"id" db:"id"`
+		DriverID     string  `json:"driver_id" db:"driver_id"`
+		CarID        string  `json:"car_id" db:"car_id"`
+		Seats        int     `json:"seats" db:"seats"`
+		StartCity    string  `json:"start_city" db:"start_city"`
+		EndCity      string  `json:"end_city" db:"end_city"`
+		StartLat     float64 `json:"start_dest_lat" db:"start_dest_lat"`
+		StartLon     float64 `json:"start_dest_lon" db:"start_dest_lon"`
+		EndLat       float64 `json:"end_dest_lat" db:"end_dest_lat"`
+		EndLon       float64 `json:"end_dest_lon" db:"end_dest_lon"`
+		PricePerSeat float64 `json:"price_per_seat" db:"price_per_seat"`
+		Info         string  `json:"info" db:"info"`
+		// Days are the RRULE BYDAY days the ride is given on, like MO,WE,FR
+		Days string `json:"days" db:"days"`
+		// DepartureTime is the local time the ride departs at, like 08:30
+		DepartureTime string `json:"departure_time" db:"departure_time"`
+		TimeZone      string `json:"time_zone" db:"time_zone"`
+		// StartsOn and EndsOn are the first and last dates rides can be given on
+		StartsOn string `json:"starts_on" db:"starts_on"`
+		EndsOn   string `json:"ends_on" db:"ends_on"`
+		// MaterializedThrough is the last date rides have been materialized for
+		MaterializedThrough *string    `json:"materialized_through,omitempty" db:"materialized_through"`
+		CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+		UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
+		DeletedAt           *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+		Version             int        `json:"version" db:"version"`
+	}
+
+	// RideSeriesChangeSet is the fields that are modifiable in the ride series
+	RideSeriesChangeSet struct {
+		DriverID      *string  `json:"-"`
+		CarID         *string  `json:"car_id"`
+		Seats         *int     `json:"seats"`
+		StartCity     *string  `json:"start_city"`
+		EndCity       *string  `json:"end_city"`
+		StartLat      *float64 `json:"start_dest_lat"`
+		StartLon      *float64 `json:"start_dest_lon"`
+		EndLat        *float64 `json:"end_dest_lat"`
+		EndLon        *float64 `json:"end_dest_lon"`
+		PricePerSeat  *float64 `json:"price_per_seat"`
+		Info          *string  `json:"info"`
+		Days          *string  `json:"days"`
+		DepartureTime *string  `json:"departure_time"`
+		TimeZone      *string  `json:"time_zone"`
+		StartsOn      *string  `json:"starts_on"`
+		EndsOn        *string  `json:"ends_on"`
+		Version       *int     `json:"version"`
+	}
+)
+
+// NewRideSeries returns a RideSeries with the change set fields applied, it departs
+// in DefaultSeriesTimeZone unless another is given
+func NewRideSeries(s *RideSeriesChangeSet) (*RideSeries, error) {
+	series := RideSeries{TimeZone: DefaultSeriesTimeZone}
+
+	if err := series.ApplyUpdates(s); err != nil {
+		return nil, err
+	}
+
+	return &series, nil
+}
+
+// Validate validates a ride series
+func (s *RideSeries) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.DriverID, validation.Required),
+		validation.Field(&s.CarID, validation.Required),
+		validation.Field(&s.Seats, validation.Min(0)),
+		validation.Field(&s.StartCity, validation.Required),
+		validation.Field(&s.EndCity, validation.Required),
+		validation.Field(&s.StartLat, validation.Required, validation.Min(-90.0), validation.Max(90.0)),
+		validation.Field(&s.EndLat, validation.Required, validation.Min(-90.0), validation.Max(90.0)),
+		validation.Field(&s.StartLon, validation.Required, validation.Min(-180.0), validation.Max(180.0)),
+		validation.Field(&s.EndLon, validation.Required, validation.Min(-180.0), validation.Max(180.0)),
+		validation.Field(&s.PricePerSeat, validation.Min(0.0), validation.Max(100000000.00)),
+		validation.Field(&s.Days, validation.Required, validation.By(validSeriesDays)),
+		validation.Field(&s.DepartureTime, validation.Required, validation.Match(departureTimePattern)),
+		validation.Field(&s.TimeZone, validation.Required, validation.By(validTimeZone)),
+		validation.Field(&s.StartsOn, validation.Required, validation.Date(DateLayout)),
+		validation.Field(&s.EndsOn, validation.Required, validation.Date(DateLayout), validation.By(s.validEndsOn)),
+	)
+}
+
+// String returns a string representation of a ride series
+func (s *RideSeries) String() string {
+	return fmt.Sprintf("<RideSeries id:%s driver:%s days:%s>", s.ID, s.DriverID, s.Days)
+}
+
+// ApplyUpdates attempts to update the ride series and validates the updates, days
+// are upper cased
+func (s *RideSeries) ApplyUpdates(o *RideSeriesChangeSet) error {
+	newSeries := *s
+
+	if o.DriverID != nil {
+		newSeries.DriverID = *o.DriverID
+	}
+
+	if o.CarID != nil {
+		newSeries.CarID = *o.CarID
+	}
+
+	if o.Seats != nil {
+		newSeries.Seats = *o.Seats
+	}
+
+	if o.StartCity != nil {
+		newSeries.StartCity = *o.StartCity
+	}
+
+	if o.EndCity != nil {
+		newSeries.EndCity = *o.EndCity
+	}
+
+	if o.StartLat != nil {
+		newSeries.StartLat = *o.StartLat
+	}
+
+	if o.StartLon != nil {
+		newSeries.StartLon = *o.StartLon
+	}
+
+	if o.EndLat != nil {
+		newSeries.EndLat = *o.EndLat
+	}
+
+	if o.EndLon != nil {
+		newSeries.EndLon = *o.EndLon
+	}
+
+	if o.PricePerSeat != nil {
+		newSeries.PricePerSeat = *o.PricePerSeat
+	}
+
+	if o.Info != nil {
+		newSeries.Info = *o.Info
+	}
+
+	if o.Days != nil {
+		newSeries.Days = strings.ToUpper(strings.Replace(*o.Days, " ", "", -1))
+	}
+
+	if o.DepartureTime != nil {
+		newSeries.DepartureTime = *o.DepartureTime
+	}
+
+	if o.TimeZone != nil {
+		newSeries.TimeZone = *o.TimeZone
+	}
+
+	if o.StartsOn != nil {
+		newSeries.StartsOn = *o.StartsOn
+	}
+
+	if o.EndsOn != nil {
+		newSeries.EndsOn = *o.EndsOn
+	}
+
+	if o.Version != nil {
+		newSeries.Version = *o.Version
+	}
+
+	err := newSeries.Validate()
+	if err != nil {
+		return err
+	}
+
+	*s = newSeries
+
+	return nil
+}
+
+// Includes reports whether the series gives a ride on the date, which must be in DateLayout
+func (s *RideSeries) Includes(date string) bool {
+	if date < s.StartsOn || date > s.EndsOn {
+		return false
+	}
+
+	day, err := time.Parse(DateLayout, date)
+	if err != nil {
+		return false
+	}
+
+	for _, name := range strings.Split(s.Days, ",") {
+		if name == SeriesDays[day.Weekday()] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Dates returns the dates from through through the series gives rides on
+func (s *RideSeries) Dates(from, through string) []string {
+	dates := []string{}
+
+	day, err := time.Parse(DateLayout, from)
+	if err != nil {
+		return dates
+	}
+
+	for date := from; date <= through; date = day.Format(DateLayout) {
+		if s.Includes(date) {
+			dates = append(dates, date)
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return dates
+}
+
+// Today is the current date where the series departs
+func (s *RideSeries) Today(now time.Time) string {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	return now.In(loc).Format(DateLayout)
+}
+
+// Occurrence returns the ride the series gives on the date, departing at the
+// departure time in the series' time zone
+func (s *RideSeries) Occurrence(date string) (*Ride, error) {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := time.ParseInLocation(DateLayout+" 15:04", date+" "+s.DepartureTime, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	seriesID, occurrenceDate := s.ID, date
+
+	return &Ride{
+		DriverID:       s.DriverID,
+		CarID:          s.CarID,
+		Seats:          s.Seats,
+		StartCity:      s.StartCity,
+		EndCity:        s.EndCity,
+		StartLat:       s.StartLat,
+		StartLon:       s.StartLon,
+		EndLat:         s.EndLat,
+		EndLon:         s.EndLon,
+		PricePerSeat:   s.PricePerSeat,
+		Info:           s.Info,
+		StartDate:      start,
+		SeriesID:       &seriesID,
+		OccurrenceDate: &occurrenceDate,
+		Status:         RideScheduled,
+	}, nil
+}
+
+// ApplyTo copies the series' template onto one of its occurrences, keeping the
+// occurrence's date but moving it to the series' departure time
+func (s *RideSeries) ApplyTo(ride *Ride) error {
+	if ride.OccurrenceDate == nil {
+		return fmt.Errorf("ride %s is not an occurrence of a series", ride.ID)
+	}
+
+	occurrence, err := s.Occurrence(*ride.OccurrenceDate)
+	if err != nil {
+		return err
+	}
+
+	occurrence.ID = ride.ID
+	occurrence.CreatedAt = ride.CreatedAt
+	occurrence.UpdatedAt = ride.UpdatedAt
+	occurrence.Version = ride.Version
+	occurrence.SeatsTaken = ride.SeatsTaken
+	occurrence.Status = ride.Status
+	*ride = *occurrence
+
+	return nil
+}
+
+func validSeriesDays(value interface{}) error {
+	days, _ := value.(string)
+	seen := map[string]bool{}
+
+	for _, name := range strings.Split(days, ",") {
+		known := false
+		for _, day := range SeriesDays {
+			known = known || name == day
+		}
+
+		if !known || seen[name] {
+			return ErrSeriesDays
+		}
+		seen[name] = true
+	}
+
+	return nil
+}
+
+func validTimeZone(value interface{}) error {
+	zone, _ := value.(string)
+
+	if _, err := time.LoadLocation(zone); err != nil || zone == "" || zone == "Local" {
+		return ErrSeriesTimeZone
+	}
+
+	return nil
+}
+
+func (s *RideSeries) validEndsOn(value interface{}) error {
+	start, err := time.Parse(DateLayout, s.StartsOn)
+	if err != nil {
+		// starts_on is reported on its own
+		return nil
+	}
+
+	end, err := time.Parse(DateLayout, s.EndsOn)
+	if err != nil {
+		return nil
+	}
+
+	if end.Before(start) || end.After(start.AddDate(0, 0, MaxSeriesDays)) {
+		return ErrSeriesEndsOn
+	}
+
+	return nil
+}
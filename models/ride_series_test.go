@@ -0,0 +1,97 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ucladevx/BPool/models"
+)
+
+func newSeriesChangeSet() *models.RideSeriesChangeSet {
+	driverID, carID := "123", "abc"
+	startCity, endCity := "Los Angeles", "San Francisco"
+	startLat, startLon, endLat, endLon := 34.068921, -118.445181, 37.774929, -122.419416
+	days, departure := "mo, we,fr", "08:30"
+	startsOn, endsOn := "2018-03-05", "2018-03-18"
+
+	return &models.RideSeriesChangeSet{
+		DriverID:      &driverID,
+		CarID:         &carID,
+		StartCity:     &startCity,
+		EndCity:       &endCity,
+		StartLat:      &startLat,
+		StartLon:      &startLon,
+		EndLat:        &endLat,
+		EndLon:        &endLon,
+		Days:          &days,
+		DepartureTime: &departure,
+		StartsOn:      &startsOn,
+		EndsOn:        &endsOn,
+	}
+}
+
+func TestNewRideSeries(t *testing.T) {
+	assert := assert.New(t)
+
+	series, err := models.NewRideSeries(newSeriesChangeSet())
+	assert.Nil(err)
+	assert.Equal("MO,WE,FR", series.Days, "days should be normalized")
+	assert.Equal(models.DefaultSeriesTimeZone, series.TimeZone)
+
+	invalid := []func(cs *models.RideSeriesChangeSet){
+		func(cs *models.RideSeriesChangeSet) { days := "MO,XX"; cs.Days = &days },
+		func(cs *models.RideSeriesChangeSet) { days := "MO,MO"; cs.Days = &days },
+		func(cs *models.RideSeriesChangeSet) { departure := "25:00"; cs.DepartureTime = &departure },
+		func(cs *models.RideSeriesChangeSet) { zone := "Mars/Olympus_Mons"; cs.TimeZone = &zone },
+		func(cs *models.RideSeriesChangeSet) { endsOn := "2018-03-04"; cs.EndsOn = &endsOn },
+		func(cs *models.RideSeriesChangeSet) { endsOn := "2019-06-01"; cs.EndsOn = &endsOn },
+		func(cs *models.RideSeriesChangeSet) { startsOn := "03/05/2018"; cs.StartsOn = &startsOn },
+	}
+
+	for i, change := range invalid {
+		cs := newSeriesChangeSet()
+		change(cs)
+
+		_, err := models.NewRideSeries(cs)
+		assert.NotNil(err, "change %d should be invalid", i)
+	}
+}
+
+func TestRideSeriesDates(t *testing.T) {
+	assert := assert.New(t)
+
+	series, err := models.NewRideSeries(newSeriesChangeSet())
+	assert.Nil(err)
+
+	assert.Equal([]string{"2018-03-05", "2018-03-07", "2018-03-09", "2018-03-12"}, series.Dates("2018-03-01", "2018-03-12"))
+	assert.Equal([]string{"2018-03-16"}, series.Dates("2018-03-15", "2018-03-31"), "dates should stop at ends_on")
+	assert.False(series.Includes("2018-03-06"), "tuesdays should not be included")
+}
+
+func TestRideSeriesOccurrence(t *testing.T) {
+	assert := assert.New(t)
+
+	series, err := models.NewRideSeries(newSeriesChangeSet())
+	assert.Nil(err)
+	series.ID = "series1"
+
+	// daylight saving time starts in Los Angeles on 2018-03-11
+	ride, err := series.Occurrence("2018-03-12")
+	assert.Nil(err)
+	assert.Equal(time.Date(2018, 3, 12, 15, 30, 0, 0, time.UTC), ride.StartDate.UTC())
+	assert.Equal("series1", *ride.SeriesID)
+	assert.Equal("2018-03-12", *ride.OccurrenceDate)
+	assert.Equal(series.StartCity, ride.StartCity)
+
+	ride.ID, ride.Version, ride.StartDate = "ride1", 2, ride.StartDate.Add(time.Hour)
+	series.Info = "leaving from the parking lot"
+
+	assert.Nil(series.ApplyTo(ride))
+	assert.Equal("ride1", ride.ID, "the ride should keep its id")
+	assert.Equal(2, ride.Version, "the ride should keep its version")
+	assert.Equal("leaving from the parking lot", ride.Info)
+	assert.Equal(time.Date(2018, 3, 12, 15, 30, 0, 0, time.UTC), ride.StartDate.UTC(), "the ride should depart at the series' time")
+
+	assert.NotNil(series.ApplyTo(&models.Ride{}), "rides that are not occurrences cannot be updated from a series")
+}
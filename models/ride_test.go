@@ -23,6 +23,7 @@ func TestRideApplyUpdates(t *testing.T) {
 		EndLon:       80.934523,
 		PricePerSeat: 15,
 		Info:         "",
+		Status:       models.RideScheduled,
 		StartDate:    time.Now().Add(time.Hour),
 	}
 
@@ -45,3 +46,57 @@ func TestRideApplyUpdates(t *testing.T) {
 	assert.Equal(newEndCity, ride1.EndCity)
 	assert.Equal(newPricePerSeat, ride1.PricePerSeat)
 }
+
+func TestRideValidateStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	ride := models.Ride{
+		DriverID:  "123",
+		CarID:     "abc",
+		StartCity: "Los Angeles",
+		EndCity:   "San Francisco",
+		StartLat:  34.068921,
+		StartLon:  -118.445181,
+		EndLat:    37.774929,
+		EndLon:    -122.419416,
+		Status:    models.RideScheduled,
+		StartDate: time.Now().Add(-time.Hour),
+	}
+
+	assert.NotNil(ride.Validate(), "scheduled rides should start in the future")
+
+	ride.Status = models.RideCompleted
+	assert.Nil(ride.Validate(), "rides that are no longer scheduled can be in the past")
+
+	ride.Status = "lost"
+	assert.NotNil(ride.Validate(), "rides should have a known status")
+
+	ride.Status = models.RideScheduled
+	assert.True(ride.CanTransition(models.RideDeparted))
+	assert.True(ride.CanTransition(models.RideCancelled))
+	assert.False(ride.CanTransition(models.RideCompleted))
+
+	ride.Status = models.RideCompleted
+	assert.False(ride.CanTransition(models.RideCancelled), "completed rides should be final")
+}
+
+func TestRideCancel(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+	ride := models.Ride{DriverID: "123", Status: models.RideScheduled, StartDate: now.Add(3 * time.Hour)}
+
+	ride.Cancel("123", "car broke down", now, 24*time.Hour)
+	assert.Equal(models.RideCancelled, ride.Status)
+	assert.Equal("car broke down", *ride.CancelReason)
+	assert.True(ride.LateCancellation, "cancelling within the window should be late")
+
+	ride.StartDate = now.Add(48 * time.Hour)
+	ride.Cancel("123", "car broke down", now, 24*time.Hour)
+	assert.False(ride.LateCancellation)
+
+	assert.NotNil(models.ValidateCancelReason(""), "a reason should be required")
+
+	assert.Equal(1.0, models.NewDriverReliability("123", 0, 0, 0).Score, "new drivers should be fully reliable")
+	assert.Equal(0.75, models.NewDriverReliability("123", 3, 1, 1).Score)
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// the weights of matches in the fields of a ride, cities are weighted like the
+	// A weight and info like the B weight of postgres's ts_rank
+	cityMatchWeight = 1.0
+	infoMatchWeight = 0.4
+)
+
+// stopWords are left out of searches since nearly every ride would match them
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "for": true, "from": true, "i": true, "in": true, "is": true, "it": true,
+	"of": true, "on": true, "or": true, "the": true, "to": true, "with": true,
+}
+
+// Tokenize splits text into lower case words with their plural endings removed,
+// leaving out stop words, so that "Room for skis" and "ski" share the token "ski"
+func Tokenize(text string) []string {
+	tokens := []string{}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	for _, word := range words {
+		if !stopWords[word] {
+			tokens = append(tokens, stem(word))
+		}
+	}
+
+	return tokens
+}
+
+// TextRank ranks how well the ride's info and cities match the query, ok is false
+// unless every token of the query is in the ride, the fallback for stores without
+// full-text search
+func TextRank(query string, ride *Ride) (rank float64, ok bool) {
+	counts := map[string]float64{}
+	fields := []struct {
+		text   string
+		weight float64
+	}{
+		{ride.StartCity, cityMatchWeight},
+		{ride.EndCity, cityMatchWeight},
+		{ride.Info, infoMatchWeight},
+	}
+
+	for _, field := range fields {
+		for _, token := range Tokenize(field.text) {
+			counts[token] += field.weight
+		}
+	}
+
+	tokens := Tokenize(query)
+	if len(tokens) == 0 {
+		return 0, false
+	}
+
+	for _, token := range tokens {
+		if counts[token] == 0 {
+			return 0, false
+		}
+
+		rank += counts[token]
+	}
+
+	return rank, true
+}
+
+// stem removes the plural endings of a word
+func stem(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return word[:len(word)-1]
+	}
+
+	return word
+}
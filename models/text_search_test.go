@@ -0,0 +1,49 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ucladevx/BPool/models"
+)
+
+func TestTokenize(t *testing.T) {
+	tables := []struct {
+		text   string
+		tokens []string
+	}{
+		{"Room for skis!", []string{"room", "ski"}},
+		{"Stopping in Santa Barbara", []string{"stopping", "santa", "barbara"}},
+		{"no pets, lots of bags", []string{"no", "pet", "lot", "bag"}},
+		{"3 cities", []string{"3", "city"}},
+		{"the and of", []string{}},
+	}
+
+	for _, table := range tables {
+		assert.Equal(t, table.tokens, models.Tokenize(table.text), table.text)
+	}
+}
+
+func TestTextRank(t *testing.T) {
+	assert := assert.New(t)
+
+	ride := models.Ride{
+		StartCity: "Los Angeles",
+		EndCity:   "San Francisco",
+		Info:      "stopping in Santa Barbara, room for a ski",
+	}
+
+	rank, ok := models.TextRank("skis", &ride)
+	assert.True(ok, "plurals should match the singular")
+	assert.True(rank > 0)
+
+	cityRank, ok := models.TextRank("san francisco", &ride)
+	assert.True(ok)
+	assert.True(cityRank > rank, "matches in the cities should rank above matches in the info")
+
+	_, ok = models.TextRank("santa cruz", &ride)
+	assert.False(ok, "every word of the query should be matched")
+
+	_, ok = models.TextRank("the", &ride)
+	assert.False(ok, "a query of only stop words matches nothing")
+}
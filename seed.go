@@ -0,0 +1,90 @@
+package bpool
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/utils/auth"
+
+	"github.com/codyleyhan/config-loader"
+)
+
+var (
+	// ErrSeedUsage occurs when the seed command is given bad arguments
+	ErrSeedUsage = errors.New("usage: seed [--seed N] [--from YYYY-MM-DD] [--users N] [--cars N] [--rides N] [--passengers N]")
+
+	// ErrSeedMemory occurs when seeding the memory backend, whose data is gone once the command exits
+	ErrSeedMemory = errors.New("the memory db.driver cannot be seeded, its data only lasts as long as the command")
+)
+
+// Seed runs the seed command, filling the configured backend with made up data for
+// local development, the same --seed and --from always make the same data
+func Seed(args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	seed := flags.Int64("seed", 1, "the seed the data is generated from")
+	from := flags.String("from", time.Now().Format("2006-01-02"), "the day rides are seeded after")
+	users := flags.Int("users", 20, "how many users to make")
+	cars := flags.Int("cars", 10, "how many cars to make")
+	rides := flags.Int("rides", 40, "how many rides to make")
+	passengers := flags.Int("passengers", 60, "how many passengers to make")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return ErrSeedUsage
+	}
+
+	for _, count := range []int{*users, *cars, *rides, *passengers} {
+		if count < 0 {
+			return ErrSeedUsage
+		}
+	}
+
+	fromDay, err := time.ParseInLocation("2006-01-02", *from, time.Local)
+	if err != nil {
+		return ErrSeedUsage
+	}
+
+	return withStores(func(conf config.LoadedData, s *storeSet, l *Logger) error {
+		if conf.Get("db.driver") == "memory" {
+			return ErrSeedMemory
+		}
+
+		seeder := services.NewSeedService(s.users, s.cars, s.rides, s.passengers, l)
+
+		seeded, counts, err := seeder.Seed(*seed, fromDay, services.SeedCounts{
+			Users:      *users,
+			Cars:       *cars,
+			Rides:      *rides,
+			Passengers: *passengers,
+		})
+		fmt.Fprintf(os.Stderr, "seeded %d user(s), %d car(s), %d ride(s), %d passenger(s)\n",
+			counts.Users, counts.Cars, counts.Rides, counts.Passengers)
+
+		if err != nil || len(seeded) == 0 {
+			return err
+		}
+
+		// seeded users cannot log in with google, so print a token to use instead
+		tokenizer := auth.NewTokenizer(
+			conf.Get("jwt.secret"),
+			conf.Get("jwt.issuer"),
+			int(conf.GetInt("jwt.num_days_valid")),
+			l,
+		)
+
+		token, err := services.NewUserService(s.users, tokenizer, l).Token(seeded[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "log in as %s by setting the %s cookie to:\n", seeded[0].Email, conf.Get("jwt.cookie"))
+		fmt.Println(token)
+
+		return nil
+	})
+}
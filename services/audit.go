@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/ucladevx/BPool/interfaces"
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+// ErrUnknownAuditEntity occurs when audit events are requested for an entity that is not audited
+var ErrUnknownAuditEntity = errors.New("entity is not audited")
+
+// auditSort lists audit events oldest first
+var auditSort = pagination.Sort{"created_at", "id"}
+
+type (
+	// AuditService provides the history of changes made to entities
+	AuditService struct {
+		store  AuditStore
+		logger interfaces.Logger
+	}
+
+	// AuditStore any store that allows for audit events to be persisted, events
+	// are only ever added
+	AuditStore interface {
+		Insert(event *models.AuditEvent) error
+		WhereMany(clauses []stores.QueryModifier) ([]*models.AuditEvent, error)
+	}
+)
+
+// NewAuditService creates a new audit service
+func NewAuditService(store AuditStore, l interfaces.Logger) *AuditService {
+	return &AuditService{
+		store:  store,
+		logger: l,
+	}
+}
+
+// GetForEntity returns a page of the audit events of an entity, oldest first, and
+// the cursor of the next page, if there is one, only admins can view them
+func (a *AuditService) GetForEntity(entity, entityID string, page pagination.Page, user *auth.UserClaims) ([]*models.AuditEvent, *pagination.Cursor, error) {
+	if user.AuthLevel < AdminLevel {
+		return nil, nil, ErrNotAllowed
+	}
+
+	if entity != models.AuditEntityRide && entity != models.AuditEntityPassenger {
+		return nil, nil, ErrUnknownAuditEntity
+	}
+
+	page = page.WithDefaults(auditSort)
+	if err := page.Validate("created_at"); err != nil {
+		return nil, nil, err
+	}
+
+	clauses := []stores.QueryModifier{
+		stores.QueryMod("entity", stores.EQ, entity),
+		stores.And,
+		stores.QueryMod("entity_id", stores.EQ, entityID),
+	}
+
+	events, err := a.store.WhereMany(stores.Paginate(clauses, page.Peek()))
+	if err != nil {
+		a.logger.Error("AuditService.GetForEntity - unable to get events", "error", err.Error())
+		return nil, nil, err
+	}
+
+	next, err := page.Trim(&events)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return events, next, nil
+}
+
+// audit records a change made by user to an entity in the transaction
+func audit(tx Tx, user *auth.UserClaims, entity, entityID, action string, before, after interface{}) error {
+	event, err := models.NewAuditEvent(user.ID, entity, entityID, action, before, after)
+	if err != nil {
+		return err
+	}
+
+	return tx.Audit().Insert(event)
+}
@@ -0,0 +1,48 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/ucladevx/BPool/mocks"
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+func TestAuditGetForEntity(t *testing.T) {
+	assert := assert.New(t)
+	store := new(mocks.AuditStore)
+	service := services.NewAuditService(store, mocks.Logger{})
+
+	admin := auth.UserClaims{ID: "123", AuthLevel: services.AdminLevel}
+	user := auth.UserClaims{ID: "123", AuthLevel: services.UserLevel}
+	event := models.AuditEvent{ID: "event", Entity: models.AuditEntityRide, EntityID: "abc"}
+
+	store.On("WhereMany", mock.MatchedBy(func(clauses []stores.QueryModifier) bool {
+		filters, _ := clauses[0].Value.([]stores.QueryModifier)
+		return len(clauses) == 4 && len(filters) == 3 &&
+			filters[0] == stores.QueryMod("entity", stores.EQ, models.AuditEntityRide) &&
+			filters[2] == stores.QueryMod("entity_id", stores.EQ, "abc") &&
+			clauses[1] == stores.OrderBy("created_at", stores.Asc) &&
+			clauses[2] == stores.OrderBy("id", stores.Asc)
+	})).Return([]*models.AuditEvent{&event}, nil).Once()
+
+	events, next, err := service.GetForEntity(models.AuditEntityRide, "abc", pagination.Page{}, &admin)
+	assert.Nil(err, "there should be no error")
+	assert.Equal([]*models.AuditEvent{&event}, events, "the ride's events should be returned")
+	assert.Nil(next, "there should be no next page")
+
+	events, _, err = service.GetForEntity(models.AuditEntityRide, "abc", pagination.Page{}, &user)
+	assert.Equal(services.ErrNotAllowed, err, "only admins can view audit events")
+	assert.Nil(events)
+
+	events, _, err = service.GetForEntity("car", "abc", pagination.Page{}, &admin)
+	assert.Equal(services.ErrUnknownAuditEntity, err, "only audited entities can be viewed")
+	assert.Nil(events)
+
+	store.AssertExpectations(t)
+}
@@ -2,17 +2,22 @@ package services
 
 import (
 	"errors"
+	"time"
 
 	"github.com/ucladevx/BPool/interfaces"
 	"github.com/ucladevx/BPool/models"
 	"github.com/ucladevx/BPool/stores"
 	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
 const (
 	userCarLimit = 10
 )
 
+// carSortColumns are the columns cars can be listed by, besides id
+var carSortColumns = []string{"created_at"}
+
 var (
 	// ErrCarValidation error when user submits invalid car object
 	ErrCarValidation = errors.New("car model validation failed")
@@ -33,11 +38,14 @@ type (
 
 	// CarStore any store that allows for users to be persisted
 	CarStore interface {
-		GetAll(lastID string, limit int) ([]*models.Car, error)
+		GetAll(page pagination.Page) ([]*models.Car, error)
 		GetByID(id string) (*models.Car, error)
 		GetCount(queryModifiers []stores.QueryModifier) (int, error)
 		Insert(car *models.Car) error
+		Import(car *models.Car) error
 		Remove(id string) error
+		Restore(id string) error
+		Purge(before time.Time) (int, error)
 	}
 )
 
@@ -49,17 +57,28 @@ func NewCarService(store CarStore, l interfaces.Logger) *CarService {
 	}
 }
 
-// GetAllCars returns all cars
-func (c *CarService) GetAll(lastID string, limit int, authLevel int) ([]*models.Car, error) {
+// GetAll returns a page of cars and the cursor of the next page, if there is one
+func (c *CarService) GetAll(page pagination.Page, authLevel int) ([]*models.Car, *pagination.Cursor, error) {
 	if authLevel < AdminLevel {
-		return nil, ErrNotAllowed
+		return nil, nil, ErrNotAllowed
 	}
 
-	if limit <= 0 || limit > 100 {
-		limit = 15
+	page = page.WithDefaults(pagination.ByID)
+	if err := page.Validate(carSortColumns...); err != nil {
+		return nil, nil, err
+	}
+
+	cars, err := c.store.GetAll(page.Peek())
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return c.store.GetAll(lastID, limit)
+	next, err := page.Trim(&cars)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cars, next, nil
 }
 
 // GetCar returns a car by id
@@ -124,6 +143,19 @@ func (c *CarService) DeleteCar(id, userID string) error {
 	return c.store.Remove(id)
 }
 
+// RestoreCar undoes the removal of a car and its rides, only admins can restore cars
+func (c *CarService) RestoreCar(id string, authLevel int) (*models.Car, error) {
+	if authLevel < AdminLevel {
+		return nil, ErrNotAllowed
+	}
+
+	if err := c.store.Restore(id); err != nil {
+		return nil, err
+	}
+
+	return c.store.GetByID(id)
+}
+
 // IsOwnerOrAdmin indicates if the user is the car owner
 func (c *CarService) IsOwnerOrAdmin(carID string, user *auth.UserClaims) (bool, error) {
 	car, err := c.GetCar(carID)
@@ -10,6 +10,7 @@ import (
 	"github.com/ucladevx/BPool/mocks"
 	"github.com/ucladevx/BPool/models"
 	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
 var (
@@ -107,6 +108,24 @@ func TestCarDelete(t *testing.T) {
 	store.AssertExpectations(t)
 }
 
+func TestCarRestore(t *testing.T) {
+	store := new(mocks.CarStore)
+	service := newCarService(store)
+	assert := assert.New(t)
+
+	_, err := service.RestoreCar(testCar.ID, services.UserLevel)
+	assert.Equal(services.ErrNotAllowed, err, "only admins should be able to restore cars")
+
+	store.On("Restore", testCar.ID).Return(nil)
+	store.On("GetByID", testCar.ID).Return(&testCar, nil)
+
+	car, err := service.RestoreCar(testCar.ID, services.AdminLevel)
+	assert.Nil(err, "there should be no error")
+	assert.Equal(&testCar, car, "the restored car should be returned")
+
+	store.AssertExpectations(t)
+}
+
 func TestCarGetByID(t *testing.T) {
 	store := new(mocks.CarStore)
 	service := newCarService(store)
@@ -136,14 +155,14 @@ func TestCarGetAll(t *testing.T) {
 	service := newCarService(store)
 	assert := assert.New(t)
 
-	noCars, err := service.GetAll("", 10, services.UserLevel)
+	noCars, _, err := service.GetAll(pagination.Page{Limit: 10}, services.UserLevel)
 	assert.Nil(noCars, "returns no cars when user does not have correct auth level")
 	assert.EqualError(err, "user is not allowed")
 
-	store.On("GetAll", "", 15).Return([]*models.Car{&testCar}, nil)
+	store.On("GetAll", pagination.Page{Sort: pagination.ByID, Limit: 16}).Return([]*models.Car{&testCar}, nil)
 	badLimit := -1
 
-	cars, err := service.GetAll("", badLimit, services.AdminLevel)
+	cars, _, err := service.GetAll(pagination.Page{Limit: badLimit}, services.AdminLevel)
 
 	assert.Nil(err, "no error should be returned for a bad limit")
 	assert.Equal(1, len(cars), "returned cars should have length 1")
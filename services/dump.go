@@ -0,0 +1,263 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/ucladevx/BPool/interfaces"
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+const (
+	// DumpUser is the kind of dump records holding a user
+	DumpUser = "user"
+	// DumpCar is the kind of dump records holding a car
+	DumpCar = "car"
+	// DumpRideSeries is the kind of dump records holding a ride series
+	DumpRideSeries = "ride_series"
+	// DumpRide is the kind of dump records holding a ride
+	DumpRide = "ride"
+	// DumpRideRequest is the kind of dump records holding a ride request
+	DumpRideRequest = "ride_request"
+	// DumpPassenger is the kind of dump records holding a passenger
+	DumpPassenger = "passenger"
+
+	// dumpPageSize is how many records are read from a store at a time when exporting
+	dumpPageSize = 100
+)
+
+var (
+	// DumpKinds are the kinds of dump records in the order they are exported and
+	// must be imported, so that records come after the records they reference
+	DumpKinds = []string{DumpUser, DumpCar, DumpRideSeries, DumpRide, DumpRideRequest, DumpPassenger}
+
+	// ErrUnknownDumpKind occurs when a dump record is not one of the DumpKinds
+	ErrUnknownDumpKind = errors.New("unknown dump record kind")
+	// ErrDumpOrder occurs when a dump record comes before a record it may reference
+	ErrDumpOrder = errors.New("dump records must be in the order users, cars, ride series, rides, ride requests, passengers")
+)
+
+type (
+	// DumpService exports and imports everything in the stores as JSON lines, keeping
+	// ids and timestamps so that data can be moved between backends
+	DumpService struct {
+		users      UserStore
+		cars       CarStore
+		series     RideSeriesStore
+		rides      RideStore
+		requests   RideRequestStore
+		passengers PassengerStore
+		logger     interfaces.Logger
+	}
+
+	// DumpCounts is how many records of each kind were exported or imported
+	DumpCounts map[string]int
+
+	// dumpRecord is a line of a dump, Data is the record in its JSON form
+	dumpRecord struct {
+		Kind string      `json:"kind"`
+		Data interface{} `json:"data"`
+	}
+)
+
+// NewDumpService creates a new dump service
+func NewDumpService(users UserStore, cars CarStore, series RideSeriesStore, rides RideStore, requests RideRequestStore, passengers PassengerStore, l interfaces.Logger) *DumpService {
+	return &DumpService{
+		users:      users,
+		cars:       cars,
+		series:     series,
+		rides:      rides,
+		requests:   requests,
+		passengers: passengers,
+		logger:     l,
+	}
+}
+
+// Export writes every user, car, ride series, ride, ride request and passenger to w,
+// one record per line, soft deleted records are left out since the stores do not
+// list them, rides of deleted series are exported without their series and
+// passengers of deleted requests without their request
+func (d *DumpService) Export(w io.Writer) (DumpCounts, error) {
+	encoder := json.NewEncoder(w)
+	counts := DumpCounts{}
+	exportedSeries := map[string]bool{}
+	exportedRequests := map[string]bool{}
+
+	pages := map[string]func(page pagination.Page) (interface{}, error){
+		DumpUser: func(page pagination.Page) (interface{}, error) {
+			return d.users.GetAll(page)
+		},
+		DumpCar: func(page pagination.Page) (interface{}, error) {
+			return d.cars.GetAll(page)
+		},
+		DumpRideSeries: func(page pagination.Page) (interface{}, error) {
+			all, err := d.series.GetAll(page)
+			for _, series := range all {
+				exportedSeries[series.ID] = true
+			}
+			return all, err
+		},
+		DumpRide: func(page pagination.Page) (interface{}, error) {
+			rides, err := d.rides.GetAll(page)
+			for _, ride := range rides {
+				if ride.SeriesID != nil && !exportedSeries[*ride.SeriesID] {
+					ride.SeriesID, ride.OccurrenceDate = nil, nil
+				}
+			}
+			return rides, err
+		},
+		DumpRideRequest: func(page pagination.Page) (interface{}, error) {
+			all, err := d.requests.GetAll(page)
+			for _, request := range all {
+				exportedRequests[request.ID] = true
+			}
+			return all, err
+		},
+		DumpPassenger: func(page pagination.Page) (interface{}, error) {
+			passengers, err := d.passengers.GetAll(page)
+			for _, passenger := range passengers {
+				if passenger.RequestID != nil && !exportedRequests[*passenger.RequestID] {
+					passenger.RequestID = nil
+				}
+			}
+			return passengers, err
+		},
+	}
+
+	for _, kind := range DumpKinds {
+		count, err := d.exportKind(encoder, kind, pages[kind])
+		counts[kind] = count
+
+		if err != nil {
+			d.logger.Error("DumpService.Export - unable to export", "kind", kind, "error", err.Error())
+			return counts, err
+		}
+	}
+
+	return counts, nil
+}
+
+// Import reads records written by Export from r and persists them as they are,
+// stopping at the first record that cannot be imported
+func (d *DumpService) Import(r io.Reader) (DumpCounts, error) {
+	decoder := json.NewDecoder(r)
+	counts := DumpCounts{}
+	lastOrder := 0
+
+	for n := 1; ; n++ {
+		record := struct {
+			Kind string          `json:"kind"`
+			Data json.RawMessage `json:"data"`
+		}{}
+
+		if err := decoder.Decode(&record); err == io.EOF {
+			return counts, nil
+		} else if err != nil {
+			return counts, fmt.Errorf("record %d: %s", n, err.Error())
+		}
+
+		order := dumpOrder(record.Kind)
+		if order < 0 {
+			return counts, fmt.Errorf("record %d: %s %q", n, ErrUnknownDumpKind.Error(), record.Kind)
+		}
+		if order < lastOrder {
+			return counts, fmt.Errorf("record %d: %s", n, ErrDumpOrder.Error())
+		}
+		lastOrder = order
+
+		if err := d.importRecord(record.Kind, record.Data); err != nil {
+			d.logger.Error("DumpService.Import - unable to import", "record", n, "kind", record.Kind, "error", err.Error())
+			return counts, fmt.Errorf("record %d (%s): %s", n, record.Kind, err.Error())
+		}
+
+		counts[record.Kind]++
+	}
+}
+
+// exportKind writes every record of a kind, reading them a page at a time in id order
+func (d *DumpService) exportKind(encoder *json.Encoder, kind string, getPage func(page pagination.Page) (interface{}, error)) (int, error) {
+	page := pagination.Page{Sort: pagination.ByID, Limit: dumpPageSize}
+	count := 0
+
+	for {
+		rows, err := getPage(page)
+		if err != nil {
+			return count, err
+		}
+
+		rv := reflect.ValueOf(rows)
+		for i := 0; i < rv.Len(); i++ {
+			if err := encoder.Encode(dumpRecord{Kind: kind, Data: rv.Index(i).Interface()}); err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		if rv.Len() < page.Limit {
+			return count, nil
+		}
+
+		if page.After, err = pagination.NewCursor(page.Sort, rv.Index(rv.Len()-1).Interface()); err != nil {
+			return count, err
+		}
+	}
+}
+
+func (d *DumpService) importRecord(kind string, data json.RawMessage) error {
+	switch kind {
+	case DumpUser:
+		user := models.User{}
+		if err := json.Unmarshal(data, &user); err != nil {
+			return err
+		}
+		return d.users.Import(&user)
+	case DumpCar:
+		car := models.Car{}
+		if err := json.Unmarshal(data, &car); err != nil {
+			return err
+		}
+		return d.cars.Import(&car)
+	case DumpRideSeries:
+		series := models.RideSeries{}
+		if err := json.Unmarshal(data, &series); err != nil {
+			return err
+		}
+		return d.series.Import(&series)
+	case DumpRide:
+		ride := models.Ride{}
+		if err := json.Unmarshal(data, &ride); err != nil {
+			return err
+		}
+		return d.rides.Import(&ride)
+	case DumpRideRequest:
+		request := models.RideRequest{}
+		if err := json.Unmarshal(data, &request); err != nil {
+			return err
+		}
+		return d.requests.Import(&request)
+	case DumpPassenger:
+		// passengers dumped before parties could ask for more seats take one
+		passenger := models.Passenger{SeatsRequested: 1}
+		if err := json.Unmarshal(data, &passenger); err != nil {
+			return err
+		}
+		return d.passengers.Import(&passenger)
+	}
+
+	return ErrUnknownDumpKind
+}
+
+// dumpOrder is the position of kind in DumpKinds, or -1 if it is not one
+func dumpOrder(kind string) int {
+	for i, k := range DumpKinds {
+		if k == kind {
+			return i
+		}
+	}
+
+	return -1
+}
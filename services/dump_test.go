@@ -0,0 +1,120 @@
+package services_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ucladevx/BPool/mocks"
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+type dumpStores struct {
+	users      *mocks.UserStore
+	cars       *mocks.CarStore
+	series     *mocks.RideSeriesStore
+	rides      *mocks.RideStore
+	requests   *mocks.RideRequestStore
+	passengers *mocks.PassengerStore
+	service    *services.DumpService
+}
+
+func newDumpStores() dumpStores {
+	d := dumpStores{
+		users:      new(mocks.UserStore),
+		cars:       new(mocks.CarStore),
+		series:     new(mocks.RideSeriesStore),
+		rides:      new(mocks.RideStore),
+		requests:   new(mocks.RideRequestStore),
+		passengers: new(mocks.PassengerStore),
+	}
+	d.service = services.NewDumpService(d.users, d.cars, d.series, d.rides, d.requests, d.passengers, mocks.Logger{})
+
+	return d
+}
+
+func TestDumpExport(t *testing.T) {
+	assert := assert.New(t)
+	d := newDumpStores()
+
+	created := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	user := models.User{ID: "user1", Email: "joe@ucla.edu", CreatedAt: created, UpdatedAt: created}
+	car := models.Car{ID: "car1", UserID: "user1", CreatedAt: created, UpdatedAt: created}
+	series := models.RideSeries{ID: "series1", DriverID: "user1", CarID: "car1", CreatedAt: created, UpdatedAt: created}
+	seriesID, deletedSeriesID, date := "series1", "series2", "2018-01-03"
+	occurrence := models.Ride{ID: "ride1", SeriesID: &seriesID, OccurrenceDate: &date}
+	orphan := models.Ride{ID: "ride2", SeriesID: &deletedSeriesID, OccurrenceDate: &date}
+	request := models.RideRequest{ID: "request1", PassengerID: "user1", CreatedAt: created, UpdatedAt: created}
+	requestID, deletedRequestID := "request1", "request2"
+	offered := models.Passenger{ID: "passenger1", RideID: "ride1", RequestID: &requestID}
+	unlinked := models.Passenger{ID: "passenger2", RideID: "ride1", RequestID: &deletedRequestID}
+
+	firstPage := pagination.Page{Sort: pagination.ByID, Limit: 100}
+	d.users.On("GetAll", firstPage).Return([]*models.User{&user}, nil)
+	d.cars.On("GetAll", firstPage).Return([]*models.Car{&car}, nil)
+	d.series.On("GetAll", firstPage).Return([]*models.RideSeries{&series}, nil)
+	d.rides.On("GetAll", firstPage).Return([]*models.Ride{&occurrence, &orphan}, nil)
+	d.requests.On("GetAll", firstPage).Return([]*models.RideRequest{&request}, nil)
+	d.passengers.On("GetAll", firstPage).Return([]*models.Passenger{&offered, &unlinked}, nil)
+
+	out := bytes.Buffer{}
+	counts, err := d.service.Export(&out)
+	assert.Nil(err, "there should be no error")
+	assert.Equal(services.DumpCounts{"user": 1, "car": 1, "ride_series": 1, "ride": 2, "ride_request": 1, "passenger": 2}, counts)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if assert.Len(lines, 8, "there should be a line for each record") {
+		assert.Contains(lines[0], `"kind":"user"`, "users should be exported before the cars that reference them")
+		assert.Contains(lines[0], `"id":"user1"`)
+		assert.Contains(lines[1], `"kind":"car"`)
+		assert.Contains(lines[2], `"kind":"ride_series"`, "series should be exported before the rides that reference them")
+		assert.Contains(lines[3], `"series_id":"series1"`)
+		assert.NotContains(lines[4], `"series_id"`, "rides of series that were not exported should not reference them")
+		assert.Contains(lines[5], `"kind":"ride_request"`, "requests should be exported before the passengers that reference them")
+		assert.Contains(lines[6], `"request_id":"request1"`)
+		assert.NotContains(lines[7], `"request_id"`, "passengers of requests that were not exported should not reference them")
+	}
+
+	d.users.AssertExpectations(t)
+	d.cars.AssertExpectations(t)
+	d.series.AssertExpectations(t)
+	d.requests.AssertExpectations(t)
+}
+
+func TestDumpImport(t *testing.T) {
+	assert := assert.New(t)
+	d := newDumpStores()
+
+	dump := `{"kind":"user","data":{"id":"user1","email":"joe@ucla.edu","auth_level":1,"created_at":"2018-01-02T03:04:05Z","updated_at":"2018-01-02T03:04:05Z"}}
+{"kind":"ride","data":{"id":"ride1","driver_id":"user1","car_id":"car1","version":3}}
+`
+
+	d.users.On("Import", mock.MatchedBy(func(u *models.User) bool {
+		return u.ID == "user1" && u.AuthLevel == 1 && u.CreatedAt.Equal(time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC))
+	})).Return(nil)
+	d.rides.On("Import", mock.MatchedBy(func(r *models.Ride) bool {
+		return r.ID == "ride1" && r.Version == 3
+	})).Return(nil)
+
+	counts, err := d.service.Import(strings.NewReader(dump))
+	assert.Nil(err, "there should be no error")
+	assert.Equal(services.DumpCounts{"user": 1, "ride": 1}, counts)
+
+	counts, err = d.service.Import(strings.NewReader(`{"kind":"ride","data":{"id":"ride1","version":3}}` + "\n" + `{"kind":"user","data":{}}`))
+	assert.NotNil(err, "records must come after the records they reference")
+	assert.Contains(err.Error(), services.ErrDumpOrder.Error())
+	assert.Equal(1, counts["ride"], "records before the error should be counted")
+
+	_, err = d.service.Import(strings.NewReader(`{"kind":"trip","data":{}}`))
+	assert.NotNil(err)
+	assert.Contains(err.Error(), services.ErrUnknownDumpKind.Error())
+
+	d.users.AssertExpectations(t)
+	d.rides.AssertExpectations(t)
+}
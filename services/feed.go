@@ -2,12 +2,17 @@ package services
 
 import (
 	"sort"
+	"time"
 
 	"github.com/ucladevx/BPool/interfaces"
 	"github.com/ucladevx/BPool/models"
 	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
+// upcomingSort lists upcoming rides soonest first
+var upcomingSort = pagination.Sort{"start_date", "id"}
+
 // FeedService provides all data for the feed
 type FeedService struct {
 	rideStore RideStore
@@ -22,12 +27,21 @@ func NewFeedService(store RideStore, l interfaces.Logger) *FeedService {
 	}
 }
 
-// GetUserRides gets all user's associated rides
-func (f *FeedService) GetUserRides(userID string) ([]*models.Ride, error) {
+// GetUserRides gets all user's associated rides, only the rides with one of the
+// statuses given if there are any
+func (f *FeedService) GetUserRides(userID string, statuses []string) ([]*models.Ride, error) {
+	if err := models.ValidateRideStatuses(statuses); err != nil {
+		return nil, err
+	}
+
 	clauses := []stores.QueryModifier{
 		stores.QueryMod("driver_id", stores.EQ, userID),
 	}
 
+	if len(statuses) > 0 {
+		clauses = append(clauses, stores.And, stores.QueryMod("status", stores.IN, statuses))
+	}
+
 	rides, err := f.rideStore.WhereMany(clauses)
 	if err != nil {
 		return nil, err
@@ -38,10 +52,54 @@ func (f *FeedService) GetUserRides(userID string) ([]*models.Ride, error) {
 		return nil, err
 	}
 
-	allRides := append(rides, passengerRides...)
+	allRides := rides
+	for _, ride := range passengerRides {
+		if hasStatus(ride, statuses) {
+			allRides = append(allRides, ride)
+		}
+	}
 
 	// Sort rides by descending start time
 	sort.Slice(allRides, func(i, j int) bool { return allRides[j].StartDate.Before(allRides[i].StartDate) })
 
 	return allRides, nil
 }
+
+// GetUpcomingRides gets a page of the scheduled rides that have not started yet, soonest first,
+// and the cursor of the next page, if there is one
+func (f *FeedService) GetUpcomingRides(page pagination.Page) ([]*models.Ride, *pagination.Cursor, error) {
+	page = page.WithDefaults(upcomingSort)
+	if err := page.Validate("start_date"); err != nil {
+		return nil, nil, err
+	}
+
+	clauses := []stores.QueryModifier{
+		stores.QueryMod("start_date", stores.GT, time.Now()),
+		stores.And,
+		stores.QueryMod("status", stores.EQ, models.RideScheduled),
+	}
+
+	rides, err := f.rideStore.WhereMany(stores.Paginate(clauses, page.Peek()))
+	if err != nil {
+		f.logger.Error("FeedService.GetUpcomingRides - unable to get rides", "error", err.Error())
+		return nil, nil, err
+	}
+
+	next, err := page.Trim(&rides)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rides, next, nil
+}
+
+// hasStatus reports whether the ride has one of the statuses, any status if there are none
+func hasStatus(ride *models.Ride, statuses []string) bool {
+	for _, status := range statuses {
+		if ride.Status == status {
+			return true
+		}
+	}
+
+	return len(statuses) == 0
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/ucladevx/BPool/mocks"
 	"github.com/ucladevx/BPool/models"
 	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
 type mockedFeedService struct {
@@ -35,10 +37,30 @@ func TestGetUserFeedRides(t *testing.T) {
 	feed.rideStore.On("WhereMany", mock.Anything).Return([]*models.Ride{&ride2}, nil)
 	feed.rideStore.On("GetAllWherePassenger", userID).Return([]*models.Ride{&ride1}, nil)
 
-	rides, err := feed.feedService.GetUserRides(userID)
+	rides, err := feed.feedService.GetUserRides(userID, nil)
 	assert.Nil(err, "there should be no error")
 	assert.NotNil(rides, "there should be some rides")
 	assert.Equal(2, len(rides), "there should be two rides for the user")
 	assert.Equal(&ride1, rides[0], "the first ride should be the one with the later start date")
 	assert.Equal(&ride2, rides[1], "the second ride should have a start date before the first ride")
 }
+
+func TestGetUpcomingRides(t *testing.T) {
+	feed := newMockedFeedService()
+	assert := assert.New(t)
+
+	feed.rideStore.On("WhereMany", mock.MatchedBy(func(clauses []stores.QueryModifier) bool {
+		filters, _ := clauses[0].Value.([]stores.QueryModifier)
+		return len(clauses) == 4 && len(filters) == 3 &&
+			filters[0].Column == "start_date" && filters[0].Operator == stores.GT &&
+			filters[2] == stores.QueryMod("status", stores.EQ, models.RideScheduled) &&
+			clauses[1] == stores.OrderBy("start_date", stores.Asc) &&
+			clauses[2] == stores.OrderBy("id", stores.Asc) &&
+			clauses[3] == stores.Limit(11)
+	})).Return([]*models.Ride{&ride2, &ride1}, nil)
+
+	rides, next, err := feed.feedService.GetUpcomingRides(pagination.Page{Limit: 10})
+	assert.Nil(err, "there should be no error")
+	assert.Equal([]*models.Ride{&ride2, &ride1}, rides, "rides should be in the order the store returns them")
+	assert.Nil(next, "there should be no next page when the store has no more rides")
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ucladevx/BPool/interfaces"
+	"github.com/ucladevx/BPool/models"
+)
+
+const (
+	// AllOutboxEvents is the event type of handlers that handle every event
+	AllOutboxEvents = "*"
+
+	// MaxOutboxAttempts is how many times an event is tried before it is given up on
+	MaxOutboxAttempts = 10
+
+	// outboxBatchSize is how many due events are read at a time
+	outboxBatchSize = 100
+
+	// outboxClaimLease is how long claimed events are kept from other dispatchers, an
+	// event still claimed when it runs out is delivered again
+	outboxClaimLease = 5 * time.Minute
+)
+
+type (
+	// OutboxHandler handles a delivered event, returning an error to have it retried,
+	// events are delivered at least once so handlers must cope with duplicates
+	OutboxHandler func(event *models.OutboxEvent) error
+
+	// OutboxDispatcher delivers the events in the outbox to the handlers registered
+	// for their type, retrying failed deliveries with exponential backoff
+	OutboxDispatcher struct {
+		store    OutboxStore
+		mu       sync.RWMutex
+		handlers map[string][]OutboxHandler
+		logger   interfaces.Logger
+		now      func() time.Time
+	}
+
+	// OutboxStore any store that allows for outbox events to be persisted
+	OutboxStore interface {
+		Insert(event *models.OutboxEvent) error
+		GetDue(now time.Time, limit int) ([]*models.OutboxEvent, error)
+		// Claim returns up to limit events due by now, oldest first, and makes them due
+		// again at until so that concurrent claims never return the same events
+		Claim(now, until time.Time, limit int) ([]*models.OutboxEvent, error)
+		Update(event *models.OutboxEvent) error
+		Purge(before time.Time) (int, error)
+	}
+)
+
+// NewOutboxDispatcher creates a new outbox dispatcher
+func NewOutboxDispatcher(store OutboxStore, l interfaces.Logger) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		store:    store,
+		handlers: map[string][]OutboxHandler{},
+		logger:   l,
+		now:      time.Now,
+	}
+}
+
+// Handle registers a handler for events of the type given, or every event for AllOutboxEvents
+func (d *OutboxDispatcher) Handle(eventType string, h OutboxHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.handlers[eventType] = append(d.handlers[eventType], h)
+}
+
+// Dispatch claims a batch of due events, oldest first, delivers them and returns how
+// many were delivered. An event is delivered once every handler for it succeeds, if
+// any fails every handler is run again on the next attempt. Claiming keeps dispatchers
+// running in other instances from delivering the same events at the same time.
+func (d *OutboxDispatcher) Dispatch() (int, error) {
+	now := d.now()
+	events, err := d.store.Claim(now, now.Add(outboxClaimLease), outboxBatchSize)
+	if err != nil {
+		d.logger.Error("OutboxDispatcher.Dispatch - unable to get due events", "error", err.Error())
+		return 0, err
+	}
+
+	delivered := 0
+
+	for _, event := range events {
+		event.Attempts++
+
+		if err := d.deliver(event); err != nil {
+			message := err.Error()
+			event.LastError = &message
+			event.NextAttemptAt = nil
+
+			if event.Attempts < MaxOutboxAttempts {
+				next := d.now().Add(outboxRetryDelay(event.Attempts))
+				event.NextAttemptAt = &next
+			}
+
+			d.logger.Error("OutboxDispatcher.Dispatch - unable to deliver event", "id", event.ID, "type", event.Type, "attempts", event.Attempts, "error", message)
+		} else {
+			now := d.now()
+			event.DeliveredAt = &now
+			event.NextAttemptAt = nil
+			delivered++
+		}
+
+		if err := d.store.Update(event); err != nil {
+			d.logger.Error("OutboxDispatcher.Dispatch - unable to update event", "id", event.ID, "error", err.Error())
+			return delivered, err
+		}
+	}
+
+	return delivered, nil
+}
+
+// Run dispatches due events every interval until stop is closed, a full batch is
+// followed straight away by the next
+func (d *OutboxDispatcher) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for {
+				if count, err := d.Dispatch(); err != nil || count < outboxBatchSize {
+					break
+				}
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// deliver runs every handler for the event, a panicking handler fails the delivery
+func (d *OutboxDispatcher) deliver(event *models.OutboxEvent) (err error) {
+	d.mu.RLock()
+	handlers := append(append([]OutboxHandler{}, d.handlers[event.Type]...), d.handlers[AllOutboxEvents]...)
+	d.mu.RUnlock()
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("handler panicked: %v", p)
+		}
+	}()
+
+	for _, h := range handlers {
+		if err := h(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// outboxRetryDelay is how long to wait before another attempt, doubling from a
+// second up to an hour
+func outboxRetryDelay(attempts int) time.Duration {
+	delay := time.Second << uint(attempts-1)
+	if attempts > 13 || delay > time.Hour {
+		return time.Hour
+	}
+
+	return delay
+}
+
+// publish writes an event to the outbox of the unit of work, so that it is only
+// delivered if the change it describes is committed
+func publish(tx Tx, eventType, entityID string, payload interface{}) error {
+	event, err := models.NewOutboxEvent(eventType, entityID, payload)
+	if err != nil {
+		return err
+	}
+
+	return tx.Outbox().Insert(event)
+}
@@ -0,0 +1,114 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ucladevx/BPool/mocks"
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/stores/memory"
+	"github.com/ucladevx/BPool/utils/auth"
+)
+
+func TestOutboxDispatch(t *testing.T) {
+	assert := assert.New(t)
+	store := new(mocks.OutboxStore)
+	dispatcher := services.NewOutboxDispatcher(store, mocks.Logger{})
+
+	created := &models.OutboxEvent{ID: "1", Type: models.EventRideCreated, EntityID: "ride1"}
+	failing := &models.OutboxEvent{ID: "2", Type: models.EventPassengerAccepted, EntityID: "passenger1"}
+	lastTry := &models.OutboxEvent{ID: "3", Type: models.EventPassengerAccepted, EntityID: "passenger2", Attempts: services.MaxOutboxAttempts - 1}
+	store.On("Claim", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time"), 100).Return([]*models.OutboxEvent{created, failing, lastTry}, nil).Once()
+
+	var handled, all []string
+	dispatcher.Handle(models.EventRideCreated, func(event *models.OutboxEvent) error {
+		handled = append(handled, event.ID)
+		return nil
+	})
+	dispatcher.Handle(models.EventPassengerAccepted, func(event *models.OutboxEvent) error {
+		return errors.New("mail server down")
+	})
+	dispatcher.Handle(services.AllOutboxEvents, func(event *models.OutboxEvent) error {
+		all = append(all, event.ID)
+		return nil
+	})
+
+	store.On("Update", mock.MatchedBy(func(event *models.OutboxEvent) bool {
+		return event.ID == "1" && event.Attempts == 1 && event.DeliveredAt != nil && event.NextAttemptAt == nil
+	})).Return(nil).Once()
+	store.On("Update", mock.MatchedBy(func(event *models.OutboxEvent) bool {
+		return event.ID == "2" && event.Attempts == 1 && event.DeliveredAt == nil &&
+			event.LastError != nil && *event.LastError == "mail server down" &&
+			event.NextAttemptAt != nil && event.NextAttemptAt.After(time.Now())
+	})).Return(nil).Once()
+	store.On("Update", mock.MatchedBy(func(event *models.OutboxEvent) bool {
+		return event.ID == "3" && event.Attempts == services.MaxOutboxAttempts && event.DeliveredAt == nil && event.NextAttemptAt == nil
+	})).Return(nil).Once()
+
+	delivered, err := dispatcher.Dispatch()
+	assert.Nil(err, "there should be no error")
+	assert.Equal(1, delivered, "only the event whose handlers all succeeded should be delivered")
+	assert.Equal([]string{"1"}, handled)
+	assert.Equal([]string{"1"}, all, "handlers for every event should run after the ones for the type fail")
+
+	store.AssertExpectations(t)
+}
+
+func TestOutboxDispatchRecoversFromPanics(t *testing.T) {
+	assert := assert.New(t)
+	store := new(mocks.OutboxStore)
+	dispatcher := services.NewOutboxDispatcher(store, mocks.Logger{})
+
+	event := &models.OutboxEvent{ID: "1", Type: models.EventRideDeleted}
+	store.On("Claim", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time"), 100).Return([]*models.OutboxEvent{event}, nil).Once()
+	store.On("Update", mock.AnythingOfType("*models.OutboxEvent")).Return(nil).Once()
+
+	dispatcher.Handle(models.EventRideDeleted, func(event *models.OutboxEvent) error {
+		panic("nil map")
+	})
+
+	delivered, err := dispatcher.Dispatch()
+	assert.Nil(err)
+	assert.Equal(0, delivered)
+	if assert.NotNil(event.LastError, "a panicking handler should fail the delivery") {
+		assert.Contains(*event.LastError, "nil map")
+	}
+}
+
+func TestOutboxEventsCommitWithChanges(t *testing.T) {
+	assert := assert.New(t)
+
+	db := memory.NewDB()
+	carStore := memory.NewCarStore(db)
+	outboxStore := memory.NewOutboxStore(db)
+	transactor := memory.NewTransactor(db)
+	rideService := services.NewRideService(memory.NewRideStore(db), services.NewCarService(carStore, mocks.Logger{}), transactor, 0, 0, mocks.Logger{})
+
+	car := models.Car{Make: "Toyota", Model: "Prius", Year: 2017, Color: "white", UserID: ride1.DriverID}
+	assert.Nil(carStore.Insert(&car))
+
+	ride := ride1
+	ride.CarID = car.ID
+	assert.Nil(rideService.Create(&ride, &auth.UserClaims{ID: ride.DriverID}))
+
+	dispatcher := services.NewOutboxDispatcher(outboxStore, mocks.Logger{})
+	var rides []string
+	dispatcher.Handle(models.EventRideCreated, func(event *models.OutboxEvent) error {
+		rides = append(rides, event.EntityID)
+		return nil
+	})
+
+	delivered, err := dispatcher.Dispatch()
+	assert.Nil(err)
+	assert.Equal(1, delivered)
+	assert.Equal([]string{ride.ID}, rides, "the ride created should be delivered")
+
+	delivered, err = dispatcher.Dispatch()
+	assert.Nil(err)
+	assert.Equal(0, delivered, "delivered events should not be delivered again")
+}
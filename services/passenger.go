@@ -2,16 +2,29 @@ package services
 
 import (
 	"errors"
+	"time"
 
 	"github.com/ucladevx/BPool/interfaces"
 	"github.com/ucladevx/BPool/models"
 	"github.com/ucladevx/BPool/stores"
 	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
-const (
-	getAllPassengerDefaultLimit = 15
-)
+// passengerSortColumns are the columns passengers can be listed by, besides id
+var passengerSortColumns = []string{"created_at"}
+
+// passengerStatusEvents are the outbox events published when a passenger's status changes to each status
+var passengerStatusEvents = map[string]string{
+	models.PassengerAccepted:    models.EventPassengerAccepted,
+	models.PassengerRejected:    models.EventPassengerRejected,
+	models.PassengerWaitlisted:  models.EventPassengerWaitlisted,
+	models.PassengerSeatOffered: models.EventPassengerSeatOffered,
+	models.PassengerWithdrawn:   models.EventPassengerWithdrawn,
+}
+
+// seatHoldingStatuses are the statuses of the passengers that take up a seat of their ride
+var seatHoldingStatuses = []string{models.PassengerAccepted, models.PassengerSeatOffered}
 
 var (
 	// ErrNoMoreSeats occurs when a ride is already full
@@ -19,6 +32,30 @@ var (
 
 	// ErrPassengerIsDriver is returned when a driver tries to be a passenger in own ride
 	ErrPassengerIsDriver = errors.New("You cannot join your own ride")
+
+	// ErrPassengerCancelled occurs when the status of a passenger moves to or from
+	// cancelled_by_driver other than by cancelling the ride
+	ErrPassengerCancelled = errors.New("Passengers are only cancelled by cancelling the ride")
+
+	// ErrPassengerWithdrawn occurs when the driver moves a passenger to or from withdrawn
+	ErrPassengerWithdrawn = errors.New("Only the passenger can withdraw from a ride")
+
+	// ErrSeatOfferedFromWaitlist occurs when the driver moves a passenger to seat_offered,
+	// seats are only offered to the head of the waitlist when one frees up
+	ErrSeatOfferedFromWaitlist = errors.New("Seats are only offered from the waitlist")
+
+	// ErrNoSeatOffered occurs when a passenger confirms a seat they were not offered
+	ErrNoSeatOffered = errors.New("The passenger has not been offered a seat")
+
+	// ErrSeatOfferExpired occurs when a passenger confirms a seat after their offer lapsed
+	ErrSeatOfferExpired = errors.New("The seat offer has expired")
+
+	// ErrCannotWithdraw occurs when a rejected, cancelled or withdrawn passenger withdraws
+	ErrCannotWithdraw = errors.New("The passenger is no longer in the ride")
+
+	// ErrMoreSeatsThanRequested occurs when the driver gives a party more seats than it asked for,
+	// a driver can only accept part of a party
+	ErrMoreSeatsThanRequested = errors.New("A party cannot be given more seats than it requested")
 )
 
 type (
@@ -26,26 +63,32 @@ type (
 	PassengerService struct {
 		store       PassengerStore
 		rideService *RideService
+		transactor  Transactor
 		logger      interfaces.Logger
 	}
 
 	// PassengerStore any store that allows for ride passengers to be persisted
 	PassengerStore interface {
-		GetAll(lastID string, limit int) ([]*models.Passenger, error)
+		GetAll(page pagination.Page) ([]*models.Passenger, error)
 		GetByID(id string) (*models.Passenger, error)
 		Insert(ride *models.Passenger) error
+		Import(passenger *models.Passenger) error
 		Delete(id string) error
 		Update(ride *models.Passenger) error
+		Restore(id string) error
+		Purge(before time.Time) (int, error)
 		Count(clauses []stores.QueryModifier) (int, error)
+		SumSeats(clauses []stores.QueryModifier) (int, error)
 		WhereMany(clauses []stores.QueryModifier) ([]*models.Passenger, error)
 	}
 )
 
 // NewPassengerService creates a new ride service
-func NewPassengerService(store PassengerStore, r *RideService, l interfaces.Logger) *PassengerService {
+func NewPassengerService(store PassengerStore, r *RideService, t Transactor, l interfaces.Logger) *PassengerService {
 	return &PassengerService{
 		store:       store,
 		rideService: r,
+		transactor:  t,
 		logger:      l,
 	}
 }
@@ -75,7 +118,24 @@ func (p *PassengerService) Create(passenger *models.Passenger, user *auth.UserCl
 		return ErrPassengerIsDriver
 	}
 
-	if err := p.store.Insert(passenger); err != nil {
+	if ride.Status != models.RideScheduled {
+		return ErrRideNotScheduled
+	}
+
+	// a party that could never fit in the ride is not waitlisted for it
+	if passenger.SeatsRequested > ride.Seats {
+		return ErrNoMoreSeats
+	}
+
+	err = p.transactor.WithTx(func(tx Tx) error {
+		if err := tx.Passengers().Insert(passenger); err != nil {
+			return err
+		}
+
+		return publish(tx, models.EventPassengerRequested, passenger.ID, passenger)
+	})
+
+	if err != nil {
 		p.logger.Error("PassengerService.Create - unable to create passenger", "error", err.Error())
 		return err
 	}
@@ -83,7 +143,8 @@ func (p *PassengerService) Create(passenger *models.Passenger, user *auth.UserCl
 	return nil
 }
 
-// Update attempts to apply updates to a ride passenger
+// Update attempts to apply updates to a ride passenger, the driver can accept part of
+// a party by lowering its seats requested
 func (p *PassengerService) Update(updates *models.PassengerChangeSet, passengerID string, user *auth.UserClaims) (*models.Passenger, error) {
 	passenger, err := p.store.GetByID(passengerID)
 	if err != nil {
@@ -95,43 +156,367 @@ func (p *PassengerService) Update(updates *models.PassengerChangeSet, passengerI
 		return nil, ErrForbidden
 	}
 
+	before := *passenger
+
 	err = passenger.ApplyUpdates(updates)
 	if err != nil {
 		p.logger.Error("PassengerService.Update - apply updates", "error", err.Error())
 		return nil, err
 	}
 
-	if passenger.Status == models.PassengerAccepted {
-		clauses := []stores.QueryModifier{
-			stores.QueryMod("ride_id", stores.EQ, passenger.RideID),
-			stores.And,
-			stores.QueryMod("status", stores.EQ, models.PassengerAccepted),
+	if passenger.Status != before.Status && (passenger.Status == models.PassengerCancelledByDriver || before.Status == models.PassengerCancelledByDriver) {
+		return nil, ErrPassengerCancelled
+	}
+
+	if passenger.Status != before.Status && (passenger.Status == models.PassengerWithdrawn || before.Status == models.PassengerWithdrawn) {
+		return nil, ErrPassengerWithdrawn
+	}
+
+	if passenger.Status == models.PassengerSeatOffered && before.Status != models.PassengerSeatOffered {
+		return nil, ErrSeatOfferedFromWaitlist
+	}
+
+	if passenger.SeatsRequested > before.SeatsRequested {
+		return nil, ErrMoreSeatsThanRequested
+	}
+
+	if passenger.Status == models.PassengerAccepted && before.Status != models.PassengerAccepted && passenger.RequestID != nil {
+		return nil, ErrOfferNotConfirmed
+	}
+
+	err = p.transactor.WithTx(func(tx Tx) error {
+		var err error
+		if passenger.Status == models.PassengerAccepted {
+			err = p.accept(tx, passenger)
+
+			// accepting a passenger into a full ride puts them on its waitlist
+			if err == ErrNoMoreSeats && !before.HoldsSeat() {
+				passenger.Status = models.PassengerWaitlisted
+				err = tx.Passengers().Update(passenger)
+			}
+		} else {
+			err = tx.Passengers().Update(passenger)
 		}
 
-		// NOTE: probably try to get count and ride in parallel
-		alreadyAccepted, err := p.store.Count(clauses)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		ride, err := p.rideService.Get(passenger.RideID)
-		if err != nil {
-			return nil, err
+		if err := audit(tx, user, models.AuditEntityPassenger, passenger.ID, models.AuditActionUpdate, before, passenger); err != nil {
+			return err
+		}
+
+		if eventType, ok := passengerStatusEvents[passenger.Status]; ok && passenger.Status != before.Status {
+			if err := publish(tx, eventType, passenger.ID, passenger); err != nil {
+				return err
+			}
+		}
+
+		// seats given up by the passenger, or the part of their party the driver left out, go to the waitlist
+		if before.HoldsSeat() && (!passenger.HoldsSeat() || passenger.SeatsRequested < before.SeatsRequested) {
+			return offerSeats(tx, passenger.RideID, p.rideService.offerWindow, user)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if err != ErrNoMoreSeats && err != ErrRideNotScheduled && err != stores.ErrVersionConflict {
+			p.logger.Error("PassengerService.Update - store update", "error", err.Error())
+		}
+		return nil, err
+	}
+
+	return passenger, nil
+}
+
+// accept saves an accepted passenger if the ride still has a free seat, the ride
+// stays locked until the transaction ends so concurrent accepts cannot overbook it
+func (p *PassengerService) accept(tx Tx, passenger *models.Passenger) error {
+	ride, err := tx.Rides().GetByIDForUpdate(passenger.RideID)
+	if err != nil {
+		return err
+	}
+
+	if ride.Status != models.RideScheduled {
+		return ErrRideNotScheduled
+	}
+
+	// counted after taking the lock so that accepts committed while waiting are included
+	seatsTaken, err := countSeatsTaken(tx, passenger.RideID, passenger.ID)
+	if err != nil {
+		return err
+	}
+
+	if seatsTaken+passenger.SeatsRequested > ride.Seats {
+		return ErrNoMoreSeats
+	}
+
+	return tx.Passengers().Update(passenger)
+}
+
+// countSeatsTaken adds up the seats held by the passengers of the ride other than the passenger with exceptID
+func countSeatsTaken(tx Tx, rideID, exceptID string) (int, error) {
+	return tx.Passengers().SumSeats([]stores.QueryModifier{
+		stores.QueryMod("ride_id", stores.EQ, rideID),
+		stores.And,
+		stores.QueryMod("status", stores.IN, seatHoldingStatuses),
+		stores.And,
+		stores.QueryMod("id", stores.NE, exceptID),
+	})
+}
+
+// offerSeats offers the free seats of a scheduled ride to its waitlisted passengers in
+// the order they asked to join, stopping at the first party that does not fit so that
+// no one is passed over. The seats are held for each passenger until they confirm them
+// or window passes, whichever is first of that and the start of the ride
+func offerSeats(tx Tx, rideID string, window time.Duration, user *auth.UserClaims) error {
+	ride, err := tx.Rides().GetByIDForUpdate(rideID)
+	if err != nil {
+		return err
+	}
+
+	if ride.Status != models.RideScheduled {
+		return nil
+	}
+
+	seatsTaken, err := countSeatsTaken(tx, ride.ID, "")
+	if err != nil {
+		return err
+	}
+
+	if seatsTaken >= ride.Seats {
+		return nil
+	}
+
+	waitlist, err := tx.Passengers().WhereMany([]stores.QueryModifier{
+		stores.QueryMod("ride_id", stores.EQ, ride.ID),
+		stores.And,
+		stores.QueryMod("status", stores.EQ, models.PassengerWaitlisted),
+		stores.OrderBy("created_at", stores.Asc),
+		stores.OrderBy("id", stores.Asc),
+	})
+	if err != nil {
+		return err
+	}
+
+	confirmBy := time.Now().Add(window)
+	if ride.StartDate.Before(confirmBy) {
+		confirmBy = ride.StartDate
+	}
+
+	for _, passenger := range waitlist {
+		if seatsTaken+passenger.SeatsRequested > ride.Seats {
+			break
 		}
+		seatsTaken += passenger.SeatsRequested
+
+		before := *passenger
+		passenger.Status = models.PassengerSeatOffered
+		passenger.ConfirmBy = &confirmBy
 
-		if alreadyAccepted >= ride.Seats {
-			return nil, ErrNoMoreSeats
+		if err := tx.Passengers().Update(passenger); err != nil {
+			return err
 		}
+
+		if err := audit(tx, user, models.AuditEntityPassenger, passenger.ID, models.AuditActionUpdate, before, passenger); err != nil {
+			return err
+		}
+
+		if err := publish(tx, models.EventPassengerSeatOffered, passenger.ID, passenger); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ConfirmSeat accepts a passenger into the ride whose seat was offered to them from
+// the waitlist, only the passenger can confirm the seat and only before it lapses
+func (p *PassengerService) ConfirmSeat(id string, user *auth.UserClaims) (*models.Passenger, error) {
+	passenger, err := p.store.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.AuthLevel != AdminLevel && passenger.PassengerID != user.ID {
+		return nil, ErrForbidden
+	}
+
+	if passenger.Status != models.PassengerSeatOffered {
+		return nil, ErrNoSeatOffered
+	}
+
+	if passenger.ConfirmBy != nil && time.Now().After(*passenger.ConfirmBy) {
+		return nil, ErrSeatOfferExpired
+	}
+
+	before := *passenger
+	passenger.Status = models.PassengerAccepted
+	passenger.ConfirmBy = nil
+
+	err = p.transactor.WithTx(func(tx Tx) error {
+		if err := p.accept(tx, passenger); err != nil {
+			return err
+		}
+
+		if err := audit(tx, user, models.AuditEntityPassenger, passenger.ID, models.AuditActionUpdate, before, passenger); err != nil {
+			return err
+		}
+
+		return publish(tx, models.EventPassengerAccepted, passenger.ID, passenger)
+	})
+
+	if err != nil {
+		if err != ErrNoMoreSeats && err != ErrRideNotScheduled && err != stores.ErrVersionConflict {
+			p.logger.Error("PassengerService.ConfirmSeat - unable to accept passenger", "error", err.Error())
+		}
+		return nil, err
+	}
+
+	return passenger, nil
+}
+
+// Withdraw takes the passenger out of the ride, or off its waitlist, at their own
+// request, a seat they held is offered to the head of the waitlist
+func (p *PassengerService) Withdraw(id string, user *auth.UserClaims) (*models.Passenger, error) {
+	passenger, err := p.store.GetByID(id)
+	if err != nil {
+		return nil, err
 	}
 
-	if err = p.store.Update(passenger); err != nil {
-		p.logger.Error("PassengerService.Update - store update", "error", err.Error())
+	if user.AuthLevel != AdminLevel && passenger.PassengerID != user.ID {
+		return nil, ErrForbidden
+	}
+
+	switch passenger.Status {
+	case models.PassengerInterested, models.PassengerWaitlisted, models.PassengerSeatOffered, models.PassengerAccepted:
+	default:
+		return nil, ErrCannotWithdraw
+	}
+
+	before := *passenger
+	passenger.Status = models.PassengerWithdrawn
+	passenger.ConfirmBy = nil
+
+	err = p.transactor.WithTx(func(tx Tx) error {
+		if err := tx.Passengers().Update(passenger); err != nil {
+			return err
+		}
+
+		if err := audit(tx, user, models.AuditEntityPassenger, passenger.ID, models.AuditActionUpdate, before, passenger); err != nil {
+			return err
+		}
+
+		if err := publish(tx, models.EventPassengerWithdrawn, passenger.ID, passenger); err != nil {
+			return err
+		}
+
+		if before.HoldsSeat() {
+			return offerSeats(tx, passenger.RideID, p.rideService.offerWindow, user)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if err != stores.ErrVersionConflict {
+			p.logger.Error("PassengerService.Withdraw - unable to withdraw passenger", "error", err.Error())
+		}
 		return nil, err
 	}
 
 	return passenger, nil
 }
 
+// ExpireSeatOffers withdraws the passengers who did not confirm a seat offered to them
+// in time and offers the seats to the next passengers on the waitlist, it returns how
+// many offers lapsed
+func (p *PassengerService) ExpireSeatOffers() (int, error) {
+	now := time.Now()
+
+	lapsed, err := p.store.WhereMany([]stores.QueryModifier{
+		stores.QueryMod("status", stores.EQ, models.PassengerSeatOffered),
+		stores.And,
+		stores.QueryMod("confirm_by", stores.LT, now),
+	})
+	if err != nil {
+		p.logger.Error("PassengerService.ExpireSeatOffers - unable to find lapsed offers", "error", err.Error())
+		return 0, err
+	}
+
+	count := 0
+
+	for _, offer := range lapsed {
+		expired := false
+
+		err := p.transactor.WithTx(func(tx Tx) error {
+			// the ride is locked first so that the passenger cannot confirm the seat meanwhile
+			if _, err := tx.Rides().GetByIDForUpdate(offer.RideID); err != nil {
+				return err
+			}
+
+			passenger, err := tx.Passengers().GetByID(offer.ID)
+			if err != nil {
+				return err
+			}
+
+			if passenger.Status != models.PassengerSeatOffered || passenger.ConfirmBy == nil || !passenger.ConfirmBy.Before(now) {
+				return nil
+			}
+
+			// the lapse is recorded as the passenger's doing since they let it happen
+			actor := &auth.UserClaims{ID: passenger.PassengerID}
+			before := *passenger
+			passenger.Status = models.PassengerWithdrawn
+			passenger.ConfirmBy = nil
+
+			if err := tx.Passengers().Update(passenger); err != nil {
+				return err
+			}
+
+			if err := audit(tx, actor, models.AuditEntityPassenger, passenger.ID, models.AuditActionUpdate, before, passenger); err != nil {
+				return err
+			}
+
+			if err := publish(tx, models.EventPassengerSeatOfferExpired, passenger.ID, passenger); err != nil {
+				return err
+			}
+
+			expired = true
+			return offerSeats(tx, passenger.RideID, p.rideService.offerWindow, actor)
+		})
+
+		if err != nil {
+			p.logger.Error("PassengerService.ExpireSeatOffers - unable to expire offer", "passenger", offer.ID, "error", err.Error())
+			continue
+		}
+
+		if expired {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Run expires lapsed seat offers every interval until stop is closed
+func (p *PassengerService) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if count, err := p.ExpireSeatOffers(); err == nil && count > 0 {
+				p.logger.Info("PassengerService.Run - expired seat offers", "count", count)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
 // Get returns a ride by ID
 func (p *PassengerService) Get(id string, user *auth.UserClaims) (*models.Passenger, error) {
 	passenger, err := p.store.GetByID(id)
@@ -147,17 +532,28 @@ func (p *PassengerService) Get(id string, user *auth.UserClaims) (*models.Passen
 	return passenger, nil
 }
 
-// GetAll returns a page of rides
-func (p *PassengerService) GetAll(lastID string, limit, userAuthLevel int) ([]*models.Passenger, error) {
+// GetAll returns a page of passengers and the cursor of the next page, if there is one
+func (p *PassengerService) GetAll(page pagination.Page, userAuthLevel int) ([]*models.Passenger, *pagination.Cursor, error) {
 	if userAuthLevel < AdminLevel {
-		return nil, ErrNotAllowed
+		return nil, nil, ErrNotAllowed
 	}
 
-	if limit <= 0 || limit > 100 {
-		limit = getAllPassengerDefaultLimit
+	page = page.WithDefaults(pagination.ByID)
+	if err := page.Validate(passengerSortColumns...); err != nil {
+		return nil, nil, err
 	}
 
-	return p.store.GetAll(lastID, limit)
+	passengers, err := p.store.GetAll(page.Peek())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	next, err := page.Trim(&passengers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return passengers, next, nil
 }
 
 // GetAllByRideID returns all passengers in all statuses for a given ride
@@ -173,7 +569,14 @@ func (p *PassengerService) GetAllByRideID(rideID string, user *auth.UserClaims)
 	return p.store.WhereMany([]stores.QueryModifier{stores.QueryMod("ride_id", stores.EQ, rideID)})
 }
 
-// Delete removes a passenger from the store if the user is allowed to
+// GetByRequest returns all passengers in all statuses offered rides for a ride
+// request, the caller checks that the user may see the request
+func (p *PassengerService) GetByRequest(requestID string) ([]*models.Passenger, error) {
+	return p.store.WhereMany([]stores.QueryModifier{stores.QueryMod("request_id", stores.EQ, requestID)})
+}
+
+// Delete removes a passenger from the store if the user is allowed to, the seats the
+// passenger held are offered to the waitlist
 func (p *PassengerService) Delete(id string, user *auth.UserClaims) error {
 	passenger, err := p.store.GetByID(id)
 	if err != nil {
@@ -185,5 +588,113 @@ func (p *PassengerService) Delete(id string, user *auth.UserClaims) error {
 		return ErrForbidden
 	}
 
-	return p.store.Delete(passenger.ID)
+	err = p.transactor.WithTx(func(tx Tx) error {
+		// the ride is locked and the passenger read again so that the seats they hold
+		// cannot change before they are offered
+		if _, err := tx.Rides().GetByIDForUpdate(passenger.RideID); err != nil {
+			return err
+		}
+
+		passenger, err := tx.Passengers().GetByID(id)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Passengers().Delete(passenger.ID); err != nil {
+			return err
+		}
+
+		if err := audit(tx, user, models.AuditEntityPassenger, passenger.ID, models.AuditActionDelete, passenger, nil); err != nil {
+			return err
+		}
+
+		if err := publish(tx, models.EventPassengerDeleted, passenger.ID, passenger); err != nil {
+			return err
+		}
+
+		if passenger.HoldsSeat() {
+			return offerSeats(tx, passenger.RideID, p.rideService.offerWindow, user)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		p.logger.Error("PassengerService.Delete - unable to delete passenger", "error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Restore undoes the deletion of a passenger, only admins can restore passengers. A
+// passenger who held seats that have since been taken is put on the waitlist
+func (p *PassengerService) Restore(id string, user *auth.UserClaims) (*models.Passenger, error) {
+	if user.AuthLevel < AdminLevel {
+		return nil, ErrNotAllowed
+	}
+
+	var passenger *models.Passenger
+
+	err := p.transactor.WithTx(func(tx Tx) error {
+		if err := tx.Passengers().Restore(id); err != nil {
+			return err
+		}
+
+		var err error
+		passenger, err = tx.Passengers().GetByID(id)
+		if err != nil {
+			return err
+		}
+
+		if err := audit(tx, user, models.AuditEntityPassenger, passenger.ID, models.AuditActionRestore, nil, passenger); err != nil {
+			return err
+		}
+
+		if err := publish(tx, models.EventPassengerRestored, passenger.ID, passenger); err != nil {
+			return err
+		}
+
+		if !passenger.HoldsSeat() {
+			return nil
+		}
+
+		// the ride is locked so that the seats cannot be taken while they are counted
+		ride, err := tx.Rides().GetByIDForUpdate(passenger.RideID)
+		if err != nil {
+			return err
+		}
+
+		seatsTaken, err := countSeatsTaken(tx, ride.ID, passenger.ID)
+		if err != nil {
+			return err
+		}
+
+		if seatsTaken+passenger.SeatsRequested <= ride.Seats {
+			return nil
+		}
+
+		before := *passenger
+		passenger.Status = models.PassengerWaitlisted
+		passenger.ConfirmBy = nil
+
+		if err := tx.Passengers().Update(passenger); err != nil {
+			return err
+		}
+
+		if err := audit(tx, user, models.AuditEntityPassenger, passenger.ID, models.AuditActionUpdate, before, passenger); err != nil {
+			return err
+		}
+
+		return publish(tx, models.EventPassengerWaitlisted, passenger.ID, passenger)
+	})
+
+	if err != nil {
+		if err != stores.ErrNoPassengerFound {
+			p.logger.Error("PassengerService.Restore - unable to restore passenger", "error", err.Error())
+		}
+		return nil, err
+	}
+
+	return passenger, nil
 }
@@ -1,29 +1,37 @@
 package services_test
 
 import (
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/ucladevx/BPool/mocks"
 	"github.com/ucladevx/BPool/models"
 	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/stores/memory"
 	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
 var (
 	validPassenger = models.Passenger{
-		ID:          "lit",
-		DriverID:    "456",
-		PassengerID: "xyz",
-		RideID:      "abc",
-		Status:      models.PassengerInterested,
+		ID:             "lit",
+		DriverID:       "456",
+		PassengerID:    "xyz",
+		RideID:         "abc",
+		Status:         models.PassengerInterested,
+		SeatsRequested: 1,
 	}
 )
 
 type mockedPassengerService struct {
 	passengerStore   *mocks.PassengerStore
 	rideStore        *mocks.RideStore
+	auditStore       *mocks.AuditStore
+	outboxStore      *mocks.OutboxStore
 	carStore         *mocks.CarStore
 	carService       *services.CarService
 	rideService      *services.RideService
@@ -37,11 +45,16 @@ func newMockedPassengerService() *mockedPassengerService {
 	carStore := new(mocks.CarStore)
 	carService := newCarService(carStore)
 	rideService := newRideService(rideStore, carService)
-	passengerService := services.NewPassengerService(passengerStore, rideService, logger)
+	auditStore := new(mocks.AuditStore)
+	outboxStore := new(mocks.OutboxStore)
+	transactor := &mocks.Transactor{RideStore: rideStore, PassengerStore: passengerStore, AuditStore: auditStore, OutboxStore: outboxStore}
+	passengerService := services.NewPassengerService(passengerStore, rideService, transactor, logger)
 
 	return &mockedPassengerService{
 		passengerStore:   passengerStore,
 		rideStore:        rideStore,
+		auditStore:       auditStore,
+		outboxStore:      outboxStore,
 		carStore:         carStore,
 		carService:       carService,
 		rideService:      rideService,
@@ -56,11 +69,15 @@ func TestCreatePassenger(t *testing.T) {
 	user := auth.UserClaims{ID: "xyz"}
 	service.rideStore.On("GetByID", "abc").Return(&ride1, nil)
 	service.passengerStore.On("Insert", mock.AnythingOfType("*models.Passenger")).Return(nil)
+	service.outboxStore.On("Insert", mock.MatchedBy(func(event *models.OutboxEvent) bool {
+		return event.Type == models.EventPassengerRequested && event.EntityID == validPassenger.ID
+	})).Return(nil).Once()
 
 	validPass := validPassenger
 	noErr := service.passengerService.Create(&validPass, &user)
 	assert.Nil(noErr)
 	service.passengerStore.AssertExpectations(t)
+	service.outboxStore.AssertExpectations(t)
 
 	invalidPassenger := auth.UserClaims{ID: "tty"}
 	forbiddenErr := service.passengerService.Create(&validPass, &invalidPassenger)
@@ -72,6 +89,15 @@ func TestCreatePassenger(t *testing.T) {
 	newPass.PassengerID = "123"
 	passengerErr := service.passengerService.Create(&newPass, &driverPassenger)
 	assert.Equal(services.ErrPassengerIsDriver, passengerErr, "driver should not be able to be a passenger")
+
+	departed := ride1
+	departed.ID = "departed"
+	departed.Status = models.RideDeparted
+	service.rideStore.On("GetByID", departed.ID).Return(&departed, nil)
+	latePass := validPassenger
+	latePass.RideID = departed.ID
+	lateErr := service.passengerService.Create(&latePass, &user)
+	assert.Equal(services.ErrRideNotScheduled, lateErr, "rides that departed should not be joined")
 }
 
 func TestUpdatePassenger(t *testing.T) {
@@ -84,14 +110,26 @@ func TestUpdatePassenger(t *testing.T) {
 	pass := validPassenger
 
 	svc.passengerStore.On("GetByID", pass.ID).Return(&pass, nil).Once()
-	svc.passengerStore.On("Count", mock.Anything).Return(2, nil).Once()
-	svc.rideStore.On("GetByID", pass.RideID).Return(&ride1, nil).Once()
+	svc.passengerStore.On("SumSeats", mock.Anything).Return(2, nil).Once()
+	svc.rideStore.On("GetByIDForUpdate", pass.RideID).Return(&ride1, nil).Once()
 	svc.passengerStore.On("Update", mock.AnythingOfType("*models.Passenger")).Return(nil).Once()
+	svc.auditStore.On("Insert", mock.MatchedBy(func(event *models.AuditEvent) bool {
+		return event.ActorID == driver.ID &&
+			event.Entity == models.AuditEntityPassenger &&
+			event.EntityID == pass.ID &&
+			event.Before.String() == `{"status":"interested"}` &&
+			event.After.String() == `{"status":"accepted"}`
+	})).Return(nil).Once()
+	svc.outboxStore.On("Insert", mock.MatchedBy(func(event *models.OutboxEvent) bool {
+		return event.Type == models.EventPassengerAccepted && event.EntityID == pass.ID
+	})).Return(nil).Once()
 
 	newPass, noErr := svc.passengerService.Update(&update, pass.ID, &driver)
 	assert.Nil(noErr, "there should be no error")
 	assert.NotNil(newPass, "there should be a new passenger")
 	assert.Equal(models.PassengerAccepted, newPass.Status, "the passenger should be accepted")
+	svc.auditStore.AssertExpectations(t)
+	svc.outboxStore.AssertExpectations(t)
 
 	svc.passengerStore.On("GetByID", pass.ID).Return(&pass, nil).Once()
 	notDriver := auth.UserClaims{ID: "xyz"}
@@ -110,8 +148,8 @@ func TestUpdatePassenger(t *testing.T) {
 	assert.NotNil(validErr, "there should have been an error")
 
 	svc.passengerStore.On("GetByID", pass.ID).Return(&pass, nil).Once()
-	svc.passengerStore.On("Count", mock.Anything).Return(4, nil).Once()
-	svc.rideStore.On("GetByID", pass.RideID).Return(&ride1, nil).Once()
+	svc.passengerStore.On("SumSeats", mock.Anything).Return(4, nil).Once()
+	svc.rideStore.On("GetByIDForUpdate", pass.RideID).Return(&ride1, nil).Once()
 	noPass, noMoreSeats := svc.passengerService.Update(&update, pass.ID, &driver)
 
 	assert.NotNil(noMoreSeats, "there should have been an error")
@@ -119,6 +157,58 @@ func TestUpdatePassenger(t *testing.T) {
 	assert.Nil(noPass, "there should be no passengers")
 }
 
+func TestUpdatePassengerConcurrentAccepts(t *testing.T) {
+	assert := assert.New(t)
+
+	db := memory.NewDB()
+	rideStore := memory.NewRideStore(db)
+	passengerStore := memory.NewPassengerStore(db)
+	transactor := memory.NewTransactor(db)
+	rideService := services.NewRideService(rideStore, nil, transactor, 0, 0, mocks.Logger{})
+	passengerService := services.NewPassengerService(passengerStore, rideService, transactor, mocks.Logger{})
+
+	ride := ride1
+	ride.Seats = 3
+	assert.Nil(rideStore.Insert(&ride))
+
+	var ids []string
+	for i := 0; i < 8; i++ {
+		p := models.Passenger{DriverID: ride.DriverID, PassengerID: fmt.Sprintf("rider%d", i), RideID: ride.ID, Status: models.PassengerInterested, SeatsRequested: 1}
+		assert.Nil(passengerStore.Insert(&p))
+		ids = append(ids, p.ID)
+	}
+
+	driver := auth.UserClaims{ID: ride.DriverID}
+	accepted := models.PassengerAccepted
+
+	var wg sync.WaitGroup
+	statuses := make(chan string, len(ids))
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			passenger, err := passengerService.Update(&models.PassengerChangeSet{Status: &accepted}, id, &driver)
+			if assert.Nil(err, "accepting into a full ride should waitlist the passenger") {
+				statuses <- passenger.Status
+			}
+		}(id)
+	}
+	wg.Wait()
+	close(statuses)
+
+	waitlisted := 0
+	for status := range statuses {
+		if status == models.PassengerWaitlisted {
+			waitlisted++
+		}
+	}
+
+	found, err := rideStore.GetByID(ride.ID)
+	assert.Nil(err, "there should be no error")
+	assert.Equal(ride.Seats, *found.SeatsTaken, "the ride should be exactly full")
+	assert.Equal(len(ids)-ride.Seats, waitlisted, "every other accept should have been waitlisted")
+}
+
 func TestGetPassenger(t *testing.T) {
 	service := newMockedPassengerService()
 	assert := assert.New(t)
@@ -140,14 +230,14 @@ func TestGetAllPassengers(t *testing.T) {
 	service := newMockedPassengerService()
 	assert := assert.New(t)
 
-	noPasses, err := service.passengerService.GetAll("", 15, services.UserLevel)
+	noPasses, _, err := service.passengerService.GetAll(pagination.Page{Limit: 15}, services.UserLevel)
 	assert.Equal(services.ErrNotAllowed, err, "should not be allowed")
 	assert.Nil(noPasses, "there should be no passengers on error")
 
 	lowLimit := -1
-	service.passengerStore.On("GetAll", "", 15).Return([]*models.Passenger{&validPassenger}, nil)
+	service.passengerStore.On("GetAll", pagination.Page{Sort: pagination.ByID, Limit: 16}).Return([]*models.Passenger{&validPassenger}, nil)
 
-	passengers, noErr := service.passengerService.GetAll("", lowLimit, services.AdminLevel)
+	passengers, _, noErr := service.passengerService.GetAll(pagination.Page{Limit: lowLimit}, services.AdminLevel)
 	assert.Nil(noErr, "there should be no error")
 	assert.NotNil(passengers, "there should be passengers")
 	assert.Equal(1, len(passengers), "there should be a slice of 1 passenger")
@@ -160,10 +250,19 @@ func TestDeletePassenger(t *testing.T) {
 	p := validPassenger
 	m.passengerStore.On("GetByID", p.ID).Return(&p, nil)
 	m.passengerStore.On("Delete", p.ID).Return(nil)
+	m.rideStore.On("GetByIDForUpdate", p.RideID).Return(&ride1, nil)
+	m.auditStore.On("Insert", mock.MatchedBy(func(event *models.AuditEvent) bool {
+		return event.Action == models.AuditActionDelete && event.EntityID == p.ID
+	})).Return(nil).Once()
+	m.outboxStore.On("Insert", mock.MatchedBy(func(event *models.OutboxEvent) bool {
+		return event.Type == models.EventPassengerDeleted && event.EntityID == p.ID
+	})).Return(nil).Once()
 	user := auth.UserClaims{ID: p.DriverID}
 
 	noErr := m.passengerService.Delete(p.ID, &user)
 	assert.Nil(noErr, "there should be no error")
+	m.auditStore.AssertExpectations(t)
+	m.outboxStore.AssertExpectations(t)
 
 	notDriver := auth.UserClaims{ID: "adsfasdfa"}
 	m.passengerStore.On("GetByID", p.ID).Return(&p, nil)
@@ -171,3 +270,282 @@ func TestDeletePassenger(t *testing.T) {
 	assert.NotNil(err, "there should be an error")
 	assert.Equal(services.ErrForbidden, err, "the user should have been forbidden")
 }
+
+func TestRestorePassenger(t *testing.T) {
+	m := newMockedPassengerService()
+	assert := assert.New(t)
+
+	p := validPassenger
+	driver := auth.UserClaims{ID: p.DriverID}
+	_, err := m.passengerService.Restore(p.ID, &driver)
+	assert.Equal(services.ErrNotAllowed, err, "only admins should be able to restore passengers")
+
+	admin := auth.UserClaims{ID: "admin", AuthLevel: services.AdminLevel}
+	m.passengerStore.On("Restore", p.ID).Return(nil)
+	m.passengerStore.On("GetByID", p.ID).Return(&p, nil)
+	m.auditStore.On("Insert", mock.MatchedBy(func(event *models.AuditEvent) bool {
+		return event.Action == models.AuditActionRestore && event.EntityID == p.ID
+	})).Return(nil).Once()
+	m.outboxStore.On("Insert", mock.MatchedBy(func(event *models.OutboxEvent) bool {
+		return event.Type == models.EventPassengerRestored && event.EntityID == p.ID
+	})).Return(nil).Once()
+
+	passenger, err := m.passengerService.Restore(p.ID, &admin)
+	assert.Nil(err, "there should be no error")
+	assert.Equal(&p, passenger, "the restored passenger should be returned")
+	m.auditStore.AssertExpectations(t)
+	m.outboxStore.AssertExpectations(t)
+}
+
+func TestRestorePassengerWhoseSeatWasTaken(t *testing.T) {
+	assert := assert.New(t)
+
+	db := memory.NewDB()
+	rideStore := memory.NewRideStore(db)
+	passengerStore := memory.NewPassengerStore(db)
+	transactor := memory.NewTransactor(db)
+	rideService := services.NewRideService(rideStore, nil, transactor, 0, 0, mocks.Logger{})
+	passengerService := services.NewPassengerService(passengerStore, rideService, transactor, mocks.Logger{})
+
+	ride := ride1
+	ride.Seats = 1
+	ride.StartDate = time.Now().Add(24 * time.Hour)
+	assert.Nil(rideStore.Insert(&ride))
+
+	var ids []string
+	for i, status := range []string{models.PassengerAccepted, models.PassengerWaitlisted} {
+		p := models.Passenger{DriverID: ride.DriverID, PassengerID: fmt.Sprintf("rider%d", i), RideID: ride.ID, Status: status, SeatsRequested: 1}
+		assert.Nil(passengerStore.Insert(&p))
+		ids = append(ids, p.ID)
+	}
+
+	assert.Nil(passengerService.Delete(ids[0], &auth.UserClaims{ID: ride.DriverID}))
+	_, err := passengerService.ConfirmSeat(ids[1], &auth.UserClaims{ID: "rider1"})
+	assert.Nil(err)
+
+	restored, err := passengerService.Restore(ids[0], &auth.UserClaims{ID: "admin", AuthLevel: services.AdminLevel})
+	assert.Nil(err)
+	assert.Equal(models.PassengerWaitlisted, restored.Status, "a restored passenger whose seat was taken should be waitlisted")
+
+	found, err := rideStore.GetByID(ride.ID)
+	assert.Nil(err)
+	assert.Equal(1, *found.SeatsTaken, "restoring a passenger should not overbook the ride")
+}
+
+func TestPassengerWaitlist(t *testing.T) {
+	assert := assert.New(t)
+
+	db := memory.NewDB()
+	rideStore := memory.NewRideStore(db)
+	passengerStore := memory.NewPassengerStore(db)
+	transactor := memory.NewTransactor(db)
+	rideService := services.NewRideService(rideStore, nil, transactor, 0, 2, mocks.Logger{})
+	passengerService := services.NewPassengerService(passengerStore, rideService, transactor, mocks.Logger{})
+
+	ride := ride1
+	ride.Seats = 1
+	ride.StartDate = time.Now().Add(24 * time.Hour)
+	assert.Nil(rideStore.Insert(&ride))
+
+	driver := auth.UserClaims{ID: ride.DriverID}
+	accepted, rejected := models.PassengerAccepted, models.PassengerRejected
+
+	riders := make([]*models.Passenger, 3)
+	for i := range riders {
+		p := models.Passenger{DriverID: ride.DriverID, PassengerID: fmt.Sprintf("rider%d", i), RideID: ride.ID, Status: models.PassengerInterested, SeatsRequested: 1}
+		assert.Nil(passengerStore.Insert(&p))
+
+		updated, err := passengerService.Update(&models.PassengerChangeSet{Status: &accepted}, p.ID, &driver)
+		assert.Nil(err)
+		riders[i] = updated
+	}
+
+	assert.Equal(models.PassengerAccepted, riders[0].Status)
+	assert.Equal(models.PassengerWaitlisted, riders[1].Status, "a full ride should waitlist the passenger")
+	assert.Equal(models.PassengerWaitlisted, riders[2].Status)
+
+	_, err := passengerService.Update(&models.PassengerChangeSet{Status: &rejected}, riders[0].ID, &driver)
+	assert.Nil(err)
+
+	offered, err := passengerStore.GetByID(riders[1].ID)
+	assert.Nil(err)
+	assert.Equal(models.PassengerSeatOffered, offered.Status, "the head of the waitlist should be offered the seat")
+	if assert.NotNil(offered.ConfirmBy) {
+		assert.WithinDuration(time.Now().Add(2*time.Hour), *offered.ConfirmBy, time.Minute)
+	}
+
+	_, err = passengerService.ConfirmSeat(offered.ID, &auth.UserClaims{ID: "rider2"})
+	assert.Equal(services.ErrForbidden, err, "only the passenger should confirm their seat")
+
+	_, err = passengerService.ConfirmSeat(riders[2].ID, &auth.UserClaims{ID: "rider2"})
+	assert.Equal(services.ErrNoSeatOffered, err)
+
+	confirmed, err := passengerService.ConfirmSeat(offered.ID, &auth.UserClaims{ID: "rider1"})
+	assert.Nil(err)
+	assert.Equal(models.PassengerAccepted, confirmed.Status)
+	assert.Nil(confirmed.ConfirmBy)
+
+	_, err = passengerService.Withdraw(confirmed.ID, &auth.UserClaims{ID: "rider1"})
+	assert.Nil(err)
+
+	offered, err = passengerStore.GetByID(riders[2].ID)
+	assert.Nil(err)
+	assert.Equal(models.PassengerSeatOffered, offered.Status, "a withdrawn seat should go to the next on the waitlist")
+
+	lapsed := time.Now().Add(-time.Minute)
+	offered.ConfirmBy = &lapsed
+	assert.Nil(passengerStore.Update(offered))
+
+	_, err = passengerService.ConfirmSeat(offered.ID, &auth.UserClaims{ID: "rider2"})
+	assert.Equal(services.ErrSeatOfferExpired, err)
+
+	count, err := passengerService.ExpireSeatOffers()
+	assert.Nil(err)
+	assert.Equal(1, count)
+
+	expired, err := passengerStore.GetByID(offered.ID)
+	assert.Nil(err)
+	assert.Equal(models.PassengerWithdrawn, expired.Status, "a lapsed offer should withdraw the passenger")
+
+	found, err := rideStore.GetByID(ride.ID)
+	assert.Nil(err)
+	assert.Equal(0, *found.SeatsTaken, "no seat should be held once the waitlist is empty")
+}
+
+func TestDeletedPassengerSeatOfferedToWaitlist(t *testing.T) {
+	assert := assert.New(t)
+
+	db := memory.NewDB()
+	rideStore := memory.NewRideStore(db)
+	passengerStore := memory.NewPassengerStore(db)
+	transactor := memory.NewTransactor(db)
+	rideService := services.NewRideService(rideStore, nil, transactor, 0, 0, mocks.Logger{})
+	passengerService := services.NewPassengerService(passengerStore, rideService, transactor, mocks.Logger{})
+
+	ride := ride1
+	ride.Seats = 1
+	ride.StartDate = time.Now().Add(24 * time.Hour)
+	assert.Nil(rideStore.Insert(&ride))
+
+	var ids []string
+	for i, status := range []string{models.PassengerAccepted, models.PassengerWaitlisted} {
+		p := models.Passenger{DriverID: ride.DriverID, PassengerID: fmt.Sprintf("rider%d", i), RideID: ride.ID, Status: status, SeatsRequested: 1}
+		assert.Nil(passengerStore.Insert(&p))
+		ids = append(ids, p.ID)
+	}
+
+	assert.Nil(passengerService.Delete(ids[0], &auth.UserClaims{ID: ride.DriverID}))
+
+	offered, err := passengerStore.GetByID(ids[1])
+	assert.Nil(err)
+	assert.Equal(models.PassengerSeatOffered, offered.Status, "the seat of a deleted passenger should go to the waitlist")
+}
+
+func TestRideSeatsOfferedToWaitlist(t *testing.T) {
+	assert := assert.New(t)
+
+	db := memory.NewDB()
+	rideStore := memory.NewRideStore(db)
+	passengerStore := memory.NewPassengerStore(db)
+	transactor := memory.NewTransactor(db)
+	rideService := services.NewRideService(rideStore, nil, transactor, 0, 0, mocks.Logger{})
+
+	ride := ride1
+	ride.Seats = 1
+	assert.Nil(rideStore.Insert(&ride))
+
+	var waitlist []string
+	for i, status := range []string{models.PassengerAccepted, models.PassengerWaitlisted, models.PassengerWaitlisted, models.PassengerWaitlisted} {
+		p := models.Passenger{DriverID: ride.DriverID, PassengerID: fmt.Sprintf("rider%d", i), RideID: ride.ID, Status: status, SeatsRequested: 1}
+		assert.Nil(passengerStore.Insert(&p))
+		waitlist = append(waitlist, p.ID)
+	}
+
+	seats := 3
+	_, err := rideService.Update(&models.RideChangeSet{Seats: &seats}, ride.ID, &auth.UserClaims{ID: ride.DriverID})
+	assert.Nil(err)
+
+	for i, status := range []string{models.PassengerAccepted, models.PassengerSeatOffered, models.PassengerSeatOffered, models.PassengerWaitlisted} {
+		found, err := passengerStore.GetByID(waitlist[i])
+		assert.Nil(err)
+		assert.Equal(status, found.Status, "added seats should go to the waitlist in order")
+	}
+}
+
+func TestPassengerParties(t *testing.T) {
+	assert := assert.New(t)
+
+	db := memory.NewDB()
+	rideStore := memory.NewRideStore(db)
+	passengerStore := memory.NewPassengerStore(db)
+	transactor := memory.NewTransactor(db)
+	rideService := services.NewRideService(rideStore, nil, transactor, 0, 0, mocks.Logger{})
+	passengerService := services.NewPassengerService(passengerStore, rideService, transactor, mocks.Logger{})
+
+	ride := ride1
+	ride.Seats = 3
+	ride.StartDate = time.Now().Add(24 * time.Hour)
+	assert.Nil(rideStore.Insert(&ride))
+
+	driver := auth.UserClaims{ID: ride.DriverID}
+	accepted := models.PassengerAccepted
+
+	tooLarge := models.Passenger{PassengerID: "rider9", RideID: ride.ID, SeatsRequested: 4}
+	assert.Equal(services.ErrNoMoreSeats, passengerService.Create(&tooLarge, &auth.UserClaims{ID: tooLarge.PassengerID}), "a party larger than the ride should be rejected")
+
+	var parties []*models.Passenger
+	for i, size := range []int{1, 3, 2, 1} {
+		p := models.Passenger{DriverID: ride.DriverID, PassengerID: fmt.Sprintf("rider%d", i), RideID: ride.ID, Status: models.PassengerInterested, SeatsRequested: size}
+		assert.Nil(passengerStore.Insert(&p))
+		parties = append(parties, &p)
+	}
+
+	single, err := passengerService.Update(&models.PassengerChangeSet{Status: &accepted}, parties[0].ID, &driver)
+	assert.Nil(err)
+	assert.Equal(models.PassengerAccepted, single.Status)
+
+	trio, err := passengerService.Update(&models.PassengerChangeSet{Status: &accepted}, parties[1].ID, &driver)
+	assert.Nil(err)
+	assert.Equal(models.PassengerWaitlisted, trio.Status, "a party larger than the free seats should be waitlisted")
+
+	more := 4
+	_, err = passengerService.Update(&models.PassengerChangeSet{Status: &accepted, SeatsRequested: &more}, parties[2].ID, &driver)
+	assert.Equal(services.ErrMoreSeatsThanRequested, err)
+
+	two := 2
+	partial, err := passengerService.Update(&models.PassengerChangeSet{Status: &accepted, SeatsRequested: &two}, parties[1].ID, &driver)
+	assert.Nil(err)
+	assert.Equal(models.PassengerAccepted, partial.Status, "the driver should be able to accept part of a party")
+	assert.Equal(2, partial.SeatsRequested)
+
+	found, err := rideStore.GetByID(ride.ID)
+	assert.Nil(err)
+	assert.Equal(3, *found.SeatsTaken)
+
+	for _, party := range parties[2:] {
+		waitlisted, err := passengerService.Update(&models.PassengerChangeSet{Status: &accepted}, party.ID, &driver)
+		assert.Nil(err)
+		assert.Equal(models.PassengerWaitlisted, waitlisted.Status)
+	}
+
+	_, err = passengerService.Withdraw(single.ID, &auth.UserClaims{ID: single.PassengerID})
+	assert.Nil(err)
+
+	for _, party := range parties[2:] {
+		found, err := passengerStore.GetByID(party.ID)
+		assert.Nil(err)
+		assert.Equal(models.PassengerWaitlisted, found.Status, "a free seat should not skip the pair at the head of the waitlist")
+	}
+
+	one := 1
+	_, err = passengerService.Update(&models.PassengerChangeSet{SeatsRequested: &one}, partial.ID, &driver)
+	assert.Nil(err)
+
+	pair, err := passengerStore.GetByID(parties[2].ID)
+	assert.Nil(err)
+	assert.Equal(models.PassengerSeatOffered, pair.Status, "the seats left by a smaller party should go to the waitlist")
+
+	last, err := passengerStore.GetByID(parties[3].ID)
+	assert.Nil(err)
+	assert.Equal(models.PassengerWaitlisted, last.Status)
+}
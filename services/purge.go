@@ -0,0 +1,69 @@
+package services
+
+import (
+	"time"
+
+	"github.com/ucladevx/BPool/interfaces"
+)
+
+type (
+	// PurgeService permanently deletes records that have been soft deleted for
+	// longer than the retention period
+	PurgeService struct {
+		purgers   []Purger
+		retention time.Duration
+		logger    interfaces.Logger
+		now       func() time.Time
+	}
+
+	// Purger any store that can permanently delete the records it soft deleted
+	Purger interface {
+		Purge(before time.Time) (int, error)
+	}
+)
+
+// NewPurgeService creates a new purge service, purgers are purged in the order given
+func NewPurgeService(retention time.Duration, l interfaces.Logger, purgers ...Purger) *PurgeService {
+	return &PurgeService{
+		purgers:   purgers,
+		retention: retention,
+		logger:    l,
+		now:       time.Now,
+	}
+}
+
+// Purge permanently deletes everything deleted before the retention period and
+// returns how many records were deleted
+func (p *PurgeService) Purge() (int, error) {
+	before := p.now().Add(-p.retention)
+	total := 0
+
+	for _, purger := range p.purgers {
+		count, err := purger.Purge(before)
+		if err != nil {
+			p.logger.Error("PurgeService.Purge - unable to purge", "error", err.Error())
+			return total, err
+		}
+
+		total += count
+	}
+
+	return total, nil
+}
+
+// Run purges every interval until stop is closed
+func (p *PurgeService) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if count, err := p.Purge(); err == nil && count > 0 {
+				p.logger.Info("PurgeService.Run - purged deleted records", "count", count)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
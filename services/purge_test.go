@@ -0,0 +1,55 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/ucladevx/BPool/mocks"
+	"github.com/ucladevx/BPool/services"
+)
+
+func TestPurge(t *testing.T) {
+	assert := assert.New(t)
+	passengerStore := new(mocks.PassengerStore)
+	rideStore := new(mocks.RideStore)
+	carStore := new(mocks.CarStore)
+
+	retention := 30 * 24 * time.Hour
+	service := services.NewPurgeService(retention, mocks.Logger{}, passengerStore, rideStore, carStore)
+
+	cutOff := mock.MatchedBy(func(before time.Time) bool {
+		expected := time.Now().Add(-retention)
+		return before.After(expected.Add(-time.Minute)) && before.Before(expected.Add(time.Minute))
+	})
+
+	passengerStore.On("Purge", cutOff).Return(3, nil)
+	rideStore.On("Purge", cutOff).Return(2, nil)
+	carStore.On("Purge", cutOff).Return(1, nil)
+
+	count, err := service.Purge()
+	assert.Nil(err, "there should be no error")
+	assert.Equal(6, count, "the count should be the total purged from every store")
+
+	passengerStore.AssertExpectations(t)
+	rideStore.AssertExpectations(t)
+	carStore.AssertExpectations(t)
+}
+
+func TestPurgeStopsOnError(t *testing.T) {
+	assert := assert.New(t)
+	rideStore := new(mocks.RideStore)
+	carStore := new(mocks.CarStore)
+
+	service := services.NewPurgeService(time.Hour, mocks.Logger{}, rideStore, carStore)
+
+	purgeErr := errors.New("connection lost")
+	rideStore.On("Purge", mock.Anything).Return(0, purgeErr)
+
+	_, err := service.Purge()
+	assert.Equal(purgeErr, err, "the store error should be returned")
+	carStore.AssertNotCalled(t, "Purge", mock.Anything)
+}
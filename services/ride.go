@@ -1,10 +1,44 @@
 package services
 
 import (
+	"errors"
+	"time"
+
 	"github.com/ucladevx/BPool/interfaces"
 	"github.com/ucladevx/BPool/models"
 	"github.com/ucladevx/BPool/stores"
 	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+// DefaultLateCancellationHours is how close to the start of a ride cancelling it counts against the driver
+const DefaultLateCancellationHours = 24
+
+// DefaultSeatOfferHours is how long a waitlisted passenger offered a seat has to confirm it
+const DefaultSeatOfferHours = 12
+
+// rideSortColumns are the columns rides can be listed by, besides id
+var rideSortColumns = []string{"created_at", "start_date", "price_per_seat"}
+
+// rideStatusEvents are the outbox events published when a ride's status changes to each status
+var rideStatusEvents = map[string]string{
+	models.RideDeparted:  models.EventRideDeparted,
+	models.RideCompleted: models.EventRideCompleted,
+	models.RideCancelled: models.EventRideCancelled,
+}
+
+var (
+	// ErrInvalidRideTransition occurs when a ride cannot change from its status to the one asked for
+	ErrInvalidRideTransition = errors.New("The ride cannot change to that status")
+
+	// ErrRideCancelled occurs when a cancelled ride is changed
+	ErrRideCancelled = errors.New("The ride has been cancelled")
+
+	// ErrRideNotScheduled occurs when joining a ride that has departed, arrived or been cancelled
+	ErrRideNotScheduled = errors.New("The ride is no longer scheduled")
+
+	// ErrFewerSeatsThanTaken occurs when a ride is given fewer seats than its passengers hold
+	ErrFewerSeatsThanTaken = errors.New("The ride cannot have fewer seats than its passengers hold")
 )
 
 type (
@@ -12,32 +46,58 @@ type (
 	RideService struct {
 		store      RideStore
 		carService *CarService
-		logger     interfaces.Logger
+		transactor Transactor
+		lateWindow time.Duration
+		// offerWindow is how long seats offered from the waitlist are held
+		offerWindow time.Duration
+		logger      interfaces.Logger
 	}
 
 	// RideStore any store that allows for rides to be persisted
 	RideStore interface {
-		GetAll(lastID string, limit int) ([]*models.Ride, error)
+		GetAll(page pagination.Page) ([]*models.Ride, error)
 		GetAllWherePassenger(passengerID string) ([]*models.Ride, error)
 		WhereMany(clauses []stores.QueryModifier) ([]*models.Ride, error)
+		Count(clauses []stores.QueryModifier) (int, error)
+		Search(search *models.RideSearch) ([]*models.Ride, error)
 		GetByID(id string) (*models.Ride, error)
+		GetByIDForUpdate(id string) (*models.Ride, error)
 		Insert(ride *models.Ride) error
+		Import(ride *models.Ride) error
 		Delete(id string) error
 		Update(ride *models.Ride) error
+		Restore(id string) error
+		Purge(before time.Time) (int, error)
 	}
 )
 
-// NewRideService creates a new ride service
-func NewRideService(store RideStore, c *CarService, l interfaces.Logger) *RideService {
+// NewRideService creates a new ride service where cancelling a ride within
+// lateCancellationHours of its start is late and waitlisted passengers have
+// seatOfferHours to confirm a seat that freed up, DefaultLateCancellationHours and
+// DefaultSeatOfferHours if they are not positive
+func NewRideService(store RideStore, c *CarService, t Transactor, lateCancellationHours, seatOfferHours int, l interfaces.Logger) *RideService {
+	if lateCancellationHours <= 0 {
+		lateCancellationHours = DefaultLateCancellationHours
+	}
+
+	if seatOfferHours <= 0 {
+		seatOfferHours = DefaultSeatOfferHours
+	}
+
 	return &RideService{
-		store:      store,
-		carService: c,
-		logger:     l,
+		store:       store,
+		carService:  c,
+		transactor:  t,
+		lateWindow:  time.Duration(lateCancellationHours) * time.Hour,
+		offerWindow: time.Duration(seatOfferHours) * time.Hour,
+		logger:      l,
 	}
 }
 
 // Create persists a ride
 func (r *RideService) Create(ride *models.Ride, user *auth.UserClaims) error {
+	ride.Status = models.RideScheduled
+
 	if err := ride.Validate(); err != nil {
 		return err
 	}
@@ -51,7 +111,15 @@ func (r *RideService) Create(ride *models.Ride, user *auth.UserClaims) error {
 		return ErrNotCarOwner
 	}
 
-	if err := r.store.Insert(ride); err != nil {
+	err = r.transactor.WithTx(func(tx Tx) error {
+		if err := tx.Rides().Insert(ride); err != nil {
+			return err
+		}
+
+		return publish(tx, models.EventRideCreated, ride.ID, ride)
+	})
+
+	if err != nil {
 		r.logger.Error("RideService.Create - unable to create ride", "error", err.Error())
 		return err
 	}
@@ -70,7 +138,11 @@ func (r *RideService) Update(updates *models.RideChangeSet, rideID string, user
 		return nil, ErrForbidden
 	}
 
-	originalCarID := ride.CarID
+	if ride.Status == models.RideCancelled {
+		return nil, ErrRideCancelled
+	}
+
+	before := *ride
 
 	err = ride.ApplyUpdates(updates)
 	if err != nil {
@@ -79,7 +151,7 @@ func (r *RideService) Update(updates *models.RideChangeSet, rideID string, user
 	}
 
 	// Only check if the car is owned by the user if that field gets updated
-	if originalCarID != ride.CarID {
+	if before.CarID != ride.CarID {
 		isOwner, err := r.carService.IsOwnerOrAdmin(ride.CarID, user)
 		if err != nil {
 			return nil, err
@@ -90,30 +162,252 @@ func (r *RideService) Update(updates *models.RideChangeSet, rideID string, user
 		}
 	}
 
-	if err = r.store.Update(ride); err != nil {
-		r.logger.Error("RideService.Update - store update", "error", err.Error())
+	err = r.transactor.WithTx(func(tx Tx) error {
+		if err := tx.Rides().Update(ride); err != nil {
+			return err
+		}
+
+		if err := audit(tx, user, models.AuditEntityRide, ride.ID, models.AuditActionUpdate, before, ride); err != nil {
+			return err
+		}
+
+		if err := publish(tx, models.EventRideUpdated, ride.ID, ride); err != nil {
+			return err
+		}
+
+		// added seats go to the waitlist first
+		if ride.Seats > before.Seats {
+			return offerSeats(tx, ride.ID, r.offerWindow, user)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if err != stores.ErrVersionConflict {
+			r.logger.Error("RideService.Update - store update", "error", err.Error())
+		}
 		return nil, err
 	}
 
 	return ride, nil
 }
 
+// Start marks a scheduled ride as departed
+func (r *RideService) Start(id string, user *auth.UserClaims) (*models.Ride, error) {
+	return r.transition(id, models.RideDeparted, user, nil)
+}
+
+// Complete marks a departed ride as completed
+func (r *RideService) Complete(id string, user *auth.UserClaims) (*models.Ride, error) {
+	return r.transition(id, models.RideCompleted, user, nil)
+}
+
+// Cancel marks a scheduled ride as cancelled for the reason given and tells its
+// accepted and interested passengers, unlike a deleted ride a cancelled ride can
+// still be seen by its driver and passengers
+func (r *RideService) Cancel(id, reason string, user *auth.UserClaims) (*models.Ride, error) {
+	if err := models.ValidateCancelReason(reason); err != nil {
+		return nil, err
+	}
+
+	return r.transition(id, models.RideCancelled, user, func(tx Tx, ride *models.Ride) error {
+		ride.Cancel(user.ID, reason, time.Now(), r.lateWindow)
+		return cancelPassengers(tx, ride, user)
+	})
+}
+
+// Reliability counts how many of the driver's rides were completed and cancelled
+func (r *RideService) Reliability(driverID string) (*models.DriverReliability, error) {
+	counts := []struct {
+		column string
+		value  interface{}
+		count  int
+	}{
+		{"status", models.RideCompleted, 0},
+		{"status", models.RideCancelled, 0},
+		{"late_cancellation", true, 0},
+	}
+
+	for i, c := range counts {
+		count, err := r.store.Count([]stores.QueryModifier{
+			stores.QueryMod("driver_id", stores.EQ, driverID),
+			stores.And,
+			stores.QueryMod(c.column, stores.EQ, c.value),
+		})
+		if err != nil {
+			r.logger.Error("RideService.Reliability - unable to count rides", "error", err.Error())
+			return nil, err
+		}
+
+		counts[i].count = count
+	}
+
+	return models.NewDriverReliability(driverID, counts[0].count, counts[1].count, counts[2].count), nil
+}
+
+// transition changes the status of a ride if the user drives it, the ride is locked
+// while its status is checked so that concurrent changes cannot skip a transition,
+// change is run in the same transaction before the ride is saved if it is given
+func (r *RideService) transition(id, status string, user *auth.UserClaims, change func(tx Tx, ride *models.Ride) error) (*models.Ride, error) {
+	var ride *models.Ride
+
+	err := r.transactor.WithTx(func(tx Tx) error {
+		var err error
+		ride, err = tx.Rides().GetByIDForUpdate(id)
+		if err != nil {
+			return err
+		}
+
+		if user.AuthLevel != AdminLevel && ride.DriverID != user.ID {
+			return ErrForbidden
+		}
+
+		if !ride.CanTransition(status) {
+			return ErrInvalidRideTransition
+		}
+
+		before := *ride
+		ride.Status = status
+
+		if change != nil {
+			if err := change(tx, ride); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Rides().Update(ride); err != nil {
+			return err
+		}
+
+		if err := audit(tx, user, models.AuditEntityRide, ride.ID, models.AuditActionUpdate, before, ride); err != nil {
+			return err
+		}
+
+		return publish(tx, rideStatusEvents[status], ride.ID, ride)
+	})
+
+	if err != nil {
+		if err != ErrForbidden && err != ErrInvalidRideTransition && err != stores.ErrNoRideFound {
+			r.logger.Error("RideService.transition - store update", "status", status, "error", err.Error())
+		}
+		return nil, err
+	}
+
+	return ride, nil
+}
+
+// cancelPassengers moves the accepted, interested, waitlisted and seat offered
+// passengers of a cancelled ride to PassengerCancelledByDriver
+func cancelPassengers(tx Tx, ride *models.Ride, user *auth.UserClaims) error {
+	passengers, err := tx.Passengers().WhereMany([]stores.QueryModifier{
+		stores.QueryMod("ride_id", stores.EQ, ride.ID),
+		stores.And,
+		stores.QueryMod("status", stores.IN, []string{
+			models.PassengerAccepted, models.PassengerInterested, models.PassengerWaitlisted, models.PassengerSeatOffered,
+		}),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, passenger := range passengers {
+		before := *passenger
+		passenger.Status = models.PassengerCancelledByDriver
+		passenger.ConfirmBy = nil
+
+		if err := tx.Passengers().Update(passenger); err != nil {
+			return err
+		}
+
+		if err := audit(tx, user, models.AuditEntityPassenger, passenger.ID, models.AuditActionUpdate, before, passenger); err != nil {
+			return err
+		}
+
+		if err := publish(tx, models.EventPassengerCancelled, passenger.ID, passenger); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Search finds a page of the rides matching the search, along with the cursor of
+// the next page if there is one, upcoming scheduled rides are found by default sorted by
+// relevance when there is a query, distance when searching near points, else date
+func (r *RideService) Search(search *models.RideSearch) ([]*models.Ride, *models.RideSearchCursor, error) {
+	if search.Limit <= 0 || search.Limit > 100 {
+		search.Limit = 15
+	}
+
+	if search.Sort == "" {
+		switch {
+		case search.Query != "":
+			search.Sort = models.RideSortRelevance
+		case search.Near != nil:
+			search.Sort = models.RideSortDistance
+		default:
+			search.Sort = models.RideSortDate
+		}
+	}
+
+	if search.StartAfter.IsZero() {
+		search.StartAfter = time.Now()
+	}
+
+	if len(search.Statuses) == 0 {
+		search.Statuses = []string{models.RideScheduled}
+	}
+
+	if err := search.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	// one more ride than the limit is found to tell if there is a next page
+	query := *search
+	query.Limit++
+
+	rides, err := r.store.Search(&query)
+	if err != nil {
+		r.logger.Error("RideService.Search - unable to search rides", "error", err.Error())
+		return nil, nil, err
+	}
+
+	if len(rides) <= search.Limit {
+		return rides, nil, nil
+	}
+
+	rides = rides[:search.Limit]
+	return rides, models.NewRideSearchCursor(search.Sort, rides[len(rides)-1]), nil
+}
+
 // Get returns a ride by ID
 func (r *RideService) Get(id string) (*models.Ride, error) {
 	return r.store.GetByID(id)
 }
 
-// GetAll returns a page of rides
-func (r *RideService) GetAll(lastID string, limit, userAuthLevel int) ([]*models.Ride, error) {
+// GetAll returns a page of rides and the cursor of the next page, if there is one
+func (r *RideService) GetAll(page pagination.Page, userAuthLevel int) ([]*models.Ride, *pagination.Cursor, error) {
 	if userAuthLevel < AdminLevel {
-		return nil, ErrNotAllowed
+		return nil, nil, ErrNotAllowed
+	}
+
+	page = page.WithDefaults(pagination.ByID)
+	if err := page.Validate(rideSortColumns...); err != nil {
+		return nil, nil, err
 	}
 
-	if limit <= 0 || limit > 100 {
-		limit = 15
+	rides, err := r.store.GetAll(page.Peek())
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return r.store.GetAll(lastID, limit)
+	next, err := page.Trim(&rides)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rides, next, nil
 }
 
 // Delete removes a ride from the store if the user is allowed to
@@ -128,5 +422,47 @@ func (r *RideService) Delete(id string, user *auth.UserClaims) error {
 		return ErrForbidden
 	}
 
-	return r.store.Delete(ride.ID)
+	return r.transactor.WithTx(func(tx Tx) error {
+		if err := tx.Rides().Delete(ride.ID); err != nil {
+			return err
+		}
+
+		return publish(tx, models.EventRideDeleted, ride.ID, ride)
+	})
+}
+
+// Restore undoes the deletion of a ride and its passengers, only admins can restore rides
+func (r *RideService) Restore(id string, user *auth.UserClaims) (*models.Ride, error) {
+	if user.AuthLevel < AdminLevel {
+		return nil, ErrNotAllowed
+	}
+
+	var ride *models.Ride
+
+	err := r.transactor.WithTx(func(tx Tx) error {
+		if err := tx.Rides().Restore(id); err != nil {
+			return err
+		}
+
+		var err error
+		ride, err = tx.Rides().GetByID(id)
+		if err != nil {
+			return err
+		}
+
+		if err := audit(tx, user, models.AuditEntityRide, ride.ID, models.AuditActionRestore, nil, ride); err != nil {
+			return err
+		}
+
+		return publish(tx, models.EventRideRestored, ride.ID, ride)
+	})
+
+	if err != nil {
+		if err != stores.ErrNoRideFound {
+			r.logger.Error("RideService.Restore - unable to restore ride", "error", err.Error())
+		}
+		return nil, err
+	}
+
+	return ride, nil
 }
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ucladevx/BPool/interfaces"
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+// rideRequestSortColumns are the columns ride requests can be listed by, besides id
+var rideRequestSortColumns = []string{"created_at", "earliest_start"}
+
+// openRequestSort lists open ride requests soonest first
+var openRequestSort = pagination.Sort{"earliest_start", "id"}
+
+var (
+	// ErrRideRequestClosed occurs when a ride request that is no longer open is changed or offered a ride
+	ErrRideRequestClosed = errors.New("The ride request is no longer open")
+
+	// ErrRideDoesNotFit occurs when a driver offers a ride outside of the request's
+	// window, above its max price or without enough free seats
+	ErrRideDoesNotFit = errors.New("The ride does not fit the ride request")
+
+	// ErrOfferNotPending occurs when a passenger confirms an offer that was already accepted, rejected or cancelled
+	ErrOfferNotPending = errors.New("The offer is no longer pending")
+
+	// ErrOfferNotConfirmed occurs when the driver accepts a passenger they offered a
+	// ride to, only the passenger can accept an offer by confirming it
+	ErrOfferNotConfirmed = errors.New("Offered rides are accepted by the passenger confirming them")
+)
+
+type (
+	// RideRequestService provides all use cases for ride requests, drivers offer their
+	// rides to open requests as interested passengers that the requester confirms
+	RideRequestService struct {
+		store            RideRequestStore
+		passengerService *PassengerService
+		transactor       Transactor
+		logger           interfaces.Logger
+	}
+
+	// RideRequestStore any store that allows for ride requests to be persisted
+	RideRequestStore interface {
+		GetAll(page pagination.Page) ([]*models.RideRequest, error)
+		WhereMany(clauses []stores.QueryModifier) ([]*models.RideRequest, error)
+		GetByID(id string) (*models.RideRequest, error)
+		Insert(request *models.RideRequest) error
+		Import(request *models.RideRequest) error
+		Update(request *models.RideRequest) error
+		Delete(id string) error
+		Purge(before time.Time) (int, error)
+	}
+)
+
+// NewRideRequestService creates a new ride request service
+func NewRideRequestService(store RideRequestStore, p *PassengerService, t Transactor, l interfaces.Logger) *RideRequestService {
+	return &RideRequestService{
+		store:            store,
+		passengerService: p,
+		transactor:       t,
+		logger:           l,
+	}
+}
+
+// Create persists an open ride request
+func (s *RideRequestService) Create(request *models.RideRequest, user *auth.UserClaims) error {
+	request.Status = models.RideRequestOpen
+
+	if err := request.Validate(); err != nil {
+		return err
+	}
+
+	if request.PassengerID != user.ID && user.AuthLevel != AdminLevel {
+		return ErrForbidden
+	}
+
+	if err := s.store.Insert(request); err != nil {
+		s.logger.Error("RideRequestService.Create - unable to create request", "error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Get returns a ride request by ID. Open requests can be seen by every driver, once
+// closed only by the requester, admins and the drivers who offered it a ride.
+func (s *RideRequestService) Get(id string, user *auth.UserClaims) (*models.RideRequest, error) {
+	request, err := s.store.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.AuthLevel == AdminLevel || request.PassengerID == user.ID || request.Status == models.RideRequestOpen {
+		return request, nil
+	}
+
+	offers, err := s.passengerService.GetByRequest(id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, offer := range offers {
+		if offer.DriverID == user.ID {
+			return request, nil
+		}
+	}
+
+	return nil, ErrForbidden
+}
+
+// GetOpen returns a page of the open ride requests that can still be fulfilled,
+// soonest first, and the cursor of the next page, if there is one
+func (s *RideRequestService) GetOpen(page pagination.Page) ([]*models.RideRequest, *pagination.Cursor, error) {
+	page = page.WithDefaults(openRequestSort)
+	if err := page.Validate(rideRequestSortColumns...); err != nil {
+		return nil, nil, err
+	}
+
+	clauses := []stores.QueryModifier{
+		stores.QueryMod("status", stores.EQ, models.RideRequestOpen),
+		stores.And,
+		stores.QueryMod("latest_start", stores.GT, time.Now()),
+	}
+
+	all, err := s.store.WhereMany(stores.Paginate(clauses, page.Peek()))
+	if err != nil {
+		s.logger.Error("RideRequestService.GetOpen - unable to get requests", "error", err.Error())
+		return nil, nil, err
+	}
+
+	next, err := page.Trim(&all)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return all, next, nil
+}
+
+// GetAllByPassenger returns a page of the user's ride requests in every status and
+// the cursor of the next page, if there is one
+func (s *RideRequestService) GetAllByPassenger(page pagination.Page, user *auth.UserClaims) ([]*models.RideRequest, *pagination.Cursor, error) {
+	page = page.WithDefaults(pagination.ByID)
+	if err := page.Validate(rideRequestSortColumns...); err != nil {
+		return nil, nil, err
+	}
+
+	byPassenger := []stores.QueryModifier{stores.QueryMod("passenger_id", stores.EQ, user.ID)}
+
+	all, err := s.store.WhereMany(stores.Paginate(byPassenger, page.Peek()))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	next, err := page.Trim(&all)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return all, next, nil
+}
+
+// Update applies updates to an open ride request, offers already made are kept
+func (s *RideRequestService) Update(updates *models.RideRequestChangeSet, id string, user *auth.UserClaims) (*models.RideRequest, error) {
+	request, err := s.store.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.AuthLevel != AdminLevel && request.PassengerID != user.ID {
+		return nil, ErrForbidden
+	}
+
+	if request.Status != models.RideRequestOpen {
+		return nil, ErrRideRequestClosed
+	}
+
+	if err := request.ApplyUpdates(updates); err != nil {
+		s.logger.Error("RideRequestService.Update - apply updates", "error", err.Error())
+		return nil, err
+	}
+
+	if err := s.store.Update(request); err != nil {
+		if err != stores.ErrVersionConflict {
+			s.logger.Error("RideRequestService.Update - store update", "error", err.Error())
+		}
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// Delete removes a ride request from the store if the user is allowed to, the
+// passengers offered rides for it are left alone
+func (s *RideRequestService) Delete(id string, user *auth.UserClaims) error {
+	request, err := s.store.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	if user.AuthLevel != AdminLevel && request.PassengerID != user.ID {
+		return ErrForbidden
+	}
+
+	return s.store.Delete(request.ID)
+}
+
+// Offer offers the driver's ride to an open ride request by adding the requester
+// as an interested passenger of the ride, which they accept by confirming the offer
+func (s *RideRequestService) Offer(id, rideID string, user *auth.UserClaims) (*models.Passenger, error) {
+	request, err := s.store.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Status != models.RideRequestOpen {
+		return nil, ErrRideRequestClosed
+	}
+
+	passenger := &models.Passenger{
+		PassengerID:    request.PassengerID,
+		RideID:         rideID,
+		Status:         models.PassengerInterested,
+		SeatsRequested: request.Seats,
+		RequestID:      &request.ID,
+	}
+
+	err = s.transactor.WithTx(func(tx Tx) error {
+		// locked so that the free seats cannot change until the offer is made
+		ride, err := tx.Rides().GetByIDForUpdate(rideID)
+		if err != nil {
+			return err
+		}
+
+		if user.AuthLevel != AdminLevel && ride.DriverID != user.ID {
+			return ErrForbidden
+		}
+
+		if ride.DriverID == request.PassengerID {
+			return ErrPassengerIsDriver
+		}
+
+		if ride.Status != models.RideScheduled {
+			return ErrRideNotScheduled
+		}
+
+		seatsTaken, err := countSeatsTaken(tx, ride.ID, "")
+		if err != nil {
+			return err
+		}
+
+		if !request.Fits(ride, ride.Seats-seatsTaken) {
+			return ErrRideDoesNotFit
+		}
+
+		passenger.DriverID = ride.DriverID
+		if err := tx.Passengers().Insert(passenger); err != nil {
+			return err
+		}
+
+		return publish(tx, models.EventPassengerOffered, passenger.ID, passenger)
+	})
+
+	if err != nil {
+		if err != ErrForbidden && err != ErrPassengerIsDriver && err != ErrRideNotScheduled && err != ErrRideDoesNotFit && err != stores.ErrAlreadyPassenger {
+			s.logger.Error("RideRequestService.Offer - unable to offer ride", "error", err.Error())
+		}
+		return nil, err
+	}
+
+	return passenger, nil
+}
+
+// GetOffers returns the passengers offered rides for a ride request, in every status
+func (s *RideRequestService) GetOffers(id string, user *auth.UserClaims) ([]*models.Passenger, error) {
+	request, err := s.store.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.AuthLevel != AdminLevel && request.PassengerID != user.ID {
+		return nil, ErrForbidden
+	}
+
+	return s.passengerService.GetByRequest(id)
+}
+
+// Confirm accepts one of the offers made for the user's ride request, if the ride
+// still has a free seat, fulfilling the request and rejecting its other offers
+func (s *RideRequestService) Confirm(id, passengerID string, user *auth.UserClaims) (*models.Passenger, error) {
+	request, err := s.store.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.AuthLevel != AdminLevel && request.PassengerID != user.ID {
+		return nil, ErrForbidden
+	}
+
+	if request.Status != models.RideRequestOpen {
+		return nil, ErrRideRequestClosed
+	}
+
+	var passenger *models.Passenger
+
+	err = s.transactor.WithTx(func(tx Tx) error {
+		offers, err := tx.Passengers().WhereMany([]stores.QueryModifier{
+			stores.QueryMod("request_id", stores.EQ, id),
+			stores.And,
+			stores.QueryMod("status", stores.EQ, models.PassengerInterested),
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, offer := range offers {
+			before := *offer
+
+			if offer.ID == passengerID {
+				passenger = offer
+				offer.Status = models.PassengerAccepted
+				err = s.passengerService.accept(tx, offer)
+			} else {
+				offer.Status = models.PassengerRejected
+				err = tx.Passengers().Update(offer)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			if err := audit(tx, user, models.AuditEntityPassenger, offer.ID, models.AuditActionUpdate, before, offer); err != nil {
+				return err
+			}
+
+			if err := publish(tx, passengerStatusEvents[offer.Status], offer.ID, offer); err != nil {
+				return err
+			}
+		}
+
+		if passenger == nil {
+			offer, err := tx.Passengers().GetByID(passengerID)
+			if err != nil {
+				return err
+			}
+
+			if offer.RequestID == nil || *offer.RequestID != id {
+				return stores.ErrNoPassengerFound
+			}
+
+			return ErrOfferNotPending
+		}
+
+		request.Status = models.RideRequestFulfilled
+		if err := tx.RideRequests().Update(request); err != nil {
+			return err
+		}
+
+		return publish(tx, models.EventRideRequestFulfilled, request.ID, request)
+	})
+
+	if err != nil {
+		if err != ErrNoMoreSeats && err != ErrRideNotScheduled && err != ErrOfferNotPending && err != stores.ErrVersionConflict {
+			s.logger.Error("RideRequestService.Confirm - unable to confirm offer", "error", err.Error())
+		}
+		return nil, err
+	}
+
+	return passenger, nil
+}
@@ -0,0 +1,174 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ucladevx/BPool/mocks"
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/stores/memory"
+	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+type rideRequestStores struct {
+	cars       *memory.CarStore
+	passengers *memory.PassengerStore
+	requests   *memory.RideRequestStore
+	outbox     *memory.OutboxStore
+	rides      *services.RideService
+	riders     *services.PassengerService
+	service    *services.RideRequestService
+}
+
+func newRideRequestStores() rideRequestStores {
+	db := memory.NewDB()
+	transactor := memory.NewTransactor(db)
+
+	s := rideRequestStores{
+		cars:       memory.NewCarStore(db),
+		passengers: memory.NewPassengerStore(db),
+		requests:   memory.NewRideRequestStore(db),
+		outbox:     memory.NewOutboxStore(db),
+	}
+	s.rides = services.NewRideService(memory.NewRideStore(db), services.NewCarService(s.cars, mocks.Logger{}), transactor, 0, 0, mocks.Logger{})
+	s.riders = services.NewPassengerService(s.passengers, s.rides, transactor, mocks.Logger{})
+	s.service = services.NewRideRequestService(s.requests, s.riders, transactor, mocks.Logger{})
+
+	return s
+}
+
+// newRide creates a scheduled ride of the driver that starts in the given time
+func (s rideRequestStores) newRide(t *testing.T, driverID string, in time.Duration) *models.Ride {
+	car := models.Car{Make: "Toyota", Model: "Prius", Year: 2017, Color: "white", UserID: driverID}
+	require.Nil(t, s.cars.Insert(&car))
+
+	ride := ride1
+	ride.CarID, ride.DriverID = car.ID, driverID
+	ride.StartDate = time.Now().Add(in)
+	require.Nil(t, s.rides.Create(&ride, &auth.UserClaims{ID: driverID}))
+
+	return &ride
+}
+
+func newRideRequest(t *testing.T, s rideRequestStores, rider *auth.UserClaims) *models.RideRequest {
+	cs := &models.RideRequestChangeSet{PassengerID: &rider.ID}
+	startCity, endCity := "Los Angeles", "San Francisco"
+	startLat, startLon, endLat, endLon := 11.0, 12.0, -1.0, -23.0
+	earliest, latest := time.Now().Add(24*time.Hour), time.Now().Add(30*time.Hour)
+	maxPrice := 20.0
+	cs.StartCity, cs.EndCity = &startCity, &endCity
+	cs.StartLat, cs.StartLon, cs.EndLat, cs.EndLon = &startLat, &startLon, &endLat, &endLon
+	cs.EarliestStart, cs.LatestStart, cs.MaxPrice = &earliest, &latest, &maxPrice
+
+	request, err := models.NewRideRequest(cs)
+	require.Nil(t, err)
+	require.Nil(t, s.service.Create(request, rider))
+
+	return request
+}
+
+func TestRideRequestOffer(t *testing.T) {
+	assert := assert.New(t)
+	s := newRideRequestStores()
+
+	rider := &auth.UserClaims{ID: "rider1"}
+	driver := &auth.UserClaims{ID: "driver1"}
+	request := newRideRequest(t, s, rider)
+
+	ride := s.newRide(t, driver.ID, 26*time.Hour)
+
+	_, err := s.service.Offer(request.ID, ride.ID, &auth.UserClaims{ID: "driver2"})
+	assert.Equal(services.ErrForbidden, err, "drivers should only offer their own rides")
+
+	tooSoon := s.newRide(t, driver.ID, 2*time.Hour)
+	_, err = s.service.Offer(request.ID, tooSoon.ID, driver)
+	assert.Equal(services.ErrRideDoesNotFit, err, "rides outside the window should not be offered")
+
+	passenger, err := s.service.Offer(request.ID, ride.ID, driver)
+	require.Nil(t, err)
+	assert.Equal(rider.ID, passenger.PassengerID)
+	assert.Equal(driver.ID, passenger.DriverID)
+	assert.Equal(models.PassengerInterested, passenger.Status, "offers should wait for the passenger")
+	if assert.NotNil(passenger.RequestID) {
+		assert.Equal(request.ID, *passenger.RequestID)
+	}
+
+	_, err = s.service.Offer(request.ID, ride.ID, driver)
+	assert.Equal(stores.ErrAlreadyPassenger, err, "a ride should only be offered once")
+
+	accepted := models.PassengerAccepted
+	_, err = s.riders.Update(&models.PassengerChangeSet{Status: &accepted}, passenger.ID, driver)
+	assert.Equal(services.ErrOfferNotConfirmed, err, "the driver should not accept an offer for the passenger")
+
+	offers, err := s.service.GetOffers(request.ID, rider)
+	require.Nil(t, err)
+	assert.Len(offers, 1)
+
+	_, err = s.service.GetOffers(request.ID, driver)
+	assert.Equal(services.ErrForbidden, err, "only the requester should see every offer")
+
+	open, _, err := s.service.GetOpen(pagination.Page{})
+	require.Nil(t, err)
+	assert.Len(open, 1, "the request should stay open until an offer is confirmed")
+}
+
+func TestRideRequestConfirm(t *testing.T) {
+	assert := assert.New(t)
+	s := newRideRequestStores()
+
+	rider := &auth.UserClaims{ID: "rider1"}
+	request := newRideRequest(t, s, rider)
+
+	first, err := s.service.Offer(request.ID, s.newRide(t, "driver1", 25*time.Hour).ID, &auth.UserClaims{ID: "driver1"})
+	require.Nil(t, err)
+	second, err := s.service.Offer(request.ID, s.newRide(t, "driver2", 27*time.Hour).ID, &auth.UserClaims{ID: "driver2"})
+	require.Nil(t, err)
+
+	_, err = s.service.Confirm(request.ID, second.ID, &auth.UserClaims{ID: "driver2"})
+	assert.Equal(services.ErrForbidden, err, "only the requester should confirm an offer")
+
+	confirmed, err := s.service.Confirm(request.ID, second.ID, rider)
+	require.Nil(t, err)
+	assert.Equal(models.PassengerAccepted, confirmed.Status)
+
+	found, err := s.passengers.GetByID(first.ID)
+	require.Nil(t, err)
+	assert.Equal(models.PassengerRejected, found.Status, "the other offers should be rejected")
+
+	fulfilled, err := s.service.Get(request.ID, rider)
+	require.Nil(t, err)
+	assert.Equal(models.RideRequestFulfilled, fulfilled.Status)
+
+	_, err = s.service.Get(request.ID, &auth.UserClaims{ID: "driver1"})
+	assert.Nil(err, "drivers who offered a ride should still see the request")
+
+	_, err = s.service.Get(request.ID, &auth.UserClaims{ID: "driver3"})
+	assert.Equal(services.ErrForbidden, err, "other users should not see a closed request")
+
+	_, err = s.service.Confirm(request.ID, first.ID, rider)
+	assert.Equal(services.ErrRideRequestClosed, err, "a fulfilled request should not be confirmed again")
+
+	_, err = s.service.Offer(request.ID, s.newRide(t, "driver3", 26*time.Hour).ID, &auth.UserClaims{ID: "driver3"})
+	assert.Equal(services.ErrRideRequestClosed, err, "a fulfilled request should not be offered rides")
+
+	open, _, err := s.service.GetOpen(pagination.Page{})
+	require.Nil(t, err)
+	assert.Empty(open)
+
+	events, err := s.outbox.GetDue(time.Now().Add(time.Second), 100)
+	require.Nil(t, err)
+	published := map[string]int{}
+	for _, event := range events {
+		published[event.Type]++
+	}
+	assert.Equal(2, published[models.EventPassengerOffered])
+	assert.Equal(1, published[models.EventPassengerAccepted])
+	assert.Equal(1, published[models.EventPassengerRejected])
+	assert.Equal(1, published[models.EventRideRequestFulfilled])
+}
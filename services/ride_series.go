@@ -0,0 +1,449 @@
+package services
+
+import (
+	"time"
+
+	"github.com/ucladevx/BPool/interfaces"
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+// DefaultSeriesHorizonDays is how many days ahead the rides of a series are materialized
+const DefaultSeriesHorizonDays = 14
+
+const (
+	// seriesCancelledReason is the reason upcoming rides are cancelled for when their series is
+	seriesCancelledReason = "series cancelled"
+	// seriesUpdatedReason is the reason rides are cancelled for when their series no longer includes their date
+	seriesUpdatedReason = "series updated"
+)
+
+// rideSeriesSortColumns are the columns ride series can be listed by, besides id
+var rideSeriesSortColumns = []string{"created_at", "starts_on"}
+
+type (
+	// RideSeriesService provides all use cases for ride series, the rides of a series
+	// are materialized ahead of time so that they can be searched and joined like
+	// any other ride, and edited or cancelled one at a time through the RideService
+	RideSeriesService struct {
+		store      RideSeriesStore
+		rides      RideStore
+		carService *CarService
+		transactor Transactor
+		horizon    int
+		lateWindow time.Duration
+		// offerWindow is how long seats offered from the waitlist are held
+		offerWindow time.Duration
+		logger      interfaces.Logger
+		now         func() time.Time
+	}
+
+	// RideSeriesStore any store that allows for ride series to be persisted
+	RideSeriesStore interface {
+		GetAll(page pagination.Page) ([]*models.RideSeries, error)
+		WhereMany(clauses []stores.QueryModifier) ([]*models.RideSeries, error)
+		GetByID(id string) (*models.RideSeries, error)
+		GetActive() ([]*models.RideSeries, error)
+		Insert(series *models.RideSeries) error
+		Import(series *models.RideSeries) error
+		Update(series *models.RideSeries) error
+		SetMaterializedThrough(id string, date *string) error
+		Delete(id string) error
+		Purge(before time.Time) (int, error)
+	}
+)
+
+// NewRideSeriesService creates a new ride series service that materializes rides
+// horizonDays ahead, cancels rides within lateCancellationHours of their start as
+// late and holds the seats it offers to waitlists for seatOfferHours, the defaults
+// if they are not positive
+func NewRideSeriesService(store RideSeriesStore, rides RideStore, c *CarService, t Transactor, horizonDays, lateCancellationHours, seatOfferHours int, l interfaces.Logger) *RideSeriesService {
+	if horizonDays <= 0 {
+		horizonDays = DefaultSeriesHorizonDays
+	}
+
+	if lateCancellationHours <= 0 {
+		lateCancellationHours = DefaultLateCancellationHours
+	}
+
+	if seatOfferHours <= 0 {
+		seatOfferHours = DefaultSeatOfferHours
+	}
+
+	return &RideSeriesService{
+		store:       store,
+		rides:       rides,
+		carService:  c,
+		transactor:  t,
+		horizon:     horizonDays,
+		lateWindow:  time.Duration(lateCancellationHours) * time.Hour,
+		offerWindow: time.Duration(seatOfferHours) * time.Hour,
+		logger:      l,
+		now:         time.Now,
+	}
+}
+
+// Create persists a ride series and materializes its first rides. The series is
+// created even if its rides cannot be materialized yet, Materialize catches up on them.
+func (s *RideSeriesService) Create(series *models.RideSeries, user *auth.UserClaims) error {
+	if err := series.Validate(); err != nil {
+		return err
+	}
+
+	isOwner, err := s.carService.IsOwnerOrAdmin(series.CarID, user)
+	if err != nil {
+		return err
+	}
+
+	if !isOwner {
+		return ErrNotCarOwner
+	}
+
+	if err := s.store.Insert(series); err != nil {
+		s.logger.Error("RideSeriesService.Create - unable to create series", "error", err.Error())
+		return err
+	}
+
+	if _, err := s.materialize(series); err != nil {
+		s.logger.Error("RideSeriesService.Create - unable to materialize series", "id", series.ID, "error", err.Error())
+	}
+
+	return nil
+}
+
+// Get returns a ride series by ID
+func (s *RideSeriesService) Get(id string) (*models.RideSeries, error) {
+	return s.store.GetByID(id)
+}
+
+// GetAllByDriver returns a page of the user's ride series and the cursor of the next
+// page, if there is one
+func (s *RideSeriesService) GetAllByDriver(page pagination.Page, user *auth.UserClaims) ([]*models.RideSeries, *pagination.Cursor, error) {
+	page = page.WithDefaults(pagination.ByID)
+	if err := page.Validate(rideSeriesSortColumns...); err != nil {
+		return nil, nil, err
+	}
+
+	byDriver := []stores.QueryModifier{stores.QueryMod("driver_id", stores.EQ, user.ID)}
+
+	all, err := s.store.WhereMany(stores.Paginate(byDriver, page.Peek()))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	next, err := page.Trim(&all)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return all, next, nil
+}
+
+// GetRides returns the rides of a series that have not been cancelled, in date order
+func (s *RideSeriesService) GetRides(id string) ([]*models.Ride, error) {
+	if _, err := s.store.GetByID(id); err != nil {
+		return nil, err
+	}
+
+	return s.rides.WhereMany([]stores.QueryModifier{
+		stores.QueryMod("series_id", stores.EQ, id),
+		stores.And,
+		stores.QueryMod("status", stores.NE, models.RideCancelled),
+		stores.OrderBy("start_date", stores.Asc),
+	})
+}
+
+// Update applies updates to a ride series and to its upcoming rides, the rides on
+// dates the series no longer includes are cancelled and the ones on new dates are
+// materialized. Changes made to single upcoming rides are overwritten.
+func (s *RideSeriesService) Update(updates *models.RideSeriesChangeSet, id string, user *auth.UserClaims) (*models.RideSeries, error) {
+	series, err := s.store.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.AuthLevel != AdminLevel && series.DriverID != user.ID {
+		return nil, ErrForbidden
+	}
+
+	before := *series
+
+	if err := series.ApplyUpdates(updates); err != nil {
+		s.logger.Error("RideSeriesService.Update - apply updates", "error", err.Error())
+		return nil, err
+	}
+
+	// Only check if the car is owned by the user if that field gets updated
+	if before.CarID != series.CarID {
+		isOwner, err := s.carService.IsOwnerOrAdmin(series.CarID, user)
+		if err != nil {
+			return nil, err
+		}
+
+		if !isOwner {
+			return nil, ErrNotCarOwner
+		}
+	}
+
+	err = s.transactor.WithTx(func(tx Tx) error {
+		if err := tx.RideSeries().Update(series); err != nil {
+			return err
+		}
+
+		rides, err := s.upcoming(tx, series.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, occurrence := range rides {
+			// the ride is locked so that passengers cannot join it while its seats change
+			ride, err := tx.Rides().GetByIDForUpdate(occurrence.ID)
+			if err != nil {
+				return err
+			}
+
+			if ride.Status != models.RideScheduled {
+				continue
+			}
+
+			if !series.Includes(*ride.OccurrenceDate) {
+				if err := s.cancelOccurrence(tx, ride, seriesUpdatedReason, user); err != nil {
+					return err
+				}
+				continue
+			}
+
+			rideBefore := *ride
+			if err := series.ApplyTo(ride); err != nil {
+				return err
+			}
+
+			if ride.Seats < rideBefore.Seats {
+				seatsTaken, err := countSeatsTaken(tx, ride.ID, "")
+				if err != nil {
+					return err
+				}
+
+				if seatsTaken > ride.Seats {
+					return ErrFewerSeatsThanTaken
+				}
+			}
+
+			if err := tx.Rides().Update(ride); err != nil {
+				return err
+			}
+
+			if err := audit(tx, user, models.AuditEntityRide, ride.ID, models.AuditActionUpdate, rideBefore, ride); err != nil {
+				return err
+			}
+
+			if err := publish(tx, models.EventRideUpdated, ride.ID, ride); err != nil {
+				return err
+			}
+
+			// added seats go to the waitlist first
+			if ride.Seats > rideBefore.Seats {
+				if err := offerSeats(tx, ride.ID, s.offerWindow, user); err != nil {
+					return err
+				}
+			}
+		}
+
+		// the dates are materialized again so that dates the series now includes get rides
+		return tx.RideSeries().SetMaterializedThrough(series.ID, nil)
+	})
+
+	if err != nil {
+		if err != stores.ErrVersionConflict && err != ErrFewerSeatsThanTaken {
+			s.logger.Error("RideSeriesService.Update - store update", "error", err.Error())
+		}
+		return nil, err
+	}
+
+	// the update is committed, rides that cannot be materialized now are left to Materialize
+	series.MaterializedThrough = nil
+	if _, err := s.materialize(series); err != nil {
+		s.logger.Error("RideSeriesService.Update - unable to materialize series", "id", series.ID, "error", err.Error())
+	}
+
+	return series, nil
+}
+
+// Delete cancels a ride series along with its upcoming rides, rides that have
+// already departed are kept
+func (s *RideSeriesService) Delete(id string, user *auth.UserClaims) error {
+	series, err := s.store.GetByID(id)
+	if err != nil {
+		s.logger.Error("RideSeriesService.Delete - GetSeries", "error", err.Error())
+		return err
+	}
+
+	if user.AuthLevel != AdminLevel && series.DriverID != user.ID {
+		return ErrForbidden
+	}
+
+	return s.transactor.WithTx(func(tx Tx) error {
+		if err := tx.RideSeries().Delete(series.ID); err != nil {
+			return err
+		}
+
+		rides, err := s.upcoming(tx, series.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, ride := range rides {
+			if err := s.cancelOccurrence(tx, ride, seriesCancelledReason, user); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Materialize creates the rides of every active series up to the horizon and
+// returns how many were created, a series that fails is logged and skipped
+func (s *RideSeriesService) Materialize() (int, error) {
+	active, err := s.store.GetActive()
+	if err != nil {
+		s.logger.Error("RideSeriesService.Materialize - unable to get active series", "error", err.Error())
+		return 0, err
+	}
+
+	total := 0
+
+	for _, series := range active {
+		count, err := s.materialize(series)
+		total += count
+
+		if err != nil {
+			s.logger.Error("RideSeriesService.Materialize - unable to materialize series", "id", series.ID, "error", err.Error())
+		}
+	}
+
+	return total, nil
+}
+
+// Run materializes the rides of every active series every interval until stop is closed
+func (s *RideSeriesService) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if count, err := s.Materialize(); err == nil && count > 0 {
+				s.logger.Info("RideSeriesService.Run - materialized rides", "count", count)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// materialize creates the series' rides from the day after it was last materialized
+// through, or today, up to the horizon. Every date is only ever materialized once,
+// so a ride that was cancelled on its own is not created again.
+func (s *RideSeriesService) materialize(series *models.RideSeries) (int, error) {
+	now := s.now()
+	today := series.Today(now)
+
+	from := today
+	if series.StartsOn > from {
+		from = series.StartsOn
+	}
+	if series.MaterializedThrough != nil {
+		if next := addDays(*series.MaterializedThrough, 1); next > from {
+			from = next
+		}
+	}
+
+	through := series.EndsOn
+	if horizon := addDays(today, s.horizon); horizon < through {
+		through = horizon
+	}
+
+	count := 0
+
+	for _, date := range series.Dates(from, through) {
+		ride, err := series.Occurrence(date)
+		if err != nil {
+			return count, err
+		}
+
+		// the ride may have departed already today
+		if ride.StartDate.Before(now) {
+			continue
+		}
+
+		err = s.transactor.WithTx(func(tx Tx) error {
+			if err := tx.Rides().Insert(ride); err != nil {
+				return err
+			}
+
+			return publish(tx, models.EventRideCreated, ride.ID, ride)
+		})
+
+		if err == stores.ErrDuplicateOccurrence {
+			continue
+		}
+		if err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	if series.MaterializedThrough == nil || through > *series.MaterializedThrough {
+		if err := s.store.SetMaterializedThrough(series.ID, &through); err != nil {
+			return count, err
+		}
+		series.MaterializedThrough = &through
+	}
+
+	return count, nil
+}
+
+// upcoming returns the series' rides that are still scheduled to depart
+func (s *RideSeriesService) upcoming(tx Tx, seriesID string) ([]*models.Ride, error) {
+	return tx.Rides().WhereMany([]stores.QueryModifier{
+		stores.QueryMod("series_id", stores.EQ, seriesID),
+		stores.And,
+		stores.QueryMod("status", stores.EQ, models.RideScheduled),
+		stores.And,
+		stores.QueryMod("start_date", stores.GT, s.now()),
+	})
+}
+
+// cancelOccurrence cancels a ride of a series and its passengers as RideService.Cancel does
+func (s *RideSeriesService) cancelOccurrence(tx Tx, ride *models.Ride, reason string, user *auth.UserClaims) error {
+	before := *ride
+	ride.Cancel(user.ID, reason, s.now(), s.lateWindow)
+
+	if err := cancelPassengers(tx, ride, user); err != nil {
+		return err
+	}
+
+	if err := tx.Rides().Update(ride); err != nil {
+		return err
+	}
+
+	if err := audit(tx, user, models.AuditEntityRide, ride.ID, models.AuditActionUpdate, before, ride); err != nil {
+		return err
+	}
+
+	return publish(tx, models.EventRideCancelled, ride.ID, ride)
+}
+
+// addDays returns the date days after a date in models.DateLayout
+func addDays(date string, days int) string {
+	day, err := time.Parse(models.DateLayout, date)
+	if err != nil {
+		return date
+	}
+
+	return day.AddDate(0, 0, days).Format(models.DateLayout)
+}
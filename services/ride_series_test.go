@@ -0,0 +1,275 @@
+package services_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ucladevx/BPool/mocks"
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/stores/memory"
+	"github.com/ucladevx/BPool/utils/auth"
+)
+
+type rideSeriesStores struct {
+	rides      *memory.RideStore
+	series     *memory.RideSeriesStore
+	passengers *memory.PassengerStore
+	outbox     *memory.OutboxStore
+	car        *models.Car
+	service    *services.RideSeriesService
+}
+
+func newRideSeriesStores(t *testing.T, horizonDays int) rideSeriesStores {
+	db := memory.NewDB()
+	carStore := memory.NewCarStore(db)
+
+	s := rideSeriesStores{
+		rides:      memory.NewRideStore(db),
+		series:     memory.NewRideSeriesStore(db),
+		passengers: memory.NewPassengerStore(db),
+		outbox:     memory.NewOutboxStore(db),
+		car:        &models.Car{Make: "Toyota", Model: "Prius", Year: 2017, Color: "white", UserID: "driver1"},
+	}
+	require.Nil(t, carStore.Insert(s.car))
+
+	s.service = services.NewRideSeriesService(s.series, s.rides, services.NewCarService(carStore, mocks.Logger{}), memory.NewTransactor(db), horizonDays, 0, 0, mocks.Logger{})
+
+	return s
+}
+
+// newDailySeries returns a series starting tomorrow where it departs that gives a
+// ride every day for four weeks
+func newDailySeries(t *testing.T, car *models.Car) *models.RideSeries {
+	loc, err := time.LoadLocation(models.DefaultSeriesTimeZone)
+	require.Nil(t, err)
+
+	tomorrow := time.Now().In(loc).AddDate(0, 0, 1)
+	startsOn := tomorrow.Format(models.DateLayout)
+	endsOn := tomorrow.AddDate(0, 0, 27).Format(models.DateLayout)
+
+	driverID, carID, days, departure := car.UserID, car.ID, "SU,MO,TU,WE,TH,FR,SA", "08:30"
+	startCity, endCity := "Los Angeles", "San Francisco"
+	startLat, startLon, endLat, endLon := 34.068921, -118.445181, 37.774929, -122.419416
+
+	series, err := models.NewRideSeries(&models.RideSeriesChangeSet{
+		DriverID:      &driverID,
+		CarID:         &carID,
+		StartCity:     &startCity,
+		EndCity:       &endCity,
+		StartLat:      &startLat,
+		StartLon:      &startLon,
+		EndLat:        &endLat,
+		EndLon:        &endLon,
+		Days:          &days,
+		DepartureTime: &departure,
+		StartsOn:      &startsOn,
+		EndsOn:        &endsOn,
+	})
+	require.Nil(t, err)
+	series.Seats = 3
+
+	return series
+}
+
+func dueEventTypes(t *testing.T, outbox *memory.OutboxStore) map[string]int {
+	events, err := outbox.GetDue(time.Now().Add(time.Second), 1000)
+	require.Nil(t, err)
+
+	types := map[string]int{}
+	for _, event := range events {
+		types[event.Type]++
+	}
+
+	return types
+}
+
+func TestCreateRideSeries(t *testing.T) {
+	assert := assert.New(t)
+	s := newRideSeriesStores(t, 7)
+
+	series := newDailySeries(t, s.car)
+	assert.Equal(services.ErrNotCarOwner, s.service.Create(series, &auth.UserClaims{ID: "someone else"}))
+
+	require.Nil(t, s.service.Create(series, &auth.UserClaims{ID: s.car.UserID}))
+
+	rides, err := s.service.GetRides(series.ID)
+	require.Nil(t, err)
+	assert.Len(rides, 7, "rides should be materialized up to the horizon")
+	for _, ride := range rides {
+		if assert.NotNil(ride.SeriesID) {
+			assert.Equal(series.ID, *ride.SeriesID, "rides should link back to their series")
+		}
+		assert.True(ride.StartDate.After(time.Now()))
+	}
+	assert.Equal(*rides[0].OccurrenceDate, series.StartsOn)
+	assert.Equal(7, dueEventTypes(t, s.outbox)[models.EventRideCreated], "every materialized ride should be published")
+
+	created, err := s.service.Materialize()
+	assert.Nil(err)
+	assert.Equal(0, created, "dates should only be materialized once")
+}
+
+func TestUpdateRideSeries(t *testing.T) {
+	assert := assert.New(t)
+	s := newRideSeriesStores(t, 7)
+
+	driver := auth.UserClaims{ID: s.car.UserID}
+	series := newDailySeries(t, s.car)
+	require.Nil(t, s.service.Create(series, &driver))
+
+	rides, err := s.service.GetRides(series.ID)
+	require.Nil(t, err)
+
+	// cancelling a single ride leaves the rest of the series alone
+	cancelled := rides[1]
+	require.Nil(t, s.rides.Delete(cancelled.ID))
+
+	info, version := "leaving from the parking lot", series.Version
+	_, err = s.service.Update(&models.RideSeriesChangeSet{Info: &info, Version: &version}, series.ID, &auth.UserClaims{ID: "someone else"})
+	assert.Equal(services.ErrForbidden, err)
+
+	updated, err := s.service.Update(&models.RideSeriesChangeSet{Info: &info, Version: &version}, series.ID, &driver)
+	require.Nil(t, err)
+	assert.Equal(version+1, updated.Version)
+
+	rides, err = s.service.GetRides(series.ID)
+	require.Nil(t, err)
+	assert.Len(rides, 6, "a cancelled ride should not be materialized again")
+	for _, ride := range rides {
+		assert.NotEqual(cancelled.ID, ride.ID)
+		assert.Equal(info, ride.Info, "upcoming rides should be updated")
+		assert.Equal(2, ride.Version)
+	}
+
+	// dropping a day cancels the rides on it
+	first, err := time.Parse(models.DateLayout, *rides[0].OccurrenceDate)
+	require.Nil(t, err)
+	dropped := models.SeriesDays[first.Weekday()]
+
+	days := ""
+	for _, day := range models.SeriesDays {
+		if day != dropped {
+			days += day + ","
+		}
+	}
+	days = days[:len(days)-1]
+
+	version = updated.Version
+	_, err = s.service.Update(&models.RideSeriesChangeSet{Days: &days, Version: &version}, series.ID, &driver)
+	require.Nil(t, err)
+
+	rides, err = s.service.GetRides(series.ID)
+	require.Nil(t, err)
+	assert.Len(rides, 5)
+	for _, ride := range rides {
+		day, err := time.Parse(models.DateLayout, *ride.OccurrenceDate)
+		require.Nil(t, err)
+		assert.NotEqual(first.Weekday(), day.Weekday(), "rides on the dropped day should be cancelled")
+	}
+	assert.True(dueEventTypes(t, s.outbox)[models.EventRideCancelled] > 0, "cancelled rides should be published")
+
+	droppedRides, err := s.rides.WhereMany([]stores.QueryModifier{
+		stores.QueryMod("series_id", stores.EQ, series.ID),
+		stores.And,
+		stores.QueryMod("status", stores.EQ, models.RideCancelled),
+	})
+	require.Nil(t, err)
+	for _, ride := range droppedRides {
+		if assert.NotNil(ride.CancelReason) {
+			assert.Equal("series updated", *ride.CancelReason)
+		}
+	}
+
+	_, err = s.service.Update(&models.RideSeriesChangeSet{Info: &info, Version: &version}, series.ID, &driver)
+	assert.Equal(stores.ErrVersionConflict, err)
+}
+
+func TestUpdateRideSeriesSeats(t *testing.T) {
+	assert := assert.New(t)
+	s := newRideSeriesStores(t, 7)
+
+	driver := auth.UserClaims{ID: s.car.UserID}
+	series := newDailySeries(t, s.car)
+	require.Nil(t, s.service.Create(series, &driver))
+
+	rides, err := s.service.GetRides(series.ID)
+	require.Nil(t, err)
+
+	for i, status := range []string{models.PassengerAccepted, models.PassengerAccepted, models.PassengerAccepted, models.PassengerWaitlisted} {
+		passenger := &models.Passenger{DriverID: driver.ID, PassengerID: fmt.Sprintf("rider%d", i), RideID: rides[0].ID, Status: status, SeatsRequested: 1}
+		require.Nil(t, s.passengers.Insert(passenger))
+	}
+
+	fewer, version := 2, series.Version
+	_, err = s.service.Update(&models.RideSeriesChangeSet{Seats: &fewer, Version: &version}, series.ID, &driver)
+	assert.Equal(services.ErrFewerSeatsThanTaken, err, "rides should not be overbooked by lowering the seats of their series")
+
+	found, err := s.rides.GetByID(rides[0].ID)
+	require.Nil(t, err)
+	assert.Equal(3, found.Seats, "no ride should change when one of them cannot")
+
+	more := 4
+	_, err = s.service.Update(&models.RideSeriesChangeSet{Seats: &more, Version: &version}, series.ID, &driver)
+	require.Nil(t, err)
+
+	waitlisted, err := s.passengers.WhereMany([]stores.QueryModifier{stores.QueryMod("passenger_id", stores.EQ, "rider3")})
+	require.Nil(t, err)
+	require.Len(t, waitlisted, 1)
+	assert.Equal(models.PassengerSeatOffered, waitlisted[0].Status, "added seats should be offered to the waitlist")
+}
+
+func TestDeleteRideSeries(t *testing.T) {
+	assert := assert.New(t)
+	s := newRideSeriesStores(t, 7)
+
+	driver := auth.UserClaims{ID: s.car.UserID}
+	series := newDailySeries(t, s.car)
+	require.Nil(t, s.service.Create(series, &driver))
+
+	rides, err := s.service.GetRides(series.ID)
+	require.Nil(t, err)
+
+	var passengers []*models.Passenger
+	for _, status := range []string{models.PassengerAccepted, models.PassengerInterested, models.PassengerWaitlisted} {
+		passenger := &models.Passenger{DriverID: driver.ID, PassengerID: "rider-" + status, RideID: rides[0].ID, Status: status, SeatsRequested: 1}
+		require.Nil(t, s.passengers.Insert(passenger))
+		passengers = append(passengers, passenger)
+	}
+
+	assert.Equal(services.ErrForbidden, s.service.Delete(series.ID, &auth.UserClaims{ID: "someone else"}))
+	require.Nil(t, s.service.Delete(series.ID, &driver))
+
+	_, err = s.service.Get(series.ID)
+	assert.Equal(stores.ErrNoRideSeriesFound, err)
+
+	rides, err = s.rides.WhereMany([]stores.QueryModifier{stores.QueryMod("series_id", stores.EQ, series.ID)})
+	require.Nil(t, err)
+	assert.Len(rides, 7, "cancelled rides should still be shown to their driver and passengers")
+	for _, ride := range rides {
+		assert.Equal(models.RideCancelled, ride.Status, "upcoming rides should be cancelled with the series")
+		if assert.NotNil(ride.CancelReason) {
+			assert.Equal("series cancelled", *ride.CancelReason)
+		}
+		assert.True(ride.LateCancellation == ride.StartDate.Before(time.Now().Add(services.DefaultLateCancellationHours*time.Hour)), "rides starting soon should be cancelled late")
+	}
+
+	for _, passenger := range passengers {
+		found, err := s.passengers.GetByID(passenger.ID)
+		require.Nil(t, err)
+		assert.Equal(models.PassengerCancelledByDriver, found.Status, "the passengers of cancelled rides should be told")
+	}
+
+	events := dueEventTypes(t, s.outbox)
+	assert.Equal(7, events[models.EventRideCancelled])
+	assert.Equal(len(passengers), events[models.EventPassengerCancelled])
+
+	created, err := s.service.Materialize()
+	assert.Nil(err)
+	assert.Equal(0, created, "deleted series should not be materialized")
+}
@@ -1,17 +1,22 @@
 package services_test
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/ucladevx/BPool/mocks"
 	"github.com/ucladevx/BPool/models"
 	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/stores/memory"
 	"github.com/ucladevx/BPool/stores/postgres"
 	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
 var (
@@ -28,6 +33,7 @@ var (
 		EndLon:       -23.00,
 		PricePerSeat: 15,
 		Info:         "test",
+		Status:       models.RideScheduled,
 		StartDate:    time.Now().Add(time.Hour),
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
@@ -46,6 +52,7 @@ var (
 		EndLon:       -23.00,
 		PricePerSeat: 15,
 		Info:         "",
+		Status:       models.RideDeparted,
 		StartDate:    time.Now(),
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
@@ -54,7 +61,15 @@ var (
 
 func newRideService(store *mocks.RideStore, carService *services.CarService) *services.RideService {
 	logger := mocks.Logger{}
-	return services.NewRideService(store, carService, logger)
+	auditStore := new(mocks.AuditStore)
+	auditStore.On("Insert", mock.AnythingOfType("*models.AuditEvent")).Return(nil)
+	outboxStore := new(mocks.OutboxStore)
+	outboxStore.On("Insert", mock.AnythingOfType("*models.OutboxEvent")).Return(nil)
+	passengerStore := new(mocks.PassengerStore)
+	passengerStore.On("WhereMany", mock.Anything).Return([]*models.Passenger{}, nil)
+	transactor := &mocks.Transactor{RideStore: store, PassengerStore: passengerStore, AuditStore: auditStore, OutboxStore: outboxStore}
+
+	return services.NewRideService(store, carService, transactor, 0, 0, logger)
 }
 
 func TestRideGet(t *testing.T) {
@@ -190,17 +205,32 @@ func TestRideGetAll(t *testing.T) {
 	service := newRideService(store, carService)
 	assert := assert.New(t)
 
-	noRides, err := service.GetAll("", 15, services.UserLevel)
+	noRides, _, err := service.GetAll(pagination.Page{Limit: 15}, services.UserLevel)
 	assert.Nil(noRides, "when a user does not have the right auth level there should be no rides")
 	assert.Equal(services.ErrNotAllowed, err, "when a user does not have the right auth level there should be a not allowed error")
 
 	badLimit := -1
-	store.On("GetAll", "", 15).Return([]*models.Ride{&ride1, &ride2}, nil)
+	store.On("GetAll", pagination.Page{Sort: pagination.ByID, Limit: 16}).Return([]*models.Ride{&ride1, &ride2}, nil)
 
-	rides, err := service.GetAll("", badLimit, services.AdminLevel)
+	rides, next, err := service.GetAll(pagination.Page{Limit: badLimit}, services.AdminLevel)
 
 	assert.Nil(err, "for a bad limit, should still return no error")
 	assert.Equal(2, len(rides), "the returned rides should have length 2")
+	assert.Nil(next, "there should be no next page when the store has no more rides")
+
+	byDate := pagination.Sort{"start_date", "id"}
+	store.On("GetAll", pagination.Page{Sort: byDate, Limit: 2}).Return([]*models.Ride{&ride1, &ride2}, nil)
+
+	rides, next, err = service.GetAll(pagination.Page{Sort: byDate, Limit: 1}, services.AdminLevel)
+	assert.Nil(err)
+	assert.Equal([]*models.Ride{&ride1}, rides, "only a page of rides should be returned")
+	if assert.NotNil(next, "there should be a next page when the store has more rides") {
+		assert.Equal([]interface{}{ride1.StartDate, ride1.ID}, next.Values, "the cursor should be at the page's last ride")
+	}
+
+	rides, _, err = service.GetAll(pagination.Page{Sort: pagination.Sort{"info", "id"}}, services.AdminLevel)
+	assert.Equal(pagination.ErrInvalidSort, err, "rides should only be listed by allowed columns")
+	assert.Nil(rides)
 
 	store.AssertExpectations(t)
 }
@@ -229,3 +259,251 @@ func TestRideDelete(t *testing.T) {
 
 	assert.Nil(err, "should have successfully delete ride if user is owner")
 }
+
+func TestRideRestore(t *testing.T) {
+	store := new(mocks.RideStore)
+	carStore := new(mocks.CarStore)
+	carService := newCarService(carStore)
+	service := newRideService(store, carService)
+	assert := assert.New(t)
+
+	user := auth.UserClaims{ID: ride1.DriverID, AuthLevel: services.UserLevel}
+	noRide, err := service.Restore(ride1.ID, &user)
+	assert.Nil(noRide, "only admins should be able to restore rides")
+	assert.Equal(services.ErrNotAllowed, err, "a driver restoring their own ride should not be allowed")
+
+	admin := auth.UserClaims{ID: "admin", AuthLevel: services.AdminLevel}
+
+	store.On("Restore", "missing").Return(postgres.ErrNoRideFound)
+	_, err = service.Restore("missing", &admin)
+	assert.Equal(postgres.ErrNoRideFound, err, "restoring a ride that is not deleted should return no ride found")
+
+	store.On("Restore", ride1.ID).Return(nil)
+	store.On("GetByID", ride1.ID).Return(&ride1, nil)
+	ride, err := service.Restore(ride1.ID, &admin)
+	assert.Nil(err, "there should be no error")
+	assert.Equal(&ride1, ride, "the restored ride should be returned")
+
+	store.AssertExpectations(t)
+}
+
+func TestRideUpdateVersionConflict(t *testing.T) {
+	store := new(mocks.RideStore)
+	service := newRideService(store, newCarService(new(mocks.CarStore)))
+	assert := assert.New(t)
+
+	validRide := ride1
+	validRide.Version = 3
+	driver := auth.UserClaims{ID: validRide.DriverID}
+	staleVersion := 2
+	info := "new info"
+
+	store.On("GetByID", validRide.ID).Return(&validRide, nil)
+	store.On("Update", mock.MatchedBy(func(ride *models.Ride) bool {
+		return ride.Version == staleVersion
+	})).Return(stores.ErrVersionConflict)
+
+	noRide, err := service.Update(&models.RideChangeSet{Info: &info, Version: &staleVersion}, validRide.ID, &driver)
+	assert.Nil(noRide, "there should be no ride when the version is stale")
+	assert.Equal(stores.ErrVersionConflict, err, "the conflict should be returned")
+	store.AssertExpectations(t)
+}
+
+func TestRideTransitions(t *testing.T) {
+	store := new(mocks.RideStore)
+	service := newRideService(store, newCarService(new(mocks.CarStore)))
+	assert := assert.New(t)
+
+	ride := ride1
+	driver := auth.UserClaims{ID: ride.DriverID}
+
+	store.On("GetByIDForUpdate", ride.ID).Return(&ride, nil)
+	store.On("Update", mock.AnythingOfType("*models.Ride")).Return(nil)
+
+	_, err := service.Start(ride.ID, &auth.UserClaims{ID: "ghy"})
+	assert.Equal(services.ErrForbidden, err, "only the driver should be able to start the ride")
+
+	_, err = service.Complete(ride.ID, &driver)
+	assert.Equal(services.ErrInvalidRideTransition, err, "a scheduled ride should not be able to complete")
+
+	started, err := service.Start(ride.ID, &driver)
+	assert.Nil(err)
+	assert.Equal(models.RideDeparted, started.Status)
+
+	_, err = service.Cancel(ride.ID, "car broke down", &driver)
+	assert.Equal(services.ErrInvalidRideTransition, err, "a departed ride should not be able to be cancelled")
+
+	completed, err := service.Complete(ride.ID, &driver)
+	assert.Nil(err)
+	assert.Equal(models.RideCompleted, completed.Status)
+
+	// a completed ride that started in the past can still be edited
+	ride.StartDate = time.Now().Add(-time.Hour)
+	info := "left from the parking lot"
+	store.On("GetByID", ride.ID).Return(&ride, nil)
+
+	updated, err := service.Update(&models.RideChangeSet{Info: &info}, ride.ID, &driver)
+	assert.Nil(err)
+	assert.Equal(info, updated.Info)
+
+	cancelled := ride1
+	cancelled.ID = "cancelled"
+	store.On("GetByIDForUpdate", cancelled.ID).Return(&cancelled, nil)
+	store.On("GetByID", cancelled.ID).Return(&cancelled, nil)
+
+	_, err = service.Cancel(cancelled.ID, "", &driver)
+	assert.NotNil(err, "rides should only be cancelled with a reason")
+
+	_, err = service.Cancel(cancelled.ID, "car broke down", &auth.UserClaims{ID: "admin", AuthLevel: services.AdminLevel})
+	assert.Nil(err, "admins should be able to cancel any ride")
+	assert.Equal(models.RideCancelled, cancelled.Status)
+
+	_, err = service.Update(&models.RideChangeSet{Info: &info}, cancelled.ID, &driver)
+	assert.Equal(services.ErrRideCancelled, err, "cancelled rides should not be edited")
+}
+
+func TestRideCancel(t *testing.T) {
+	assert := assert.New(t)
+
+	db := memory.NewDB()
+	carStore := memory.NewCarStore(db)
+	rideStore := memory.NewRideStore(db)
+	passengerStore := memory.NewPassengerStore(db)
+	outboxStore := memory.NewOutboxStore(db)
+	service := services.NewRideService(rideStore, services.NewCarService(carStore, mocks.Logger{}), memory.NewTransactor(db), 24, 0, mocks.Logger{})
+
+	car := models.Car{Make: "Toyota", Model: "Prius", Year: 2017, Color: "white", UserID: ride1.DriverID}
+	require.Nil(t, carStore.Insert(&car))
+	driver := auth.UserClaims{ID: car.UserID}
+
+	ride := ride1
+	ride.CarID = car.ID
+	ride.StartDate = time.Now().Add(48 * time.Hour)
+	require.Nil(t, service.Create(&ride, &driver))
+
+	statuses := []string{models.PassengerAccepted, models.PassengerInterested, models.PassengerRejected}
+	passengers := []*models.Passenger{}
+	for i, status := range statuses {
+		passenger := &models.Passenger{DriverID: ride.DriverID, PassengerID: fmt.Sprintf("rider%d", i), RideID: ride.ID, Status: status, SeatsRequested: 1}
+		require.Nil(t, passengerStore.Insert(passenger))
+		passengers = append(passengers, passenger)
+	}
+
+	cancelled, err := service.Cancel(ride.ID, "car broke down", &driver)
+	require.Nil(t, err)
+	assert.Equal(models.RideCancelled, cancelled.Status)
+	assert.Equal(driver.ID, *cancelled.CancelledBy)
+	assert.Equal("car broke down", *cancelled.CancelReason)
+	assert.NotNil(cancelled.CancelledAt)
+	assert.False(cancelled.LateCancellation, "cancelling two days ahead should not be late")
+
+	wanted := []string{models.PassengerCancelledByDriver, models.PassengerCancelledByDriver, models.PassengerRejected}
+	for i, passenger := range passengers {
+		found, err := passengerStore.GetByID(passenger.ID)
+		require.Nil(t, err)
+		assert.Equal(wanted[i], found.Status, "only accepted and interested passengers should be cancelled")
+	}
+
+	events, err := outboxStore.GetDue(time.Now().Add(time.Second), 100)
+	require.Nil(t, err)
+	published := map[string]int{}
+	for _, event := range events {
+		published[event.Type]++
+	}
+	assert.Equal(1, published[models.EventRideCancelled])
+	assert.Equal(2, published[models.EventPassengerCancelled], "cancelled passengers should be told")
+
+	late := ride1
+	late.CarID = car.ID
+	late.StartDate = time.Now().Add(2 * time.Hour)
+	require.Nil(t, service.Create(&late, &driver))
+
+	cancelled, err = service.Cancel(late.ID, "overslept", &driver)
+	require.Nil(t, err)
+	assert.True(cancelled.LateCancellation, "cancelling within the late window should be late")
+
+	reliability, err := service.Reliability(driver.ID)
+	require.Nil(t, err)
+	assert.Equal(0, reliability.Completed)
+	assert.Equal(2, reliability.Cancelled)
+	assert.Equal(1, reliability.LateCancellations)
+	assert.Equal(0.5, reliability.Score)
+}
+
+func TestRideSearch(t *testing.T) {
+	store := new(mocks.RideStore)
+	service := newRideService(store, newCarService(new(mocks.CarStore)))
+	assert := assert.New(t)
+
+	near := models.RideSearchNear{
+		FromLat:      34.068921,
+		FromLon:      -118.445181,
+		ToLat:        37.774929,
+		ToLon:        -122.419416,
+		FromRadiusKm: 25,
+		ToRadiusKm:   25,
+	}
+
+	store.On("Search", mock.MatchedBy(func(s *models.RideSearch) bool {
+		return s.Limit == 16 && s.Sort == models.RideSortDistance && !s.StartAfter.IsZero()
+	})).Return([]*models.Ride{&ride1}, nil).Once()
+
+	rides, next, err := service.Search(&models.RideSearch{Near: &near})
+	assert.Nil(err, "there should be no error for a valid search")
+	assert.Equal([]*models.Ride{&ride1}, rides, "the store's rides should be returned")
+	assert.Nil(next, "there should be no next page when the store has no more rides")
+
+	rides, _, err = service.Search(&models.RideSearch{Sort: models.RideSortDistance})
+	assert.Equal(models.ErrSortNeedsNear, err, "only near searches can be sorted by distance")
+	assert.Nil(rides)
+
+	tooFar := near
+	tooFar.FromRadiusKm = models.MaxSearchRadiusKm + 1
+	rides, _, err = service.Search(&models.RideSearch{Near: &tooFar})
+	assert.NotNil(err, "the radius should be limited")
+	assert.Nil(rides)
+
+	badLat := near
+	badLat.ToLat = 91
+	rides, _, err = service.Search(&models.RideSearch{Near: &badLat})
+	assert.NotNil(err, "latitudes should be validated")
+	assert.Nil(rides)
+
+	store.On("Search", mock.MatchedBy(func(s *models.RideSearch) bool {
+		return s.Sort == models.RideSortRelevance && s.Query == "skis"
+	})).Return([]*models.Ride{&ride1}, nil).Once()
+
+	rides, _, err = service.Search(&models.RideSearch{Query: "skis", Near: &near})
+	assert.Nil(err, "there should be no error for a valid search")
+	assert.Equal([]*models.Ride{&ride1}, rides, "rides matching a query should be sorted by relevance by default")
+
+	rides, _, err = service.Search(&models.RideSearch{Sort: models.RideSortRelevance})
+	assert.Equal(models.ErrSortNeedsQuery, err, "only searches for a query can be sorted by relevance")
+	assert.Nil(rides)
+
+	store.AssertExpectations(t)
+}
+
+func TestRideSearchNextCursor(t *testing.T) {
+	store := new(mocks.RideStore)
+	service := newRideService(store, newCarService(new(mocks.CarStore)))
+	assert := assert.New(t)
+
+	first := models.Ride{ID: "a", PricePerSeat: 10}
+	second := models.Ride{ID: "b", PricePerSeat: 20}
+
+	store.On("Search", mock.MatchedBy(func(s *models.RideSearch) bool {
+		return s.Limit == 2 && s.Sort == models.RideSortPrice
+	})).Return([]*models.Ride{&first, &second}, nil).Once()
+
+	rides, next, err := service.Search(&models.RideSearch{Sort: models.RideSortPrice, Limit: 1})
+	assert.Nil(err)
+	assert.Equal([]*models.Ride{&first}, rides, "only a page of rides should be returned")
+	assert.Equal(models.NewRideSearchCursor(models.RideSortPrice, &first), next, "the cursor should be at the page's last ride")
+
+	rides, _, err = service.Search(&models.RideSearch{Sort: models.RideSortDate, After: next})
+	assert.Equal(models.ErrCursorSortMismatch, err, "a cursor should only continue a search with the same sort")
+	assert.Nil(rides)
+
+	store.AssertExpectations(t)
+}
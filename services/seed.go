@@ -0,0 +1,337 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/ucladevx/BPool/interfaces"
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+)
+
+// ErrSeedNeedsUsers occurs when cars, rides or passengers are seeded without the users they need
+var ErrSeedNeedsUsers = errors.New("seeding cars needs a user, rides need a car and passengers need two users and a ride")
+
+// seedCity is a California city rides are seeded between
+type seedCity struct {
+	name string
+	lat  float64
+	lon  float64
+}
+
+var (
+	seedCities = []seedCity{
+		{"Los Angeles", 34.068921, -118.445181},
+		{"San Francisco", 37.774929, -122.419416},
+		{"San Diego", 32.715738, -117.161084},
+		{"San Jose", 37.338208, -121.886329},
+		{"Sacramento", 38.581572, -121.494400},
+		{"Santa Barbara", 34.420831, -119.698190},
+		{"San Luis Obispo", 35.282753, -120.659616},
+		{"Fresno", 36.737798, -119.787125},
+		{"Berkeley", 37.871593, -122.272743},
+		{"Irvine", 33.684567, -117.826505},
+		{"Santa Cruz", 36.974117, -122.030796},
+		{"Palm Springs", 33.830296, -116.545292},
+		{"Bakersfield", 35.373292, -119.018712},
+		{"Davis", 38.544907, -121.740517},
+		{"Riverside", 33.980601, -117.375494},
+		{"Lake Tahoe", 38.939926, -119.977186},
+		{"Mammoth Lakes", 37.648546, -118.972079},
+		{"Monterey", 36.600238, -121.894676},
+	}
+
+	seedFirstNames = []string{"Joe", "Josie", "Alex", "Sam", "Maria", "Kevin", "Priya", "Daniel", "Grace", "Omar", "Emily", "Wei", "Sofia", "Jordan", "Aisha", "Luis"}
+	seedLastNames  = []string{"Bruin", "Nguyen", "Garcia", "Kim", "Patel", "Smith", "Chen", "Lopez", "Johnson", "Park", "Rivera", "Cohen", "Singh", "Martinez"}
+
+	seedCarModels = map[string][]string{
+		"Toyota":     {"Prius", "Corolla", "Camry", "RAV4"},
+		"Honda":      {"Civic", "Accord", "CR-V", "Fit"},
+		"Tesla":      {"Model 3", "Model S", "Model Y"},
+		"Subaru":     {"Outback", "Forester", "Impreza"},
+		"Ford":       {"Focus", "Fusion", "Escape"},
+		"Hyundai":    {"Elantra", "Sonata"},
+		"Volkswagen": {"Jetta", "Golf"},
+	}
+	seedCarMakes  = []string{"Toyota", "Honda", "Tesla", "Subaru", "Ford", "Hyundai", "Volkswagen"}
+	seedCarColors = []string{"black", "white", "silver", "gray", "blue", "red", "green"}
+
+	seedRideInfos = []string{
+		"",
+		"leaving from Westwood",
+		"room for skis",
+		"stopping in Santa Barbara for lunch",
+		"no pets please",
+		"can pick up near campus",
+		"one small bag each",
+		"flexible on the departure time",
+		"good music guaranteed",
+	}
+
+	seedPassengerStatuses = []string{models.PassengerAccepted, models.PassengerInterested, models.PassengerRejected}
+)
+
+type (
+	// SeedService fills the stores with made up data for local development
+	SeedService struct {
+		users      UserStore
+		cars       CarStore
+		rides      RideStore
+		passengers PassengerStore
+		logger     interfaces.Logger
+	}
+
+	// SeedCounts is how many of each record are seeded
+	SeedCounts struct {
+		Users      int
+		Cars       int
+		Rides      int
+		Passengers int
+	}
+
+	// seeded is what has been seeded so far, which later records reference
+	seeded struct {
+		from       time.Time
+		users      []*models.User
+		existing   int
+		cars       []*models.Car
+		rides      []*models.Ride
+		passengers map[string]bool
+		seatsTaken map[string]int
+	}
+)
+
+// NewSeedService creates a new seed service
+func NewSeedService(users UserStore, cars CarStore, rides RideStore, passengers PassengerStore, l interfaces.Logger) *SeedService {
+	return &SeedService{
+		users:      users,
+		cars:       cars,
+		rides:      rides,
+		passengers: passengers,
+		logger:     l,
+	}
+}
+
+// Seed persists made up users, cars, rides between California cities and passengers
+// in every status, the same seed and from always make the same records apart from
+// their ids and timestamps, rides start within a month of from. Users that already
+// exist are reused, and nothing else is seeded when all of them do, so seeding again
+// does not duplicate the data. Fewer passengers than asked for are seeded when every
+// user is already on every ride. The seeded users are returned.
+func (s *SeedService) Seed(seed int64, from time.Time, counts SeedCounts) ([]*models.User, SeedCounts, error) {
+	if (counts.Cars > 0 && counts.Users < 1) ||
+		(counts.Rides > 0 && counts.Cars < 1) ||
+		(counts.Passengers > 0 && (counts.Users < 2 || counts.Rides < 1)) {
+		return nil, SeedCounts{}, ErrSeedNeedsUsers
+	}
+
+	random := rand.New(rand.NewSource(seed))
+	done := seeded{from: from, passengers: map[string]bool{}, seatsTaken: map[string]int{}}
+
+	steps := []struct {
+		name  string
+		count int
+		seed  func(random *rand.Rand, done *seeded, i int) error
+	}{
+		{"users", counts.Users, s.seedUser},
+		{"cars", counts.Cars, s.seedCar},
+		{"rides", counts.Rides, s.seedRide},
+	}
+
+	for _, step := range steps {
+		for i := 0; i < step.count; i++ {
+			if err := step.seed(random, &done, i); err != nil {
+				s.logger.Error("SeedService.Seed - unable to seed", "records", step.name, "error", err.Error())
+				return done.users, done.counts(), err
+			}
+		}
+
+		if step.name == "users" && counts.Users > 0 && done.existing == counts.Users {
+			s.logger.Info("SeedService.Seed - already seeded", "seed", seed)
+			return done.users, done.counts(), nil
+		}
+	}
+
+	// a passenger is tried a few times before giving up, since the users picked may
+	// already be on the ride picked
+	for i, misses := 0, 0; i < counts.Passengers && misses < 10*counts.Passengers; {
+		ok, err := s.seedPassenger(random, &done)
+		if err != nil {
+			s.logger.Error("SeedService.Seed - unable to seed", "records", "passengers", "error", err.Error())
+			return done.users, done.counts(), err
+		}
+
+		if ok {
+			i++
+		} else {
+			misses++
+		}
+	}
+
+	return done.users, done.counts(), nil
+}
+
+func (s *SeedService) seedUser(random *rand.Rand, done *seeded, i int) error {
+	first := seedFirstNames[random.Intn(len(seedFirstNames))]
+	last := seedLastNames[random.Intn(len(seedLastNames))]
+
+	user := &models.User{
+		FirstName: first,
+		LastName:  last,
+		// the index keeps emails unique however the names repeat
+		Email:        fmt.Sprintf("%s.%s%d@g.ucla.edu", strings.ToLower(first), strings.ToLower(last), i+1),
+		ProfileImage: fmt.Sprintf("https://i.pravatar.cc/150?u=%d", random.Int63()),
+	}
+
+	existing, err := s.users.GetByEmail(user.Email)
+	if err == nil {
+		done.users = append(done.users, existing)
+		done.existing++
+		return nil
+	}
+	if err != stores.ErrNoUserFound {
+		return err
+	}
+
+	if err := s.users.Insert(user); err != nil {
+		return err
+	}
+
+	done.users = append(done.users, user)
+	return nil
+}
+
+func (s *SeedService) seedCar(random *rand.Rand, done *seeded, i int) error {
+	carMake := seedCarMakes[random.Intn(len(seedCarMakes))]
+	carModels := seedCarModels[carMake]
+
+	car := &models.Car{
+		Make:   carMake,
+		Model:  carModels[random.Intn(len(carModels))],
+		Year:   done.from.Year() - random.Intn(15),
+		Color:  seedCarColors[random.Intn(len(seedCarColors))],
+		UserID: done.users[i%len(done.users)].ID,
+	}
+
+	if errs := car.Validate(); len(errs) > 0 {
+		return errs[0]
+	}
+
+	if err := s.cars.Insert(car); err != nil {
+		return err
+	}
+
+	done.cars = append(done.cars, car)
+	return nil
+}
+
+func (s *SeedService) seedRide(random *rand.Rand, done *seeded, i int) error {
+	car := done.cars[random.Intn(len(done.cars))]
+
+	start := seedCities[random.Intn(len(seedCities))]
+	end := seedCities[random.Intn(len(seedCities)-1)]
+	if end == start {
+		end = seedCities[len(seedCities)-1]
+	}
+
+	startLat, startLon := jitter(random, start)
+	endLat, endLon := jitter(random, end)
+
+	// rides leave on the quarter hour between a day and a month after from
+	quarters := 4*24 + random.Intn(4*24*29)
+	startDate := done.from.Truncate(15 * time.Minute).Add(time.Duration(quarters) * 15 * time.Minute)
+
+	// about 8 cents a km, to the nearest 50 cents and at least $5
+	price := math.Max(5, round(models.DistanceKm(startLat, startLon, endLat, endLon)*0.08, 2))
+
+	ride := &models.Ride{
+		DriverID:     car.UserID,
+		CarID:        car.ID,
+		Seats:        1 + random.Intn(4),
+		StartCity:    start.name,
+		EndCity:      end.name,
+		StartLat:     startLat,
+		StartLon:     startLon,
+		EndLat:       endLat,
+		EndLon:       endLon,
+		PricePerSeat: price,
+		Info:         seedRideInfos[random.Intn(len(seedRideInfos))],
+		Status:       models.RideScheduled,
+		StartDate:    startDate,
+	}
+
+	if err := ride.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.rides.Insert(ride); err != nil {
+		return err
+	}
+
+	done.rides = append(done.rides, ride)
+	return nil
+}
+
+// seedPassenger adds a user other than the driver to a ride, ok is false when the
+// user picked is already on the ride, passengers are only accepted while there are seats
+func (s *SeedService) seedPassenger(random *rand.Rand, done *seeded) (ok bool, err error) {
+	ride := done.rides[random.Intn(len(done.rides))]
+	user := done.users[random.Intn(len(done.users))]
+	status := seedPassengerStatuses[random.Intn(len(seedPassengerStatuses))]
+
+	key := ride.ID + "/" + user.ID
+	if user.ID == ride.DriverID || done.passengers[key] {
+		return false, nil
+	}
+
+	if status == models.PassengerAccepted && done.seatsTaken[ride.ID] >= ride.Seats {
+		status = models.PassengerInterested
+	}
+
+	passenger := &models.Passenger{
+		DriverID:       ride.DriverID,
+		PassengerID:    user.ID,
+		RideID:         ride.ID,
+		Status:         status,
+		SeatsRequested: 1,
+	}
+
+	if err := passenger.Validate(); err != nil {
+		return false, err
+	}
+
+	if err := s.passengers.Insert(passenger); err != nil {
+		return false, err
+	}
+
+	done.passengers[key] = true
+	if status == models.PassengerAccepted {
+		done.seatsTaken[ride.ID]++
+	}
+
+	return true, nil
+}
+
+func (d *seeded) counts() SeedCounts {
+	return SeedCounts{
+		Users:      len(d.users) - d.existing,
+		Cars:       len(d.cars),
+		Rides:      len(d.rides),
+		Passengers: len(d.passengers),
+	}
+}
+
+// jitter moves a point up to about 2km from the city's center, rounded to the
+// 6 decimal places coordinates are stored with
+func jitter(random *rand.Rand, city seedCity) (lat, lon float64) {
+	return round(city.lat+(random.Float64()-0.5)*0.04, 1e6), round(city.lon+(random.Float64()-0.5)*0.04, 1e6)
+}
+
+// round rounds v to the nearest 1/per
+func round(v, per float64) float64 {
+	return math.Floor(v*per+0.5) / per
+}
@@ -0,0 +1,146 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ucladevx/BPool/mocks"
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/stores/memory"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+type seededData struct {
+	users      []*models.User
+	cars       []*models.Car
+	rides      []*models.Ride
+	passengers []*models.Passenger
+}
+
+// seedFrom is when rides are seeded after, the same for every run so that their dates match
+var seedFrom = time.Now()
+
+func seedMemory(t *testing.T, seed int64, counts services.SeedCounts) (seededData, services.SeedCounts) {
+	return seedMemoryRuns(t, seed, counts, 1)
+}
+
+// seedMemoryRuns seeds the same memory db runs times and returns what is in it and
+// what the last run seeded
+func seedMemoryRuns(t *testing.T, seed int64, counts services.SeedCounts, runs int) (seededData, services.SeedCounts) {
+	db := memory.NewDB()
+	users, cars, rides, passengers := memory.NewUserStore(db), memory.NewCarStore(db), memory.NewRideStore(db), memory.NewPassengerStore(db)
+
+	var seeded services.SeedCounts
+	var err error
+	for i := 0; i < runs; i++ {
+		_, seeded, err = services.NewSeedService(users, cars, rides, passengers, mocks.Logger{}).Seed(seed, seedFrom, counts)
+		require.Nil(t, err)
+	}
+
+	all := pagination.Page{Sort: pagination.Sort{"created_at", "id"}, Limit: 1000}
+	data := seededData{}
+
+	data.users, err = users.GetAll(all)
+	require.Nil(t, err)
+	data.cars, err = cars.GetAll(all)
+	require.Nil(t, err)
+	data.rides, err = rides.GetAll(pagination.Page{Sort: pagination.ByID, Limit: 1000})
+	require.Nil(t, err)
+	data.passengers, err = passengers.GetAll(pagination.Page{Sort: pagination.ByID, Limit: 1000})
+	require.Nil(t, err)
+
+	return data, seeded
+}
+
+func TestSeed(t *testing.T) {
+	assert := assert.New(t)
+	counts := services.SeedCounts{Users: 8, Cars: 4, Rides: 10, Passengers: 20}
+
+	data, seeded := seedMemory(t, 7, counts)
+	assert.Equal(counts, seeded, "every record asked for should be seeded")
+	assert.Len(data.users, 8)
+	assert.Len(data.cars, 4)
+	assert.Len(data.rides, 10)
+	assert.Len(data.passengers, 20)
+
+	for _, car := range data.cars {
+		assert.Empty(car.Validate(), "seeded cars should be valid")
+	}
+
+	rides := map[string]*models.Ride{}
+	for _, ride := range data.rides {
+		rides[ride.ID] = ride
+		assert.Nil(ride.Validate(), "seeded rides should be valid and start in the future")
+		assert.NotEqual(ride.StartCity, ride.EndCity, "rides should go between different cities")
+		assert.True(ride.StartDate.Before(time.Now().Add(31*24*time.Hour)), "rides should start within a month")
+	}
+
+	statuses := map[string]int{}
+	accepted := map[string]int{}
+	for _, passenger := range data.passengers {
+		statuses[passenger.Status]++
+		assert.NotEqual(rides[passenger.RideID].DriverID, passenger.PassengerID, "drivers should not be passengers of their rides")
+		if passenger.Status == models.PassengerAccepted {
+			accepted[passenger.RideID]++
+		}
+	}
+
+	assert.Len(statuses, 3, "passengers should be seeded in every status")
+	for rideID, count := range accepted {
+		assert.True(count <= rides[rideID].Seats, "rides should not have more accepted passengers than seats")
+	}
+}
+
+func TestSeedIsDeterministic(t *testing.T) {
+	assert := assert.New(t)
+	counts := services.SeedCounts{Users: 5, Cars: 3, Rides: 6, Passengers: 8}
+
+	first, _ := seedMemory(t, 42, counts)
+	second, _ := seedMemory(t, 42, counts)
+	other, _ := seedMemory(t, 43, counts)
+
+	emails := func(data seededData) []string {
+		emails := []string{}
+		for _, user := range data.users {
+			emails = append(emails, user.FirstName+" "+user.LastName+" "+user.Email)
+		}
+		return emails
+	}
+
+	assert.Equal(emails(first), emails(second), "the same seed should make the same users")
+	assert.NotEqual(emails(first), emails(other), "another seed should make other users")
+
+	for i := range first.cars {
+		assert.Equal(first.cars[i].Make+first.cars[i].Model+first.cars[i].Color, second.cars[i].Make+second.cars[i].Model+second.cars[i].Color)
+	}
+
+	for i := range first.rides {
+		assert.Equal(first.rides[i].StartDate, second.rides[i].StartDate, "the same from should make the same dates")
+	}
+}
+
+func TestSeedTwice(t *testing.T) {
+	assert := assert.New(t)
+	counts := services.SeedCounts{Users: 5, Cars: 3, Rides: 6, Passengers: 8}
+
+	data, seeded := seedMemoryRuns(t, 42, counts, 2)
+	assert.Equal(services.SeedCounts{}, seeded, "seeding again should not seed anything")
+	assert.Len(data.users, 5)
+	assert.Len(data.cars, 3)
+	assert.Len(data.rides, 6)
+	assert.Len(data.passengers, 8)
+}
+
+func TestSeedNeedsUsers(t *testing.T) {
+	service := services.NewSeedService(new(mocks.UserStore), new(mocks.CarStore), new(mocks.RideStore), new(mocks.PassengerStore), mocks.Logger{})
+
+	_, _, err := service.Seed(1, seedFrom, services.SeedCounts{Cars: 1})
+	assert.Equal(t, services.ErrSeedNeedsUsers, err, "cars need users to own them")
+
+	_, _, err = service.Seed(1, seedFrom, services.SeedCounts{Users: 1, Cars: 1, Rides: 1, Passengers: 1})
+	assert.Equal(t, services.ErrSeedNeedsUsers, err, "passengers need a user besides the driver")
+}
@@ -0,0 +1,19 @@
+package services
+
+type (
+	// Tx is a unit of work, every store it returns shares the same transaction
+	Tx interface {
+		Rides() RideStore
+		RideSeries() RideSeriesStore
+		RideRequests() RideRequestStore
+		Passengers() PassengerStore
+		Audit() AuditStore
+		Outbox() OutboxStore
+	}
+
+	// Transactor runs units of work atomically, the changes made through the Tx are
+	// committed if fn returns nil and rolled back otherwise
+	Transactor interface {
+		WithTx(fn func(tx Tx) error) error
+	}
+)
@@ -3,8 +3,9 @@ package services
 import (
 	"github.com/ucladevx/BPool/interfaces"
 	"github.com/ucladevx/BPool/models"
-	"github.com/ucladevx/BPool/stores/postgres"
+	"github.com/ucladevx/BPool/stores"
 	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
 const (
@@ -14,6 +15,9 @@ const (
 	AdminLevel = 300
 )
 
+// userSortColumns are the columns users can be listed by, besides id
+var userSortColumns = []string{"created_at", "email"}
+
 type (
 	// UserService provides all use cases for users
 	UserService struct {
@@ -25,10 +29,11 @@ type (
 
 	// UserStore any store that allows for users to be persisted
 	UserStore interface {
-		GetAll(lastID string, limit int) ([]*models.User, error)
+		GetAll(page pagination.Page) ([]*models.User, error)
 		GetByID(id string) (*models.User, error)
 		GetByEmail(email string) (*models.User, error)
 		Insert(user *models.User) error
+		Import(user *models.User) error
 	}
 )
 
@@ -54,7 +59,7 @@ func (u *UserService) Login(token string) (string, error) {
 
 	// check if user exists
 	user, err := u.store.GetByEmail(googleUser.Email)
-	if err != nil && err != postgres.ErrNoUserFound {
+	if err != nil && err != stores.ErrNoUserFound {
 		return "", err
 	}
 
@@ -73,7 +78,11 @@ func (u *UserService) Login(token string) (string, error) {
 		}
 	}
 
-	// generate our own access_token
+	return u.Token(user)
+}
+
+// Token generates our own access token for the user
+func (u *UserService) Token(user *models.User) (string, error) {
 	claims := map[string]interface{}{
 		"id":         user.ID,
 		"email":      user.Email,
@@ -81,13 +90,7 @@ func (u *UserService) Login(token string) (string, error) {
 		"sub":        "access",
 	}
 
-	authToken, err := u.tokenizer.NewToken(claims)
-
-	if err != nil {
-		return "", err
-	}
-
-	return authToken, nil
+	return u.tokenizer.NewToken(claims)
 }
 
 // Get returns a user by ID
@@ -95,15 +98,26 @@ func (u *UserService) Get(id string) (*models.User, error) {
 	return u.store.GetByID(id)
 }
 
-// GetAll returns a page of users
-func (u *UserService) GetAll(lastID string, limit, userAuthLevel int) ([]*models.User, error) {
+// GetAll returns a page of users and the cursor of the next page, if there is one
+func (u *UserService) GetAll(page pagination.Page, userAuthLevel int) ([]*models.User, *pagination.Cursor, error) {
 	if userAuthLevel < AdminLevel {
-		return nil, ErrNotAllowed
+		return nil, nil, ErrNotAllowed
+	}
+
+	page = page.WithDefaults(pagination.ByID)
+	if err := page.Validate(userSortColumns...); err != nil {
+		return nil, nil, err
 	}
 
-	if limit <= 0 || limit > 100 {
-		limit = 15
+	users, err := u.store.GetAll(page.Peek())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	next, err := page.Trim(&users)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return u.store.GetAll(lastID, limit)
+	return users, next, nil
 }
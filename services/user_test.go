@@ -15,6 +15,7 @@ import (
 	"github.com/ucladevx/BPool/services"
 	"github.com/ucladevx/BPool/stores/postgres"
 	"github.com/ucladevx/BPool/utils/auth"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
 var (
@@ -138,14 +139,14 @@ func TestUserGetAll(t *testing.T) {
 	service := newUserService(store)
 	assert := assert.New(t)
 
-	noUsers, err := service.GetAll("", 15, services.UserLevel)
+	noUsers, _, err := service.GetAll(pagination.Page{Limit: 15}, services.UserLevel)
 	assert.Nil(noUsers, "when a user does not have the right auth level there should be no users")
 	assert.Equal(services.ErrNotAllowed, err, "when a user does not have the right auth level there should be a not allowed error")
 
 	badLimit := -1
-	store.On("GetAll", "", 15).Return([]*models.User{&johnDoe, &janeSmith}, nil)
+	store.On("GetAll", pagination.Page{Sort: pagination.ByID, Limit: 16}).Return([]*models.User{&johnDoe, &janeSmith}, nil)
 
-	users, err := service.GetAll("", badLimit, services.AdminLevel)
+	users, _, err := service.GetAll(pagination.Page{Limit: badLimit}, services.AdminLevel)
 
 	assert.Nil(err, "for a bad limit, should still return no error")
 	assert.Equal(2, len(users), "the returned users should have length 2")
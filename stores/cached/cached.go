@@ -0,0 +1,65 @@
+// Package cached wraps stores with read-through caches of the records looked up
+// by id the most, the wrapped stores invalidate what they change so that reads
+// after a write never see the old record
+package cached
+
+import (
+	"time"
+
+	"github.com/ucladevx/BPool/utils/cache"
+)
+
+// Caches are the caches the stores read through, a nil cache is not used
+type Caches struct {
+	Users cache.Cache
+	Cars  cache.Cache
+	Rides cache.Cache
+}
+
+// Stats returns the stats of each cache by name
+func (c Caches) Stats() map[string]cache.Stats {
+	stats := map[string]cache.Stats{}
+
+	for name, ch := range map[string]cache.Cache{"users": c.Users, "cars": c.Cars, "rides": c.Rides} {
+		if ch != nil {
+			stats[name] = ch.Stats()
+		}
+	}
+
+	return stats
+}
+
+// the copy helpers copy what a pointer field points to, records are cached and handed
+// out as copies so that callers changing them never change the cache
+
+func copyInt(v *int) *int {
+	if v == nil {
+		return nil
+	}
+	copied := *v
+	return &copied
+}
+
+func copyFloat(v *float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	copied := *v
+	return &copied
+}
+
+func copyString(v *string) *string {
+	if v == nil {
+		return nil
+	}
+	copied := *v
+	return &copied
+}
+
+func copyTime(v *time.Time) *time.Time {
+	if v == nil {
+		return nil
+	}
+	copied := *v
+	return &copied
+}
@@ -0,0 +1,97 @@
+package cached
+
+import (
+	"time"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/utils/cache"
+)
+
+// CarStore caches cars by id, removing a car removes its rides so the ride cache
+// is cleared as well
+type CarStore struct {
+	services.CarStore
+	cars  cache.Cache
+	rides cache.Cache
+}
+
+// NewCarStore wraps a car store with a cache of cars
+func NewCarStore(store services.CarStore, cars, rides cache.Cache) *CarStore {
+	return &CarStore{
+		CarStore: store,
+		cars:     cars,
+		rides:    rides,
+	}
+}
+
+// GetByID finds a car by id, reading through the cache
+func (c *CarStore) GetByID(id string) (*models.Car, error) {
+	if cachedCar, ok := c.cars.Get(id); ok {
+		return copyCar(cachedCar.(*models.Car)), nil
+	}
+
+	version := c.cars.Version()
+
+	car, err := c.CarStore.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cars.SetIfUnchanged(id, copyCar(car), version)
+
+	return car, nil
+}
+
+// Insert persists a car
+func (c *CarStore) Insert(car *models.Car) error {
+	err := c.CarStore.Insert(car)
+	c.cars.Delete(car.ID)
+
+	return err
+}
+
+// Import persists a car as it is
+func (c *CarStore) Import(car *models.Car) error {
+	err := c.CarStore.Import(car)
+	c.cars.Delete(car.ID)
+
+	return err
+}
+
+// Remove soft deletes a car along with its rides
+func (c *CarStore) Remove(id string) error {
+	err := c.CarStore.Remove(id)
+	c.cars.Delete(id)
+	c.rides.Clear()
+
+	return err
+}
+
+// Restore undoes the removal of a car along with its rides
+func (c *CarStore) Restore(id string) error {
+	err := c.CarStore.Restore(id)
+	c.cars.Delete(id)
+	c.rides.Clear()
+
+	return err
+}
+
+// Purge permanently deletes cars removed before the time given along with their rides
+func (c *CarStore) Purge(before time.Time) (int, error) {
+	count, err := c.CarStore.Purge(before)
+	if count > 0 {
+		c.cars.Clear()
+		c.rides.Clear()
+	}
+
+	return count, err
+}
+
+// copyCar copies a car along with what its pointer fields point to
+func copyCar(car *models.Car) *models.Car {
+	copied := *car
+	copied.DeletedAt = copyTime(car.DeletedAt)
+
+	return &copied
+}
@@ -0,0 +1,70 @@
+package cached
+
+import (
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/utils/cache"
+)
+
+// PassengerStore invalidates the cached rides whose seats taken its writes change,
+// passengers themselves are not cached
+type PassengerStore struct {
+	services.PassengerStore
+	rides cache.Cache
+}
+
+// NewPassengerStore wraps a passenger store so that it invalidates cached rides
+func NewPassengerStore(store services.PassengerStore, rides cache.Cache) *PassengerStore {
+	return &PassengerStore{
+		PassengerStore: store,
+		rides:          rides,
+	}
+}
+
+// Insert persists a passenger
+func (p *PassengerStore) Insert(passenger *models.Passenger) error {
+	err := p.PassengerStore.Insert(passenger)
+	p.rides.Delete(passenger.RideID)
+
+	return err
+}
+
+// Import persists a passenger as it is
+func (p *PassengerStore) Import(passenger *models.Passenger) error {
+	err := p.PassengerStore.Import(passenger)
+	p.rides.Delete(passenger.RideID)
+
+	return err
+}
+
+// Update updates a passenger
+func (p *PassengerStore) Update(passenger *models.Passenger) error {
+	err := p.PassengerStore.Update(passenger)
+	p.rides.Delete(passenger.RideID)
+
+	return err
+}
+
+// Delete soft deletes a passenger, the passenger is looked up first to find its
+// ride, every ride is invalidated if it cannot be
+func (p *PassengerStore) Delete(id string) error {
+	passenger, lookupErr := p.PassengerStore.GetByID(id)
+
+	err := p.PassengerStore.Delete(id)
+	if lookupErr != nil {
+		p.rides.Clear()
+	} else {
+		p.rides.Delete(passenger.RideID)
+	}
+
+	return err
+}
+
+// Restore undoes the deletion of a passenger, deleted passengers cannot be looked
+// up so every ride is invalidated
+func (p *PassengerStore) Restore(id string) error {
+	err := p.PassengerStore.Restore(id)
+	p.rides.Clear()
+
+	return err
+}
@@ -0,0 +1,111 @@
+package cached
+
+import (
+	"time"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/utils/cache"
+)
+
+// RideStore caches rides by id, along with the seats taken on them
+type RideStore struct {
+	services.RideStore
+	rides cache.Cache
+}
+
+// NewRideStore wraps a ride store with a cache of rides
+func NewRideStore(store services.RideStore, rides cache.Cache) *RideStore {
+	return &RideStore{
+		RideStore: store,
+		rides:     rides,
+	}
+}
+
+// GetByID finds a ride by id, reading through the cache
+func (r *RideStore) GetByID(id string) (*models.Ride, error) {
+	if cachedRide, ok := r.rides.Get(id); ok {
+		return copyRide(cachedRide.(*models.Ride)), nil
+	}
+
+	// the version is taken before the read so that a ride read before it changed
+	// is not cached after it was invalidated
+	version := r.rides.Version()
+
+	ride, err := r.RideStore.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.rides.SetIfUnchanged(id, copyRide(ride), version)
+
+	return ride, nil
+}
+
+// Insert persists a ride
+func (r *RideStore) Insert(ride *models.Ride) error {
+	err := r.RideStore.Insert(ride)
+	r.rides.Delete(ride.ID)
+
+	return err
+}
+
+// Import persists a ride as it is
+func (r *RideStore) Import(ride *models.Ride) error {
+	err := r.RideStore.Import(ride)
+	r.rides.Delete(ride.ID)
+
+	return err
+}
+
+// Update updates a ride
+func (r *RideStore) Update(ride *models.Ride) error {
+	err := r.RideStore.Update(ride)
+	r.rides.Delete(ride.ID)
+
+	return err
+}
+
+// Delete soft deletes a ride
+func (r *RideStore) Delete(id string) error {
+	err := r.RideStore.Delete(id)
+	r.rides.Delete(id)
+
+	return err
+}
+
+// Restore undoes the deletion of a ride
+func (r *RideStore) Restore(id string) error {
+	err := r.RideStore.Restore(id)
+	r.rides.Delete(id)
+
+	return err
+}
+
+// Purge permanently deletes rides deleted before the time given
+func (r *RideStore) Purge(before time.Time) (int, error) {
+	count, err := r.RideStore.Purge(before)
+	if count > 0 {
+		r.rides.Clear()
+	}
+
+	return count, err
+}
+
+// copyRide copies a ride along with what its pointer fields point to, so that changing
+// the copy never changes the cached ride
+func copyRide(ride *models.Ride) *models.Ride {
+	copied := *ride
+	copied.SeatsTaken = copyInt(ride.SeatsTaken)
+	copied.PassengerStatus = copyString(ride.PassengerStatus)
+	copied.Distance = copyFloat(ride.Distance)
+	copied.Rank = copyFloat(ride.Rank)
+	copied.SeriesID = copyString(ride.SeriesID)
+	copied.OccurrenceDate = copyString(ride.OccurrenceDate)
+	copied.CancelledAt = copyTime(ride.CancelledAt)
+	copied.CancelledBy = copyString(ride.CancelledBy)
+	copied.CancelReason = copyString(ride.CancelReason)
+	copied.DeletedAt = copyTime(ride.DeletedAt)
+
+	return &copied
+}
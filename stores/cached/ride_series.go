@@ -0,0 +1,33 @@
+package cached
+
+import (
+	"time"
+
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/utils/cache"
+)
+
+// RideSeriesStore leaves ride series uncached, purging a series unlinks its rides
+// so the ride cache is cleared when any are purged
+type RideSeriesStore struct {
+	services.RideSeriesStore
+	rides cache.Cache
+}
+
+// NewRideSeriesStore wraps a ride series store so that purges invalidate the rides of the series
+func NewRideSeriesStore(store services.RideSeriesStore, rides cache.Cache) *RideSeriesStore {
+	return &RideSeriesStore{
+		RideSeriesStore: store,
+		rides:           rides,
+	}
+}
+
+// Purge permanently deletes ride series deleted before the time given
+func (s *RideSeriesStore) Purge(before time.Time) (int, error) {
+	count, err := s.RideSeriesStore.Purge(before)
+	if count > 0 {
+		s.rides.Clear()
+	}
+
+	return count, err
+}
@@ -0,0 +1,168 @@
+package cached_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/stores/cached"
+	"github.com/ucladevx/BPool/stores/memory"
+	"github.com/ucladevx/BPool/stores/storetest"
+	"github.com/ucladevx/BPool/utils/cache"
+)
+
+func newStores() (storetest.Stores, cached.Caches) {
+	db := memory.NewDB()
+	caches := cached.Caches{
+		Users: cache.NewLRU(100, time.Minute),
+		Cars:  cache.NewLRU(100, time.Minute),
+		Rides: cache.NewLRU(100, time.Minute),
+	}
+
+	return storetest.Stores{
+		Users:      cached.NewUserStore(memory.NewUserStore(db), caches.Users),
+		Cars:       cached.NewCarStore(memory.NewCarStore(db), caches.Cars, caches.Rides),
+		Rides:      cached.NewRideStore(memory.NewRideStore(db), caches.Rides),
+		RideSeries: cached.NewRideSeriesStore(memory.NewRideSeriesStore(db), caches.Rides),
+		Requests:   memory.NewRideRequestStore(db),
+		Passengers: cached.NewPassengerStore(memory.NewPassengerStore(db), caches.Rides),
+		Audit:      memory.NewAuditStore(db),
+		Outbox:     memory.NewOutboxStore(db),
+		Transactor: cached.NewTransactor(memory.NewTransactor(db), caches.Rides),
+	}, caches
+}
+
+// the cached stores must keep the contract of the stores they wrap, every read
+// after a write in it would see a stale record if an invalidation were missing
+func TestStoreContract(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		s, _ := newStores()
+		return s
+	})
+}
+
+func TestRideInvalidation(t *testing.T) {
+	assert := assert.New(t)
+	s, caches := newStores()
+
+	user := models.User{FirstName: "Joe", LastName: "Bruin", Email: "joe@ucla.edu"}
+	require.Nil(t, s.Users.Insert(&user))
+	rider := models.User{FirstName: "Josie", LastName: "Bruin", Email: "josie@ucla.edu"}
+	require.Nil(t, s.Users.Insert(&rider))
+	car := models.Car{Make: "Toyota", Model: "Prius", Year: 2017, Color: "white", UserID: user.ID}
+	require.Nil(t, s.Cars.Insert(&car))
+	ride := models.Ride{DriverID: user.ID, CarID: car.ID, Seats: 3, StartCity: "Los Angeles", EndCity: "San Francisco", StartDate: time.Now().Add(24 * time.Hour)}
+	require.Nil(t, s.Rides.Insert(&ride))
+
+	found, err := s.Rides.GetByID(ride.ID)
+	require.Nil(t, err)
+	assert.Equal(0, *found.SeatsTaken)
+	found.Seats = 1
+
+	found, err = s.Rides.GetByID(ride.ID)
+	require.Nil(t, err)
+	assert.Equal(3, found.Seats, "changing a returned ride should not change the cached ride")
+	assert.Equal(cache.Stats{Hits: 1, Misses: 1, Size: 1}, caches.Rides.Stats())
+
+	err = s.Transactor.WithTx(func(tx services.Tx) error {
+		return tx.Passengers().Insert(&models.Passenger{DriverID: user.ID, PassengerID: rider.ID, RideID: ride.ID, Status: models.PassengerAccepted, SeatsRequested: 1})
+	})
+	require.Nil(t, err)
+
+	found, err = s.Rides.GetByID(ride.ID)
+	require.Nil(t, err)
+	assert.Equal(1, *found.SeatsTaken, "passengers added in a unit of work should invalidate their ride")
+
+	require.Nil(t, s.Cars.Remove(car.ID))
+	_, err = s.Rides.GetByID(ride.ID)
+	assert.NotNil(err, "removing a car should invalidate its rides")
+}
+
+// pausedRideStore reads rides, then waits to be resumed before returning them
+type pausedRideStore struct {
+	services.RideStore
+	read, resume chan struct{}
+}
+
+func (r *pausedRideStore) GetByID(id string) (*models.Ride, error) {
+	ride, err := r.RideStore.GetByID(id)
+	r.read <- struct{}{}
+	<-r.resume
+
+	return ride, err
+}
+
+func TestReadDuringWriteIsNotCached(t *testing.T) {
+	assert := assert.New(t)
+	db := memory.NewDB()
+	rides := cache.NewLRU(100, time.Minute)
+
+	user := models.User{FirstName: "Joe", LastName: "Bruin", Email: "joe@ucla.edu"}
+	require.Nil(t, memory.NewUserStore(db).Insert(&user))
+	car := models.Car{Make: "Toyota", Model: "Prius", Year: 2017, Color: "white", UserID: user.ID}
+	require.Nil(t, memory.NewCarStore(db).Insert(&car))
+	ride := models.Ride{DriverID: user.ID, CarID: car.ID, Seats: 3, StartCity: "Los Angeles", EndCity: "San Francisco", StartDate: time.Now().Add(24 * time.Hour)}
+	require.Nil(t, memory.NewRideStore(db).Insert(&ride))
+
+	paused := &pausedRideStore{RideStore: memory.NewRideStore(db), read: make(chan struct{}), resume: make(chan struct{})}
+	store := cached.NewRideStore(paused, rides)
+	transactor := cached.NewTransactor(memory.NewTransactor(db), rides)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		found, err := store.GetByID(ride.ID)
+		assert.Nil(err)
+		assert.Equal(3, found.Seats, "the read began before the write")
+	}()
+
+	// the ride is read before the write is committed but cached after it
+	<-paused.read
+	err := transactor.WithTx(func(tx services.Tx) error {
+		updated := ride
+		updated.Seats = 4
+		return tx.Rides().Update(&updated)
+	})
+	require.Nil(t, err)
+	close(paused.resume)
+	<-done
+
+	_, cachedRide := rides.Get(ride.ID)
+	assert.False(cachedRide, "a ride read before a committed write should not be cached after it")
+
+	go func() { <-paused.read }()
+	found, err := store.GetByID(ride.ID)
+	require.Nil(t, err)
+	assert.Equal(4, found.Seats)
+}
+
+func TestCachedRidesAreCopies(t *testing.T) {
+	assert := assert.New(t)
+	s, caches := newStores()
+
+	user := models.User{FirstName: "Joe", LastName: "Bruin", Email: "joe@ucla.edu"}
+	require.Nil(t, s.Users.Insert(&user))
+	car := models.Car{Make: "Toyota", Model: "Prius", Year: 2017, Color: "white", UserID: user.ID}
+	require.Nil(t, s.Cars.Insert(&car))
+	occurrence := "2030-01-01"
+	ride := models.Ride{DriverID: user.ID, CarID: car.ID, Seats: 3, StartCity: "Los Angeles", EndCity: "San Francisco", StartDate: time.Now().Add(24 * time.Hour), OccurrenceDate: &occurrence}
+	require.Nil(t, s.Rides.Insert(&ride))
+
+	_, err := s.Rides.GetByID(ride.ID)
+	require.Nil(t, err)
+
+	cachedRide, err := s.Rides.GetByID(ride.ID)
+	require.Nil(t, err)
+	*cachedRide.OccurrenceDate = "2031-01-01"
+	*cachedRide.SeatsTaken = 3
+
+	found, err := s.Rides.GetByID(ride.ID)
+	require.Nil(t, err)
+	assert.Equal("2030-01-01", *found.OccurrenceDate, "changing what a returned ride points to should not change the cached ride")
+	assert.Equal(0, *found.SeatsTaken)
+	assert.Equal(cache.Stats{Hits: 2, Misses: 1, Size: 1}, caches.Rides.Stats())
+}
@@ -0,0 +1,112 @@
+package cached
+
+import (
+	"sync"
+
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/utils/cache"
+)
+
+type (
+	// Transactor wraps a transactor so that the rides changed in a unit of work are
+	// invalidated once it is done, reads in the unit of work skip the cache. Reads
+	// outside of it that began before the invalidation are not cached, see
+	// cache.Cache.SetIfUnchanged, so they cannot cache a ride as it was before
+	Transactor struct {
+		services.Transactor
+		rides cache.Cache
+	}
+
+	// tx is a unit of work whose stores record the rides to invalidate
+	tx struct {
+		services.Tx
+		rides *pending
+	}
+
+	// pending is a cache that never hits and records the keys deleted from it, so
+	// that they can be deleted from the real cache after the unit of work commits
+	pending struct {
+		mu      sync.Mutex
+		keys    []string
+		cleared bool
+	}
+)
+
+// NewTransactor wraps a transactor so that the rides changed in units of work are invalidated
+func NewTransactor(t services.Transactor, rides cache.Cache) *Transactor {
+	return &Transactor{
+		Transactor: t,
+		rides:      rides,
+	}
+}
+
+// WithTx runs fn in a unit of work, invalidating the rides it changed afterwards
+// whether it was committed or rolled back
+func (t *Transactor) WithTx(fn func(tx services.Tx) error) error {
+	rides := &pending{}
+	defer rides.apply(t.rides)
+
+	return t.Transactor.WithTx(func(unit services.Tx) error {
+		return fn(&tx{Tx: unit, rides: rides})
+	})
+}
+
+// Rides returns a ride store for the unit of work
+func (t *tx) Rides() services.RideStore {
+	return NewRideStore(t.Tx.Rides(), t.rides)
+}
+
+// RideSeries returns a ride series store for the unit of work
+func (t *tx) RideSeries() services.RideSeriesStore {
+	return NewRideSeriesStore(t.Tx.RideSeries(), t.rides)
+}
+
+// Passengers returns a passenger store for the unit of work
+func (t *tx) Passengers() services.PassengerStore {
+	return NewPassengerStore(t.Tx.Passengers(), t.rides)
+}
+
+func (p *pending) Get(key string) (interface{}, bool) {
+	return nil, false
+}
+
+func (p *pending) Set(key string, value interface{}) {}
+
+func (p *pending) SetIfUnchanged(key string, value interface{}, version uint64) {}
+
+func (p *pending) Version() uint64 {
+	return 0
+}
+
+func (p *pending) Delete(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.keys = append(p.keys, key)
+}
+
+func (p *pending) Clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.cleared = true
+}
+
+func (p *pending) Stats() cache.Stats {
+	return cache.Stats{}
+}
+
+// apply deletes the recorded keys from c
+func (p *pending) apply(c cache.Cache) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cleared {
+		c.Clear()
+		return
+	}
+
+	for _, key := range p.keys {
+		c.Delete(key)
+	}
+}
@@ -0,0 +1,57 @@
+package cached
+
+import (
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/utils/cache"
+)
+
+// UserStore caches users by id
+type UserStore struct {
+	services.UserStore
+	users cache.Cache
+}
+
+// NewUserStore wraps a user store with a cache of users
+func NewUserStore(store services.UserStore, users cache.Cache) *UserStore {
+	return &UserStore{
+		UserStore: store,
+		users:     users,
+	}
+}
+
+// GetByID finds a user by id, reading through the cache
+func (u *UserStore) GetByID(id string) (*models.User, error) {
+	if cachedUser, ok := u.users.Get(id); ok {
+		user := *cachedUser.(*models.User)
+		return &user, nil
+	}
+
+	version := u.users.Version()
+
+	user, err := u.UserStore.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := *user
+	u.users.SetIfUnchanged(id, &stored, version)
+
+	return user, nil
+}
+
+// Insert persists a user
+func (u *UserStore) Insert(user *models.User) error {
+	err := u.UserStore.Insert(user)
+	u.users.Delete(user.ID)
+
+	return err
+}
+
+// Import persists a user as it is
+func (u *UserStore) Import(user *models.User) error {
+	err := u.UserStore.Import(user)
+	u.users.Delete(user.ID)
+
+	return err
+}
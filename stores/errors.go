@@ -0,0 +1,45 @@
+package stores
+
+import "errors"
+
+var (
+	// ErrNoUserFound error when no user in db
+	ErrNoUserFound = errors.New("no user found")
+
+	// ErrUserAlreadyExists error when a duplicate user insertion is attempted
+	ErrUserAlreadyExists = errors.New("user already exists")
+
+	// ErrInvalidCarEntry error when user submits invalid car object
+	ErrInvalidCarEntry = errors.New("invalid car entry")
+
+	// ErrNoCarFound error when no car is in db
+	ErrNoCarFound = errors.New("no car found")
+
+	// ErrNoRideFound error when no ride in db
+	ErrNoRideFound = errors.New("no ride found")
+
+	// ErrNoRideSeriesFound error when no ride series in db
+	ErrNoRideSeriesFound = errors.New("no ride series found")
+
+	// ErrNoRideRequestFound error when no ride request in db
+	ErrNoRideRequestFound = errors.New("no ride request found")
+
+	// ErrNoPassengerFound error when no passenger in db
+	ErrNoPassengerFound = errors.New("no passenger found")
+
+	// ErrAlreadyPassenger occurs when a user has already shown interest
+	ErrAlreadyPassenger = errors.New("user is already a passenger")
+
+	// ErrNoOutboxEventFound error when no outbox event in db
+	ErrNoOutboxEventFound = errors.New("no outbox event found")
+
+	// ErrDuplicateOccurrence occurs when a ride series already has a ride on the date,
+	// even a deleted one
+	ErrDuplicateOccurrence = errors.New("series already has a ride on that date")
+
+	// ErrDuplicateID occurs when a record is imported with the id of an existing record
+	ErrDuplicateID = errors.New("a record with that id already exists")
+
+	// ErrVersionConflict occurs when a record was changed since the version being updated was read
+	ErrVersionConflict = errors.New("record was changed by someone else, reload it and try again")
+)
@@ -1,5 +1,7 @@
 package stores
 
+import "github.com/ucladevx/BPool/utils/pagination"
+
 // QueryModifierOp is a enum type to indicate the modifer operation
 type QueryModifierOp = string
 
@@ -10,6 +12,33 @@ const (
 	NE  = "!="
 	GT  = ">"
 	GTE = ">="
+
+	// IN matches a column against a slice of values
+	IN = "IN"
+	// NOTIN excludes a slice of values
+	NOTIN = "NOT IN"
+	// ILIKE is a case insensitive SQL pattern match, % matches any run of characters and _ any one
+	ILIKE = "ILIKE"
+	// ISNULL matches columns without a value, it takes no value
+	ISNULL = "IS NULL"
+	// NOTNULL matches columns with a value, it takes no value
+	NOTNULL = "IS NOT NULL"
+	// BETWEEN matches an inclusive range, its value is a two element slice
+	BETWEEN = "BETWEEN"
+
+	// GROUP wraps the modifiers in its value in parentheses
+	GROUP = "()"
+	// ORDERBY sorts results by the column, its value is Asc or Desc
+	ORDERBY = "ORDER BY"
+	// LIMIT caps the number of results
+	LIMIT = "LIMIT"
+	// OFFSET skips results
+	OFFSET = "OFFSET"
+
+	// Asc sorts smallest first
+	Asc = "ASC"
+	// Desc sorts largest first
+	Desc = "DESC"
 )
 
 var (
@@ -33,3 +62,129 @@ func QueryMod(col string, operator QueryModifierOp, value interface{}) QueryModi
 		Value:    value,
 	}
 }
+
+// IsNull matches rows where the column has no value
+func IsNull(col string) QueryModifier {
+	return QueryMod(col, ISNULL, nil)
+}
+
+// NotNull matches rows where the column has a value
+func NotNull(col string) QueryModifier {
+	return QueryMod(col, NOTNULL, nil)
+}
+
+// Between matches rows where the column is in the inclusive range [low, high]
+func Between(col string, low, high interface{}) QueryModifier {
+	return QueryMod(col, BETWEEN, []interface{}{low, high})
+}
+
+// Group wraps modifiers in parentheses so they are evaluated together
+func Group(modifiers ...QueryModifier) QueryModifier {
+	return QueryMod("", GROUP, modifiers)
+}
+
+// OrderBy sorts results by the column in the direction given, Asc or Desc,
+// order bys are applied in the order they are given
+func OrderBy(col string, direction string) QueryModifier {
+	return QueryMod(col, ORDERBY, direction)
+}
+
+// Limit caps the number of results
+func Limit(n int) QueryModifier {
+	return QueryMod("", LIMIT, n)
+}
+
+// Offset skips the first n results
+func Offset(n int) QueryModifier {
+	return QueryMod("", OFFSET, n)
+}
+
+// IsOption reports whether the modifier changes ordering or paging rather than filtering
+func IsOption(modifier QueryModifier) bool {
+	switch modifier.Operator {
+	case ORDERBY, LIMIT, OFFSET:
+		return true
+	}
+
+	return false
+}
+
+// Filters returns only the modifiers that filter results, for queries like counts
+// where ordering and paging do not apply
+func Filters(modifiers []QueryModifier) []QueryModifier {
+	var filters []QueryModifier
+
+	for _, modifier := range modifiers {
+		if !IsOption(modifier) {
+			filters = append(filters, modifier)
+		}
+	}
+
+	return filters
+}
+
+// NotDeleted restricts modifiers to records that have not been soft deleted
+func NotDeleted(modifiers []QueryModifier) []QueryModifier {
+	result := []QueryModifier{IsNull("deleted_at")}
+
+	if filters := Filters(modifiers); len(filters) > 0 {
+		result = append(result, And, Group(filters...))
+	}
+
+	for _, modifier := range modifiers {
+		if IsOption(modifier) {
+			result = append(result, modifier)
+		}
+	}
+
+	return result
+}
+
+// Paginate adds the modifiers that select the page, the rows after the page's cursor
+// in the order of its sort, to modifiers
+func Paginate(modifiers []QueryModifier, page pagination.Page) []QueryModifier {
+	result := []QueryModifier{}
+
+	if filters := Filters(modifiers); len(filters) > 0 {
+		result = append(result, Group(filters...))
+	}
+
+	if after := page.After; after != nil {
+		if len(result) > 0 {
+			result = append(result, And)
+		}
+		result = append(result, Group(keyset(after)...))
+	}
+
+	for _, column := range page.Sort {
+		result = append(result, OrderBy(column, Asc))
+	}
+
+	if page.Limit > 0 {
+		result = append(result, Limit(page.Limit))
+	}
+
+	return result
+}
+
+// keyset matches the rows after the cursor, (a, b) > (x, y) is spelled out as
+// a > x OR (a = x AND b > y) since sqlite and the memory stores lack row comparisons
+func keyset(after *pagination.Cursor) []QueryModifier {
+	var modifiers []QueryModifier
+
+	for i, column := range after.Sort {
+		if i > 0 {
+			modifiers = append(modifiers, Or)
+		}
+
+		var group []QueryModifier
+		for j := 0; j < i; j++ {
+			group = append(group, QueryMod(after.Sort[j], EQ, after.Values[j]), And)
+		}
+		group = append(group, QueryMod(column, GT, after.Values[i]))
+
+		modifiers = append(modifiers, Group(group...))
+	}
+
+	return modifiers
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/id"
+)
+
+// auditColumns are the audit_events columns query modifiers may use, matching the sql stores
+var auditColumns = stores.Columns(
+	"id",
+	"actor_id",
+	"entity",
+	"entity_id",
+	"action",
+	"created_at",
+)
+
+// AuditStore persists audit events in memory, events are never updated or deleted
+type AuditStore struct {
+	db    *DB
+	idGen IDgen
+	inTx  bool // set for the stores of a transaction, which hold db.txMu already
+}
+
+// NewAuditStore creates a new memory audit store
+func NewAuditStore(db *DB) *AuditStore {
+	return &AuditStore{
+		db:    db,
+		idGen: id.New,
+	}
+}
+
+// Insert persists an audit event to the store
+func (a *AuditStore) Insert(event *models.AuditEvent) error {
+	defer a.db.writeLock(a.inTx)()
+
+	event.ID = a.idGen()
+	event.CreatedAt = a.db.now()
+
+	stored := *event
+	a.db.audit[event.ID] = &stored
+
+	return nil
+}
+
+// WhereMany provides a generic query interface to get many audit events
+func (a *AuditStore) WhereMany(clauses []stores.QueryModifier) ([]*models.AuditEvent, error) {
+	if err := validate(clauses, auditColumns); err != nil {
+		return nil, err
+	}
+
+	a.db.mu.RLock()
+	defer a.db.mu.RUnlock()
+
+	events := []*models.AuditEvent{}
+	for _, stored := range a.db.audit {
+		if matches(stored, clauses) {
+			event := *stored
+			events = append(events, &event)
+		}
+	}
+
+	start, end := arrange(events, clauses)
+	return events[start:end], nil
+}
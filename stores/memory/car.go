@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/id"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+// carColumns are the cars columns query modifiers may use, matching the sql stores
+var carColumns = stores.Columns(
+	"id",
+	"make",
+	"model",
+	"year",
+	"color",
+	"user_id",
+	"created_at",
+	"updated_at",
+	"deleted_at",
+)
+
+// CarStore persists cars in memory
+type CarStore struct {
+	db    *DB
+	idGen IDgen
+}
+
+// NewCarStore creates a new memory car store
+func NewCarStore(db *DB) *CarStore {
+	return &CarStore{
+		db:    db,
+		idGen: id.New,
+	}
+}
+
+// GetAll returns a page of cars in the page's sort order
+func (c *CarStore) GetAll(page pagination.Page) ([]*models.Car, error) {
+	clauses := stores.NotDeleted(stores.Paginate(nil, page))
+	if err := validate(clauses, carColumns); err != nil {
+		return nil, err
+	}
+
+	c.db.mu.RLock()
+	defer c.db.mu.RUnlock()
+
+	cars := []*models.Car{}
+	for _, stored := range c.db.cars {
+		if matches(stored, clauses) {
+			car := *stored
+			cars = append(cars, &car)
+		}
+	}
+
+	start, end := arrange(cars, clauses)
+	return cars[start:end], nil
+}
+
+// GetByID finds car by id if it exists in the store
+func (c *CarStore) GetByID(id string) (*models.Car, error) {
+	c.db.mu.RLock()
+	defer c.db.mu.RUnlock()
+
+	car, ok := c.db.cars[id]
+	if !ok || car.DeletedAt != nil {
+		return nil, stores.ErrNoCarFound
+	}
+
+	found := *car
+	return &found, nil
+}
+
+// GetCount gets the count of cars matching the query modifiers
+func (c *CarStore) GetCount(queryModifiers []stores.QueryModifier) (int, error) {
+	if err := validate(stores.Filters(queryModifiers), carColumns); err != nil {
+		return -1, err
+	}
+
+	c.db.mu.RLock()
+	defer c.db.mu.RUnlock()
+
+	count := 0
+	for _, car := range c.db.cars {
+		if matches(car, stores.NotDeleted(queryModifiers)) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Insert persists a car to the store
+func (c *CarStore) Insert(car *models.Car) error {
+	defer c.db.writeLock(false)()
+
+	car.ID = c.idGen()
+	car.CreatedAt = c.db.now()
+	car.UpdatedAt = car.CreatedAt
+
+	stored := *car
+	c.db.cars[car.ID] = &stored
+
+	return nil
+}
+
+// Import persists a car exactly as given, keeping its id and timestamps, its user
+// must already be stored
+func (c *CarStore) Import(car *models.Car) error {
+	defer c.db.writeLock(false)()
+
+	if _, ok := c.db.cars[car.ID]; ok {
+		return stores.ErrDuplicateID
+	}
+
+	if _, ok := c.db.users[car.UserID]; !ok {
+		return stores.ErrNoUserFound
+	}
+
+	stored := *car
+	c.db.cars[car.ID] = &stored
+
+	return nil
+}
+
+// Remove soft deletes the car along with its rides and their passengers
+func (c *CarStore) Remove(id string) error {
+	defer c.db.writeLock(false)()
+
+	c.db.deleteCar(id, c.db.now())
+
+	return nil
+}
+
+// Restore undoes the removal of a car, along with the rides and passengers removed with it
+func (c *CarStore) Restore(id string) error {
+	defer c.db.writeLock(false)()
+
+	if !c.db.restoreCar(id) {
+		return stores.ErrNoCarFound
+	}
+
+	return nil
+}
+
+// Purge permanently deletes cars removed before the time given
+func (c *CarStore) Purge(before time.Time) (int, error) {
+	defer c.db.writeLock(false)()
+
+	count := 0
+	for id, car := range c.db.cars {
+		if car.DeletedAt != nil && car.DeletedAt.Before(before) {
+			c.db.purgeCar(id)
+			count++
+		}
+	}
+
+	return count, nil
+}
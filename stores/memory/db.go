@@ -0,0 +1,336 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ucladevx/BPool/models"
+)
+
+// IDgen generates ids for new records
+type IDgen = func() string
+
+// DB holds every table for the memory stores, stores sharing a DB see each other's
+// records so that derived fields and cascading deletes work like they do in postgres
+type DB struct {
+	mu         sync.RWMutex
+	txMu       sync.Mutex
+	users      map[string]*models.User
+	cars       map[string]*models.Car
+	series     map[string]*models.RideSeries
+	requests   map[string]*models.RideRequest
+	rides      map[string]*models.Ride
+	passengers map[string]*models.Passenger
+	audit      map[string]*models.AuditEvent
+	outbox     map[string]*models.OutboxEvent
+	now        func() time.Time
+}
+
+// NewDB creates an empty in-memory database
+func NewDB() *DB {
+	return &DB{
+		users:      map[string]*models.User{},
+		cars:       map[string]*models.Car{},
+		series:     map[string]*models.RideSeries{},
+		requests:   map[string]*models.RideRequest{},
+		rides:      map[string]*models.Ride{},
+		passengers: map[string]*models.Passenger{},
+		audit:      map[string]*models.AuditEvent{},
+		outbox:     map[string]*models.OutboxEvent{},
+		now:        time.Now,
+	}
+}
+
+// writeLock takes the lock for a store write and returns its unlock. Writes outside of
+// a transaction wait for any running transaction first, so that rolling it back to the
+// snapshot taken when it began cannot undo them, the stores of a transaction hold
+// txMu already
+func (db *DB) writeLock(inTx bool) func() {
+	if !inTx {
+		db.txMu.Lock()
+	}
+	db.mu.Lock()
+
+	return func() {
+		db.mu.Unlock()
+		if !inTx {
+			db.txMu.Unlock()
+		}
+	}
+}
+
+// snapshot is a copy of every table in a DB
+type snapshot struct {
+	users      map[string]models.User
+	cars       map[string]models.Car
+	series     map[string]models.RideSeries
+	requests   map[string]models.RideRequest
+	rides      map[string]models.Ride
+	passengers map[string]models.Passenger
+	audit      map[string]models.AuditEvent
+	outbox     map[string]models.OutboxEvent
+}
+
+// snapshot copies every table so they can be restored if a transaction fails
+func (db *DB) snapshot() *snapshot {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	s := &snapshot{
+		users:      make(map[string]models.User, len(db.users)),
+		cars:       make(map[string]models.Car, len(db.cars)),
+		series:     make(map[string]models.RideSeries, len(db.series)),
+		requests:   make(map[string]models.RideRequest, len(db.requests)),
+		rides:      make(map[string]models.Ride, len(db.rides)),
+		passengers: make(map[string]models.Passenger, len(db.passengers)),
+		audit:      make(map[string]models.AuditEvent, len(db.audit)),
+		outbox:     make(map[string]models.OutboxEvent, len(db.outbox)),
+	}
+
+	for id, user := range db.users {
+		s.users[id] = *user
+	}
+	for id, car := range db.cars {
+		s.cars[id] = *car
+	}
+	for id, series := range db.series {
+		s.series[id] = *series
+	}
+	for id, request := range db.requests {
+		s.requests[id] = *request
+	}
+	for id, ride := range db.rides {
+		s.rides[id] = *ride
+	}
+	for id, passenger := range db.passengers {
+		s.passengers[id] = *passenger
+	}
+	for id, event := range db.audit {
+		s.audit[id] = *event
+	}
+	for id, event := range db.outbox {
+		s.outbox[id] = *event
+	}
+
+	return s
+}
+
+// restore replaces every table with the ones in the snapshot
+func (db *DB) restore(s *snapshot) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.users = make(map[string]*models.User, len(s.users))
+	for id, user := range s.users {
+		user := user
+		db.users[id] = &user
+	}
+
+	db.cars = make(map[string]*models.Car, len(s.cars))
+	for id, car := range s.cars {
+		car := car
+		db.cars[id] = &car
+	}
+
+	db.series = make(map[string]*models.RideSeries, len(s.series))
+	for id, series := range s.series {
+		series := series
+		db.series[id] = &series
+	}
+
+	db.requests = make(map[string]*models.RideRequest, len(s.requests))
+	for id, request := range s.requests {
+		request := request
+		db.requests[id] = &request
+	}
+
+	db.rides = make(map[string]*models.Ride, len(s.rides))
+	for id, ride := range s.rides {
+		ride := ride
+		db.rides[id] = &ride
+	}
+
+	db.passengers = make(map[string]*models.Passenger, len(s.passengers))
+	for id, passenger := range s.passengers {
+		passenger := passenger
+		db.passengers[id] = &passenger
+	}
+
+	db.audit = make(map[string]*models.AuditEvent, len(s.audit))
+	for id, event := range s.audit {
+		event := event
+		db.audit[id] = &event
+	}
+
+	db.outbox = make(map[string]*models.OutboxEvent, len(s.outbox))
+	for id, event := range s.outbox {
+		event := event
+		db.outbox[id] = &event
+	}
+}
+
+// deleteCar soft deletes a car along with its rides and their passengers, db.mu must be held
+func (db *DB) deleteCar(id string, at time.Time) {
+	car, ok := db.cars[id]
+	if !ok || car.DeletedAt != nil {
+		return
+	}
+
+	car.DeletedAt = &at
+
+	for _, ride := range db.rides {
+		if ride.CarID == id {
+			db.deleteRide(ride.ID, at)
+		}
+	}
+}
+
+// deleteRide soft deletes a ride and its passengers, db.mu must be held
+func (db *DB) deleteRide(id string, at time.Time) {
+	ride, ok := db.rides[id]
+	if !ok || ride.DeletedAt != nil {
+		return
+	}
+
+	ride.DeletedAt = &at
+
+	for _, passenger := range db.passengers {
+		if passenger.RideID == id && passenger.DeletedAt == nil {
+			passenger.DeletedAt = &at
+		}
+	}
+}
+
+// restoreCar undoes the deletion of a car and the rides deleted with it, db.mu must be held
+func (db *DB) restoreCar(id string) bool {
+	car, ok := db.cars[id]
+	if !ok || car.DeletedAt == nil {
+		return false
+	}
+
+	for _, ride := range db.rides {
+		if ride.CarID == id && sameTime(ride.DeletedAt, car.DeletedAt) {
+			db.restoreRide(ride.ID)
+		}
+	}
+
+	car.DeletedAt = nil
+
+	return true
+}
+
+// restoreRide undoes the deletion of a ride and the passengers deleted with it,
+// db.mu must be held
+func (db *DB) restoreRide(id string) bool {
+	ride, ok := db.rides[id]
+	if !ok || ride.DeletedAt == nil {
+		return false
+	}
+
+	for _, passenger := range db.passengers {
+		if passenger.RideID == id && sameTime(passenger.DeletedAt, ride.DeletedAt) {
+			passenger.DeletedAt = nil
+		}
+	}
+
+	ride.DeletedAt = nil
+
+	return true
+}
+
+// purgeCar permanently removes a car and everything that references it, db.mu must be held
+func (db *DB) purgeCar(id string) {
+	delete(db.cars, id)
+
+	for _, series := range db.series {
+		if series.CarID == id {
+			db.purgeSeries(series.ID)
+		}
+	}
+
+	for _, ride := range db.rides {
+		if ride.CarID == id {
+			db.purgeRide(ride.ID)
+		}
+	}
+}
+
+// purgeSeries permanently removes a ride series, its rides are kept but no longer
+// linked to it, db.mu must be held
+func (db *DB) purgeSeries(id string) {
+	delete(db.series, id)
+
+	for _, ride := range db.rides {
+		if ride.SeriesID != nil && *ride.SeriesID == id {
+			ride.SeriesID = nil
+		}
+	}
+}
+
+// purgeRequest permanently removes a ride request, the passengers offered rides
+// for it are kept but no longer linked to it, db.mu must be held
+func (db *DB) purgeRequest(id string) {
+	delete(db.requests, id)
+
+	for _, passenger := range db.passengers {
+		if passenger.RequestID != nil && *passenger.RequestID == id {
+			passenger.RequestID = nil
+		}
+	}
+}
+
+// purgeRide permanently removes a ride and its passengers, db.mu must be held
+func (db *DB) purgeRide(id string) {
+	delete(db.rides, id)
+
+	for _, passenger := range db.passengers {
+		if passenger.RideID == id {
+			delete(db.passengers, passenger.ID)
+		}
+	}
+}
+
+// liveCar reports whether the car exists and is not deleted, db.mu must be held
+func (db *DB) liveCar(id string) bool {
+	car, ok := db.cars[id]
+	return ok && car.DeletedAt == nil
+}
+
+// liveRide reports whether the ride exists and is not deleted, db.mu must be held
+func (db *DB) liveRide(id string) bool {
+	ride, ok := db.rides[id]
+	return ok && ride.DeletedAt == nil
+}
+
+// hasOccurrence reports whether the series already has a ride on the date, even a
+// deleted one, db.mu must be held
+func (db *DB) hasOccurrence(seriesID, date *string) bool {
+	if seriesID == nil || date == nil {
+		return false
+	}
+
+	for _, ride := range db.rides {
+		if ride.SeriesID != nil && ride.OccurrenceDate != nil && *ride.SeriesID == *seriesID && *ride.OccurrenceDate == *date {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sameTime(a, b *time.Time) bool {
+	return a != nil && b != nil && a.Equal(*b)
+}
+
+// seatsTaken adds up the seats held by the passengers of a ride, db.mu must be held
+func (db *DB) seatsTaken(rideID string) int {
+	seats := 0
+
+	for _, passenger := range db.passengers {
+		if passenger.RideID == rideID && passenger.HoldsSeat() && passenger.DeletedAt == nil {
+			seats += passenger.SeatsRequested
+		}
+	}
+
+	return seats
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/id"
+)
+
+// OutboxStore persists outbox events in memory
+type OutboxStore struct {
+	db    *DB
+	idGen IDgen
+	inTx  bool // set for the stores of a transaction, which hold db.txMu already
+}
+
+// NewOutboxStore creates a new memory outbox store
+func NewOutboxStore(db *DB) *OutboxStore {
+	return &OutboxStore{
+		db:    db,
+		idGen: id.New,
+	}
+}
+
+// Insert persists an outbox event to the store, it is due straight away
+func (o *OutboxStore) Insert(event *models.OutboxEvent) error {
+	defer o.db.writeLock(o.inTx)()
+
+	event.ID = o.idGen()
+	event.CreatedAt = o.db.now()
+	event.NextAttemptAt = &event.CreatedAt
+
+	stored := *event
+	o.db.outbox[event.ID] = &stored
+
+	return nil
+}
+
+// GetDue returns up to limit events due by now, oldest first
+func (o *OutboxStore) GetDue(now time.Time, limit int) ([]*models.OutboxEvent, error) {
+	o.db.mu.RLock()
+	defer o.db.mu.RUnlock()
+
+	return o.due(now, limit), nil
+}
+
+// due copies up to limit events due by now, oldest first, the caller holds db.mu
+func (o *OutboxStore) due(now time.Time, limit int) []*models.OutboxEvent {
+	events := []*models.OutboxEvent{}
+	for _, stored := range o.db.outbox {
+		if stored.NextAttemptAt != nil && !stored.NextAttemptAt.After(now) {
+			event := *stored
+			events = append(events, &event)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].CreatedAt.Equal(events[j].CreatedAt) {
+			return events[i].CreatedAt.Before(events[j].CreatedAt)
+		}
+		return events[i].ID < events[j].ID
+	})
+
+	if len(events) > limit {
+		events = events[:limit]
+	}
+
+	return events
+}
+
+// Claim returns up to limit events due by now, oldest first, and makes them due
+// again at until
+func (o *OutboxStore) Claim(now, until time.Time, limit int) ([]*models.OutboxEvent, error) {
+	defer o.db.writeLock(o.inTx)()
+
+	events := o.due(now, limit)
+	for _, event := range events {
+		claimed := until
+		o.db.outbox[event.ID].NextAttemptAt = &claimed
+		event.NextAttemptAt = &claimed
+	}
+
+	return events, nil
+}
+
+// Update saves the delivery state of an outbox event
+func (o *OutboxStore) Update(event *models.OutboxEvent) error {
+	defer o.db.writeLock(o.inTx)()
+
+	stored, ok := o.db.outbox[event.ID]
+	if !ok {
+		return stores.ErrNoOutboxEventFound
+	}
+
+	stored.Attempts = event.Attempts
+	stored.LastError = event.LastError
+	stored.NextAttemptAt = event.NextAttemptAt
+	stored.DeliveredAt = event.DeliveredAt
+
+	return nil
+}
+
+// Purge permanently deletes events delivered before the time given, along with the
+// events created before it that were given up on
+func (o *OutboxStore) Purge(before time.Time) (int, error) {
+	defer o.db.writeLock(o.inTx)()
+
+	count := 0
+	for id, event := range o.db.outbox {
+		delivered := event.DeliveredAt != nil && event.DeliveredAt.Before(before)
+		givenUp := event.DeliveredAt == nil && event.NextAttemptAt == nil && event.CreatedAt.Before(before)
+
+		if delivered || givenUp {
+			delete(o.db.outbox, id)
+			count++
+		}
+	}
+
+	return count, nil
+}
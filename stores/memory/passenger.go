@@ -0,0 +1,264 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/id"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+// passengerColumns are the passengers columns query modifiers may use, matching the sql stores
+var passengerColumns = stores.Columns(
+	"id",
+	"driver_id",
+	"passenger_id",
+	"ride_id",
+	"status",
+	"seats_requested",
+	"request_id",
+	"confirm_by",
+	"created_at",
+	"updated_at",
+	"deleted_at",
+	"version",
+)
+
+// PassengerStore persists passengers in memory
+type PassengerStore struct {
+	db    *DB
+	idGen IDgen
+	inTx  bool // set for the stores of a transaction, which hold db.txMu already
+}
+
+// NewPassengerStore creates a new memory passenger store
+func NewPassengerStore(db *DB) *PassengerStore {
+	return &PassengerStore{
+		db:    db,
+		idGen: id.New,
+	}
+}
+
+// GetAll returns a page of passengers in the page's sort order
+func (p *PassengerStore) GetAll(page pagination.Page) ([]*models.Passenger, error) {
+	return p.WhereMany(stores.Paginate(nil, page))
+}
+
+// GetByID finds a passenger by ID if exits in the store
+func (p *PassengerStore) GetByID(id string) (*models.Passenger, error) {
+	p.db.mu.RLock()
+	defer p.db.mu.RUnlock()
+
+	stored, ok := p.db.passengers[id]
+	if !ok || stored.DeletedAt != nil {
+		return nil, stores.ErrNoPassengerFound
+	}
+
+	passenger := *stored
+	return &passenger, nil
+}
+
+// Insert persists a passenger to the store
+func (p *PassengerStore) Insert(passenger *models.Passenger) error {
+	defer p.db.writeLock(p.inTx)()
+
+	for _, existing := range p.db.passengers {
+		if existing.DeletedAt == nil &&
+			existing.DriverID == passenger.DriverID &&
+			existing.PassengerID == passenger.PassengerID &&
+			existing.RideID == passenger.RideID {
+			return stores.ErrAlreadyPassenger
+		}
+	}
+
+	passenger.ID = p.idGen()
+	passenger.CreatedAt = p.db.now()
+	passenger.UpdatedAt = passenger.CreatedAt
+	passenger.Version = 1
+
+	stored := *passenger
+	p.db.passengers[passenger.ID] = &stored
+
+	return nil
+}
+
+// Import persists a passenger exactly as given, keeping its id, timestamps and version,
+// its users and ride must already be stored
+func (p *PassengerStore) Import(passenger *models.Passenger) error {
+	defer p.db.writeLock(p.inTx)()
+
+	if _, ok := p.db.passengers[passenger.ID]; ok {
+		return stores.ErrDuplicateID
+	}
+
+	for _, userID := range []string{passenger.DriverID, passenger.PassengerID} {
+		if _, ok := p.db.users[userID]; !ok {
+			return stores.ErrNoUserFound
+		}
+	}
+
+	if _, ok := p.db.rides[passenger.RideID]; !ok {
+		return stores.ErrNoRideFound
+	}
+
+	if passenger.RequestID != nil {
+		if _, ok := p.db.requests[*passenger.RequestID]; !ok {
+			return stores.ErrNoRideRequestFound
+		}
+	}
+
+	stored := *passenger
+	p.db.passengers[passenger.ID] = &stored
+
+	return nil
+}
+
+// Update persists the updates for the given passenger if it is still at the passenger's
+// version, returning stores.ErrVersionConflict if it is not
+func (p *PassengerStore) Update(passenger *models.Passenger) error {
+	defer p.db.writeLock(p.inTx)()
+
+	stored, ok := p.db.passengers[passenger.ID]
+	if !ok || stored.DeletedAt != nil {
+		return stores.ErrNoPassengerFound
+	}
+
+	if stored.Version != passenger.Version {
+		return stores.ErrVersionConflict
+	}
+
+	stored.Status = passenger.Status
+	stored.SeatsRequested = passenger.SeatsRequested
+	stored.ConfirmBy = passenger.ConfirmBy
+	stored.UpdatedAt = p.db.now()
+	stored.Version++
+
+	passenger.UpdatedAt = stored.UpdatedAt
+	passenger.Version = stored.Version
+
+	return nil
+}
+
+// Delete soft deletes the passenger, does no verification
+func (p *PassengerStore) Delete(id string) error {
+	defer p.db.writeLock(p.inTx)()
+
+	if passenger, ok := p.db.passengers[id]; ok && passenger.DeletedAt == nil {
+		deletedAt := p.db.now()
+		passenger.DeletedAt = &deletedAt
+	}
+
+	return nil
+}
+
+// Restore undoes the deletion of a passenger whose ride has not been deleted
+func (p *PassengerStore) Restore(id string) error {
+	defer p.db.writeLock(p.inTx)()
+
+	passenger, ok := p.db.passengers[id]
+	if !ok || passenger.DeletedAt == nil || !p.db.liveRide(passenger.RideID) {
+		return stores.ErrNoPassengerFound
+	}
+
+	for _, existing := range p.db.passengers {
+		if existing.DeletedAt == nil &&
+			existing.DriverID == passenger.DriverID &&
+			existing.PassengerID == passenger.PassengerID &&
+			existing.RideID == passenger.RideID {
+			return stores.ErrAlreadyPassenger
+		}
+	}
+
+	passenger.DeletedAt = nil
+
+	return nil
+}
+
+// Purge permanently deletes passengers deleted before the time given
+func (p *PassengerStore) Purge(before time.Time) (int, error) {
+	defer p.db.writeLock(p.inTx)()
+
+	count := 0
+	for id, passenger := range p.db.passengers {
+		if passenger.DeletedAt != nil && passenger.DeletedAt.Before(before) {
+			delete(p.db.passengers, id)
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Count determines the number of passengers in the store that fit the where clauses
+func (p *PassengerStore) Count(clauses []stores.QueryModifier) (int, error) {
+	if err := validate(stores.Filters(clauses), passengerColumns); err != nil {
+		return 0, err
+	}
+
+	p.db.mu.RLock()
+	defer p.db.mu.RUnlock()
+
+	count := 0
+	for _, passenger := range p.db.passengers {
+		if matches(passenger, stores.NotDeleted(clauses)) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// SumSeats adds up the seats requested by the passengers that fit the where clauses
+func (p *PassengerStore) SumSeats(clauses []stores.QueryModifier) (int, error) {
+	if err := validate(stores.Filters(clauses), passengerColumns); err != nil {
+		return 0, err
+	}
+
+	p.db.mu.RLock()
+	defer p.db.mu.RUnlock()
+
+	seats := 0
+	for _, passenger := range p.db.passengers {
+		if matches(passenger, stores.NotDeleted(clauses)) {
+			seats += passenger.SeatsRequested
+		}
+	}
+
+	return seats, nil
+}
+
+// Where provides a generic query interface to get a single passenger
+func (p *PassengerStore) Where(clauses []stores.QueryModifier) (*models.Passenger, error) {
+	passengers, err := p.WhereMany(append(clauses[:len(clauses):len(clauses)], stores.Limit(1)))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(passengers) == 0 {
+		return nil, stores.ErrNoPassengerFound
+	}
+
+	return passengers[0], nil
+}
+
+// WhereMany provides a generic query interface to get many passengers
+func (p *PassengerStore) WhereMany(clauses []stores.QueryModifier) ([]*models.Passenger, error) {
+	if err := validate(clauses, passengerColumns); err != nil {
+		return nil, err
+	}
+
+	p.db.mu.RLock()
+	defer p.db.mu.RUnlock()
+
+	passengers := []*models.Passenger{}
+	for _, stored := range p.db.passengers {
+		if matches(stored, stores.NotDeleted(clauses)) {
+			passenger := *stored
+			passengers = append(passengers, &passenger)
+		}
+	}
+
+	start, end := arrange(passengers, clauses)
+	return passengers[start:end], nil
+}
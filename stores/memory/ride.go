@@ -0,0 +1,309 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/id"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+// rideColumns are the rides columns query modifiers may use, matching the sql stores
+var rideColumns = stores.Columns(
+	"id",
+	"driver_id",
+	"car_id",
+	"seats",
+	"start_city",
+	"end_city",
+	"start_dest_lat",
+	"start_dest_lon",
+	"end_dest_lat",
+	"end_dest_lon",
+	"price_per_seat",
+	"info",
+	"series_id",
+	"occurrence_date",
+	"status",
+	"cancelled_at",
+	"cancelled_by",
+	"cancel_reason",
+	"late_cancellation",
+	"start_date",
+	"created_at",
+	"updated_at",
+	"deleted_at",
+	"version",
+)
+
+// RideStore persists rides in memory
+type RideStore struct {
+	db    *DB
+	idGen IDgen
+	inTx  bool // set for the stores of a transaction, which hold db.txMu already
+}
+
+// NewRideStore creates a new memory ride store
+func NewRideStore(db *DB) *RideStore {
+	return &RideStore{
+		db:    db,
+		idGen: id.New,
+	}
+}
+
+// GetAll returns a page of rides in the page's sort order
+func (r *RideStore) GetAll(page pagination.Page) ([]*models.Ride, error) {
+	return r.WhereMany(stores.Paginate(nil, page))
+}
+
+// GetAllWherePassenger returns all rides that the user passed is a passenger
+func (r *RideStore) GetAllWherePassenger(passengerID string) ([]*models.Ride, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	rides := []*models.Ride{}
+	for _, passenger := range r.db.passengers {
+		if passenger.PassengerID != passengerID || passenger.DeletedAt != nil {
+			continue
+		}
+
+		stored, ok := r.db.rides[passenger.RideID]
+		if !ok || stored.DeletedAt != nil {
+			continue
+		}
+
+		ride := *stored
+		status := passenger.Status
+		ride.PassengerStatus = &status
+		rides = append(rides, &ride)
+	}
+
+	return rides, nil
+}
+
+// WhereMany provides a generic query interface to get many rides
+func (r *RideStore) WhereMany(clauses []stores.QueryModifier) ([]*models.Ride, error) {
+	if err := validate(clauses, rideColumns); err != nil {
+		return nil, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	rides := []*models.Ride{}
+	for _, stored := range r.db.rides {
+		if matches(stored, stores.NotDeleted(clauses)) {
+			ride := *stored
+			rides = append(rides, &ride)
+		}
+	}
+
+	start, end := arrange(rides, clauses)
+	return rides[start:end], nil
+}
+
+// Count determines the number of rides in the store that fit the where clauses
+func (r *RideStore) Count(clauses []stores.QueryModifier) (int, error) {
+	if err := validate(stores.Filters(clauses), rideColumns); err != nil {
+		return 0, err
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	count := 0
+	for _, ride := range r.db.rides {
+		if matches(ride, stores.NotDeleted(clauses)) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Search finds the rides that match the search in the search's sort order
+func (r *RideStore) Search(search *models.RideSearch) ([]*models.Ride, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	rides := []*models.Ride{}
+	for id, stored := range r.db.rides {
+		if stored.DeletedAt == nil {
+			ride := *stored
+			seatsTaken := r.db.seatsTaken(id)
+			ride.SeatsTaken = &seatsTaken
+			rides = append(rides, &ride)
+		}
+	}
+
+	return stores.SearchRides(rides, search), nil
+}
+
+// GetByID finds a ride by ID if exits in the store
+func (r *RideStore) GetByID(id string) (*models.Ride, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	stored, ok := r.db.rides[id]
+	if !ok || stored.DeletedAt != nil {
+		return nil, stores.ErrNoRideFound
+	}
+
+	ride := *stored
+	seatsTaken := r.db.seatsTaken(id)
+	ride.SeatsTaken = &seatsTaken
+
+	return &ride, nil
+}
+
+// GetByIDForUpdate finds a ride by ID, units of work already run one at a time
+// so it is the same as GetByID
+func (r *RideStore) GetByIDForUpdate(id string) (*models.Ride, error) {
+	return r.GetByID(id)
+}
+
+// Insert persists a ride to the store, rides without a status are scheduled
+func (r *RideStore) Insert(ride *models.Ride) error {
+	if ride.Status == "" {
+		ride.Status = models.RideScheduled
+	}
+
+	defer r.db.writeLock(r.inTx)()
+
+	if r.db.hasOccurrence(ride.SeriesID, ride.OccurrenceDate) {
+		return stores.ErrDuplicateOccurrence
+	}
+
+	ride.ID = r.idGen()
+	ride.CreatedAt = r.db.now()
+	ride.UpdatedAt = ride.CreatedAt
+	ride.Version = 1
+
+	stored := *ride
+	stored.SeatsTaken = nil
+	stored.PassengerStatus = nil
+	stored.Distance = nil
+	stored.Rank = nil
+	r.db.rides[ride.ID] = &stored
+
+	return nil
+}
+
+// Import persists a ride exactly as given, keeping its id, timestamps and version,
+// its driver and car must already be stored, rides exported before rides had
+// statuses are scheduled
+func (r *RideStore) Import(ride *models.Ride) error {
+	if ride.Status == "" {
+		ride.Status = models.RideScheduled
+	}
+
+	defer r.db.writeLock(r.inTx)()
+
+	if _, ok := r.db.rides[ride.ID]; ok {
+		return stores.ErrDuplicateID
+	}
+
+	if _, ok := r.db.users[ride.DriverID]; !ok {
+		return stores.ErrNoUserFound
+	}
+
+	if _, ok := r.db.cars[ride.CarID]; !ok {
+		return stores.ErrNoCarFound
+	}
+
+	if ride.SeriesID != nil {
+		if _, ok := r.db.series[*ride.SeriesID]; !ok {
+			return stores.ErrNoRideSeriesFound
+		}
+	}
+
+	if r.db.hasOccurrence(ride.SeriesID, ride.OccurrenceDate) {
+		return stores.ErrDuplicateOccurrence
+	}
+
+	stored := *ride
+	stored.SeatsTaken = nil
+	stored.PassengerStatus = nil
+	stored.Distance = nil
+	stored.Rank = nil
+	r.db.rides[ride.ID] = &stored
+
+	return nil
+}
+
+// Update persists the updates for the given ride if it is still at the ride's version,
+// returning stores.ErrVersionConflict if it is not
+func (r *RideStore) Update(ride *models.Ride) error {
+	defer r.db.writeLock(r.inTx)()
+
+	stored, ok := r.db.rides[ride.ID]
+	if !ok || stored.DeletedAt != nil {
+		return stores.ErrNoRideFound
+	}
+
+	if stored.Version != ride.Version {
+		return stores.ErrVersionConflict
+	}
+
+	stored.CarID = ride.CarID
+	stored.Seats = ride.Seats
+	stored.StartCity = ride.StartCity
+	stored.EndCity = ride.EndCity
+	stored.StartLat = ride.StartLat
+	stored.StartLon = ride.StartLon
+	stored.EndLat = ride.EndLat
+	stored.EndLon = ride.EndLon
+	stored.PricePerSeat = ride.PricePerSeat
+	stored.Info = ride.Info
+	stored.Status = ride.Status
+	stored.CancelledAt = ride.CancelledAt
+	stored.CancelledBy = ride.CancelledBy
+	stored.CancelReason = ride.CancelReason
+	stored.LateCancellation = ride.LateCancellation
+	stored.StartDate = ride.StartDate
+	stored.UpdatedAt = r.db.now()
+	stored.Version++
+
+	ride.UpdatedAt = stored.UpdatedAt
+	ride.Version = stored.Version
+
+	return nil
+}
+
+// Delete soft deletes the ride and its passengers, does no verification
+func (r *RideStore) Delete(id string) error {
+	defer r.db.writeLock(r.inTx)()
+
+	r.db.deleteRide(id, r.db.now())
+
+	return nil
+}
+
+// Restore undoes the deletion of a ride and the passengers deleted with it, the
+// ride's car must not be deleted
+func (r *RideStore) Restore(id string) error {
+	defer r.db.writeLock(r.inTx)()
+
+	ride, ok := r.db.rides[id]
+	if !ok || !r.db.liveCar(ride.CarID) || !r.db.restoreRide(id) {
+		return stores.ErrNoRideFound
+	}
+
+	return nil
+}
+
+// Purge permanently deletes rides deleted before the time given
+func (r *RideStore) Purge(before time.Time) (int, error) {
+	defer r.db.writeLock(r.inTx)()
+
+	count := 0
+	for id, ride := range r.db.rides {
+		if ride.DeletedAt != nil && ride.DeletedAt.Before(before) {
+			r.db.purgeRide(id)
+			count++
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,180 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/id"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+// rideRequestColumns are the ride_requests columns query modifiers may use, matching the sql stores
+var rideRequestColumns = stores.Columns(
+	"id",
+	"passenger_id",
+	"start_city",
+	"end_city",
+	"earliest_start",
+	"latest_start",
+	"seats",
+	"max_price",
+	"status",
+	"created_at",
+	"updated_at",
+	"deleted_at",
+	"version",
+)
+
+// RideRequestStore persists ride requests in memory
+type RideRequestStore struct {
+	db    *DB
+	idGen IDgen
+	inTx  bool // set for the stores of a transaction, which hold db.txMu already
+}
+
+// NewRideRequestStore creates a new memory ride request store
+func NewRideRequestStore(db *DB) *RideRequestStore {
+	return &RideRequestStore{
+		db:    db,
+		idGen: id.New,
+	}
+}
+
+// GetAll returns a page of ride requests in the page's sort order
+func (s *RideRequestStore) GetAll(page pagination.Page) ([]*models.RideRequest, error) {
+	return s.WhereMany(stores.Paginate(nil, page))
+}
+
+// WhereMany provides a generic query interface to get many ride requests
+func (s *RideRequestStore) WhereMany(clauses []stores.QueryModifier) ([]*models.RideRequest, error) {
+	if err := validate(clauses, rideRequestColumns); err != nil {
+		return nil, err
+	}
+
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	all := []*models.RideRequest{}
+	for _, stored := range s.db.requests {
+		if matches(stored, stores.NotDeleted(clauses)) {
+			request := *stored
+			all = append(all, &request)
+		}
+	}
+
+	start, end := arrange(all, clauses)
+	return all[start:end], nil
+}
+
+// GetByID finds a ride request by ID if it exists in the store
+func (s *RideRequestStore) GetByID(id string) (*models.RideRequest, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	stored, ok := s.db.requests[id]
+	if !ok || stored.DeletedAt != nil {
+		return nil, stores.ErrNoRideRequestFound
+	}
+
+	request := *stored
+	return &request, nil
+}
+
+// Insert persists a ride request to the store
+func (s *RideRequestStore) Insert(request *models.RideRequest) error {
+	defer s.db.writeLock(s.inTx)()
+
+	request.ID = s.idGen()
+	request.CreatedAt = s.db.now()
+	request.UpdatedAt = request.CreatedAt
+	request.Version = 1
+
+	stored := *request
+	s.db.requests[request.ID] = &stored
+
+	return nil
+}
+
+// Import persists a ride request exactly as given, keeping its id, timestamps and
+// version, its passenger must already be stored
+func (s *RideRequestStore) Import(request *models.RideRequest) error {
+	defer s.db.writeLock(s.inTx)()
+
+	if _, ok := s.db.requests[request.ID]; ok {
+		return stores.ErrDuplicateID
+	}
+
+	if _, ok := s.db.users[request.PassengerID]; !ok {
+		return stores.ErrNoUserFound
+	}
+
+	stored := *request
+	s.db.requests[request.ID] = &stored
+
+	return nil
+}
+
+// Update persists the updates for the given ride request if it is still at the
+// request's version, returning stores.ErrVersionConflict if it is not
+func (s *RideRequestStore) Update(request *models.RideRequest) error {
+	defer s.db.writeLock(s.inTx)()
+
+	stored, ok := s.db.requests[request.ID]
+	if !ok || stored.DeletedAt != nil {
+		return stores.ErrNoRideRequestFound
+	}
+
+	if stored.Version != request.Version {
+		return stores.ErrVersionConflict
+	}
+
+	stored.StartCity = request.StartCity
+	stored.EndCity = request.EndCity
+	stored.StartLat = request.StartLat
+	stored.StartLon = request.StartLon
+	stored.EndLat = request.EndLat
+	stored.EndLon = request.EndLon
+	stored.EarliestStart = request.EarliestStart
+	stored.LatestStart = request.LatestStart
+	stored.Seats = request.Seats
+	stored.MaxPrice = request.MaxPrice
+	stored.Info = request.Info
+	stored.Status = request.Status
+	stored.UpdatedAt = s.db.now()
+	stored.Version++
+
+	request.UpdatedAt = stored.UpdatedAt
+	request.Version = stored.Version
+
+	return nil
+}
+
+// Delete soft deletes the ride request, the passengers offered rides for it are left alone
+func (s *RideRequestStore) Delete(id string) error {
+	defer s.db.writeLock(s.inTx)()
+
+	stored, ok := s.db.requests[id]
+	if ok && stored.DeletedAt == nil {
+		at := s.db.now()
+		stored.DeletedAt = &at
+	}
+
+	return nil
+}
+
+// Purge permanently deletes ride requests deleted before the time given, the
+// passengers offered rides for them are kept but no longer linked to them
+func (s *RideRequestStore) Purge(before time.Time) (int, error) {
+	defer s.db.writeLock(s.inTx)()
+
+	count := 0
+	for id, request := range s.db.requests {
+		if request.DeletedAt != nil && request.DeletedAt.Before(before) {
+			s.db.purgeRequest(id)
+			count++
+		}
+	}
+
+	return count, nil
+}
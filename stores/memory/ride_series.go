@@ -0,0 +1,233 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/id"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+// rideSeriesColumns are the ride_series columns query modifiers may use, matching the sql stores
+var rideSeriesColumns = stores.Columns(
+	"id",
+	"driver_id",
+	"car_id",
+	"seats",
+	"start_city",
+	"end_city",
+	"price_per_seat",
+	"days",
+	"departure_time",
+	"time_zone",
+	"starts_on",
+	"ends_on",
+	"materialized_through",
+	"created_at",
+	"updated_at",
+	"deleted_at",
+	"version",
+)
+
+// RideSeriesStore persists ride series in memory
+type RideSeriesStore struct {
+	db    *DB
+	idGen IDgen
+	inTx  bool // set for the stores of a transaction, which hold db.txMu already
+}
+
+// NewRideSeriesStore creates a new memory ride series store
+func NewRideSeriesStore(db *DB) *RideSeriesStore {
+	return &RideSeriesStore{
+		db:    db,
+		idGen: id.New,
+	}
+}
+
+// GetAll returns a page of ride series in the page's sort order
+func (s *RideSeriesStore) GetAll(page pagination.Page) ([]*models.RideSeries, error) {
+	return s.WhereMany(stores.Paginate(nil, page))
+}
+
+// WhereMany provides a generic query interface to get many ride series
+func (s *RideSeriesStore) WhereMany(clauses []stores.QueryModifier) ([]*models.RideSeries, error) {
+	if err := validate(clauses, rideSeriesColumns); err != nil {
+		return nil, err
+	}
+
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	all := []*models.RideSeries{}
+	for _, stored := range s.db.series {
+		if matches(stored, stores.NotDeleted(clauses)) {
+			series := *stored
+			all = append(all, &series)
+		}
+	}
+
+	start, end := arrange(all, clauses)
+	return all[start:end], nil
+}
+
+// GetByID finds a ride series by ID if it exists in the store
+func (s *RideSeriesStore) GetByID(id string) (*models.RideSeries, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	stored, ok := s.db.series[id]
+	if !ok || stored.DeletedAt != nil {
+		return nil, stores.ErrNoRideSeriesFound
+	}
+
+	series := *stored
+	return &series, nil
+}
+
+// GetActive returns the series whose car is not deleted that have not been
+// materialized through their last date
+func (s *RideSeriesStore) GetActive() ([]*models.RideSeries, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	active := []*models.RideSeries{}
+	for _, stored := range s.db.series {
+		if stored.DeletedAt != nil || !s.db.liveCar(stored.CarID) {
+			continue
+		}
+
+		if stored.MaterializedThrough != nil && *stored.MaterializedThrough >= stored.EndsOn {
+			continue
+		}
+
+		series := *stored
+		active = append(active, &series)
+	}
+
+	return active, nil
+}
+
+// Insert persists a ride series to the store
+func (s *RideSeriesStore) Insert(series *models.RideSeries) error {
+	defer s.db.writeLock(s.inTx)()
+
+	series.ID = s.idGen()
+	series.CreatedAt = s.db.now()
+	series.UpdatedAt = series.CreatedAt
+	series.Version = 1
+
+	stored := *series
+	s.db.series[series.ID] = &stored
+
+	return nil
+}
+
+// Import persists a ride series exactly as given, keeping its id, timestamps and
+// version, its driver and car must already be stored
+func (s *RideSeriesStore) Import(series *models.RideSeries) error {
+	defer s.db.writeLock(s.inTx)()
+
+	if _, ok := s.db.series[series.ID]; ok {
+		return stores.ErrDuplicateID
+	}
+
+	if _, ok := s.db.users[series.DriverID]; !ok {
+		return stores.ErrNoUserFound
+	}
+
+	if _, ok := s.db.cars[series.CarID]; !ok {
+		return stores.ErrNoCarFound
+	}
+
+	stored := *series
+	s.db.series[series.ID] = &stored
+
+	return nil
+}
+
+// Update persists the updates for the given ride series if it is still at the
+// series' version, returning stores.ErrVersionConflict if it is not
+func (s *RideSeriesStore) Update(series *models.RideSeries) error {
+	defer s.db.writeLock(s.inTx)()
+
+	stored, ok := s.db.series[series.ID]
+	if !ok || stored.DeletedAt != nil {
+		return stores.ErrNoRideSeriesFound
+	}
+
+	if stored.Version != series.Version {
+		return stores.ErrVersionConflict
+	}
+
+	stored.CarID = series.CarID
+	stored.Seats = series.Seats
+	stored.StartCity = series.StartCity
+	stored.EndCity = series.EndCity
+	stored.StartLat = series.StartLat
+	stored.StartLon = series.StartLon
+	stored.EndLat = series.EndLat
+	stored.EndLon = series.EndLon
+	stored.PricePerSeat = series.PricePerSeat
+	stored.Info = series.Info
+	stored.Days = series.Days
+	stored.DepartureTime = series.DepartureTime
+	stored.TimeZone = series.TimeZone
+	stored.StartsOn = series.StartsOn
+	stored.EndsOn = series.EndsOn
+	stored.UpdatedAt = s.db.now()
+	stored.Version++
+
+	series.UpdatedAt = stored.UpdatedAt
+	series.Version = stored.Version
+
+	return nil
+}
+
+// SetMaterializedThrough records the last date the series' rides have been
+// materialized for, it does not change the series' version
+func (s *RideSeriesStore) SetMaterializedThrough(id string, date *string) error {
+	defer s.db.writeLock(s.inTx)()
+
+	stored, ok := s.db.series[id]
+	if !ok || stored.DeletedAt != nil {
+		return stores.ErrNoRideSeriesFound
+	}
+
+	if date != nil {
+		through := *date
+		date = &through
+	}
+	stored.MaterializedThrough = date
+
+	return nil
+}
+
+// Delete soft deletes the ride series, its rides are left alone
+func (s *RideSeriesStore) Delete(id string) error {
+	defer s.db.writeLock(s.inTx)()
+
+	stored, ok := s.db.series[id]
+	if ok && stored.DeletedAt == nil {
+		at := s.db.now()
+		stored.DeletedAt = &at
+	}
+
+	return nil
+}
+
+// Purge permanently deletes ride series deleted before the time given, their rides
+// are kept but no longer linked to them
+func (s *RideSeriesStore) Purge(before time.Time) (int, error) {
+	defer s.db.writeLock(s.inTx)()
+
+	count := 0
+	for id, series := range s.db.series {
+		if series.DeletedAt != nil && series.DeletedAt.Before(before) {
+			s.db.purgeSeries(id)
+			count++
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,48 @@
+package memory_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/stores/memory"
+)
+
+func TestRideDerivedFields(t *testing.T) {
+	assert := assert.New(t)
+
+	db := memory.NewDB()
+	rides := memory.NewRideStore(db)
+	passengers := memory.NewPassengerStore(db)
+
+	ride := models.Ride{DriverID: "driver", CarID: "car", Seats: 3, StartDate: time.Now()}
+	assert.Nil(rides.Insert(&ride))
+	assert.NotEmpty(ride.ID, "the ride should have been given an id")
+
+	for i, status := range []string{models.PassengerAccepted, models.PassengerAccepted, models.PassengerInterested} {
+		p := models.Passenger{DriverID: "driver", PassengerID: string('a' + rune(i)), RideID: ride.ID, Status: status, SeatsRequested: 1}
+		assert.Nil(passengers.Insert(&p))
+	}
+
+	found, err := rides.GetByID(ride.ID)
+	assert.Nil(err, "there should be no error")
+	assert.NotNil(found.SeatsTaken, "seats taken should be set by GetByID")
+	assert.Equal(2, *found.SeatsTaken, "only accepted passengers take seats")
+
+	passengerRides, err := rides.GetAllWherePassenger("c")
+	assert.Nil(err, "there should be no error")
+	assert.Equal(1, len(passengerRides), "the passenger should have one ride")
+	assert.Equal(models.PassengerInterested, *passengerRides[0].PassengerStatus, "the passenger status should be set")
+
+	assert.Nil(rides.Delete(ride.ID))
+
+	count, err := passengers.Count([]stores.QueryModifier{stores.QueryMod("ride_id", stores.EQ, ride.ID)})
+	assert.Nil(err, "there should be no error")
+	assert.Equal(0, count, "deleting a ride should delete its passengers")
+
+	_, err = rides.GetByID(ride.ID)
+	assert.Equal(stores.ErrNoRideFound, err, "the ride should be gone")
+}
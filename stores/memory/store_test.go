@@ -0,0 +1,26 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/ucladevx/BPool/stores/memory"
+	"github.com/ucladevx/BPool/stores/storetest"
+)
+
+func TestStoreContract(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		db := memory.NewDB()
+
+		return storetest.Stores{
+			Users:      memory.NewUserStore(db),
+			Cars:       memory.NewCarStore(db),
+			Rides:      memory.NewRideStore(db),
+			RideSeries: memory.NewRideSeriesStore(db),
+			Requests:   memory.NewRideRequestStore(db),
+			Passengers: memory.NewPassengerStore(db),
+			Audit:      memory.NewAuditStore(db),
+			Outbox:     memory.NewOutboxStore(db),
+			Transactor: memory.NewTransactor(db),
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/utils/id"
+)
+
+type (
+	// Transactor runs units of work against a memory DB one at a time, rolling
+	// back by restoring a snapshot taken before the unit of work started, writes
+	// outside of a unit of work wait for it to end so a rollback only undoes its own
+	Transactor struct {
+		db *DB
+	}
+
+	// tx is a unit of work against a memory DB
+	tx struct {
+		rides      *RideStore
+		series     *RideSeriesStore
+		requests   *RideRequestStore
+		passengers *PassengerStore
+		audit      *AuditStore
+		outbox     *OutboxStore
+	}
+)
+
+// NewTransactor creates a new memory transactor
+func NewTransactor(db *DB) *Transactor {
+	return &Transactor{
+		db: db,
+	}
+}
+
+// WithTx runs fn while holding the DB's transaction lock, restoring the DB if it
+// returns an error, reads made outside of the transaction while fn runs see its writes
+func (t *Transactor) WithTx(fn func(tx services.Tx) error) error {
+	t.db.txMu.Lock()
+	defer t.db.txMu.Unlock()
+
+	s := t.db.snapshot()
+
+	defer func() {
+		if p := recover(); p != nil {
+			t.db.restore(s)
+			panic(p)
+		}
+	}()
+
+	unit := &tx{
+		rides:      &RideStore{db: t.db, idGen: id.New, inTx: true},
+		series:     &RideSeriesStore{db: t.db, idGen: id.New, inTx: true},
+		requests:   &RideRequestStore{db: t.db, idGen: id.New, inTx: true},
+		passengers: &PassengerStore{db: t.db, idGen: id.New, inTx: true},
+		audit:      &AuditStore{db: t.db, idGen: id.New, inTx: true},
+		outbox:     &OutboxStore{db: t.db, idGen: id.New, inTx: true},
+	}
+
+	if err := fn(unit); err != nil {
+		t.db.restore(s)
+		return err
+	}
+
+	return nil
+}
+
+// Rides returns a ride store for the unit of work
+func (t *tx) Rides() services.RideStore {
+	return t.rides
+}
+
+// RideSeries returns a ride series store for the unit of work
+func (t *tx) RideSeries() services.RideSeriesStore {
+	return t.series
+}
+
+// RideRequests returns a ride request store for the unit of work
+func (t *tx) RideRequests() services.RideRequestStore {
+	return t.requests
+}
+
+// Passengers returns a passenger store for the unit of work
+func (t *tx) Passengers() services.PassengerStore {
+	return t.passengers
+}
+
+// Audit returns an audit store for the unit of work
+func (t *tx) Audit() services.AuditStore {
+	return t.audit
+}
+
+// Outbox returns an outbox store for the unit of work
+func (t *tx) Outbox() services.OutboxStore {
+	return t.outbox
+}
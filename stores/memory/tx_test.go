@@ -0,0 +1,69 @@
+package memory_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/stores/memory"
+)
+
+func TestRollbackKeepsWritesOutsideTheTx(t *testing.T) {
+	assert := assert.New(t)
+
+	db := memory.NewDB()
+	outbox := memory.NewOutboxStore(db)
+	transactor := memory.NewTransactor(db)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	failed := errors.New("failed")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	var insideID string
+	go func() {
+		defer wg.Done()
+		err := transactor.WithTx(func(tx services.Tx) error {
+			event, err := models.NewOutboxEvent(models.EventRideCreated, "inside", nil)
+			require.Nil(t, err)
+			require.Nil(t, tx.Outbox().Insert(event))
+			insideID = event.ID
+
+			close(started)
+			<-release
+			return failed
+		})
+		assert.Equal(failed, err)
+	}()
+
+	<-started
+
+	outside, err := models.NewOutboxEvent(models.EventRideCreated, "outside", nil)
+	require.Nil(t, err)
+	go func() {
+		defer wg.Done()
+		assert.Nil(outbox.Insert(outside), "writes outside the tx should wait for it")
+	}()
+
+	// gives the outside write time to start waiting before the tx rolls back
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	events, err := outbox.GetDue(time.Now().Add(time.Second), 10)
+	require.Nil(t, err)
+
+	ids := map[string]bool{}
+	for _, event := range events {
+		ids[event.ID] = true
+	}
+	assert.True(ids[outside.ID], "rolling back the tx should keep writes made outside of it")
+	assert.False(ids[insideID], "rolling back the tx should undo its own writes")
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/id"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+// userColumns are the users columns query modifiers may use, matching the sql stores
+var userColumns = stores.Columns(
+	"id",
+	"first_name",
+	"last_name",
+	"email",
+	"profile_image",
+	"auth_level",
+	"created_at",
+	"updated_at",
+)
+
+// UserStore persists users in memory
+type UserStore struct {
+	db    *DB
+	idGen IDgen
+}
+
+// NewUserStore creates a new memory user store
+func NewUserStore(db *DB) *UserStore {
+	return &UserStore{
+		db:    db,
+		idGen: id.New,
+	}
+}
+
+// GetAll returns a page of users in the page's sort order
+func (u *UserStore) GetAll(page pagination.Page) ([]*models.User, error) {
+	clauses := stores.Paginate(nil, page)
+	if err := validate(clauses, userColumns); err != nil {
+		return nil, err
+	}
+
+	u.db.mu.RLock()
+	defer u.db.mu.RUnlock()
+
+	users := []*models.User{}
+	for _, stored := range u.db.users {
+		if matches(stored, clauses) {
+			user := *stored
+			users = append(users, &user)
+		}
+	}
+
+	start, end := arrange(users, clauses)
+	return users[start:end], nil
+}
+
+// GetByID finds a user by ID if exits in the store
+func (u *UserStore) GetByID(id string) (*models.User, error) {
+	return u.getBy(func(user *models.User) bool { return user.ID == id })
+}
+
+// GetByEmail finds a user by email if exits in the store
+func (u *UserStore) GetByEmail(email string) (*models.User, error) {
+	return u.getBy(func(user *models.User) bool { return user.Email == email })
+}
+
+// Insert persists a user to the store
+func (u *UserStore) Insert(user *models.User) error {
+	defer u.db.writeLock(false)()
+
+	for _, existing := range u.db.users {
+		if existing.Email == user.Email {
+			return stores.ErrUserAlreadyExists
+		}
+	}
+
+	user.ID = u.idGen()
+	user.AuthLevel = 0
+	user.CreatedAt = u.db.now()
+	user.UpdatedAt = user.CreatedAt
+
+	stored := *user
+	u.db.users[user.ID] = &stored
+
+	return nil
+}
+
+// Import persists a user exactly as given, keeping its id and timestamps
+func (u *UserStore) Import(user *models.User) error {
+	defer u.db.writeLock(false)()
+
+	if _, ok := u.db.users[user.ID]; ok {
+		return stores.ErrDuplicateID
+	}
+
+	for _, existing := range u.db.users {
+		if existing.Email == user.Email {
+			return stores.ErrUserAlreadyExists
+		}
+	}
+
+	stored := *user
+	u.db.users[user.ID] = &stored
+
+	return nil
+}
+
+func (u *UserStore) getBy(match func(*models.User) bool) (*models.User, error) {
+	u.db.mu.RLock()
+	defer u.db.mu.RUnlock()
+
+	for _, user := range u.db.users {
+		if match(user) {
+			found := *user
+			return &found, nil
+		}
+	}
+
+	return nil, stores.ErrNoUserFound
+}
@@ -0,0 +1,318 @@
+package memory
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ucladevx/BPool/stores"
+)
+
+// validate rejects the same malformed modifiers the sql stores do
+func validate(clauses []stores.QueryModifier, allowed stores.ColumnSet) error {
+	_, _, err := stores.BuildWhere(clauses, stores.Dialect{Placeholder: stores.QuestionPlaceholder}, allowed)
+	return err
+}
+
+// matches reports whether the record satisfies the clauses, evaluated with the same
+// precedence as the SQL the postgres stores generate: AND binds tighter than OR.
+// Ordering and paging options are ignored, see arrange.
+func matches(record interface{}, clauses []stores.QueryModifier) bool {
+	return evaluate(columns(record), stores.Filters(clauses))
+}
+
+func evaluate(cols map[string]interface{}, clauses []stores.QueryModifier) bool {
+	result := false
+	group := true
+
+	for _, clause := range clauses {
+		switch clause.Column {
+		case stores.Or.Column:
+			result = result || group
+			group = true
+			continue
+		case stores.And.Column:
+			continue
+		}
+
+		group = group && test(cols, clause)
+	}
+
+	return result || group
+}
+
+// test applies a single modifier to a record's columns
+func test(cols map[string]interface{}, clause stores.QueryModifier) bool {
+	if clause.Operator == stores.GROUP {
+		group, _ := clause.Value.([]stores.QueryModifier)
+		return evaluate(cols, group)
+	}
+
+	col := cols[clause.Column]
+
+	switch clause.Operator {
+	case stores.ISNULL:
+		return col == nil
+	case stores.NOTNULL:
+		return col != nil
+	case stores.IN, stores.NOTIN:
+		values, _ := stores.List(clause.Value)
+		if len(values) == 0 {
+			return clause.Operator == stores.NOTIN
+		}
+
+		if col == nil {
+			return false
+		}
+
+		found := false
+		for _, v := range values {
+			found = found || compare(col, stores.EQ, v)
+		}
+
+		return found == (clause.Operator == stores.IN)
+	case stores.BETWEEN:
+		values, _ := stores.List(clause.Value)
+		return compare(col, stores.GTE, values[0]) && compare(col, stores.LTE, values[1])
+	case stores.ILIKE:
+		s, ok := col.(string)
+		return ok && like(s, clause.Value.(string))
+	}
+
+	return compare(col, clause.Operator, clause.Value)
+}
+
+// like matches s against a SQL pattern ignoring case
+func like(s, pattern string) bool {
+	expr := "(?is)^"
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			expr += ".*"
+		case '_':
+			expr += "."
+		default:
+			expr += regexp.QuoteMeta(string(r))
+		}
+	}
+
+	return regexp.MustCompile(expr + "$").MatchString(s)
+}
+
+// arrange sorts records, a slice of models, by id and then by the clauses' order bys,
+// and returns the bounds of the page selected by their limit and offset
+func arrange(records interface{}, clauses []stores.QueryModifier) (int, int) {
+	rv := reflect.ValueOf(records)
+	n := rv.Len()
+
+	cols := make([]map[string]interface{}, n)
+	for i := range cols {
+		cols[i] = columns(rv.Index(i).Interface())
+	}
+
+	var orders []stores.QueryModifier
+	start, limit := 0, n
+
+	for _, clause := range clauses {
+		switch clause.Operator {
+		case stores.ORDERBY:
+			orders = append(orders, clause)
+		case stores.LIMIT:
+			limit = clause.Value.(int)
+		case stores.OFFSET:
+			start = clause.Value.(int)
+		}
+	}
+
+	orders = append(orders, stores.OrderBy("id", stores.Asc))
+
+	swap := reflect.Swapper(records)
+	sort.Sort(sorter{cols: cols, orders: orders, swap: swap})
+
+	if start > n {
+		start = n
+	}
+
+	if limit > n-start {
+		limit = n - start
+	}
+
+	return start, start + limit
+}
+
+type sorter struct {
+	cols   []map[string]interface{}
+	orders []stores.QueryModifier
+	swap   func(i, j int)
+}
+
+func (s sorter) Len() int {
+	return len(s.cols)
+}
+
+func (s sorter) Swap(i, j int) {
+	s.swap(i, j)
+	s.cols[i], s.cols[j] = s.cols[j], s.cols[i]
+}
+
+// Less orders nulls last when ascending and first when descending, as postgres does
+func (s sorter) Less(i, j int) bool {
+	for _, o := range s.orders {
+		a, b := s.cols[i][o.Column], s.cols[j][o.Column]
+
+		c := 0
+		switch {
+		case a == nil && b == nil:
+		case a == nil:
+			c = 1
+		case b == nil:
+			c = -1
+		default:
+			c, _ = order(a, b)
+		}
+
+		if o.Value == stores.Desc {
+			c = -c
+		}
+
+		if c != 0 {
+			return c < 0
+		}
+	}
+
+	return false
+}
+
+// columns maps a model's column names to its values the same way sqlx does
+func columns(record interface{}) map[string]interface{} {
+	v := reflect.Indirect(reflect.ValueOf(record))
+	t := v.Type()
+
+	cols := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("db")
+		if name == "" {
+			name = strings.ToLower(t.Field(i).Name)
+		}
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				cols[name] = nil
+				continue
+			}
+			field = field.Elem()
+		}
+
+		cols[name] = field.Interface()
+	}
+
+	return cols
+}
+
+// compare applies op to a column value and a query value
+func compare(a interface{}, op stores.QueryModifierOp, b interface{}) bool {
+	if a == nil || b == nil {
+		return false
+	}
+
+	c, ok := order(a, b)
+	if !ok {
+		return false
+	}
+
+	switch op {
+	case stores.EQ:
+		return c == 0
+	case stores.NE:
+		return c != 0
+	case stores.LT:
+		return c < 0
+	case stores.LTE:
+		return c <= 0
+	case stores.GT:
+		return c > 0
+	case stores.GTE:
+		return c >= 0
+	}
+
+	return false
+}
+
+// order returns -1, 0 or 1 as a is less than, equal to or greater than b, and
+// false when the two values cannot be compared
+func order(a, b interface{}) (int, bool) {
+	if af, ok := number(a); ok {
+		bf, ok := number(b)
+		if !ok {
+			return 0, false
+		}
+
+		return sign(af - bf), true
+	}
+
+	switch av := a.(type) {
+	case string:
+		bv, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+
+		return strings.Compare(av, bv), true
+	case bool:
+		bv, ok := b.(bool)
+		if !ok || av == bv {
+			return 0, ok
+		}
+
+		if bv {
+			return -1, true
+		}
+
+		return 1, true
+	case time.Time:
+		bv, ok := b.(time.Time)
+		if !ok {
+			return 0, false
+		}
+
+		switch {
+		case av.Before(bv):
+			return -1, true
+		case av.After(bv):
+			return 1, true
+		}
+
+		return 0, true
+	}
+
+	return 0, false
+}
+
+func number(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+
+	return 0, false
+}
+
+func sign(f float64) int {
+	switch {
+	case f < 0:
+		return -1
+	case f > 0:
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+)
+
+func TestMatches(t *testing.T) {
+	now := time.Now()
+	status := models.PassengerAccepted
+
+	ride := models.Ride{
+		ID:              "abc",
+		DriverID:        "123",
+		Seats:           3,
+		PricePerSeat:    15.5,
+		StartDate:       now,
+		PassengerStatus: &status,
+	}
+
+	tables := []struct {
+		name    string
+		clauses []stores.QueryModifier
+		result  bool
+	}{
+		{"no clauses", nil, true},
+		{"single arg", []stores.QueryModifier{stores.QueryMod("id", stores.EQ, "abc")}, true},
+		{"single arg mismatch", []stores.QueryModifier{stores.QueryMod("id", stores.EQ, "xyz")}, false},
+		{"two args with AND", []stores.QueryModifier{stores.QueryMod("id", stores.EQ, "abc"), stores.And, stores.QueryMod("driver_id", stores.NE, "123")}, false},
+		{"two args with OR", []stores.QueryModifier{stores.QueryMod("id", stores.EQ, "xyz"), stores.Or, stores.QueryMod("driver_id", stores.EQ, "123")}, true},
+		{"AND binds tighter than OR", []stores.QueryModifier{stores.QueryMod("id", stores.EQ, "abc"), stores.Or, stores.QueryMod("seats", stores.GT, 5), stores.And, stores.QueryMod("seats", stores.LT, 1)}, true},
+		{"ints compare to floats", []stores.QueryModifier{stores.QueryMod("seats", stores.GTE, 3.0)}, true},
+		{"floats", []stores.QueryModifier{stores.QueryMod("price_per_seat", stores.LT, 16)}, true},
+		{"times", []stores.QueryModifier{stores.QueryMod("start_date", stores.LT, now.Add(time.Hour))}, true},
+		{"pointer fields", []stores.QueryModifier{stores.QueryMod("passenger_status", stores.EQ, models.PassengerAccepted)}, true},
+		{"nil pointer fields", []stores.QueryModifier{stores.QueryMod("seats_taken", stores.EQ, 0)}, false},
+		{"mismatched types", []stores.QueryModifier{stores.QueryMod("id", stores.EQ, 1)}, false},
+		{"in", []stores.QueryModifier{stores.QueryMod("driver_id", stores.IN, []string{"123", "456"})}, true},
+		{"empty in", []stores.QueryModifier{stores.QueryMod("driver_id", stores.IN, []string{})}, false},
+		{"not in", []stores.QueryModifier{stores.QueryMod("seats", stores.NOTIN, []int{1, 3})}, false},
+		{"ilike", []stores.QueryModifier{stores.QueryMod("id", stores.ILIKE, "A_%")}, true},
+		{"ilike mismatch", []stores.QueryModifier{stores.QueryMod("id", stores.ILIKE, "b%")}, false},
+		{"is null", []stores.QueryModifier{stores.IsNull("seats_taken")}, true},
+		{"is not null", []stores.QueryModifier{stores.NotNull("passenger_status")}, true},
+		{"between", []stores.QueryModifier{stores.Between("start_date", now.Add(-time.Hour), now)}, true},
+		{"between mismatch", []stores.QueryModifier{stores.Between("seats", 4, 6)}, false},
+		{"group binds tighter than AND", []stores.QueryModifier{stores.QueryMod("id", stores.EQ, "xyz"), stores.Or, stores.QueryMod("seats", stores.EQ, 3), stores.And, stores.Group(stores.QueryMod("seats", stores.LT, 1), stores.Or, stores.QueryMod("driver_id", stores.EQ, "123"))}, true},
+		{"ignores options", []stores.QueryModifier{stores.OrderBy("id", stores.Desc), stores.Limit(0)}, true},
+	}
+
+	for _, tt := range tables {
+		if result := matches(&ride, tt.clauses); result != tt.result {
+			t.Errorf("%s should have matched %t, but matched %t", tt.name, tt.result, result)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tables := []struct {
+		name    string
+		clauses []stores.QueryModifier
+		valid   bool
+	}{
+		{"no clauses", nil, true},
+		{"missing column", []stores.QueryModifier{stores.QueryMod("", stores.EQ, "")}, false},
+		{"nil value", []stores.QueryModifier{stores.QueryMod("id", stores.EQ, nil)}, false},
+		{"in without a list", []stores.QueryModifier{stores.QueryMod("id", stores.IN, "abc")}, false},
+		{"between needs two values", []stores.QueryModifier{stores.QueryMod("seats", stores.BETWEEN, []int{1})}, false},
+		{"empty group", []stores.QueryModifier{stores.Group()}, false},
+		{"bad direction", []stores.QueryModifier{stores.OrderBy("id", "sideways")}, false},
+		{"negative limit", []stores.QueryModifier{stores.Limit(-1)}, false},
+		{"column not allowed", []stores.QueryModifier{stores.QueryMod("seats_taken", stores.EQ, 1)}, false},
+		{"unknown operator", []stores.QueryModifier{stores.QueryMod("id", "~", "abc")}, false},
+	}
+
+	for _, tt := range tables {
+		if err := validate(tt.clauses, rideColumns); (err == nil) != tt.valid {
+			t.Errorf("%s should have been valid %t, but got %v", tt.name, tt.valid, err)
+		}
+	}
+}
+
+func TestArrange(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	rides := []*models.Ride{
+		{ID: "a", Seats: 2, StartDate: later},
+		{ID: "b", Seats: 1, StartDate: now},
+		{ID: "c", Seats: 2, StartDate: now},
+	}
+
+	start, end := arrange(rides, []stores.QueryModifier{stores.OrderBy("start_date", stores.Asc), stores.OrderBy("seats", stores.Desc), stores.Offset(1), stores.Limit(5)})
+
+	ids := []string{}
+	for _, ride := range rides[start:end] {
+		ids = append(ids, ride.ID)
+	}
+
+	if got := strings.Join(ids, ","); got != "b,a" {
+		t.Errorf("expected rides b,a but got %s", got)
+	}
+}
@@ -0,0 +1,267 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ucladevx/BPool/interfaces"
+)
+
+// lockKey is the postgres advisory lock key held while migrations run
+const lockKey = 7158023451
+
+const (
+	createMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version bigint primary key,
+	name varchar(255) NOT NULL,
+	applied_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`
+
+	migrationsGetAllSQL = "SELECT version, applied_at FROM schema_migrations ORDER BY version"
+
+	migrationsInsertSQL = "INSERT INTO schema_migrations (version, name) VALUES (?, ?)"
+
+	migrationsDeleteSQL = "DELETE FROM schema_migrations WHERE version=?"
+)
+
+var (
+	// ErrInvalidVersion occurs when a migration has a version less than 1
+	ErrInvalidVersion = errors.New("migration versions must be greater than 0")
+
+	// ErrDuplicateVersion occurs when two migrations share a version
+	ErrDuplicateVersion = errors.New("migration version is used more than once")
+
+	// ErrUnknownVersion occurs when the db has a version applied that is not known
+	ErrUnknownVersion = errors.New("db has an applied migration that is not known")
+)
+
+type (
+	// Migration is a single numbered schema change
+	Migration struct {
+		Version int
+		Name    string
+		Up      string
+		Down    string
+	}
+
+	// Status is a migration and whether it has been applied to the db
+	Status struct {
+		Migration
+		Applied   bool
+		AppliedAt *time.Time
+	}
+
+	// Migrator applies and rolls back migrations, tracking them in schema_migrations
+	Migrator struct {
+		db         *sqlx.DB
+		migrations []Migration
+		logger     interfaces.Logger
+	}
+)
+
+// New creates a migrator for the given migrations
+func New(db *sqlx.DB, migrations []Migration, l interfaces.Logger) *Migrator {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	return &Migrator{
+		db:         db,
+		migrations: sorted,
+		logger:     l,
+	}
+}
+
+// Up applies every pending migration in order and returns how many were applied
+func (m *Migrator) Up() (int, error) {
+	count := 0
+
+	err := m.withLock(func(conn *sql.Conn) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			m.logger.Info("MIGRATE UP", "version", migration.Version, "name", migration.Name)
+
+			if err := m.run(conn, migration.Up, m.db.Rebind(migrationsInsertSQL), migration.Version, migration.Name); err != nil {
+				return fmt.Errorf("migration %04d_%s: %v", migration.Version, migration.Name, err)
+			}
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// Down rolls back the n most recently applied migrations and returns how many were rolled back
+func (m *Migrator) Down(n int) (int, error) {
+	count := 0
+
+	err := m.withLock(func(conn *sql.Conn) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && count < n; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+
+			m.logger.Info("MIGRATE DOWN", "version", migration.Version, "name", migration.Name)
+
+			if err := m.run(conn, migration.Down, m.db.Rebind(migrationsDeleteSQL), migration.Version); err != nil {
+				return fmt.Errorf("migration %04d_%s: %v", migration.Version, migration.Name, err)
+			}
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// Status returns every known migration and whether it has been applied
+func (m *Migrator) Status() ([]Status, error) {
+	var statuses []Status
+
+	err := m.withLock(func(conn *sql.Conn) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := Status{Migration: migration}
+
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.Applied = true
+				status.AppliedAt = &appliedAt
+			}
+
+			statuses = append(statuses, status)
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+// withLock runs fn on a single connection that holds the migration lock
+func (m *Migrator) withLock(fn func(conn *sql.Conn) error) error {
+	if err := validate(m.migrations); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// advisory locks are per session, so both calls must use the same connection
+	if m.db.DriverName() == "postgres" {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+			return err
+		}
+		defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockKey)
+	}
+
+	if _, err := conn.ExecContext(ctx, createMigrationsTable); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// applied returns the versions in schema_migrations and when they were applied
+func (m *Migrator) applied(conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(context.Background(), migrationsGetAllSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	known := make(map[int]bool, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+	}
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		if !known[version] {
+			return nil, fmt.Errorf("%v: %d", ErrUnknownVersion, version)
+		}
+
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// run executes the migration sql and records it in a single transaction
+func (m *Migrator) run(conn *sql.Conn, migrationSQL, recordSQL string, args ...interface{}) error {
+	ctx := context.Background()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, migrationSQL); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, recordSQL, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func validate(migrations []Migration) error {
+	seen := map[int]bool{}
+
+	for _, migration := range migrations {
+		if migration.Version < 1 {
+			return ErrInvalidVersion
+		}
+
+		if seen[migration.Version] {
+			return fmt.Errorf("%v: %d", ErrDuplicateVersion, migration.Version)
+		}
+
+		seen[migration.Version] = true
+	}
+
+	return nil
+}
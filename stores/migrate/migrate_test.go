@@ -0,0 +1,48 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ucladevx/BPool/mocks"
+)
+
+func TestNewSortsMigrations(t *testing.T) {
+	assert := assert.New(t)
+
+	m := New(nil, []Migration{{Version: 3}, {Version: 1}, {Version: 2}}, mocks.Logger{})
+
+	for i, migration := range m.migrations {
+		assert.Equal(i+1, migration.Version, "migrations should be sorted by version")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tables := []struct {
+		name       string
+		migrations []Migration
+		err        error
+	}{
+		{"valid", []Migration{{Version: 1}, {Version: 2}}, nil},
+		{"empty", nil, nil},
+		{"zero version", []Migration{{Version: 0}}, ErrInvalidVersion},
+		{"duplicate version", []Migration{{Version: 1}, {Version: 1}}, ErrDuplicateVersion},
+	}
+
+	for _, tt := range tables {
+		err := validate(tt.migrations)
+
+		if tt.err == nil {
+			if err != nil {
+				t.Errorf("%s should have no error, but got %s", tt.name, err.Error())
+			}
+			continue
+		}
+
+		if err == nil || !strings.HasPrefix(err.Error(), tt.err.Error()) {
+			t.Errorf("%s should have error %v, but got %v", tt.name, tt.err, err)
+		}
+	}
+}
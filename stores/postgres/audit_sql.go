@@ -0,0 +1,19 @@
+package postgres
+
+import "github.com/ucladevx/BPool/stores"
+
+const (
+	auditTableName = "audit_events"
+
+	auditInsertSQL = "INSERT INTO audit_events (id, actor_id, entity, entity_id, action, before, after) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING created_at"
+)
+
+// auditColumns are the columns of audit_events that query modifiers may use
+var auditColumns = stores.Columns(
+	"id",
+	"actor_id",
+	"entity",
+	"entity_id",
+	"action",
+	"created_at",
+)
@@ -2,27 +2,28 @@ package postgres
 
 import (
 	"database/sql"
-	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 
 	"github.com/ucladevx/BPool/models"
 	"github.com/ucladevx/BPool/stores"
 	"github.com/ucladevx/BPool/utils/id"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
 var (
 	// ErrInvalidCarEntry error when user submits invalid car object
-	ErrInvalidCarEntry = errors.New("invalid car entry")
+	ErrInvalidCarEntry = stores.ErrInvalidCarEntry
 
 	// ErrNoCarFound error when no car is in db
-	ErrNoCarFound = errors.New("no car found")
+	ErrNoCarFound = stores.ErrNoCarFound
 )
 
 type (
 	//CarStore persists cars in pgsql db
 	CarStore struct {
-		db    *sqlx.DB
+		db    queryer
 		idGen IDgen
 	}
 
@@ -40,11 +41,17 @@ func NewCarStore(db *sqlx.DB) *CarStore {
 	}
 }
 
-// GetAll finds all cars from db
-func (c *CarStore) GetAll(lastID string, limit int) ([]*models.Car, error) {
+// GetAll returns a page of cars in the page's sort order
+func (c *CarStore) GetAll(page pagination.Page) ([]*models.Car, error) {
+	clauses := stores.NotDeleted(stores.Paginate(nil, page))
+	where, vals, err := generateWhereStatement(&clauses, carColumns)
+	if err != nil {
+		return nil, err
+	}
+
 	cars := []*models.Car{}
 
-	if err := c.db.Select(&cars, carsGetAllSQL, lastID, limit); err != nil {
+	if err := c.db.Select(&cars, "SELECT * FROM cars "+where, vals...); err != nil {
 		return nil, err
 	}
 
@@ -60,10 +67,14 @@ func (c *CarStore) GetByID(id string) (*models.Car, error) {
 func (c *CarStore) GetCount(queryModifiers []stores.QueryModifier) (int, error) {
 	var count int
 
-	query, vals := generateWhereStatement(&queryModifiers)
+	filters := stores.Filters(stores.NotDeleted(queryModifiers))
+	query, vals, err := generateWhereStatement(&filters, carColumns)
+	if err != nil {
+		return -1, err
+	}
 	queryString := carsGetCountSQL + query
 
-	err := c.db.Get(&count, queryString, vals...)
+	err = c.db.Get(&count, queryString, vals...)
 
 	if err != nil {
 		return -1, err
@@ -85,7 +96,14 @@ func (c *CarStore) Insert(car *models.Car) error {
 	return nil
 }
 
-// Remove car from DB
+// Import persists a car exactly as given, keeping its id and timestamps
+func (c *CarStore) Import(car *models.Car) error {
+	_, err := c.db.Exec(carsImportSQL, car.ID, car.Make, car.Model, car.Year, car.Color, car.UserID, car.CreatedAt, car.UpdatedAt, car.DeletedAt)
+
+	return err
+}
+
+// Remove soft deletes the car along with its rides and their passengers
 func (c *CarStore) Remove(id string) error {
 	_, err := c.db.Exec(carsDeleteSQL, id)
 
@@ -96,6 +114,16 @@ func (c *CarStore) Remove(id string) error {
 	return nil
 }
 
+// Restore undoes the removal of a car, along with the rides and passengers removed with it
+func (c *CarStore) Restore(id string) error {
+	return execOne(c.db, ErrNoCarFound, carsRestoreSQL, id)
+}
+
+// Purge permanently deletes cars removed before the time given
+func (c *CarStore) Purge(before time.Time) (int, error) {
+	return purge(c.db, carsPurgeSQL, before)
+}
+
 func (c *CarStore) getBy(query string, args interface{}) (*models.Car, error) {
 	var car models.Car
 
@@ -109,7 +137,3 @@ func (c *CarStore) getBy(query string, args interface{}) (*models.Car, error) {
 
 	return &car, nil
 }
-
-func (c *CarStore) migrate() {
-	c.db.MustExec(carsCreateTable)
-}
@@ -1,22 +1,9 @@
 package postgres
 
+import "github.com/ucladevx/BPool/stores"
+
 const (
-	carsCreateTable = `
-	CREATE TABLE IF NOT EXISTS cars (
-		id varchar(20) primary key,
-		make varchar(128) NOT NULL,
-		model varchar(128),
-		year int NOT NULL ,
-		color varchar(64) NOT NULL,
-		user_id varchar(20) NOT NULL,
-		created_at timestamptz DEFAULT NOW(),
-		updated_at timestamptz DEFAULT NOW(),
-		FOREIGN KEY(user_id) REFERENCES users (id) ON DELETE CASCADE
-	);`
-
-	carsGetAllSQL = "SELECT * FROM cars WHERE id > $1 LIMIT $2"
-
-	carsGetByIDSQL = "SELECT * FROM cars WHERE id=$1"
+	carsGetByIDSQL = "SELECT * FROM cars WHERE id=$1 AND deleted_at IS NULL"
 
 	carsGetCountSQL = "SELECT COUNT(*) FROM cars "
 
@@ -26,5 +13,49 @@ const (
 		RETURNING created_at, updated_at
 	`
 
-	carsDeleteSQL = "DELETE FROM cars WHERE id=$1"
+	carsImportSQL = `
+		INSERT INTO cars (id, make, model, year, color, user_id, created_at, updated_at, deleted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	// soft deletes the car along with its rides and their passengers, all with the
+	// same deleted_at so that restoring the car can restore them too
+	carsDeleteSQL = `
+		WITH deleted_car AS (
+			UPDATE cars SET deleted_at=NOW() WHERE id=$1 AND deleted_at IS NULL RETURNING id, deleted_at
+		), deleted_rides AS (
+			UPDATE rides SET deleted_at=deleted_car.deleted_at FROM deleted_car
+			WHERE rides.car_id=deleted_car.id AND rides.deleted_at IS NULL RETURNING rides.id, rides.deleted_at
+		)
+		UPDATE passengers SET deleted_at=deleted_rides.deleted_at FROM deleted_rides
+		WHERE passengers.ride_id=deleted_rides.id AND passengers.deleted_at IS NULL
+	`
+
+	carsRestoreSQL = `
+		WITH deleted_car AS (
+			SELECT id, deleted_at FROM cars WHERE id=$1 AND deleted_at IS NOT NULL
+		), restored_rides AS (
+			UPDATE rides SET deleted_at=NULL FROM deleted_car
+			WHERE rides.car_id=deleted_car.id AND rides.deleted_at=deleted_car.deleted_at RETURNING rides.id
+		), restored_passengers AS (
+			UPDATE passengers SET deleted_at=NULL FROM deleted_car
+			WHERE passengers.ride_id IN (SELECT id FROM restored_rides) AND passengers.deleted_at=deleted_car.deleted_at
+		)
+		UPDATE cars SET deleted_at=NULL FROM deleted_car WHERE cars.id=deleted_car.id
+	`
+
+	carsPurgeSQL = "DELETE FROM cars WHERE deleted_at < $1"
+)
+
+// carColumns are the columns of cars that query modifiers may use
+var carColumns = stores.Columns(
+	"id",
+	"make",
+	"model",
+	"year",
+	"color",
+	"user_id",
+	"created_at",
+	"updated_at",
+	"deleted_at",
 )
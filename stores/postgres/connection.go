@@ -2,24 +2,125 @@ package postgres
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/ucladevx/BPool/interfaces"
 
 	"github.com/jmoiron/sqlx"
 )
 
-// NewConnection creates a new connection pool to a Postgres DB
-func NewConnection(user, password, name, port, host string, l interfaces.Logger) *sqlx.DB {
-	dbConnection := fmt.Sprintf("user=%s password=%s dbname=%s port=%s host=%s sslmode=disable",
-		user, password, name, port, host)
+const (
+	// firstRetryDelay is how long to wait after the first failed connection attempt,
+	// the wait doubles after every attempt up to maxRetryDelay
+	firstRetryDelay = 250 * time.Millisecond
+	maxRetryDelay   = 10 * time.Second
+)
+
+// Config is how to connect to a Postgres DB and size its connection pool,
+// zero values leave the database/sql defaults in place
+type Config struct {
+	User     string
+	Password string
+	Name     string
+	Port     string
+	Host     string
+	SSLMode  string
 
-	l.Info("DB CONN", "host", host, "port", port)
+	MaxOpenConns     int
+	MaxIdleConns     int
+	ConnMaxLifetime  time.Duration
+	StatementTimeout time.Duration
 
-	var db *sqlx.DB
-	var err error
-	if db, err = sqlx.Connect("postgres", dbConnection); err != nil {
-		l.Panic("DB CONN FAILED", "error", err.Error())
+	// ConnectDeadline is how long to keep retrying the first connection
+	ConnectDeadline time.Duration
+}
+
+// DSN is the connection string for the config, sslmode defaults to disable
+func (c Config) DSN() string {
+	sslMode := c.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
 	}
 
-	return db
+	params := []string{
+		"user=" + quoteDSN(c.User),
+		"password=" + quoteDSN(c.Password),
+		"dbname=" + quoteDSN(c.Name),
+		"port=" + quoteDSN(c.Port),
+		"host=" + quoteDSN(c.Host),
+		"sslmode=" + quoteDSN(sslMode),
+	}
+
+	// unknown params are sent to the server as session settings
+	if c.StatementTimeout > 0 {
+		params = append(params, fmt.Sprintf("statement_timeout=%d", c.StatementTimeout/time.Millisecond))
+	}
+
+	return strings.Join(params, " ")
+}
+
+// NewConnection creates a new connection pool to a Postgres DB, retrying with
+// exponential backoff until the config's ConnectDeadline has passed
+func NewConnection(conf Config, l interfaces.Logger) (*sqlx.DB, error) {
+	l.Info("DB CONN", "host", conf.Host, "port", conf.Port, "sslmode", conf.SSLMode)
+
+	deadline := time.Now().Add(conf.ConnectDeadline)
+
+	for attempt := 0; ; attempt++ {
+		db, err := sqlx.Connect("postgres", conf.DSN())
+		if err == nil {
+			configurePool(db, conf)
+			return db, nil
+		}
+
+		delay := retryDelay(attempt)
+		if time.Now().Add(delay).After(deadline) {
+			return nil, fmt.Errorf("could not connect to db after %d attempt(s): %s", attempt+1, err.Error())
+		}
+
+		l.Warn("DB CONN FAILED", "error", err.Error(), "retry_in", delay.String())
+		time.Sleep(delay)
+	}
+}
+
+// retryDelay is how long to wait after the failed connection attempt, starting at
+// 0, before trying again
+func retryDelay(attempt int) time.Duration {
+	delay := firstRetryDelay
+	for i := 0; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+
+	return delay
+}
+
+func configurePool(db *sqlx.DB, conf Config) {
+	if conf.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(conf.MaxOpenConns)
+	}
+
+	if conf.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(conf.MaxIdleConns)
+	}
+
+	if conf.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(conf.ConnMaxLifetime)
+	}
+}
+
+// quoteDSN quotes a connection string value so it can hold spaces and quotes
+func quoteDSN(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `'`, `\'`, -1)
+
+	return "'" + value + "'"
 }
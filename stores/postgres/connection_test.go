@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigDSN(t *testing.T) {
+	tables := []struct {
+		name string
+		conf Config
+		dsn  string
+	}{
+		{
+			"defaults to sslmode disable",
+			Config{User: "bpool", Password: "secret", Name: "bpool", Port: "5432", Host: "localhost"},
+			"user=bpool password=secret dbname=bpool port=5432 host=localhost sslmode=disable",
+		},
+		{
+			"ssl mode and statement timeout",
+			Config{User: "bpool", Password: "secret", Name: "bpool", Port: "5432", Host: "db", SSLMode: "verify-full", StatementTimeout: 5 * time.Second},
+			"user=bpool password=secret dbname=bpool port=5432 host=db sslmode=verify-full statement_timeout=5000",
+		},
+		{
+			"quotes values with spaces and quotes",
+			Config{User: "bpool", Password: `it's a \secret`, Name: "bpool", Port: "5432", Host: "db"},
+			`user=bpool password='it\'s a \\secret' dbname=bpool port=5432 host=db sslmode=disable`,
+		},
+		{
+			"quotes empty values",
+			Config{User: "bpool", Name: "bpool", Port: "5432", Host: "db"},
+			"user=bpool password='' dbname=bpool port=5432 host=db sslmode=disable",
+		},
+	}
+
+	for _, table := range tables {
+		assert.Equal(t, table.dsn, table.conf.DSN(), table.name)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(250*time.Millisecond, retryDelay(0), "the first retry should wait the shortest")
+	assert.Equal(500*time.Millisecond, retryDelay(1), "the wait should double")
+	assert.Equal(2*time.Second, retryDelay(3))
+	assert.Equal(10*time.Second, retryDelay(6), "the wait should stop growing at the max")
+	assert.Equal(10*time.Second, retryDelay(100), "many attempts should not overflow")
+}
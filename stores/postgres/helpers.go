@@ -1,30 +1,75 @@
 package postgres
 
 import (
-	"strconv"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
 
 	"github.com/ucladevx/BPool/stores"
 )
 
-func generateWhereStatement(modifiers *[]stores.QueryModifier) (string, []interface{}) {
-	var args []interface{}
-	where := "WHERE "
+// IDgen generates ids for new records
+type IDgen = func() string
+
+// queryer is implemented by both *sqlx.DB and *sqlx.Tx, so stores can be used in transactions
+type queryer interface {
+	sqlx.Ext
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+var dialect = stores.Dialect{
+	Placeholder: stores.DollarPlaceholder,
+	ILike:       "ILIKE",
+}
+
+func generateWhereStatement(modifiers *[]stores.QueryModifier, allowed stores.ColumnSet) (string, []interface{}, error) {
+	return stores.BuildWhere(*modifiers, dialect, allowed)
+}
 
-	count := 1
-	for _, modifier := range *modifiers {
-		if modifier.Column == "AND" || modifier.Column == "OR" {
-			where += modifier.Column + " "
-			continue
-		}
+// execOne runs a statement that should change a row, returning notFound if none changed
+func execOne(db queryer, notFound error, query string, args ...interface{}) error {
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
 
-		if modifier.Column == "" || modifier.Value == nil {
-			return "", nil
-		}
+	if count == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+// purge permanently deletes rows soft deleted before the time given
+func purge(db queryer, query string, before time.Time) (int, error) {
+	result, err := db.Exec(query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := result.RowsAffected()
+	return int(count), err
+}
+
+// missedUpdate explains why an update matched no rows, the row either changed
+// version since it was read or does not exist
+func missedUpdate(db queryer, existsQuery, id string, notFound error) error {
+	var exists bool
+	if err := db.Get(&exists, existsQuery, id); err != nil {
+		return err
+	}
 
-		where += modifier.Column + modifier.Operator + "$" + strconv.Itoa(count) + " "
-		args = append(args, modifier.Value)
-		count++
+	if exists {
+		return stores.ErrVersionConflict
 	}
 
-	return where, args
+	return notFound
 }
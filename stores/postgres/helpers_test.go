@@ -7,23 +7,44 @@ import (
 )
 
 func TestGenerateWhereStatement(t *testing.T) {
+	allowed := stores.Columns("id", "val", "admin", "a", "b", "seats", "start_city", "start_date")
+
 	tables := []struct {
 		name         string
 		args         []stores.QueryModifier
 		resultQuery  string
 		resultValues []interface{}
+		invalid      bool
 	}{
-		{"single arg", []stores.QueryModifier{stores.QueryMod("id", stores.EQ, "123")}, "WHERE id=$1 ", []interface{}{"123"}},
-		{"two args with AND", []stores.QueryModifier{stores.QueryMod("id", stores.EQ, 123), stores.And, stores.QueryMod("val", stores.NE, "abc")}, "WHERE id=$1 AND val!=$2 ", []interface{}{123, "abc"}},
-		{"bad args", []stores.QueryModifier{stores.QueryMod("", stores.EQ, "")}, "", nil},
-		{"tests floats", []stores.QueryModifier{stores.QueryMod("id", stores.EQ, 1.0)}, "WHERE id=$1 ", []interface{}{1.0}},
-		{"tests bools", []stores.QueryModifier{stores.QueryMod("admin", stores.EQ, true)}, "WHERE admin=$1 ", []interface{}{true}},
+		{"single arg", []stores.QueryModifier{stores.QueryMod("id", stores.EQ, "123")}, "WHERE id=$1 ", []interface{}{"123"}, false},
+		{"two args with AND", []stores.QueryModifier{stores.QueryMod("id", stores.EQ, 123), stores.And, stores.QueryMod("val", stores.NE, "abc")}, "WHERE id=$1 AND val!=$2 ", []interface{}{123, "abc"}, false},
+		{"bad args", []stores.QueryModifier{stores.QueryMod("", stores.EQ, "")}, "", nil, true},
+		{"nil value", []stores.QueryModifier{stores.QueryMod("id", stores.EQ, nil)}, "", nil, true},
+		{"tests floats", []stores.QueryModifier{stores.QueryMod("id", stores.EQ, 1.0)}, "WHERE id=$1 ", []interface{}{1.0}, false},
+		{"tests bools", []stores.QueryModifier{stores.QueryMod("admin", stores.EQ, true)}, "WHERE admin=$1 ", []interface{}{true}, false},
+		{"no args", nil, "", nil, false},
+		{"in", []stores.QueryModifier{stores.QueryMod("id", stores.IN, []string{"a", "b"})}, "WHERE id IN ($1, $2) ", []interface{}{"a", "b"}, false},
+		{"empty not in", []stores.QueryModifier{stores.QueryMod("id", stores.NOTIN, []string{})}, "WHERE 1=1 ", nil, false},
+		{"ilike", []stores.QueryModifier{stores.QueryMod("start_city", stores.ILIKE, "los%")}, "WHERE start_city ILIKE $1 ", []interface{}{"los%"}, false},
+		{"null checks", []stores.QueryModifier{stores.IsNull("a"), stores.Or, stores.NotNull("b")}, "WHERE a IS NULL OR b IS NOT NULL ", nil, false},
+		{"between", []stores.QueryModifier{stores.Between("seats", 1, 3)}, "WHERE seats BETWEEN $1 AND $2 ", []interface{}{1, 3}, false},
+		{"groups", []stores.QueryModifier{stores.QueryMod("id", stores.EQ, "a"), stores.And, stores.Group(stores.QueryMod("seats", stores.GT, 1), stores.Or, stores.IsNull("seats"))}, "WHERE id=$1 AND (seats>$2 OR seats IS NULL) ", []interface{}{"a", 1}, false},
+		{"options", []stores.QueryModifier{stores.QueryMod("seats", stores.GT, 1), stores.OrderBy("start_date", stores.Asc), stores.OrderBy("id", stores.Desc), stores.Offset(20), stores.Limit(10)}, "WHERE seats>$1 ORDER BY start_date ASC, id DESC LIMIT 10 OFFSET 20 ", []interface{}{1}, false},
+		{"bad direction", []stores.QueryModifier{stores.OrderBy("id", "up")}, "", nil, true},
+		{"column not allowed", []stores.QueryModifier{stores.QueryMod("password", stores.EQ, "abc")}, "", nil, true},
+		{"injected column", []stores.QueryModifier{stores.QueryMod("id=id OR 1", stores.EQ, 1)}, "", nil, true},
+		{"order by column not allowed", []stores.QueryModifier{stores.OrderBy("(SELECT 1)", stores.Asc)}, "", nil, true},
+		{"unknown operator", []stores.QueryModifier{stores.QueryMod("id", "=1 OR id", 1)}, "", nil, true},
+		{"options in a group", []stores.QueryModifier{stores.Group(stores.Limit(1))}, "", nil, true},
 	}
 
 	for _, tt := range tables {
 		tt := tt
 
-		query, vals := generateWhereStatement(&tt.args)
+		query, vals, err := generateWhereStatement(&tt.args, allowed)
+		if _, invalid := err.(*stores.QueryModifierError); invalid != tt.invalid || (err != nil && !invalid) {
+			t.Errorf("%s should have been invalid %t, but has error %v", tt.name, tt.invalid, err)
+		}
 		if query != tt.resultQuery {
 			t.Errorf("%s should have query of %s, but is %s", tt.name, tt.resultQuery, query)
 		}
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ucladevx/BPool/interfaces"
+	"github.com/ucladevx/BPool/stores/migrate"
+)
+
+// Migrations are the schema changes for the postgres stores, in order
+var Migrations = []migrate.Migration{
+	{Version: 1, Name: "create_tables", Up: migration0001Up, Down: migration0001Down},
+	{Version: 2, Name: "soft_delete", Up: migration0002Up, Down: migration0002Down},
+	{Version: 3, Name: "audit_events", Up: migration0003Up, Down: migration0003Down},
+	{Version: 4, Name: "versions", Up: migration0004Up, Down: migration0004Down},
+	{Version: 5, Name: "ride_points", Up: migration0005Up, Down: migration0005Down},
+	{Version: 6, Name: "ride_search_vector", Up: migration0006Up, Down: migration0006Down},
+	{Version: 7, Name: "outbox", Up: migration0007Up, Down: migration0007Down},
+	{Version: 8, Name: "ride_series", Up: migration0008Up, Down: migration0008Down},
+	{Version: 9, Name: "ride_status", Up: migration0009Up, Down: migration0009Down},
+	{Version: 10, Name: "ride_cancellation", Up: migration0010Up, Down: migration0010Down},
+	{Version: 11, Name: "ride_requests", Up: migration0011Up, Down: migration0011Down},
+	{Version: 12, Name: "waitlist", Up: migration0012Up, Down: migration0012Down},
+	{Version: 13, Name: "party_size", Up: migration0013Up, Down: migration0013Down},
+}
+
+// NewMigrator creates a migrator for the postgres schema
+func NewMigrator(db *sqlx.DB, l interfaces.Logger) *migrate.Migrator {
+	return migrate.New(db, Migrations, l)
+}
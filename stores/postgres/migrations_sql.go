@@ -0,0 +1,340 @@
+package postgres
+
+const (
+	migration0001Up = `
+CREATE TABLE IF NOT EXISTS users (
+    id varchar(20) primary key,
+    first_name varchar(128) NOT NULL,
+    last_name varchar(128) NOT NULL,
+    email varchar(512) NOT NULL ,
+    profile_image varchar(1024),
+    auth_level integer DEFAULT 0,
+    created_at timestamptz DEFAULT NOW(),
+    updated_at timestamptz DEFAULT NOW(),
+    UNIQUE ("email")
+);
+
+CREATE TABLE IF NOT EXISTS cars (
+	id varchar(20) primary key,
+	make varchar(128) NOT NULL,
+	model varchar(128),
+	year int NOT NULL ,
+	color varchar(64) NOT NULL,
+	user_id varchar(20) NOT NULL,
+	created_at timestamptz DEFAULT NOW(),
+	updated_at timestamptz DEFAULT NOW(),
+	FOREIGN KEY(user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE EXTENSION IF NOT EXISTS postgis;
+CREATE TABLE IF NOT EXISTS rides (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	car_id varchar(20) NOT NULL,
+	seats int NOT NULL DEFAULT 1,
+	start_city varchar(128) NOT NULL,
+	end_city varchar(128) NOT NULL,
+	start_dest_lat NUMERIC(9,6) NOT NULL,
+	start_dest_lon NUMERIC(9,6) NOT NULL,
+	end_dest_lat NUMERIC(9,6) NOT NULL,
+	end_dest_lon NUMERIC(9,6) NOT NULL,
+	price_per_seat NUMERIC(10,2) DEFAULT 15 NOT NULL,
+	info TEXT,
+	start_date timestamptz NOT NULL,
+	created_at timestamptz DEFAULT NOW(),
+	updated_at timestamptz DEFAULT NOW(),
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (car_id) REFERENCES cars (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS passengers (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	passenger_id varchar(20) NOT NULL,
+	ride_id varchar(20) NOT NULL,
+	status varchar(20) NOT NULL,
+	created_at timestamptz DEFAULT NOW(),
+	updated_at timestamptz DEFAULT NOW(),
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (passenger_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (ride_id) REFERENCES rides (id) ON DELETE CASCADE,
+	UNIQUE (driver_id, passenger_id, ride_id)
+);`
+
+	migration0001Down = `
+DROP TABLE IF EXISTS passengers;
+DROP TABLE IF EXISTS rides;
+DROP TABLE IF EXISTS cars;
+DROP TABLE IF EXISTS users;`
+
+	migration0002Up = `
+ALTER TABLE cars ADD COLUMN deleted_at timestamptz;
+ALTER TABLE rides ADD COLUMN deleted_at timestamptz;
+ALTER TABLE passengers ADD COLUMN deleted_at timestamptz;
+
+ALTER TABLE passengers DROP CONSTRAINT IF EXISTS passengers_driver_id_passenger_id_ride_id_key;
+CREATE UNIQUE INDEX passengers_not_deleted_key ON passengers (driver_id, passenger_id, ride_id) WHERE deleted_at IS NULL;
+
+CREATE INDEX cars_deleted_at_idx ON cars (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX rides_deleted_at_idx ON rides (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX passengers_deleted_at_idx ON passengers (deleted_at) WHERE deleted_at IS NOT NULL;`
+
+	migration0002Down = `
+DELETE FROM cars WHERE deleted_at IS NOT NULL;
+DELETE FROM rides WHERE deleted_at IS NOT NULL;
+DELETE FROM passengers WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS passengers_deleted_at_idx;
+DROP INDEX IF EXISTS rides_deleted_at_idx;
+DROP INDEX IF EXISTS cars_deleted_at_idx;
+
+DROP INDEX IF EXISTS passengers_not_deleted_key;
+ALTER TABLE passengers ADD CONSTRAINT passengers_driver_id_passenger_id_ride_id_key UNIQUE (driver_id, passenger_id, ride_id);
+
+ALTER TABLE passengers DROP COLUMN deleted_at;
+ALTER TABLE rides DROP COLUMN deleted_at;
+ALTER TABLE cars DROP COLUMN deleted_at;`
+
+	// actor_id has no foreign key so the history outlives the users in it
+	migration0003Up = `
+CREATE TABLE IF NOT EXISTS audit_events (
+	id varchar(20) primary key,
+	actor_id varchar(20) NOT NULL,
+	entity varchar(32) NOT NULL,
+	entity_id varchar(20) NOT NULL,
+	action varchar(32) NOT NULL,
+	before jsonb NOT NULL DEFAULT '{}',
+	after jsonb NOT NULL DEFAULT '{}',
+	created_at timestamptz DEFAULT NOW()
+);
+
+CREATE INDEX audit_events_entity_idx ON audit_events (entity, entity_id, created_at);`
+
+	migration0003Down = `
+DROP TABLE IF EXISTS audit_events;`
+
+	migration0004Up = `
+ALTER TABLE rides ADD COLUMN version integer NOT NULL DEFAULT 1;
+ALTER TABLE passengers ADD COLUMN version integer NOT NULL DEFAULT 1;`
+
+	migration0004Down = `
+ALTER TABLE passengers DROP COLUMN version;
+ALTER TABLE rides DROP COLUMN version;`
+
+	// the points are kept in sync with the coordinates by a trigger so the stores
+	// never write them
+	migration0005Up = `
+ALTER TABLE rides ADD COLUMN start_point geography(Point, 4326);
+ALTER TABLE rides ADD COLUMN end_point geography(Point, 4326);
+
+CREATE OR REPLACE FUNCTION rides_set_points() RETURNS trigger AS $$
+BEGIN
+	NEW.start_point := ST_SetSRID(ST_MakePoint(NEW.start_dest_lon, NEW.start_dest_lat), 4326)::geography;
+	NEW.end_point := ST_SetSRID(ST_MakePoint(NEW.end_dest_lon, NEW.end_dest_lat), 4326)::geography;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER rides_set_points BEFORE INSERT OR UPDATE ON rides
+	FOR EACH ROW EXECUTE PROCEDURE rides_set_points();
+
+UPDATE rides SET
+	start_point = ST_SetSRID(ST_MakePoint(start_dest_lon, start_dest_lat), 4326)::geography,
+	end_point = ST_SetSRID(ST_MakePoint(end_dest_lon, end_dest_lat), 4326)::geography;
+
+ALTER TABLE rides ALTER COLUMN start_point SET NOT NULL;
+ALTER TABLE rides ALTER COLUMN end_point SET NOT NULL;
+
+CREATE INDEX rides_start_point_idx ON rides USING GIST (start_point);
+CREATE INDEX rides_end_point_idx ON rides USING GIST (end_point);`
+
+	migration0005Down = `
+DROP TRIGGER IF EXISTS rides_set_points ON rides;
+DROP FUNCTION IF EXISTS rides_set_points();
+
+DROP INDEX IF EXISTS rides_end_point_idx;
+DROP INDEX IF EXISTS rides_start_point_idx;
+
+ALTER TABLE rides DROP COLUMN end_point;
+ALTER TABLE rides DROP COLUMN start_point;`
+
+	// cities are weighted above info so that rides to a city rank above rides
+	// that only mention it
+	migration0006Up = `
+ALTER TABLE rides ADD COLUMN search_vector tsvector;
+
+CREATE OR REPLACE FUNCTION rides_set_search_vector() RETURNS trigger AS $$
+BEGIN
+	NEW.search_vector :=
+		setweight(to_tsvector('english', coalesce(NEW.start_city, '') || ' ' || coalesce(NEW.end_city, '')), 'A') ||
+		setweight(to_tsvector('english', coalesce(NEW.info, '')), 'B');
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER rides_set_search_vector BEFORE INSERT OR UPDATE ON rides
+	FOR EACH ROW EXECUTE PROCEDURE rides_set_search_vector();
+
+UPDATE rides SET search_vector =
+	setweight(to_tsvector('english', coalesce(start_city, '') || ' ' || coalesce(end_city, '')), 'A') ||
+	setweight(to_tsvector('english', coalesce(info, '')), 'B');
+
+ALTER TABLE rides ALTER COLUMN search_vector SET NOT NULL;
+
+CREATE INDEX rides_search_vector_idx ON rides USING GIN (search_vector);`
+
+	migration0006Down = `
+DROP TRIGGER IF EXISTS rides_set_search_vector ON rides;
+DROP FUNCTION IF EXISTS rides_set_search_vector();
+
+DROP INDEX IF EXISTS rides_search_vector_idx;
+
+ALTER TABLE rides DROP COLUMN search_vector;`
+
+	// events are due while next_attempt_at is set, so the index only holds the
+	// events the dispatcher still has to deliver
+	migration0007Up = `
+CREATE TABLE IF NOT EXISTS outbox (
+	id varchar(20) primary key,
+	type varchar(64) NOT NULL,
+	entity_id varchar(20) NOT NULL,
+	payload jsonb NOT NULL DEFAULT '{}',
+	attempts integer NOT NULL DEFAULT 0,
+	last_error text,
+	next_attempt_at timestamptz DEFAULT NOW(),
+	delivered_at timestamptz,
+	created_at timestamptz DEFAULT NOW()
+);
+
+CREATE INDEX outbox_due_idx ON outbox (next_attempt_at) WHERE next_attempt_at IS NOT NULL;`
+
+	migration0007Down = `
+DROP TABLE IF EXISTS outbox;`
+
+	// occurrence dates are kept as text like the series' dates so that they compare
+	// the same in every backend, an occurrence is unique even once deleted so that a
+	// cancelled occurrence is not materialized again
+	migration0008Up = `
+CREATE TABLE IF NOT EXISTS ride_series (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	car_id varchar(20) NOT NULL,
+	seats int NOT NULL DEFAULT 1,
+	start_city varchar(128) NOT NULL,
+	end_city varchar(128) NOT NULL,
+	start_dest_lat NUMERIC(9,6) NOT NULL,
+	start_dest_lon NUMERIC(9,6) NOT NULL,
+	end_dest_lat NUMERIC(9,6) NOT NULL,
+	end_dest_lon NUMERIC(9,6) NOT NULL,
+	price_per_seat NUMERIC(10,2) DEFAULT 15 NOT NULL,
+	info TEXT NOT NULL DEFAULT '',
+	days varchar(20) NOT NULL,
+	departure_time varchar(5) NOT NULL,
+	time_zone varchar(64) NOT NULL,
+	starts_on varchar(10) NOT NULL,
+	ends_on varchar(10) NOT NULL,
+	materialized_through varchar(10),
+	created_at timestamptz DEFAULT NOW(),
+	updated_at timestamptz DEFAULT NOW(),
+	deleted_at timestamptz,
+	version integer NOT NULL DEFAULT 1,
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (car_id) REFERENCES cars (id) ON DELETE CASCADE
+);
+
+ALTER TABLE rides ADD COLUMN series_id varchar(20) REFERENCES ride_series (id) ON DELETE SET NULL;
+ALTER TABLE rides ADD COLUMN occurrence_date varchar(10);
+
+CREATE UNIQUE INDEX rides_series_occurrence_key ON rides (series_id, occurrence_date);`
+
+	migration0008Down = `
+DROP INDEX IF EXISTS rides_series_occurrence_key;
+
+ALTER TABLE rides DROP COLUMN occurrence_date;
+ALTER TABLE rides DROP COLUMN series_id;
+
+DROP TABLE IF EXISTS ride_series;`
+
+	// rides that started before there were statuses are left scheduled rather than
+	// taken to have arrived, nothing says whether they happened and completed rides
+	// count towards the driver's reliability
+	migration0009Up = `
+ALTER TABLE rides ADD COLUMN status varchar(20) NOT NULL DEFAULT 'scheduled';
+
+CREATE INDEX rides_status_idx ON rides (status);`
+
+	migration0009Down = `
+DROP INDEX IF EXISTS rides_status_idx;
+
+ALTER TABLE rides DROP COLUMN status;`
+
+	migration0010Up = `
+ALTER TABLE rides ADD COLUMN cancelled_at timestamptz;
+ALTER TABLE rides ADD COLUMN cancelled_by varchar(20) REFERENCES users (id) ON DELETE SET NULL;
+ALTER TABLE rides ADD COLUMN cancel_reason TEXT;
+ALTER TABLE rides ADD COLUMN late_cancellation boolean NOT NULL DEFAULT false;
+
+CREATE INDEX rides_late_cancellation_idx ON rides (driver_id) WHERE late_cancellation;`
+
+	migration0010Down = `
+DROP INDEX IF EXISTS rides_late_cancellation_idx;
+
+ALTER TABLE rides DROP COLUMN late_cancellation;
+ALTER TABLE rides DROP COLUMN cancel_reason;
+ALTER TABLE rides DROP COLUMN cancelled_by;
+ALTER TABLE rides DROP COLUMN cancelled_at;`
+
+	migration0011Up = `
+CREATE TABLE IF NOT EXISTS ride_requests (
+	id varchar(20) primary key,
+	passenger_id varchar(20) NOT NULL,
+	start_city varchar(128) NOT NULL,
+	end_city varchar(128) NOT NULL,
+	start_dest_lat NUMERIC(9,6) NOT NULL,
+	start_dest_lon NUMERIC(9,6) NOT NULL,
+	end_dest_lat NUMERIC(9,6) NOT NULL,
+	end_dest_lon NUMERIC(9,6) NOT NULL,
+	earliest_start timestamptz NOT NULL,
+	latest_start timestamptz NOT NULL,
+	seats int NOT NULL DEFAULT 1,
+	max_price NUMERIC(10,2),
+	info TEXT NOT NULL DEFAULT '',
+	status varchar(20) NOT NULL DEFAULT 'open',
+	created_at timestamptz DEFAULT NOW(),
+	updated_at timestamptz DEFAULT NOW(),
+	deleted_at timestamptz,
+	version integer NOT NULL DEFAULT 1,
+	FOREIGN KEY (passenger_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX ride_requests_open_idx ON ride_requests (earliest_start) WHERE status = 'open' AND deleted_at IS NULL;
+
+ALTER TABLE passengers ADD COLUMN request_id varchar(20) REFERENCES ride_requests (id) ON DELETE SET NULL;`
+
+	migration0011Down = `
+ALTER TABLE passengers DROP COLUMN request_id;
+
+DROP TABLE IF EXISTS ride_requests;`
+
+	migration0012Up = `
+ALTER TABLE passengers ADD COLUMN confirm_by timestamptz;
+
+CREATE INDEX passengers_waitlist_idx ON passengers (ride_id, created_at) WHERE status = 'waitlisted' AND deleted_at IS NULL;
+CREATE INDEX passengers_seat_offered_idx ON passengers (confirm_by) WHERE status = 'seat_offered' AND deleted_at IS NULL;`
+
+	// passengers in the waitlist statuses are moved back to statuses the previous version knows
+	migration0012Down = `
+UPDATE passengers SET status = 'interested' WHERE status IN ('waitlisted', 'seat_offered');
+UPDATE passengers SET status = 'rejected' WHERE status = 'withdrawn';
+
+DROP INDEX IF EXISTS passengers_seat_offered_idx;
+DROP INDEX IF EXISTS passengers_waitlist_idx;
+
+ALTER TABLE passengers DROP COLUMN confirm_by;`
+
+	migration0013Up = `
+ALTER TABLE passengers ADD COLUMN seats_requested integer NOT NULL DEFAULT 1 CHECK (seats_requested > 0);`
+
+	migration0013Down = `
+ALTER TABLE passengers DROP COLUMN seats_requested;`
+)
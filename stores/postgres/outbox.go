@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/id"
+)
+
+// OutboxStore persists outbox events in a pg DB
+type OutboxStore struct {
+	db    queryer
+	idGen IDgen
+}
+
+// NewOutboxStore creates a new pg outbox store
+func NewOutboxStore(db *sqlx.DB) *OutboxStore {
+	return &OutboxStore{
+		db:    db,
+		idGen: id.New,
+	}
+}
+
+// Insert persists an outbox event to the DB, it is due straight away
+func (o *OutboxStore) Insert(event *models.OutboxEvent) error {
+	event.ID = o.idGen()
+
+	row := o.db.QueryRow(
+		outboxInsertSQL,
+		event.ID,
+		event.Type,
+		event.EntityID,
+		event.Payload,
+	)
+
+	return row.Scan(&event.CreatedAt, &event.NextAttemptAt)
+}
+
+// GetDue returns up to limit events due by now, oldest first
+func (o *OutboxStore) GetDue(now time.Time, limit int) ([]*models.OutboxEvent, error) {
+	events := []*models.OutboxEvent{}
+
+	if err := o.db.Select(&events, outboxGetDueSQL, now, limit); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Claim returns up to limit events due by now, oldest first, and makes them due
+// again at until
+func (o *OutboxStore) Claim(now, until time.Time, limit int) ([]*models.OutboxEvent, error) {
+	events := []*models.OutboxEvent{}
+
+	if err := o.db.Select(&events, outboxClaimSQL, now, until, limit); err != nil {
+		return nil, err
+	}
+
+	sortOutboxEvents(events)
+
+	return events, nil
+}
+
+// Update saves the delivery state of an outbox event
+func (o *OutboxStore) Update(event *models.OutboxEvent) error {
+	return execOne(
+		o.db,
+		stores.ErrNoOutboxEventFound,
+		outboxUpdateSQL,
+		event.Attempts,
+		event.LastError,
+		event.NextAttemptAt,
+		event.DeliveredAt,
+		event.ID,
+	)
+}
+
+// Purge permanently deletes events delivered before the time given, along with the
+// events created before it that were given up on
+func (o *OutboxStore) Purge(before time.Time) (int, error) {
+	return purge(o.db, outboxPurgeSQL, before)
+}
+
+// sortOutboxEvents sorts events oldest first, UPDATE ... RETURNING has no order
+func sortOutboxEvents(events []*models.OutboxEvent) {
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].CreatedAt.Equal(events[j].CreatedAt) {
+			return events[i].CreatedAt.Before(events[j].CreatedAt)
+		}
+		return events[i].ID < events[j].ID
+	})
+}
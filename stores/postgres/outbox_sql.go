@@ -0,0 +1,16 @@
+package postgres
+
+const (
+	outboxInsertSQL = "INSERT INTO outbox (id, type, entity_id, payload) VALUES ($1, $2, $3, $4) RETURNING created_at, next_attempt_at"
+	outboxGetDueSQL = "SELECT * FROM outbox WHERE next_attempt_at IS NOT NULL AND next_attempt_at <= $1 ORDER BY created_at, id LIMIT $2"
+	outboxUpdateSQL = "UPDATE outbox SET attempts=$1, last_error=$2, next_attempt_at=$3, delivered_at=$4 WHERE id=$5"
+	outboxPurgeSQL  = "DELETE FROM outbox WHERE delivered_at < $1 OR (delivered_at IS NULL AND next_attempt_at IS NULL AND created_at < $1)"
+
+	// the rows claimed are locked until the update commits, rows another claim has
+	// locked are skipped rather than waited for
+	outboxClaimSQL = `
+		UPDATE outbox SET next_attempt_at = $2 WHERE id IN (
+			SELECT id FROM outbox WHERE next_attempt_at IS NOT NULL AND next_attempt_at <= $1
+			ORDER BY created_at, id LIMIT $3 FOR UPDATE SKIP LOCKED
+		) RETURNING *`
+)
@@ -2,7 +2,7 @@ package postgres
 
 import (
 	"database/sql"
-	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
@@ -10,19 +10,20 @@ import (
 	"github.com/ucladevx/BPool/models"
 	"github.com/ucladevx/BPool/stores"
 	"github.com/ucladevx/BPool/utils/id"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
 var (
 	// ErrNoPassengerFound error when no passenger in db
-	ErrNoPassengerFound = errors.New("no passenger found")
+	ErrNoPassengerFound = stores.ErrNoPassengerFound
 
 	// ErrAlreadyPassenger occurs when a user has already shown interest
-	ErrAlreadyPassenger = errors.New("user is already a passenger")
+	ErrAlreadyPassenger = stores.ErrAlreadyPassenger
 )
 
 // PassengerStore persits rides in a pg DB
 type PassengerStore struct {
-	db    *sqlx.DB
+	db    queryer
 	idGen IDgen
 }
 
@@ -34,15 +35,9 @@ func NewPassengerStore(db *sqlx.DB) *PassengerStore {
 	}
 }
 
-// GetAll returns paginated list of passengers, lastID = '' for no offset
-func (r *PassengerStore) GetAll(lastID string, limit int) ([]*models.Passenger, error) {
-	passengers := []*models.Passenger{}
-
-	if err := r.db.Select(&passengers, passengerGetAllSQL, lastID, limit); err != nil {
-		return nil, err
-	}
-
-	return passengers, nil
+// GetAll returns a page of passengers in the page's sort order
+func (r *PassengerStore) GetAll(page pagination.Page) ([]*models.Passenger, error) {
+	return r.WhereMany(stores.Paginate(nil, page))
 }
 
 // GetByID finds a passenger by ID if exits in the DB
@@ -60,9 +55,11 @@ func (r *PassengerStore) Insert(passenger *models.Passenger) error {
 		passenger.PassengerID,
 		passenger.RideID,
 		passenger.Status,
+		passenger.SeatsRequested,
+		passenger.RequestID,
 	)
 
-	if err := row.Scan(&passenger.CreatedAt, &passenger.UpdatedAt); err != nil {
+	if err := row.Scan(&passenger.CreatedAt, &passenger.UpdatedAt, &passenger.Version); err != nil {
 		if pgErr, ok := err.(*pq.Error); ok {
 			if pgErr.Code.Name() == "unique_violation" {
 				return ErrAlreadyPassenger
@@ -74,56 +71,136 @@ func (r *PassengerStore) Insert(passenger *models.Passenger) error {
 	return nil
 }
 
-// Update persists the updates for the given ride
+// Import persists a passenger exactly as given, keeping its id, timestamps and version
+func (r *PassengerStore) Import(passenger *models.Passenger) error {
+	_, err := r.db.Exec(
+		passengerImportSQL,
+		passenger.ID,
+		passenger.DriverID,
+		passenger.PassengerID,
+		passenger.RideID,
+		passenger.Status,
+		passenger.SeatsRequested,
+		passenger.RequestID,
+		passenger.ConfirmBy,
+		passenger.CreatedAt,
+		passenger.UpdatedAt,
+		passenger.DeletedAt,
+		passenger.Version,
+	)
+	if pgErr, ok := err.(*pq.Error); ok && pgErr.Code.Name() == "unique_violation" {
+		return ErrAlreadyPassenger
+	}
+
+	return err
+}
+
+// Update persists the updates for the given passenger if it is still at the passenger's
+// version, returning stores.ErrVersionConflict if it is not
 func (r *PassengerStore) Update(passenger *models.Passenger) error {
 	row := r.db.QueryRow(
 		passengerUpdateSQL,
 		passenger.Status,
+		passenger.SeatsRequested,
+		passenger.ConfirmBy,
 		passenger.ID,
+		passenger.Version,
 	)
 
-	if err := row.Scan(&passenger.UpdatedAt); err != nil {
+	if err := row.Scan(&passenger.UpdatedAt, &passenger.Version); err != nil {
+		if err == sql.ErrNoRows {
+			err = missedUpdate(r.db, passengerExistsSQL, passenger.ID, ErrNoPassengerFound)
+		}
+
 		return err
 	}
 
 	return nil
 }
 
-// Delete deletes the passenger, does no verification
+// Delete soft deletes the passenger, does no verification
 func (r *PassengerStore) Delete(id string) error {
 	_, err := r.db.Exec(passengerDeleteSQL, id)
 	return err
 }
 
+// Restore undoes the deletion of a passenger whose ride has not been deleted
+func (r *PassengerStore) Restore(id string) error {
+	err := execOne(r.db, ErrNoPassengerFound, passengerRestoreSQL, id)
+	if pgErr, ok := err.(*pq.Error); ok && pgErr.Code.Name() == "unique_violation" {
+		return ErrAlreadyPassenger
+	}
+
+	return err
+}
+
+// Purge permanently deletes passengers deleted before the time given
+func (r *PassengerStore) Purge(before time.Time) (int, error) {
+	return purge(r.db, passengerPurgeSQL, before)
+}
+
 // Count determines the number of records in the db that fit the where clauses
 func (r *PassengerStore) Count(clauses []stores.QueryModifier) (int, error) {
-	where, vals := generateWhereStatement(&clauses)
+	filters := stores.Filters(stores.NotDeleted(clauses))
+	where, vals, err := generateWhereStatement(&filters, passengerColumns)
+	if err != nil {
+		return 0, err
+	}
 
 	query := "SELECT COUNT(*) FROM " + passengerTableName + " " + where
 
 	row := r.db.QueryRow(query, vals...)
 	count := 0
 
-	if err := row.Scan(&row); err != nil {
+	if err := row.Scan(&count); err != nil {
 		return 0, err
 	}
 
 	return count, nil
 }
 
+// SumSeats adds up the seats requested by the passengers that fit the where clauses
+func (r *PassengerStore) SumSeats(clauses []stores.QueryModifier) (int, error) {
+	filters := stores.Filters(stores.NotDeleted(clauses))
+	where, vals, err := generateWhereStatement(&filters, passengerColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	query := "SELECT COALESCE(SUM(seats_requested), 0) FROM " + passengerTableName + " " + where
+
+	row := r.db.QueryRow(query, vals...)
+	seats := 0
+
+	if err := row.Scan(&seats); err != nil {
+		return 0, err
+	}
+
+	return seats, nil
+}
+
 // Where provides a generic query interface to get a single passenger
 func (r *PassengerStore) Where(clauses []stores.QueryModifier) (*models.Passenger, error) {
-	where, vals := generateWhereStatement(&clauses)
-	query := "SELECT * FROM " + passengerTableName + " " + where + " LIMIT 1"
+	clauses = stores.NotDeleted(append(clauses[:len(clauses):len(clauses)], stores.Limit(1)))
+	where, vals, err := generateWhereStatement(&clauses, passengerColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT * FROM " + passengerTableName + " " + where
 
 	return r.getBy(query, vals...)
 }
 
 // WhereMany provides a generic query interface to get many passengers
 func (r *PassengerStore) WhereMany(clauses []stores.QueryModifier) ([]*models.Passenger, error) {
-	where, vals := generateWhereStatement(&clauses)
-	query := "SELECT * FROM " + passengerTableName + " " + where
+	clauses = stores.NotDeleted(clauses)
+	where, vals, err := generateWhereStatement(&clauses, passengerColumns)
+	if err != nil {
+		return nil, err
+	}
 
+	query := "SELECT * FROM " + passengerTableName + " " + where
 	passengers := []*models.Passenger{}
 
 	if err := r.db.Select(&passengers, query, vals...); err != nil {
@@ -146,8 +223,3 @@ func (r *PassengerStore) getBy(query string, arg ...interface{}) (*models.Passen
 
 	return &passenger, nil
 }
-
-// Migrate creates passenger table in DB
-func (r *PassengerStore) migrate() {
-	r.db.MustExec(passengerCreateTable)
-}
@@ -1,29 +1,38 @@
 package postgres
 
+import "github.com/ucladevx/BPool/stores"
+
 const (
 	passengerTableName = "passengers"
 
-	passengerCreateTable = `
-CREATE TABLE IF NOT EXISTS passengers (
-	id varchar(20) primary key,
-	driver_id varchar(20) NOT NULL,
-	passenger_id varchar(20) NOT NULL,
-	ride_id varchar(20) NOT NULL,
-	status varchar(20) NOT NULL,
-	created_at timestamptz DEFAULT NOW(),
-	updated_at timestamptz DEFAULT NOW(),
-	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
-	FOREIGN KEY (passenger_id) REFERENCES users (id) ON DELETE CASCADE,
-	FOREIGN KEY (ride_id) REFERENCES rides (id) ON DELETE CASCADE,
-	UNIQUE (driver_id, passenger_id, ride_id)
-);`
-
-	passengerGetAllSQL = "SELECT * FROM passengers WHERE id > $1 LIMIT $2"
-
-	passengerGetByIDSQL = "SELECT * FROM passengers WHERE id=$1"
-
-	passengerInsertSQL = "INSERT INTO passengers (id, driver_id, passenger_id, ride_id, status) VALUES ($1, $2, $3, $4, $5) RETURNING created_at, updated_at"
-	passengerUpdateSQL = "UPDATE passengers SET status=$1 updated_at=NOW() WHERE id=$2 RETURNING updated_at"
-
-	passengerDeleteSQL = "DELETE FROM passengers WHERE id=$1"
+	passengerGetByIDSQL = "SELECT * FROM passengers WHERE id=$1 AND deleted_at IS NULL"
+
+	passengerInsertSQL = "INSERT INTO passengers (id, driver_id, passenger_id, ride_id, status, seats_requested, request_id) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING created_at, updated_at, version"
+	passengerImportSQL = "INSERT INTO passengers (id, driver_id, passenger_id, ride_id, status, seats_requested, request_id, confirm_by, created_at, updated_at, deleted_at, version) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)"
+	passengerUpdateSQL = "UPDATE passengers SET status=$1, seats_requested=$2, confirm_by=$3, updated_at=NOW(), version=version+1 WHERE id=$4 AND version=$5 AND deleted_at IS NULL RETURNING updated_at, version"
+	passengerExistsSQL = "SELECT EXISTS (SELECT 1 FROM passengers WHERE id=$1 AND deleted_at IS NULL)"
+
+	passengerDeleteSQL = "UPDATE passengers SET deleted_at=NOW() WHERE id=$1 AND deleted_at IS NULL"
+
+	// only restores passengers whose ride has not been deleted
+	passengerRestoreSQL = "UPDATE passengers SET deleted_at=NULL WHERE id=$1 AND deleted_at IS NOT NULL " +
+		"AND ride_id IN (SELECT id FROM rides WHERE deleted_at IS NULL)"
+
+	passengerPurgeSQL = "DELETE FROM passengers WHERE deleted_at < $1"
+)
+
+// passengerColumns are the columns of passengers that query modifiers may use
+var passengerColumns = stores.Columns(
+	"id",
+	"driver_id",
+	"passenger_id",
+	"ride_id",
+	"status",
+	"seats_requested",
+	"request_id",
+	"confirm_by",
+	"created_at",
+	"updated_at",
+	"deleted_at",
+	"version",
 )
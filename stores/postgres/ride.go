@@ -2,23 +2,27 @@ package postgres
 
 import (
 	"database/sql"
-	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 
 	"github.com/ucladevx/BPool/models"
 	"github.com/ucladevx/BPool/stores"
 	"github.com/ucladevx/BPool/utils/id"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
 var (
 	// ErrNoRideFound error when no ride in db
-	ErrNoRideFound = errors.New("no ride found")
+	ErrNoRideFound = stores.ErrNoRideFound
 )
 
 // RideStore persits rides in a pg DB
 type RideStore struct {
-	db    *sqlx.DB
+	db    queryer
 	idGen IDgen
 }
 
@@ -30,15 +34,9 @@ func NewRideStore(db *sqlx.DB) *RideStore {
 	}
 }
 
-// GetAll returns paginated list of rides, lastID = '' for no offset
-func (r *RideStore) GetAll(lastID string, limit int) ([]*models.Ride, error) {
-	rides := []*models.Ride{}
-
-	if err := r.db.Select(&rides, rideGetAllSQL, lastID, limit); err != nil {
-		return nil, err
-	}
-
-	return rides, nil
+// GetAll returns a page of rides in the page's sort order
+func (r *RideStore) GetAll(page pagination.Page) ([]*models.Ride, error) {
+	return r.WhereMany(stores.Paginate(nil, page))
 }
 
 // GetAllWherePassenger returns all rides that the user passed is a passenger
@@ -54,9 +52,13 @@ func (r *RideStore) GetAllWherePassenger(passengerID string) ([]*models.Ride, er
 
 // WhereMany provides a generic query interface to get many rides
 func (r *RideStore) WhereMany(clauses []stores.QueryModifier) ([]*models.Ride, error) {
-	where, vals := generateWhereStatement(&clauses)
-	query := "SELECT * FROM " + rideTableName + " " + where
+	clauses = stores.NotDeleted(clauses)
+	where, vals, err := generateWhereStatement(&clauses, rideColumns)
+	if err != nil {
+		return nil, err
+	}
 
+	query := "SELECT " + rideFields + " FROM " + rideTableName + " " + where
 	rides := []*models.Ride{}
 
 	if err := r.db.Select(&rides, query, vals...); err != nil {
@@ -66,13 +68,54 @@ func (r *RideStore) WhereMany(clauses []stores.QueryModifier) ([]*models.Ride, e
 	return rides, nil
 }
 
+// Count determines the number of rides in the db that fit the where clauses
+func (r *RideStore) Count(clauses []stores.QueryModifier) (int, error) {
+	filters := stores.Filters(stores.NotDeleted(clauses))
+	where, vals, err := generateWhereStatement(&filters, rideColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	query := "SELECT COUNT(*) FROM " + rideTableName + " " + where
+
+	row := r.db.QueryRow(query, vals...)
+	count := 0
+
+	if err := row.Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Search finds the rides that match the search in the search's sort order
+func (r *RideStore) Search(search *models.RideSearch) ([]*models.Ride, error) {
+	query, args := rideSearchQuery(search)
+	rides := []*models.Ride{}
+
+	if err := r.db.Select(&rides, query, args...); err != nil {
+		return nil, err
+	}
+
+	return rides, nil
+}
+
 // GetByID finds a ride by ID if exits in the DB
 func (r *RideStore) GetByID(id string) (*models.Ride, error) {
 	return r.getBy(rideGetByIDSQL, id)
 }
 
-// Insert persists a ride to the DB
+// GetByIDForUpdate finds a ride by ID and locks it until the transaction ends
+func (r *RideStore) GetByIDForUpdate(id string) (*models.Ride, error) {
+	return r.getBy(rideGetByIDForUpdateSQL, id)
+}
+
+// Insert persists a ride to the DB, rides without a status are scheduled
 func (r *RideStore) Insert(ride *models.Ride) error {
+	if ride.Status == "" {
+		ride.Status = models.RideScheduled
+	}
+
 	ride.ID = r.idGen()
 	row := r.db.QueryRow(
 		rideInsertSQL,
@@ -88,17 +131,62 @@ func (r *RideStore) Insert(ride *models.Ride) error {
 		ride.EndLon,
 		ride.PricePerSeat,
 		ride.Info,
+		ride.SeriesID,
+		ride.OccurrenceDate,
+		ride.Status,
 		ride.StartDate,
 	)
 
-	if err := row.Scan(&ride.CreatedAt, &ride.UpdatedAt); err != nil {
+	if err := row.Scan(&ride.CreatedAt, &ride.UpdatedAt, &ride.Version); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code.Name() == "unique_violation" {
+			return stores.ErrDuplicateOccurrence
+		}
 		return err
 	}
 
 	return nil
 }
 
-// Update persists the updates for the given ride
+// Import persists a ride exactly as given, keeping its id, timestamps and version,
+// rides exported before rides had statuses are scheduled
+func (r *RideStore) Import(ride *models.Ride) error {
+	if ride.Status == "" {
+		ride.Status = models.RideScheduled
+	}
+
+	_, err := r.db.Exec(
+		rideImportSQL,
+		ride.ID,
+		ride.DriverID,
+		ride.CarID,
+		ride.Seats,
+		ride.StartCity,
+		ride.EndCity,
+		ride.StartLat,
+		ride.StartLon,
+		ride.EndLat,
+		ride.EndLon,
+		ride.PricePerSeat,
+		ride.Info,
+		ride.SeriesID,
+		ride.OccurrenceDate,
+		ride.Status,
+		ride.CancelledAt,
+		ride.CancelledBy,
+		ride.CancelReason,
+		ride.LateCancellation,
+		ride.StartDate,
+		ride.CreatedAt,
+		ride.UpdatedAt,
+		ride.DeletedAt,
+		ride.Version,
+	)
+
+	return err
+}
+
+// Update persists the updates for the given ride if it is still at the ride's version,
+// returning stores.ErrVersionConflict if it is not
 func (r *RideStore) Update(ride *models.Ride) error {
 	row := r.db.QueryRow(
 		rideUpdateSQL,
@@ -112,23 +200,44 @@ func (r *RideStore) Update(ride *models.Ride) error {
 		ride.EndLon,
 		ride.PricePerSeat,
 		ride.Info,
+		ride.Status,
+		ride.CancelledAt,
+		ride.CancelledBy,
+		ride.CancelReason,
+		ride.LateCancellation,
 		ride.StartDate,
 		ride.ID,
+		ride.Version,
 	)
 
-	if err := row.Scan(&ride.UpdatedAt); err != nil {
+	if err := row.Scan(&ride.UpdatedAt, &ride.Version); err != nil {
+		if err == sql.ErrNoRows {
+			err = missedUpdate(r.db, rideExistsSQL, ride.ID, ErrNoRideFound)
+		}
+
 		return err
 	}
 
 	return nil
 }
 
-// Delete deletes the ride, does no verification
+// Delete soft deletes the ride and its passengers, does no verification
 func (r *RideStore) Delete(id string) error {
 	_, err := r.db.Exec(rideDeleteSQL, id)
 	return err
 }
 
+// Restore undoes the deletion of a ride and the passengers deleted with it, the
+// ride's car must not be deleted
+func (r *RideStore) Restore(id string) error {
+	return execOne(r.db, ErrNoRideFound, rideRestoreSQL, id)
+}
+
+// Purge permanently deletes rides deleted before the time given
+func (r *RideStore) Purge(before time.Time) (int, error) {
+	return purge(r.db, ridePurgeSQL, before)
+}
+
 func (r *RideStore) getBy(query string, arg interface{}) (*models.Ride, error) {
 	ride := models.Ride{}
 
@@ -143,7 +252,71 @@ func (r *RideStore) getBy(query string, arg interface{}) (*models.Ride, error) {
 	return &ride, nil
 }
 
-// Migrate creates ride table in DB
-func (r *RideStore) migrate() {
-	r.db.MustExec(rideCreateTable)
+// rideSearchQuery builds the query for a search, the near points and query are
+// searched for in the derived rides table of rideSearchSQL and the rest of the
+// filters are applied to it
+func rideSearchQuery(search *models.RideSearch) (string, []interface{}) {
+	args := []interface{}{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return dialect.Placeholder(len(args))
+	}
+
+	distance, rank, derivedWhere := "NULL::float8", "NULL::float8", ""
+
+	if near := search.Near; near != nil {
+		from := fmt.Sprintf(rideSearchPointSQL, arg(near.FromLon), arg(near.FromLat))
+		to := fmt.Sprintf(rideSearchPointSQL, arg(near.ToLon), arg(near.ToLat))
+
+		distance = fmt.Sprintf(rideSearchDistanceSQL, from, to)
+		derivedWhere += fmt.Sprintf(rideSearchNearSQL, from, to, arg(near.FromRadiusKm*1000), arg(near.ToRadiusKm*1000))
+	}
+
+	if search.Query != "" {
+		tsquery := fmt.Sprintf(rideSearchTSQuerySQL, arg(search.Query))
+
+		rank = fmt.Sprintf(rideSearchRankSQL, tsquery)
+		derivedWhere += fmt.Sprintf(rideSearchMatchSQL, tsquery)
+	}
+
+	query := fmt.Sprintf(rideSearchSQL, distance, rank, derivedWhere)
+	where := []string{}
+
+	if search.StartCity != "" {
+		where = append(where, "lower(start_city) = lower("+arg(search.StartCity)+")")
+	}
+	if search.EndCity != "" {
+		where = append(where, "lower(end_city) = lower("+arg(search.EndCity)+")")
+	}
+	if !search.StartAfter.IsZero() {
+		where = append(where, "start_date >= "+arg(search.StartAfter))
+	}
+	if !search.StartBefore.IsZero() {
+		where = append(where, "start_date <= "+arg(search.StartBefore))
+	}
+	if search.MinSeats > 0 {
+		where = append(where, "seats - seats_taken >= "+arg(search.MinSeats))
+	}
+	if search.MaxPrice != nil {
+		where = append(where, "price_per_seat <= "+arg(*search.MaxPrice))
+	}
+	if len(search.Statuses) > 0 {
+		where = append(where, "status = ANY("+arg(pq.Array(search.Statuses))+")")
+	}
+
+	column := rideSortColumns[search.Sort]
+	if search.After != nil {
+		where = append(where, "("+column+", id) > ("+arg(search.After.Value())+", "+arg(search.After.ID)+")")
+	}
+
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	query += " ORDER BY " + column + ", id"
+	if search.Limit > 0 {
+		query += " LIMIT " + arg(search.Limit)
+	}
+
+	return query, args
 }
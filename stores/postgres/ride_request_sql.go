@@ -0,0 +1,40 @@
+package postgres
+
+import "github.com/ucladevx/BPool/stores"
+
+const (
+	rideRequestTableName = "ride_requests"
+
+	rideRequestGetByIDSQL = "SELECT * FROM ride_requests WHERE id=$1 AND deleted_at IS NULL"
+
+	rideRequestInsertSQL = "INSERT INTO ride_requests (id, passenger_id, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, earliest_start, latest_start, seats, max_price, info, status) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING created_at, updated_at, version"
+	rideRequestImportSQL = "INSERT INTO ride_requests (id, passenger_id, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, earliest_start, latest_start, seats, max_price, info, status, created_at, updated_at, deleted_at, version) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)"
+	rideRequestUpdateSQL = "UPDATE ride_requests SET start_city=$1, end_city=$2, start_dest_lat=$3, start_dest_lon=$4, end_dest_lat=$5, end_dest_lon=$6, " +
+		"earliest_start=$7, latest_start=$8, seats=$9, max_price=$10, info=$11, status=$12, updated_at=NOW(), version=version+1 " +
+		"WHERE id=$13 AND version=$14 AND deleted_at IS NULL RETURNING updated_at, version"
+	rideRequestExistsSQL = "SELECT EXISTS (SELECT 1 FROM ride_requests WHERE id=$1 AND deleted_at IS NULL)"
+
+	rideRequestDeleteSQL = "UPDATE ride_requests SET deleted_at=NOW() WHERE id=$1 AND deleted_at IS NULL"
+
+	// the passengers offered rides for the request are kept, their request_id is set to null
+	rideRequestPurgeSQL = "DELETE FROM ride_requests WHERE deleted_at < $1"
+)
+
+// rideRequestColumns are the columns of ride_requests that query modifiers may use
+var rideRequestColumns = stores.Columns(
+	"id",
+	"passenger_id",
+	"start_city",
+	"end_city",
+	"earliest_start",
+	"latest_start",
+	"seats",
+	"max_price",
+	"status",
+	"created_at",
+	"updated_at",
+	"deleted_at",
+	"version",
+)
@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/id"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+// RideSeriesStore persists ride series in a pg DB
+type RideSeriesStore struct {
+	db    queryer
+	idGen IDgen
+}
+
+// NewRideSeriesStore creates a new pg ride series store
+func NewRideSeriesStore(db *sqlx.DB) *RideSeriesStore {
+	return &RideSeriesStore{
+		db:    db,
+		idGen: id.New,
+	}
+}
+
+// GetAll returns a page of ride series in the page's sort order
+func (s *RideSeriesStore) GetAll(page pagination.Page) ([]*models.RideSeries, error) {
+	return s.WhereMany(stores.Paginate(nil, page))
+}
+
+// WhereMany provides a generic query interface to get many ride series
+func (s *RideSeriesStore) WhereMany(clauses []stores.QueryModifier) ([]*models.RideSeries, error) {
+	clauses = stores.NotDeleted(clauses)
+	where, vals, err := generateWhereStatement(&clauses, rideSeriesColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT * FROM " + rideSeriesTableName + " " + where
+	all := []*models.RideSeries{}
+
+	if err := s.db.Select(&all, query, vals...); err != nil {
+		return nil, err
+	}
+
+	return all, nil
+}
+
+// GetByID finds a ride series by ID if it exists in the DB
+func (s *RideSeriesStore) GetByID(id string) (*models.RideSeries, error) {
+	series := models.RideSeries{}
+
+	if err := s.db.Get(&series, rideSeriesGetByIDSQL, id); err != nil {
+		if err == sql.ErrNoRows {
+			err = stores.ErrNoRideSeriesFound
+		}
+
+		return nil, err
+	}
+
+	return &series, nil
+}
+
+// GetActive returns the series whose car is not deleted that have not been
+// materialized through their last date
+func (s *RideSeriesStore) GetActive() ([]*models.RideSeries, error) {
+	active := []*models.RideSeries{}
+
+	if err := s.db.Select(&active, rideSeriesGetActiveSQL); err != nil {
+		return nil, err
+	}
+
+	return active, nil
+}
+
+// Insert persists a ride series to the DB
+func (s *RideSeriesStore) Insert(series *models.RideSeries) error {
+	series.ID = s.idGen()
+	row := s.db.QueryRow(
+		rideSeriesInsertSQL,
+		series.ID,
+		series.DriverID,
+		series.CarID,
+		series.Seats,
+		series.StartCity,
+		series.EndCity,
+		series.StartLat,
+		series.StartLon,
+		series.EndLat,
+		series.EndLon,
+		series.PricePerSeat,
+		series.Info,
+		series.Days,
+		series.DepartureTime,
+		series.TimeZone,
+		series.StartsOn,
+		series.EndsOn,
+	)
+
+	return row.Scan(&series.CreatedAt, &series.UpdatedAt, &series.Version)
+}
+
+// Import persists a ride series exactly as given, keeping its id, timestamps and version
+func (s *RideSeriesStore) Import(series *models.RideSeries) error {
+	_, err := s.db.Exec(
+		rideSeriesImportSQL,
+		series.ID,
+		series.DriverID,
+		series.CarID,
+		series.Seats,
+		series.StartCity,
+		series.EndCity,
+		series.StartLat,
+		series.StartLon,
+		series.EndLat,
+		series.EndLon,
+		series.PricePerSeat,
+		series.Info,
+		series.Days,
+		series.DepartureTime,
+		series.TimeZone,
+		series.StartsOn,
+		series.EndsOn,
+		series.MaterializedThrough,
+		series.CreatedAt,
+		series.UpdatedAt,
+		series.DeletedAt,
+		series.Version,
+	)
+
+	return err
+}
+
+// Update persists the updates for the given ride series if it is still at the
+// series' version, returning stores.ErrVersionConflict if it is not
+func (s *RideSeriesStore) Update(series *models.RideSeries) error {
+	row := s.db.QueryRow(
+		rideSeriesUpdateSQL,
+		series.CarID,
+		series.Seats,
+		series.StartCity,
+		series.EndCity,
+		series.StartLat,
+		series.StartLon,
+		series.EndLat,
+		series.EndLon,
+		series.PricePerSeat,
+		series.Info,
+		series.Days,
+		series.DepartureTime,
+		series.TimeZone,
+		series.StartsOn,
+		series.EndsOn,
+		series.ID,
+		series.Version,
+	)
+
+	if err := row.Scan(&series.UpdatedAt, &series.Version); err != nil {
+		if err == sql.ErrNoRows {
+			err = missedUpdate(s.db, rideSeriesExistsSQL, series.ID, stores.ErrNoRideSeriesFound)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// SetMaterializedThrough records the last date the series' rides have been
+// materialized for, it does not change the series' version
+func (s *RideSeriesStore) SetMaterializedThrough(id string, date *string) error {
+	return execOne(s.db, stores.ErrNoRideSeriesFound, rideSeriesSetMaterializedThroughSQL, date, id)
+}
+
+// Delete soft deletes the ride series, its rides are left alone
+func (s *RideSeriesStore) Delete(id string) error {
+	_, err := s.db.Exec(rideSeriesDeleteSQL, id)
+	return err
+}
+
+// Purge permanently deletes ride series deleted before the time given, their rides
+// are kept but no longer linked to them
+func (s *RideSeriesStore) Purge(before time.Time) (int, error) {
+	return purge(s.db, rideSeriesPurgeSQL, before)
+}
@@ -0,0 +1,51 @@
+package postgres
+
+import "github.com/ucladevx/BPool/stores"
+
+const (
+	rideSeriesTableName = "ride_series"
+
+	rideSeriesGetByIDSQL = "SELECT * FROM ride_series WHERE id=$1 AND deleted_at IS NULL"
+
+	// series are active until they have been materialized through their last date
+	rideSeriesGetActiveSQL = "SELECT ride_series.* FROM ride_series JOIN cars ON cars.id = ride_series.car_id " +
+		"WHERE ride_series.deleted_at IS NULL AND cars.deleted_at IS NULL " +
+		"AND (materialized_through IS NULL OR materialized_through < ends_on) ORDER BY ride_series.id"
+
+	rideSeriesInsertSQL = "INSERT INTO ride_series (id, driver_id, car_id, seats, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, price_per_seat, info, days, departure_time, time_zone, starts_on, ends_on) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17) RETURNING created_at, updated_at, version"
+	rideSeriesImportSQL = "INSERT INTO ride_series (id, driver_id, car_id, seats, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, price_per_seat, info, days, departure_time, time_zone, starts_on, ends_on, materialized_through, created_at, updated_at, deleted_at, version) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)"
+	rideSeriesUpdateSQL = "UPDATE ride_series SET car_id=$1, seats=$2, start_city=$3, end_city=$4, start_dest_lat=$5, start_dest_lon=$6, end_dest_lat=$7, end_dest_lon=$8, price_per_seat=$9, info=$10, " +
+		"days=$11, departure_time=$12, time_zone=$13, starts_on=$14, ends_on=$15, updated_at=NOW(), version=version+1 " +
+		"WHERE id=$16 AND version=$17 AND deleted_at IS NULL RETURNING updated_at, version"
+	rideSeriesExistsSQL = "SELECT EXISTS (SELECT 1 FROM ride_series WHERE id=$1 AND deleted_at IS NULL)"
+
+	rideSeriesSetMaterializedThroughSQL = "UPDATE ride_series SET materialized_through=$1 WHERE id=$2 AND deleted_at IS NULL"
+
+	rideSeriesDeleteSQL = "UPDATE ride_series SET deleted_at=NOW() WHERE id=$1 AND deleted_at IS NULL"
+
+	// the series' rides are kept, their series_id is set to null
+	rideSeriesPurgeSQL = "DELETE FROM ride_series WHERE deleted_at < $1"
+)
+
+// rideSeriesColumns are the columns of ride_series that query modifiers may use
+var rideSeriesColumns = stores.Columns(
+	"id",
+	"driver_id",
+	"car_id",
+	"seats",
+	"start_city",
+	"end_city",
+	"price_per_seat",
+	"days",
+	"departure_time",
+	"time_zone",
+	"starts_on",
+	"ends_on",
+	"materialized_through",
+	"created_at",
+	"updated_at",
+	"deleted_at",
+	"version",
+)
@@ -1,40 +1,108 @@
 package postgres
 
+import (
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+)
+
 const (
 	rideTableName = "rides"
 
-	rideCreateTable = `
-CREATE EXTENSION IF NOT EXISTS postgis;
-CREATE TABLE IF NOT EXISTS rides (
-	id varchar(20) primary key,
-	driver_id varchar(20) NOT NULL,
-	car_id varchar(20) NOT NULL,
-	seats int NOT NULL DEFAULT 1,
-	start_city varchar(128) NOT NULL,
-	end_city varchar(128) NOT NULL,
-	start_dest_lat NUMERIC(9,6) NOT NULL,
-	start_dest_lon NUMERIC(9,6) NOT NULL,
-	end_dest_lat NUMERIC(9,6) NOT NULL,
-	end_dest_lon NUMERIC(9,6) NOT NULL,
-	price_per_seat NUMERIC(10,2) DEFAULT 15 NOT NULL,
-	info TEXT,
-	start_date timestamptz NOT NULL,
-	created_at timestamptz DEFAULT NOW(),
-	updated_at timestamptz DEFAULT NOW(),
-	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
-	FOREIGN KEY (car_id) REFERENCES cars (id) ON DELETE CASCADE
-);`
-
-	rideGetAllWherePassenger = "SELECT rides.*, passengers.status AS passenger_status FROM passengers JOIN rides ON rides.id = ride_id WHERE passenger_id =$1"
-
-	rideGetAllSQL = "SELECT * FROM rides WHERE id > $1 LIMIT $2"
-
-	rideGetByIDSQL = "SELECT *, (SELECT COUNT(*) FROM passengers WHERE ride_id=$1 AND status='accepted') AS seats_taken FROM rides WHERE rides.id=$1;"
-
-	rideInsertSQL = "INSERT INTO rides (id, driver_id, car_id, seats, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, price_per_seat, info, start_date) " +
-		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING created_at, updated_at"
-	rideUpdateSQL = "UPDATE rides SET car_id=$1, seats=$2, start_city=$3, end_city=$4, start_dest_lat=$5, start_dest_lon=$6, end_dest_lat=$7, end_dest_lon=$8, price_per_seat=$9, info=$10, start_date=$11, updated_at=NOW() " +
-		"WHERE id=$12 RETURNING updated_at"
-
-	rideDeleteSQL = "DELETE FROM rides WHERE id=$1"
+	// the rides columns the model has, rides also has geography and tsvector columns for searching
+	rideFields = "rides.id, rides.driver_id, rides.car_id, rides.seats, rides.start_city, rides.end_city, " +
+		"rides.start_dest_lat, rides.start_dest_lon, rides.end_dest_lat, rides.end_dest_lon, rides.price_per_seat, " +
+		"rides.info, rides.series_id, rides.occurrence_date, rides.status, rides.cancelled_at, rides.cancelled_by, rides.cancel_reason, rides.late_cancellation, rides.start_date, rides.created_at, rides.updated_at, rides.deleted_at, rides.version"
+
+	rideGetAllWherePassenger = "SELECT " + rideFields + ", passengers.status AS passenger_status FROM passengers JOIN rides ON rides.id = ride_id WHERE passenger_id =$1 AND passengers.deleted_at IS NULL AND rides.deleted_at IS NULL"
+
+	rideGetByIDSQL = "SELECT " + rideFields + ", (SELECT COALESCE(SUM(seats_requested), 0) FROM passengers WHERE ride_id=$1 AND status IN ('accepted', 'seat_offered') AND passengers.deleted_at IS NULL) AS seats_taken FROM rides WHERE rides.id=$1 AND rides.deleted_at IS NULL;"
+
+	rideGetByIDForUpdateSQL = "SELECT " + rideFields + ", (SELECT COALESCE(SUM(seats_requested), 0) FROM passengers WHERE ride_id=$1 AND status IN ('accepted', 'seat_offered') AND passengers.deleted_at IS NULL) AS seats_taken FROM rides WHERE rides.id=$1 AND rides.deleted_at IS NULL FOR UPDATE;"
+
+	rideInsertSQL = "INSERT INTO rides (id, driver_id, car_id, seats, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, price_per_seat, info, series_id, occurrence_date, status, start_date) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING created_at, updated_at, version"
+	rideImportSQL = "INSERT INTO rides (id, driver_id, car_id, seats, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, price_per_seat, info, series_id, occurrence_date, status, cancelled_at, cancelled_by, cancel_reason, late_cancellation, start_date, created_at, updated_at, deleted_at, version) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24)"
+	rideUpdateSQL = "UPDATE rides SET car_id=$1, seats=$2, start_city=$3, end_city=$4, start_dest_lat=$5, start_dest_lon=$6, end_dest_lat=$7, end_dest_lon=$8, price_per_seat=$9, info=$10, status=$11, cancelled_at=$12, cancelled_by=$13, cancel_reason=$14, late_cancellation=$15, start_date=$16, updated_at=NOW(), version=version+1 " +
+		"WHERE id=$17 AND version=$18 AND deleted_at IS NULL RETURNING updated_at, version"
+	rideExistsSQL = "SELECT EXISTS (SELECT 1 FROM rides WHERE id=$1 AND deleted_at IS NULL)"
+
+	// soft deletes the ride and its passengers with the same deleted_at so that
+	// restoring the ride can restore them too
+	rideDeleteSQL = `
+		WITH deleted_ride AS (
+			UPDATE rides SET deleted_at=NOW() WHERE id=$1 AND deleted_at IS NULL RETURNING id, deleted_at
+		)
+		UPDATE passengers SET deleted_at=deleted_ride.deleted_at FROM deleted_ride
+		WHERE passengers.ride_id=deleted_ride.id AND passengers.deleted_at IS NULL
+	`
+
+	// only restores rides whose car has not been deleted
+	rideRestoreSQL = `
+		WITH deleted_ride AS (
+			SELECT rides.id, rides.deleted_at FROM rides JOIN cars ON cars.id = rides.car_id
+			WHERE rides.id=$1 AND rides.deleted_at IS NOT NULL AND cars.deleted_at IS NULL
+		), restored_passengers AS (
+			UPDATE passengers SET deleted_at=NULL FROM deleted_ride
+			WHERE passengers.ride_id=deleted_ride.id AND passengers.deleted_at=deleted_ride.deleted_at
+		)
+		UPDATE rides SET deleted_at=NULL FROM deleted_ride WHERE rides.id=deleted_ride.id
+	`
+
+	ridePurgeSQL = "DELETE FROM rides WHERE deleted_at < $1"
+
+	// rides are searched in a derived table so that seats_taken, distance_km and rank
+	// can be filtered and sorted on, seats_taken is counted the same way as rideGetByIDSQL,
+	// the distance_km, rank and extra where clauses are filled in by rideSearchQuery
+	rideSearchSQL = "SELECT * FROM (SELECT " + rideFields + ", " +
+		"(SELECT COALESCE(SUM(seats_requested), 0) FROM passengers WHERE ride_id=rides.id AND status IN ('accepted', 'seat_offered') AND passengers.deleted_at IS NULL) AS seats_taken, " +
+		"%s AS distance_km, %s AS rank FROM rides WHERE rides.deleted_at IS NULL%s) AS rides"
+
+	// the GiST indexes on start_point and end_point are used by ST_DWithin
+	rideSearchPointSQL    = "ST_SetSRID(ST_MakePoint(%s, %s), 4326)::geography"
+	rideSearchDistanceSQL = "(ST_Distance(rides.start_point, %[1]s) + ST_Distance(rides.end_point, %[2]s)) / 1000"
+	rideSearchNearSQL     = " AND ST_DWithin(rides.start_point, %[1]s, %[3]s) AND ST_DWithin(rides.end_point, %[2]s, %[4]s)"
+
+	// the GIN index on search_vector is used by @@, rank is a float8 so that cursors
+	// hold exactly the rank they are compared to
+	rideSearchTSQuerySQL = "plainto_tsquery('english', %s)"
+	rideSearchRankSQL    = "ts_rank(rides.search_vector, %s)::float8"
+	rideSearchMatchSQL   = " AND rides.search_vector @@ %s"
 )
+
+// rideColumns are the columns of rides that query modifiers may use
+var rideColumns = stores.Columns(
+	"id",
+	"driver_id",
+	"car_id",
+	"seats",
+	"start_city",
+	"end_city",
+	"start_dest_lat",
+	"start_dest_lon",
+	"end_dest_lat",
+	"end_dest_lon",
+	"price_per_seat",
+	"info",
+	"series_id",
+	"occurrence_date",
+	"status",
+	"cancelled_at",
+	"cancelled_by",
+	"cancel_reason",
+	"late_cancellation",
+	"start_date",
+	"created_at",
+	"updated_at",
+	"deleted_at",
+	"version",
+)
+
+// rideSortColumns are the columns searched rides are sorted by
+var rideSortColumns = map[string]string{
+	models.RideSortDate:     "start_date",
+	models.RideSortPrice:    "price_per_seat",
+	models.RideSortDistance: "distance_km",
+	// the best matches have the highest rank
+	models.RideSortRelevance: "-rank",
+}
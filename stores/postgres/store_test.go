@@ -0,0 +1,47 @@
+package postgres_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ucladevx/BPool/mocks"
+	"github.com/ucladevx/BPool/stores/postgres"
+	"github.com/ucladevx/BPool/stores/storetest"
+)
+
+// TestStoreContract needs a database it is allowed to wipe, for example
+// BPOOL_TEST_DB="user=postgres dbname=bpool_test sslmode=disable"
+func TestStoreContract(t *testing.T) {
+	dsn := os.Getenv("BPOOL_TEST_DB")
+	if dsn == "" {
+		t.Skip("BPOOL_TEST_DB is not set")
+	}
+
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := postgres.NewMigrator(db, mocks.Logger{}).Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		db.MustExec("TRUNCATE users, cars, ride_series, rides, ride_requests, passengers, outbox CASCADE")
+
+		return storetest.Stores{
+			Users:      postgres.NewUserStore(db),
+			Cars:       postgres.NewCarStore(db),
+			Rides:      postgres.NewRideStore(db),
+			RideSeries: postgres.NewRideSeriesStore(db),
+			Requests:   postgres.NewRideRequestStore(db),
+			Passengers: postgres.NewPassengerStore(db),
+			Audit:      postgres.NewAuditStore(db),
+			Outbox:     postgres.NewOutboxStore(db),
+			Transactor: postgres.NewTransactor(db),
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/utils/id"
+)
+
+type (
+	// Transactor runs units of work in a pg transaction
+	Transactor struct {
+		db *sqlx.DB
+	}
+
+	// tx is a unit of work whose stores all use the same pg transaction
+	tx struct {
+		rides      *RideStore
+		series     *RideSeriesStore
+		requests   *RideRequestStore
+		passengers *PassengerStore
+		audit      *AuditStore
+		outbox     *OutboxStore
+	}
+)
+
+// NewTransactor creates a new pg transactor
+func NewTransactor(db *sqlx.DB) *Transactor {
+	return &Transactor{
+		db: db,
+	}
+}
+
+// WithTx runs fn in a transaction, committing if it returns nil and rolling back otherwise
+func (t *Transactor) WithTx(fn func(tx services.Tx) error) error {
+	sqlTx, err := t.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			sqlTx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(newTx(sqlTx)); err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+
+	return sqlTx.Commit()
+}
+
+func newTx(sqlTx *sqlx.Tx) *tx {
+	return &tx{
+		rides:      &RideStore{db: sqlTx, idGen: id.New},
+		series:     &RideSeriesStore{db: sqlTx, idGen: id.New},
+		requests:   &RideRequestStore{db: sqlTx, idGen: id.New},
+		passengers: &PassengerStore{db: sqlTx, idGen: id.New},
+		audit:      &AuditStore{db: sqlTx, idGen: id.New},
+		outbox:     &OutboxStore{db: sqlTx, idGen: id.New},
+	}
+}
+
+// Rides returns a ride store that uses the transaction
+func (t *tx) Rides() services.RideStore {
+	return t.rides
+}
+
+// RideSeries returns a ride series store that uses the transaction
+func (t *tx) RideSeries() services.RideSeriesStore {
+	return t.series
+}
+
+// RideRequests returns a ride request store that uses the transaction
+func (t *tx) RideRequests() services.RideRequestStore {
+	return t.requests
+}
+
+// Passengers returns a passenger store that uses the transaction
+func (t *tx) Passengers() services.PassengerStore {
+	return t.passengers
+}
+
+// Audit returns an audit store that uses the transaction
+func (t *tx) Audit() services.AuditStore {
+	return t.audit
+}
+
+// Outbox returns an outbox store that uses the transaction
+func (t *tx) Outbox() services.OutboxStore {
+	return t.outbox
+}
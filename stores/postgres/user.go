@@ -2,26 +2,27 @@ package postgres
 
 import (
 	"database/sql"
-	"errors"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 
 	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
 	"github.com/ucladevx/BPool/utils/id"
+	"github.com/ucladevx/BPool/utils/pagination"
 )
 
 var (
 	// ErrNoUserFound error when no user in db
-	ErrNoUserFound = errors.New("no user found")
+	ErrNoUserFound = stores.ErrNoUserFound
 
 	// ErrUserAlreadyExists error when a duplicate user insertion is attempted
-	ErrUserAlreadyExists = errors.New("user already exists")
+	ErrUserAlreadyExists = stores.ErrUserAlreadyExists
 )
 
 // UserStore persits users in a pg DB
 type UserStore struct {
-	db    *sqlx.DB
+	db    queryer
 	idGen IDgen
 }
 
@@ -33,11 +34,18 @@ func NewUserStore(db *sqlx.DB) *UserStore {
 	}
 }
 
-// GetAll returns paginated list of users, lastID = '' for no offset
-func (u *UserStore) GetAll(lastID string, limit int) ([]*models.User, error) {
+// GetAll returns a page of users in the page's sort order
+func (u *UserStore) GetAll(page pagination.Page) ([]*models.User, error) {
+	clauses := stores.Paginate(nil, page)
+	where, vals, err := generateWhereStatement(&clauses, userColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT * FROM " + userTableName + " " + where
 	users := []*models.User{}
 
-	if err := u.db.Select(&users, userGetAllSQL, lastID, limit); err != nil {
+	if err := u.db.Select(&users, query, vals...); err != nil {
 		return nil, err
 	}
 
@@ -71,6 +79,16 @@ func (u *UserStore) Insert(user *models.User) error {
 	return nil
 }
 
+// Import persists a user exactly as given, keeping its id and timestamps
+func (u *UserStore) Import(user *models.User) error {
+	_, err := u.db.Exec(userImportSQL, user.ID, user.FirstName, user.LastName, user.Email, user.ProfileImage, user.AuthLevel, user.CreatedAt, user.UpdatedAt)
+	if pgErr, ok := err.(*pq.Error); ok && pgErr.Code.Name() == "unique_violation" {
+		return ErrUserAlreadyExists
+	}
+
+	return err
+}
+
 func (u *UserStore) getBy(query string, arg interface{}) (*models.User, error) {
 	var user models.User
 
@@ -84,8 +102,3 @@ func (u *UserStore) getBy(query string, arg interface{}) (*models.User, error) {
 
 	return &user, nil
 }
-
-// Migrate creates user table in DB
-func (u *UserStore) migrate() {
-	u.db.MustExec(userCreateTable)
-}
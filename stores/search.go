@@ -0,0 +1,37 @@
+package stores
+
+import (
+	"sort"
+
+	"github.com/ucladevx/BPool/models"
+)
+
+// SearchRides finds the rides that match the search in the search's sort order, for
+// stores that cannot search themselves, the rides must have SeatsTaken set
+func SearchRides(rides []*models.Ride, search *models.RideSearch) []*models.Ride {
+	found := []*models.Ride{}
+
+	for _, ride := range rides {
+		if search.Matches(ride) {
+			found = append(found, ride)
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return search.Less(found[i], found[j])
+	})
+
+	if search.Limit > 0 && len(found) > search.Limit {
+		found = found[:search.Limit]
+	}
+
+	return found
+}
+
+// LatitudeBand is the range of latitudes within radiusKm of lat, stores can use it
+// to narrow down the rides passed to SearchRides
+func LatitudeBand(lat, radiusKm float64) (min, max float64) {
+	degrees := radiusKm / models.KmPerDegreeLatitude
+
+	return lat - degrees, lat + degrees
+}
@@ -0,0 +1,252 @@
+package stores
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// QueryModifierError occurs when a modifier cannot be turned into a query, because its
+// column is not allowed, its operator is unknown or its value does not fit the operator
+type QueryModifierError struct {
+	Modifier QueryModifier
+	Reason   string
+}
+
+func (e *QueryModifierError) Error() string {
+	return fmt.Sprintf("invalid query modifier %q %q: %s", e.Modifier.Column, e.Modifier.Operator, e.Reason)
+}
+
+func invalid(modifier QueryModifier, reason string) error {
+	return &QueryModifierError{Modifier: modifier, Reason: reason}
+}
+
+// ColumnSet is the set of columns a store allows modifiers on
+type ColumnSet map[string]bool
+
+// Columns creates a column set
+func Columns(names ...string) ColumnSet {
+	set := make(ColumnSet, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+
+	return set
+}
+
+// IsOperator reports whether op is one of the QueryModifierOp constants
+func IsOperator(op QueryModifierOp) bool {
+	switch op {
+	case EQ, LT, LTE, NE, GT, GTE, IN, NOTIN, ILIKE, ISNULL, NOTNULL, BETWEEN, GROUP, ORDERBY, LIMIT, OFFSET:
+		return true
+	}
+
+	return false
+}
+
+// Dialect describes how a database spells the parts of a query that differ between
+// SQL implementations
+type Dialect struct {
+	// Placeholder renders the nth bind parameter, starting from 1
+	Placeholder func(n int) string
+	// ILike is the case insensitive pattern match operator
+	ILike string
+	// Value converts args into the form the database stores them in
+	Value func(v interface{}) interface{}
+	// NoLimit is the LIMIT that returns every row, used when only an offset is given
+	NoLimit string
+}
+
+// BuildWhere renders modifiers as a WHERE clause followed by any ORDER BY, LIMIT and
+// OFFSET options, along with the args to bind. No modifiers renders an empty string.
+// Only columns in allowed may be used, since they are written into the query as is.
+func BuildWhere(modifiers []QueryModifier, dialect Dialect, allowed ColumnSet) (string, []interface{}, error) {
+	b := builder{dialect: dialect, allowed: allowed}
+
+	var order []string
+	var limit, offset string
+
+	filters := Filters(modifiers)
+	if len(filters) > 0 {
+		where, err := b.clauses(filters)
+		if err != nil {
+			return "", nil, err
+		}
+		b.query = "WHERE " + where
+	}
+
+	for _, modifier := range modifiers {
+		switch modifier.Operator {
+		case ORDERBY:
+			if err := b.column(modifier); err != nil {
+				return "", nil, err
+			}
+
+			direction, ok := modifier.Value.(string)
+			if !ok || (direction != Asc && direction != Desc) {
+				return "", nil, invalid(modifier, "direction must be ASC or DESC")
+			}
+			order = append(order, modifier.Column+" "+direction)
+		case LIMIT, OFFSET:
+			n, ok := modifier.Value.(int)
+			if !ok || n < 0 {
+				return "", nil, invalid(modifier, "value must be a non negative int")
+			}
+
+			if modifier.Operator == LIMIT {
+				limit = "LIMIT " + strconv.Itoa(n) + " "
+			} else {
+				offset = "OFFSET " + strconv.Itoa(n) + " "
+			}
+		}
+	}
+
+	if len(order) > 0 {
+		b.query += "ORDER BY " + strings.Join(order, ", ") + " "
+	}
+
+	if offset != "" && limit == "" && dialect.NoLimit != "" {
+		limit = "LIMIT " + dialect.NoLimit + " "
+	}
+
+	return b.query + limit + offset, b.args, nil
+}
+
+type builder struct {
+	dialect Dialect
+	allowed ColumnSet
+	query   string
+	args    []interface{}
+}
+
+func (b *builder) column(modifier QueryModifier) error {
+	if !b.allowed[modifier.Column] {
+		return invalid(modifier, "column not allowed")
+	}
+
+	return nil
+}
+
+func (b *builder) arg(v interface{}) string {
+	if b.dialect.Value != nil {
+		v = b.dialect.Value(v)
+	}
+
+	b.args = append(b.args, v)
+	return b.dialect.Placeholder(len(b.args))
+}
+
+func (b *builder) clauses(modifiers []QueryModifier) (string, error) {
+	where := ""
+
+	for _, modifier := range modifiers {
+		if modifier.Column == And.Column || modifier.Column == Or.Column {
+			where += modifier.Column + " "
+			continue
+		}
+
+		clause, err := b.clause(modifier)
+		if err != nil {
+			return "", err
+		}
+		where += clause + " "
+	}
+
+	return where, nil
+}
+
+func (b *builder) clause(modifier QueryModifier) (string, error) {
+	if !IsOperator(modifier.Operator) {
+		return "", invalid(modifier, "unknown operator")
+	}
+
+	if modifier.Operator == GROUP {
+		group, ok := modifier.Value.([]QueryModifier)
+		if !ok || len(group) == 0 {
+			return "", invalid(modifier, "group must contain modifiers")
+		}
+
+		where, err := b.clauses(group)
+		if err != nil {
+			return "", err
+		}
+
+		return "(" + strings.TrimSpace(where) + ")", nil
+	}
+
+	if err := b.column(modifier); err != nil {
+		return "", err
+	}
+
+	switch modifier.Operator {
+	case ISNULL, NOTNULL:
+		return modifier.Column + " " + modifier.Operator, nil
+	case IN, NOTIN:
+		values, ok := List(modifier.Value)
+		if !ok {
+			return "", invalid(modifier, "value must be a slice")
+		}
+
+		// an empty list matches nothing, or everything when negated
+		if len(values) == 0 {
+			if modifier.Operator == IN {
+				return "1=0", nil
+			}
+			return "1=1", nil
+		}
+
+		placeholders := make([]string, len(values))
+		for i, v := range values {
+			placeholders[i] = b.arg(v)
+		}
+
+		return modifier.Column + " " + modifier.Operator + " (" + strings.Join(placeholders, ", ") + ")", nil
+	case BETWEEN:
+		values, ok := List(modifier.Value)
+		if !ok || len(values) != 2 || values[0] == nil || values[1] == nil {
+			return "", invalid(modifier, "value must be a slice of two values")
+		}
+
+		return modifier.Column + " BETWEEN " + b.arg(values[0]) + " AND " + b.arg(values[1]), nil
+	case ILIKE:
+		if _, ok := modifier.Value.(string); !ok {
+			return "", invalid(modifier, "value must be a string pattern")
+		}
+
+		return modifier.Column + " " + b.dialect.ILike + " " + b.arg(modifier.Value), nil
+	case ORDERBY, LIMIT, OFFSET:
+		return "", invalid(modifier, "options cannot be grouped")
+	}
+
+	if modifier.Value == nil {
+		return "", invalid(modifier, "value is nil, use IS NULL")
+	}
+
+	return modifier.Column + modifier.Operator + b.arg(modifier.Value), nil
+}
+
+// List converts a slice of any type into a slice of its elements
+func List(v interface{}) ([]interface{}, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+
+	values := make([]interface{}, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+
+	return values, true
+}
+
+// DollarPlaceholder renders postgres style $n placeholders
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// QuestionPlaceholder renders ? placeholders
+func QuestionPlaceholder(n int) string {
+	return "?"
+}
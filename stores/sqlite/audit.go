@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/id"
+)
+
+// AuditStore persists audit events in a sqlite DB, events are never updated or deleted
+type AuditStore struct {
+	db    queryer
+	idGen IDgen
+}
+
+// NewAuditStore creates a new sqlite audit store
+func NewAuditStore(db *sqlx.DB) *AuditStore {
+	return &AuditStore{
+		db:    db,
+		idGen: id.New,
+	}
+}
+
+// Insert persists an audit event to the DB
+func (a *AuditStore) Insert(event *models.AuditEvent) error {
+	event.ID = a.idGen()
+	event.CreatedAt = now()
+
+	_, err := a.db.Exec(
+		auditInsertSQL,
+		event.ID,
+		event.ActorID,
+		event.Entity,
+		event.EntityID,
+		event.Action,
+		event.Before,
+		event.After,
+		event.CreatedAt,
+	)
+
+	return err
+}
+
+// WhereMany provides a generic query interface to get many audit events
+func (a *AuditStore) WhereMany(clauses []stores.QueryModifier) ([]*models.AuditEvent, error) {
+	where, vals, err := generateWhereStatement(&clauses, auditColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT * FROM " + auditTableName + " " + where
+	events := []*models.AuditEvent{}
+
+	if err := a.db.Select(&events, query, vals...); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
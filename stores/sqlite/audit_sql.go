@@ -0,0 +1,19 @@
+package sqlite
+
+import "github.com/ucladevx/BPool/stores"
+
+const (
+	auditTableName = "audit_events"
+
+	auditInsertSQL = "INSERT INTO audit_events (id, actor_id, entity, entity_id, action, before, after, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+)
+
+// auditColumns are the columns of audit_events that query modifiers may use
+var auditColumns = stores.Columns(
+	"id",
+	"actor_id",
+	"entity",
+	"entity_id",
+	"action",
+	"created_at",
+)
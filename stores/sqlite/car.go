@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/id"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+// CarStore persists cars in a sqlite DB
+type CarStore struct {
+	db    queryer
+	idGen IDgen
+}
+
+// NewCarStore creates a new sqlite car store
+func NewCarStore(db *sqlx.DB) *CarStore {
+	return &CarStore{
+		db:    db,
+		idGen: id.New,
+	}
+}
+
+// GetAll returns a page of cars in the page's sort order
+func (c *CarStore) GetAll(page pagination.Page) ([]*models.Car, error) {
+	clauses := stores.NotDeleted(stores.Paginate(nil, page))
+	where, vals, err := generateWhereStatement(&clauses, carColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	cars := []*models.Car{}
+
+	if err := c.db.Select(&cars, "SELECT * FROM cars "+where, vals...); err != nil {
+		return nil, err
+	}
+
+	return cars, nil
+}
+
+// GetByID finds car by id if it exists in db
+func (c *CarStore) GetByID(id string) (*models.Car, error) {
+	var car models.Car
+
+	if err := c.db.Get(&car, carsGetByIDSQL, id); err != nil {
+		if err == sql.ErrNoRows {
+			err = stores.ErrNoCarFound
+		}
+
+		return nil, err
+	}
+
+	return &car, nil
+}
+
+// GetCount gets the count of a generated where statement
+func (c *CarStore) GetCount(queryModifiers []stores.QueryModifier) (int, error) {
+	var count int
+
+	filters := stores.Filters(stores.NotDeleted(queryModifiers))
+	query, vals, err := generateWhereStatement(&filters, carColumns)
+	if err != nil {
+		return -1, err
+	}
+
+	if err := c.db.Get(&count, carsGetCountSQL+query, vals...); err != nil {
+		return -1, err
+	}
+
+	return count, nil
+}
+
+// Insert persists a car to the DB
+func (c *CarStore) Insert(car *models.Car) error {
+	car.ID = c.idGen()
+	car.CreatedAt = now()
+	car.UpdatedAt = car.CreatedAt
+
+	_, err := c.db.Exec(carsInsertSQL, car.ID, car.Make, car.Model, car.Year, car.Color, car.UserID, car.CreatedAt, car.UpdatedAt)
+
+	return err
+}
+
+// Import persists a car exactly as given, keeping its id and timestamps
+func (c *CarStore) Import(car *models.Car) error {
+	_, err := c.db.Exec(carsImportSQL, car.ID, car.Make, car.Model, car.Year, car.Color, car.UserID, car.CreatedAt.UTC(), car.UpdatedAt.UTC(), nullTime(car.DeletedAt))
+
+	return err
+}
+
+// Remove soft deletes the car along with its rides and their passengers
+func (c *CarStore) Remove(id string) error {
+	deletedAt := now()
+
+	err := inTx(c.db, func(tx queryer) error {
+		if _, err := tx.Exec(carsDeletePassengersSQL, deletedAt, id); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(carsDeleteRidesSQL, deletedAt, id); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(carsDeleteSQL, deletedAt, id)
+		return err
+	})
+
+	if err != nil {
+		return stores.ErrInvalidCarEntry
+	}
+
+	return nil
+}
+
+// Restore undoes the removal of a car, along with the rides and passengers removed with it
+func (c *CarStore) Restore(id string) error {
+	return inTx(c.db, func(tx queryer) error {
+		if _, err := tx.Exec(carsRestorePassengersSQL, id, id, id); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(carsRestoreRidesSQL, id, id); err != nil {
+			return err
+		}
+
+		return execOne(tx, stores.ErrNoCarFound, carsRestoreSQL, id)
+	})
+}
+
+// Purge permanently deletes cars removed before the time given
+func (c *CarStore) Purge(before time.Time) (int, error) {
+	return purge(c.db, carsPurgeSQL, before)
+}
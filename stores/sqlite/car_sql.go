@@ -0,0 +1,45 @@
+package sqlite
+
+import "github.com/ucladevx/BPool/stores"
+
+const (
+	carsGetByIDSQL = "SELECT * FROM cars WHERE id=? AND deleted_at IS NULL"
+
+	carsGetCountSQL = "SELECT COUNT(*) FROM cars "
+
+	carsInsertSQL = `
+		INSERT INTO cars (id, make, model, year, color, user_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	carsImportSQL = `
+		INSERT INTO cars (id, make, model, year, color, user_id, created_at, updated_at, deleted_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	// a car is soft deleted along with its rides and their passengers, all with the
+	// same deleted_at so that restoring the car can restore them too
+	carsDeletePassengersSQL = "UPDATE passengers SET deleted_at=? WHERE deleted_at IS NULL AND ride_id IN (SELECT id FROM rides WHERE car_id=? AND deleted_at IS NULL)"
+	carsDeleteRidesSQL      = "UPDATE rides SET deleted_at=? WHERE car_id=? AND deleted_at IS NULL"
+	carsDeleteSQL           = "UPDATE cars SET deleted_at=? WHERE id=? AND deleted_at IS NULL"
+
+	carsRestorePassengersSQL = "UPDATE passengers SET deleted_at=NULL WHERE deleted_at=(SELECT deleted_at FROM cars WHERE id=?) " +
+		"AND ride_id IN (SELECT id FROM rides WHERE car_id=? AND deleted_at=(SELECT deleted_at FROM cars WHERE id=?))"
+	carsRestoreRidesSQL = "UPDATE rides SET deleted_at=NULL WHERE car_id=? AND deleted_at=(SELECT deleted_at FROM cars WHERE id=?)"
+	carsRestoreSQL      = "UPDATE cars SET deleted_at=NULL WHERE id=? AND deleted_at IS NOT NULL"
+
+	carsPurgeSQL = "DELETE FROM cars WHERE deleted_at < ?"
+)
+
+// carColumns are the columns of cars that query modifiers may use
+var carColumns = stores.Columns(
+	"id",
+	"make",
+	"model",
+	"year",
+	"color",
+	"user_id",
+	"created_at",
+	"updated_at",
+	"deleted_at",
+)
@@ -0,0 +1,39 @@
+package sqlite
+
+import (
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	// registers the sqlite3 driver
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/ucladevx/BPool/interfaces"
+)
+
+// NewConnection opens the SQLite DB at path, creating it if it does not exist.
+// Transactions begin immediately, taking the write lock up front, so that what a
+// transaction reads cannot change before it writes
+func NewConnection(path string, l interfaces.Logger) *sqlx.DB {
+	l.Info("DB CONN", "path", path)
+
+	dsn := path + "?_txlock=immediate"
+	if strings.Contains(path, "?") {
+		dsn = path + "&_txlock=immediate"
+	}
+
+	var db *sqlx.DB
+	var err error
+	if db, err = sqlx.Connect("sqlite3", dsn); err != nil {
+		l.Panic("DB CONN FAILED", "error", err.Error())
+	}
+
+	// SQLite only allows one writer at a time, and a single connection keeps
+	// the foreign_keys pragma and in-memory databases alive
+	db.SetMaxOpenConns(1)
+
+	if _, err = db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		l.Panic("DB CONN FAILED", "error", err.Error())
+	}
+
+	return db
+}
@@ -0,0 +1,127 @@
+package sqlite
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/ucladevx/BPool/stores"
+)
+
+// IDgen generates ids for new records
+type IDgen = func() string
+
+// queryer is implemented by both *sqlx.DB and *sqlx.Tx, so stores can be used in transactions
+type queryer interface {
+	sqlx.Ext
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+}
+
+// sqlite's LIKE is already case insensitive for ASCII
+var dialect = stores.Dialect{
+	Placeholder: stores.QuestionPlaceholder,
+	ILike:       "LIKE",
+	Value:       value,
+	NoLimit:     "-1",
+}
+
+func generateWhereStatement(modifiers *[]stores.QueryModifier, allowed stores.ColumnSet) (string, []interface{}, error) {
+	return stores.BuildWhere(*modifiers, dialect, allowed)
+}
+
+// value converts query args into the form they are stored in, times are stored
+// as text in UTC so that they compare correctly
+func value(v interface{}) interface{} {
+	if t, ok := v.(time.Time); ok {
+		return t.UTC()
+	}
+
+	return v
+}
+
+// nullTime converts an optional time into the form it is stored in
+func nullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+
+	return t.UTC()
+}
+
+// now is the current time truncated to what the db stores
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func isUniqueViolation(err error) bool {
+	sqliteErr, ok := err.(sqlite3.Error)
+	return ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// inTx runs fn in a transaction so that statements changing several tables apply
+// together, stores that are already part of a transaction just use it
+func inTx(db queryer, fn func(tx queryer) error) error {
+	sqlDB, ok := db.(*sqlx.DB)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := sqlDB.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// execOne runs a statement that should change a row, returning notFound if none changed
+func execOne(db queryer, notFound error, query string, args ...interface{}) error {
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+// purge permanently deletes rows soft deleted before the time given
+func purge(db queryer, query string, before time.Time) (int, error) {
+	result, err := db.Exec(query, value(before))
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := result.RowsAffected()
+	return int(count), err
+}
+
+// missedUpdate explains why an update matched no rows, the row either changed
+// version since it was read or does not exist
+func missedUpdate(db queryer, existsQuery, id string, notFound error) error {
+	var exists bool
+	if err := db.Get(&exists, existsQuery, id); err != nil {
+		return err
+	}
+
+	if exists {
+		return stores.ErrVersionConflict
+	}
+
+	return notFound
+}
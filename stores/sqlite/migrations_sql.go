@@ -0,0 +1,590 @@
+package sqlite
+
+const (
+	migration0001Up = `
+CREATE TABLE IF NOT EXISTS users (
+	id varchar(20) primary key,
+	first_name varchar(128) NOT NULL,
+	last_name varchar(128) NOT NULL,
+	email varchar(512) NOT NULL,
+	profile_image varchar(1024),
+	auth_level integer DEFAULT 0,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (email)
+);
+
+CREATE TABLE IF NOT EXISTS cars (
+	id varchar(20) primary key,
+	make varchar(128) NOT NULL,
+	model varchar(128),
+	year int NOT NULL,
+	color varchar(64) NOT NULL,
+	user_id varchar(20) NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS rides (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	car_id varchar(20) NOT NULL,
+	seats int NOT NULL DEFAULT 1,
+	start_city varchar(128) NOT NULL,
+	end_city varchar(128) NOT NULL,
+	start_dest_lat real NOT NULL,
+	start_dest_lon real NOT NULL,
+	end_dest_lat real NOT NULL,
+	end_dest_lon real NOT NULL,
+	price_per_seat real DEFAULT 15 NOT NULL,
+	info text,
+	start_date timestamp NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (car_id) REFERENCES cars (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS passengers (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	passenger_id varchar(20) NOT NULL,
+	ride_id varchar(20) NOT NULL,
+	status varchar(20) NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (passenger_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (ride_id) REFERENCES rides (id) ON DELETE CASCADE,
+	UNIQUE (driver_id, passenger_id, ride_id)
+);`
+
+	migration0001Down = `
+DROP TABLE IF EXISTS passengers;
+DROP TABLE IF EXISTS rides;
+DROP TABLE IF EXISTS cars;
+DROP TABLE IF EXISTS users;`
+
+	// sqlite cannot drop the unique constraint on passengers, so the table is rebuilt
+	// with a unique index that ignores deleted passengers instead
+	migration0002Up = `
+ALTER TABLE cars ADD COLUMN deleted_at timestamp;
+ALTER TABLE rides ADD COLUMN deleted_at timestamp;
+
+CREATE TABLE passengers_soft_delete (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	passenger_id varchar(20) NOT NULL,
+	ride_id varchar(20) NOT NULL,
+	status varchar(20) NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	deleted_at timestamp,
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (passenger_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (ride_id) REFERENCES rides (id) ON DELETE CASCADE
+);
+
+INSERT INTO passengers_soft_delete (id, driver_id, passenger_id, ride_id, status, created_at, updated_at)
+	SELECT id, driver_id, passenger_id, ride_id, status, created_at, updated_at FROM passengers;
+DROP TABLE passengers;
+ALTER TABLE passengers_soft_delete RENAME TO passengers;
+
+CREATE UNIQUE INDEX passengers_not_deleted_key ON passengers (driver_id, passenger_id, ride_id) WHERE deleted_at IS NULL;
+
+CREATE INDEX cars_deleted_at_idx ON cars (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX rides_deleted_at_idx ON rides (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX passengers_deleted_at_idx ON passengers (deleted_at) WHERE deleted_at IS NOT NULL;`
+
+	// sqlite cannot drop columns either, so the rows that were not deleted are copied
+	// aside while the tables are recreated as they were
+	migration0002Down = `
+CREATE TEMP TABLE cars_backup AS SELECT id, make, model, year, color, user_id, created_at, updated_at FROM cars WHERE deleted_at IS NULL;
+CREATE TEMP TABLE rides_backup AS SELECT id, driver_id, car_id, seats, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, price_per_seat, info, start_date, created_at, updated_at FROM rides WHERE deleted_at IS NULL;
+CREATE TEMP TABLE passengers_backup AS SELECT id, driver_id, passenger_id, ride_id, status, created_at, updated_at FROM passengers WHERE deleted_at IS NULL;
+
+DROP TABLE passengers;
+DROP TABLE rides;
+DROP TABLE cars;
+` + migration0001Up + `
+
+INSERT INTO cars (id, make, model, year, color, user_id, created_at, updated_at)
+	SELECT id, make, model, year, color, user_id, created_at, updated_at FROM cars_backup;
+INSERT INTO rides (id, driver_id, car_id, seats, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, price_per_seat, info, start_date, created_at, updated_at)
+	SELECT id, driver_id, car_id, seats, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, price_per_seat, info, start_date, created_at, updated_at FROM rides_backup;
+INSERT INTO passengers (id, driver_id, passenger_id, ride_id, status, created_at, updated_at)
+	SELECT id, driver_id, passenger_id, ride_id, status, created_at, updated_at FROM passengers_backup;
+
+DROP TABLE cars_backup;
+DROP TABLE rides_backup;
+DROP TABLE passengers_backup;`
+
+	migration0003Up = `
+CREATE TABLE IF NOT EXISTS audit_events (
+	id varchar(20) primary key,
+	actor_id varchar(20) NOT NULL,
+	entity varchar(32) NOT NULL,
+	entity_id varchar(20) NOT NULL,
+	action varchar(32) NOT NULL,
+	before TEXT NOT NULL DEFAULT '{}',
+	after TEXT NOT NULL DEFAULT '{}',
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX audit_events_entity_idx ON audit_events (entity, entity_id, created_at);`
+
+	migration0003Down = `
+DROP TABLE IF EXISTS audit_events;`
+
+	migration0004Up = `
+ALTER TABLE rides ADD COLUMN version integer NOT NULL DEFAULT 1;
+ALTER TABLE passengers ADD COLUMN version integer NOT NULL DEFAULT 1;`
+
+	// the rides and passengers tables are recreated as migration 0002 left them,
+	// passengers goes first so dropping rides does not cascade to it
+	migration0004Down = `
+CREATE TEMP TABLE rides_backup AS SELECT id, driver_id, car_id, seats, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, price_per_seat, info, start_date, created_at, updated_at, deleted_at FROM rides;
+CREATE TEMP TABLE passengers_backup AS SELECT id, driver_id, passenger_id, ride_id, status, created_at, updated_at, deleted_at FROM passengers;
+
+DROP TABLE passengers;
+DROP TABLE rides;
+
+CREATE TABLE rides (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	car_id varchar(20) NOT NULL,
+	seats int NOT NULL DEFAULT 1,
+	start_city varchar(128) NOT NULL,
+	end_city varchar(128) NOT NULL,
+	start_dest_lat real NOT NULL,
+	start_dest_lon real NOT NULL,
+	end_dest_lat real NOT NULL,
+	end_dest_lon real NOT NULL,
+	price_per_seat real DEFAULT 15 NOT NULL,
+	info text,
+	start_date timestamp NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	deleted_at timestamp,
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (car_id) REFERENCES cars (id) ON DELETE CASCADE
+);
+
+CREATE TABLE passengers (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	passenger_id varchar(20) NOT NULL,
+	ride_id varchar(20) NOT NULL,
+	status varchar(20) NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	deleted_at timestamp,
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (passenger_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (ride_id) REFERENCES rides (id) ON DELETE CASCADE
+);
+
+INSERT INTO rides SELECT * FROM rides_backup;
+INSERT INTO passengers SELECT * FROM passengers_backup;
+
+DROP TABLE rides_backup;
+DROP TABLE passengers_backup;
+
+CREATE UNIQUE INDEX passengers_not_deleted_key ON passengers (driver_id, passenger_id, ride_id) WHERE deleted_at IS NULL;
+CREATE INDEX rides_deleted_at_idx ON rides (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX passengers_deleted_at_idx ON passengers (deleted_at) WHERE deleted_at IS NOT NULL;`
+
+	// sqlite has no geography type, rides are searched by latitude first and then
+	// by distance in go
+	migration0005Up = `
+CREATE INDEX rides_start_dest_lat_idx ON rides (start_dest_lat);
+CREATE INDEX rides_end_dest_lat_idx ON rides (end_dest_lat);`
+
+	migration0005Down = `
+DROP INDEX IF EXISTS rides_end_dest_lat_idx;
+DROP INDEX IF EXISTS rides_start_dest_lat_idx;`
+
+	// numbered to match the postgres migration, sqlite has no 0006 since it
+	// searches rides without a search vector
+	migration0007Up = `
+CREATE TABLE IF NOT EXISTS outbox (
+	id varchar(20) primary key,
+	type varchar(64) NOT NULL,
+	entity_id varchar(20) NOT NULL,
+	payload TEXT NOT NULL DEFAULT '{}',
+	attempts integer NOT NULL DEFAULT 0,
+	last_error TEXT,
+	next_attempt_at timestamp,
+	delivered_at timestamp,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX outbox_due_idx ON outbox (next_attempt_at) WHERE next_attempt_at IS NOT NULL;`
+
+	migration0007Down = `
+DROP TABLE IF EXISTS outbox;`
+
+	migration0008Up = `
+CREATE TABLE IF NOT EXISTS ride_series (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	car_id varchar(20) NOT NULL,
+	seats int NOT NULL DEFAULT 1,
+	start_city varchar(128) NOT NULL,
+	end_city varchar(128) NOT NULL,
+	start_dest_lat real NOT NULL,
+	start_dest_lon real NOT NULL,
+	end_dest_lat real NOT NULL,
+	end_dest_lon real NOT NULL,
+	price_per_seat real DEFAULT 15 NOT NULL,
+	info text NOT NULL DEFAULT '',
+	days varchar(20) NOT NULL,
+	departure_time varchar(5) NOT NULL,
+	time_zone varchar(64) NOT NULL,
+	starts_on varchar(10) NOT NULL,
+	ends_on varchar(10) NOT NULL,
+	materialized_through varchar(10),
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	deleted_at timestamp,
+	version integer NOT NULL DEFAULT 1,
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (car_id) REFERENCES cars (id) ON DELETE CASCADE
+);
+
+ALTER TABLE rides ADD COLUMN series_id varchar(20) REFERENCES ride_series (id) ON DELETE SET NULL;
+ALTER TABLE rides ADD COLUMN occurrence_date varchar(10);
+
+CREATE UNIQUE INDEX rides_series_occurrence_key ON rides (series_id, occurrence_date);`
+
+	// the rides and passengers tables are recreated as migration 0005 left them,
+	// as in migration 0004's down
+	migration0008Down = `
+CREATE TEMP TABLE rides_backup AS SELECT id, driver_id, car_id, seats, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, price_per_seat, info, start_date, created_at, updated_at, deleted_at, version FROM rides;
+CREATE TEMP TABLE passengers_backup AS SELECT * FROM passengers;
+
+DROP TABLE passengers;
+DROP TABLE rides;
+DROP TABLE IF EXISTS ride_series;
+
+CREATE TABLE rides (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	car_id varchar(20) NOT NULL,
+	seats int NOT NULL DEFAULT 1,
+	start_city varchar(128) NOT NULL,
+	end_city varchar(128) NOT NULL,
+	start_dest_lat real NOT NULL,
+	start_dest_lon real NOT NULL,
+	end_dest_lat real NOT NULL,
+	end_dest_lon real NOT NULL,
+	price_per_seat real DEFAULT 15 NOT NULL,
+	info text,
+	start_date timestamp NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	deleted_at timestamp,
+	version integer NOT NULL DEFAULT 1,
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (car_id) REFERENCES cars (id) ON DELETE CASCADE
+);
+
+CREATE TABLE passengers (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	passenger_id varchar(20) NOT NULL,
+	ride_id varchar(20) NOT NULL,
+	status varchar(20) NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	deleted_at timestamp,
+	version integer NOT NULL DEFAULT 1,
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (passenger_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (ride_id) REFERENCES rides (id) ON DELETE CASCADE
+);
+
+INSERT INTO rides SELECT * FROM rides_backup;
+INSERT INTO passengers SELECT * FROM passengers_backup;
+
+DROP TABLE rides_backup;
+DROP TABLE passengers_backup;
+
+CREATE UNIQUE INDEX passengers_not_deleted_key ON passengers (driver_id, passenger_id, ride_id) WHERE deleted_at IS NULL;
+CREATE INDEX rides_deleted_at_idx ON rides (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX passengers_deleted_at_idx ON passengers (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX rides_start_dest_lat_idx ON rides (start_dest_lat);
+CREATE INDEX rides_end_dest_lat_idx ON rides (end_dest_lat);`
+
+	// rides that started before there were statuses are left scheduled rather than
+	// taken to have arrived, nothing says whether they happened and completed rides
+	// count towards the driver's reliability
+	migration0009Up = `
+ALTER TABLE rides ADD COLUMN status varchar(20) NOT NULL DEFAULT 'scheduled';
+
+CREATE INDEX rides_status_idx ON rides (status);`
+
+	// the rides and passengers tables are recreated as migration 0008 left them,
+	// as in migration 0008's down
+	migration0009Down = `
+CREATE TEMP TABLE rides_backup AS SELECT id, driver_id, car_id, seats, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, price_per_seat, info, start_date, created_at, updated_at, deleted_at, version, series_id, occurrence_date FROM rides;
+CREATE TEMP TABLE passengers_backup AS SELECT * FROM passengers;
+
+DROP TABLE passengers;
+DROP TABLE rides;
+
+CREATE TABLE rides (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	car_id varchar(20) NOT NULL,
+	seats int NOT NULL DEFAULT 1,
+	start_city varchar(128) NOT NULL,
+	end_city varchar(128) NOT NULL,
+	start_dest_lat real NOT NULL,
+	start_dest_lon real NOT NULL,
+	end_dest_lat real NOT NULL,
+	end_dest_lon real NOT NULL,
+	price_per_seat real DEFAULT 15 NOT NULL,
+	info text,
+	start_date timestamp NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	deleted_at timestamp,
+	version integer NOT NULL DEFAULT 1,
+	series_id varchar(20) REFERENCES ride_series (id) ON DELETE SET NULL,
+	occurrence_date varchar(10),
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (car_id) REFERENCES cars (id) ON DELETE CASCADE
+);
+
+CREATE TABLE passengers (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	passenger_id varchar(20) NOT NULL,
+	ride_id varchar(20) NOT NULL,
+	status varchar(20) NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	deleted_at timestamp,
+	version integer NOT NULL DEFAULT 1,
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (passenger_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (ride_id) REFERENCES rides (id) ON DELETE CASCADE
+);
+
+INSERT INTO rides SELECT * FROM rides_backup;
+INSERT INTO passengers SELECT * FROM passengers_backup;
+
+DROP TABLE rides_backup;
+DROP TABLE passengers_backup;
+
+CREATE UNIQUE INDEX passengers_not_deleted_key ON passengers (driver_id, passenger_id, ride_id) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX rides_series_occurrence_key ON rides (series_id, occurrence_date);
+CREATE INDEX rides_deleted_at_idx ON rides (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX passengers_deleted_at_idx ON passengers (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX rides_start_dest_lat_idx ON rides (start_dest_lat);
+CREATE INDEX rides_end_dest_lat_idx ON rides (end_dest_lat);`
+
+	migration0010Up = `
+ALTER TABLE rides ADD COLUMN cancelled_at timestamp;
+ALTER TABLE rides ADD COLUMN cancelled_by varchar(20) REFERENCES users (id) ON DELETE SET NULL;
+ALTER TABLE rides ADD COLUMN cancel_reason text;
+ALTER TABLE rides ADD COLUMN late_cancellation boolean NOT NULL DEFAULT 0;
+
+CREATE INDEX rides_late_cancellation_idx ON rides (driver_id) WHERE late_cancellation;`
+
+	// the rides and passengers tables are recreated as migration 0009 left them,
+	// as in migration 0009's down
+	migration0010Down = `
+CREATE TEMP TABLE rides_backup AS SELECT id, driver_id, car_id, seats, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, price_per_seat, info, start_date, created_at, updated_at, deleted_at, version, series_id, occurrence_date, status FROM rides;
+CREATE TEMP TABLE passengers_backup AS SELECT * FROM passengers;
+
+DROP TABLE passengers;
+DROP TABLE rides;
+
+CREATE TABLE rides (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	car_id varchar(20) NOT NULL,
+	seats int NOT NULL DEFAULT 1,
+	start_city varchar(128) NOT NULL,
+	end_city varchar(128) NOT NULL,
+	start_dest_lat real NOT NULL,
+	start_dest_lon real NOT NULL,
+	end_dest_lat real NOT NULL,
+	end_dest_lon real NOT NULL,
+	price_per_seat real DEFAULT 15 NOT NULL,
+	info text,
+	start_date timestamp NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	deleted_at timestamp,
+	version integer NOT NULL DEFAULT 1,
+	series_id varchar(20) REFERENCES ride_series (id) ON DELETE SET NULL,
+	occurrence_date varchar(10),
+	status varchar(20) NOT NULL DEFAULT 'scheduled',
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (car_id) REFERENCES cars (id) ON DELETE CASCADE
+);
+
+CREATE TABLE passengers (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	passenger_id varchar(20) NOT NULL,
+	ride_id varchar(20) NOT NULL,
+	status varchar(20) NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	deleted_at timestamp,
+	version integer NOT NULL DEFAULT 1,
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (passenger_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (ride_id) REFERENCES rides (id) ON DELETE CASCADE
+);
+
+INSERT INTO rides SELECT * FROM rides_backup;
+INSERT INTO passengers SELECT * FROM passengers_backup;
+
+DROP TABLE rides_backup;
+DROP TABLE passengers_backup;
+
+CREATE UNIQUE INDEX passengers_not_deleted_key ON passengers (driver_id, passenger_id, ride_id) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX rides_series_occurrence_key ON rides (series_id, occurrence_date);
+CREATE INDEX rides_status_idx ON rides (status);
+CREATE INDEX rides_deleted_at_idx ON rides (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX passengers_deleted_at_idx ON passengers (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX rides_start_dest_lat_idx ON rides (start_dest_lat);
+CREATE INDEX rides_end_dest_lat_idx ON rides (end_dest_lat);`
+
+	migration0011Up = `
+CREATE TABLE IF NOT EXISTS ride_requests (
+	id varchar(20) primary key,
+	passenger_id varchar(20) NOT NULL,
+	start_city varchar(128) NOT NULL,
+	end_city varchar(128) NOT NULL,
+	start_dest_lat real NOT NULL,
+	start_dest_lon real NOT NULL,
+	end_dest_lat real NOT NULL,
+	end_dest_lon real NOT NULL,
+	earliest_start timestamp NOT NULL,
+	latest_start timestamp NOT NULL,
+	seats int NOT NULL DEFAULT 1,
+	max_price real,
+	info text NOT NULL DEFAULT '',
+	status varchar(20) NOT NULL DEFAULT 'open',
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	deleted_at timestamp,
+	version integer NOT NULL DEFAULT 1,
+	FOREIGN KEY (passenger_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX ride_requests_open_idx ON ride_requests (earliest_start) WHERE status = 'open' AND deleted_at IS NULL;
+
+ALTER TABLE passengers ADD COLUMN request_id varchar(20) REFERENCES ride_requests (id) ON DELETE SET NULL;`
+
+	// the passengers table is recreated as migration 0010 left it, as in migration 0010's down
+	migration0011Down = `
+CREATE TEMP TABLE passengers_backup AS SELECT id, driver_id, passenger_id, ride_id, status, created_at, updated_at, deleted_at, version FROM passengers;
+
+DROP TABLE passengers;
+
+CREATE TABLE passengers (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	passenger_id varchar(20) NOT NULL,
+	ride_id varchar(20) NOT NULL,
+	status varchar(20) NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	deleted_at timestamp,
+	version integer NOT NULL DEFAULT 1,
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (passenger_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (ride_id) REFERENCES rides (id) ON DELETE CASCADE
+);
+
+INSERT INTO passengers SELECT * FROM passengers_backup;
+
+DROP TABLE passengers_backup;
+
+CREATE UNIQUE INDEX passengers_not_deleted_key ON passengers (driver_id, passenger_id, ride_id) WHERE deleted_at IS NULL;
+CREATE INDEX passengers_deleted_at_idx ON passengers (deleted_at) WHERE deleted_at IS NOT NULL;
+
+DROP TABLE IF EXISTS ride_requests;`
+
+	migration0012Up = `
+ALTER TABLE passengers ADD COLUMN confirm_by timestamp;
+
+CREATE INDEX passengers_waitlist_idx ON passengers (ride_id, created_at) WHERE status = 'waitlisted' AND deleted_at IS NULL;
+CREATE INDEX passengers_seat_offered_idx ON passengers (confirm_by) WHERE status = 'seat_offered' AND deleted_at IS NULL;`
+
+	// passengers in the waitlist statuses are moved back to statuses the previous version
+	// knows and the passengers table is recreated as migration 0011 left it
+	migration0012Down = `
+UPDATE passengers SET status = 'interested' WHERE status IN ('waitlisted', 'seat_offered');
+UPDATE passengers SET status = 'rejected' WHERE status = 'withdrawn';
+
+CREATE TEMP TABLE passengers_backup AS SELECT id, driver_id, passenger_id, ride_id, status, created_at, updated_at, deleted_at, version, request_id FROM passengers;
+
+DROP TABLE passengers;
+
+CREATE TABLE passengers (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	passenger_id varchar(20) NOT NULL,
+	ride_id varchar(20) NOT NULL,
+	status varchar(20) NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	deleted_at timestamp,
+	version integer NOT NULL DEFAULT 1,
+	request_id varchar(20) REFERENCES ride_requests (id) ON DELETE SET NULL,
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (passenger_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (ride_id) REFERENCES rides (id) ON DELETE CASCADE
+);
+
+INSERT INTO passengers SELECT * FROM passengers_backup;
+
+DROP TABLE passengers_backup;
+
+CREATE UNIQUE INDEX passengers_not_deleted_key ON passengers (driver_id, passenger_id, ride_id) WHERE deleted_at IS NULL;
+CREATE INDEX passengers_deleted_at_idx ON passengers (deleted_at) WHERE deleted_at IS NOT NULL;`
+
+	migration0013Up = `
+ALTER TABLE passengers ADD COLUMN seats_requested integer NOT NULL DEFAULT 1 CHECK (seats_requested > 0);`
+
+	// the passengers table is recreated as migration 0012 left it
+	migration0013Down = `
+CREATE TEMP TABLE passengers_backup AS SELECT id, driver_id, passenger_id, ride_id, status, created_at, updated_at, deleted_at, version, request_id, confirm_by FROM passengers;
+
+DROP TABLE passengers;
+
+CREATE TABLE passengers (
+	id varchar(20) primary key,
+	driver_id varchar(20) NOT NULL,
+	passenger_id varchar(20) NOT NULL,
+	ride_id varchar(20) NOT NULL,
+	status varchar(20) NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+	deleted_at timestamp,
+	version integer NOT NULL DEFAULT 1,
+	request_id varchar(20) REFERENCES ride_requests (id) ON DELETE SET NULL,
+	confirm_by timestamp,
+	FOREIGN KEY (driver_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (passenger_id) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (ride_id) REFERENCES rides (id) ON DELETE CASCADE
+);
+
+INSERT INTO passengers SELECT * FROM passengers_backup;
+
+DROP TABLE passengers_backup;
+
+CREATE UNIQUE INDEX passengers_not_deleted_key ON passengers (driver_id, passenger_id, ride_id) WHERE deleted_at IS NULL;
+CREATE INDEX passengers_deleted_at_idx ON passengers (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX passengers_waitlist_idx ON passengers (ride_id, created_at) WHERE status = 'waitlisted' AND deleted_at IS NULL;
+CREATE INDEX passengers_seat_offered_idx ON passengers (confirm_by) WHERE status = 'seat_offered' AND deleted_at IS NULL;`
+)
@@ -0,0 +1,103 @@
+package sqlite
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/id"
+)
+
+// OutboxStore persists outbox events in a sqlite DB
+type OutboxStore struct {
+	db    queryer
+	idGen IDgen
+}
+
+// NewOutboxStore creates a new sqlite outbox store
+func NewOutboxStore(db *sqlx.DB) *OutboxStore {
+	return &OutboxStore{
+		db:    db,
+		idGen: id.New,
+	}
+}
+
+// Insert persists an outbox event to the DB, it is due straight away
+func (o *OutboxStore) Insert(event *models.OutboxEvent) error {
+	event.ID = o.idGen()
+	event.CreatedAt = now()
+	event.NextAttemptAt = &event.CreatedAt
+
+	_, err := o.db.Exec(
+		outboxInsertSQL,
+		event.ID,
+		event.Type,
+		event.EntityID,
+		event.Payload,
+		event.CreatedAt,
+		event.CreatedAt,
+	)
+
+	return err
+}
+
+// GetDue returns up to limit events due by now, oldest first
+func (o *OutboxStore) GetDue(now time.Time, limit int) ([]*models.OutboxEvent, error) {
+	events := []*models.OutboxEvent{}
+
+	if err := o.db.Select(&events, outboxGetDueSQL, value(now), limit); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Claim returns up to limit events due by now, oldest first, and makes them due
+// again at until, transactions hold sqlite's write lock from the start so no other
+// claim can read the events before they are updated
+func (o *OutboxStore) Claim(now, until time.Time, limit int) ([]*models.OutboxEvent, error) {
+	events := []*models.OutboxEvent{}
+
+	err := inTx(o.db, func(tx queryer) error {
+		if err := tx.Select(&events, outboxGetDueSQL, value(now), limit); err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if _, err := tx.Exec(outboxClaimSQL, value(until), event.ID); err != nil {
+				return err
+			}
+			claimed := until
+			event.NextAttemptAt = &claimed
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Update saves the delivery state of an outbox event
+func (o *OutboxStore) Update(event *models.OutboxEvent) error {
+	return execOne(
+		o.db,
+		stores.ErrNoOutboxEventFound,
+		outboxUpdateSQL,
+		event.Attempts,
+		event.LastError,
+		nullTime(event.NextAttemptAt),
+		nullTime(event.DeliveredAt),
+		event.ID,
+	)
+}
+
+// Purge permanently deletes events delivered before the time given, along with the
+// events created before it that were given up on
+func (o *OutboxStore) Purge(before time.Time) (int, error) {
+	return purge(o.db, outboxPurgeSQL, before)
+}
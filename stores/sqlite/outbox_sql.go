@@ -0,0 +1,9 @@
+package sqlite
+
+const (
+	outboxInsertSQL = "INSERT INTO outbox (id, type, entity_id, payload, next_attempt_at, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+	outboxGetDueSQL = "SELECT * FROM outbox WHERE next_attempt_at IS NOT NULL AND next_attempt_at <= ? ORDER BY created_at, id LIMIT ?"
+	outboxUpdateSQL = "UPDATE outbox SET attempts=?, last_error=?, next_attempt_at=?, delivered_at=? WHERE id=?"
+	outboxPurgeSQL  = "DELETE FROM outbox WHERE delivered_at < ?1 OR (delivered_at IS NULL AND next_attempt_at IS NULL AND created_at < ?1)"
+	outboxClaimSQL  = "UPDATE outbox SET next_attempt_at=? WHERE id=?"
+)
@@ -0,0 +1,216 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/id"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+// PassengerStore persits passengers in a sqlite DB
+type PassengerStore struct {
+	db    queryer
+	idGen IDgen
+}
+
+// NewPassengerStore creates a new sqlite passenger store
+func NewPassengerStore(db *sqlx.DB) *PassengerStore {
+	return &PassengerStore{
+		db:    db,
+		idGen: id.New,
+	}
+}
+
+// GetAll returns a page of passengers in the page's sort order
+func (p *PassengerStore) GetAll(page pagination.Page) ([]*models.Passenger, error) {
+	return p.WhereMany(stores.Paginate(nil, page))
+}
+
+// GetByID finds a passenger by ID if exits in the DB
+func (p *PassengerStore) GetByID(id string) (*models.Passenger, error) {
+	return p.getBy(passengerGetByIDSQL, id)
+}
+
+// Insert persists a passenger to the DB
+func (p *PassengerStore) Insert(passenger *models.Passenger) error {
+	passenger.ID = p.idGen()
+	passenger.CreatedAt = now()
+	passenger.UpdatedAt = passenger.CreatedAt
+	passenger.Version = 1
+
+	_, err := p.db.Exec(
+		passengerInsertSQL,
+		passenger.ID,
+		passenger.DriverID,
+		passenger.PassengerID,
+		passenger.RideID,
+		passenger.Status,
+		passenger.SeatsRequested,
+		passenger.RequestID,
+		passenger.CreatedAt,
+		passenger.UpdatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return stores.ErrAlreadyPassenger
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Import persists a passenger exactly as given, keeping its id, timestamps and version
+func (p *PassengerStore) Import(passenger *models.Passenger) error {
+	_, err := p.db.Exec(
+		passengerImportSQL,
+		passenger.ID,
+		passenger.DriverID,
+		passenger.PassengerID,
+		passenger.RideID,
+		passenger.Status,
+		passenger.SeatsRequested,
+		passenger.RequestID,
+		passenger.ConfirmBy,
+		passenger.CreatedAt.UTC(),
+		passenger.UpdatedAt.UTC(),
+		nullTime(passenger.DeletedAt),
+		passenger.Version,
+	)
+	if isUniqueViolation(err) {
+		return stores.ErrAlreadyPassenger
+	}
+
+	return err
+}
+
+// Update persists the updates for the given passenger if it is still at the passenger's
+// version, returning stores.ErrVersionConflict if it is not
+func (p *PassengerStore) Update(passenger *models.Passenger) error {
+	updatedAt := now()
+
+	result, err := p.db.Exec(passengerUpdateSQL, passenger.Status, passenger.SeatsRequested, passenger.ConfirmBy, updatedAt, passenger.ID, passenger.Version)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		if err == nil {
+			err = missedUpdate(p.db, passengerExistsSQL, passenger.ID, stores.ErrNoPassengerFound)
+		}
+
+		return err
+	}
+
+	passenger.UpdatedAt = updatedAt
+	passenger.Version++
+
+	return nil
+}
+
+// Delete soft deletes the passenger, does no verification
+func (p *PassengerStore) Delete(id string) error {
+	_, err := p.db.Exec(passengerDeleteSQL, now(), id)
+	return err
+}
+
+// Restore undoes the deletion of a passenger whose ride has not been deleted
+func (p *PassengerStore) Restore(id string) error {
+	err := execOne(p.db, stores.ErrNoPassengerFound, passengerRestoreSQL, id)
+	if isUniqueViolation(err) {
+		return stores.ErrAlreadyPassenger
+	}
+
+	return err
+}
+
+// Purge permanently deletes passengers deleted before the time given
+func (p *PassengerStore) Purge(before time.Time) (int, error) {
+	return purge(p.db, passengerPurgeSQL, before)
+}
+
+// Count determines the number of records in the db that fit the where clauses
+func (p *PassengerStore) Count(clauses []stores.QueryModifier) (int, error) {
+	filters := stores.Filters(stores.NotDeleted(clauses))
+	where, vals, err := generateWhereStatement(&filters, passengerColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	query := "SELECT COUNT(*) FROM " + passengerTableName + " " + where
+
+	count := 0
+	if err := p.db.Get(&count, query, vals...); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// SumSeats adds up the seats requested by the passengers that fit the where clauses
+func (p *PassengerStore) SumSeats(clauses []stores.QueryModifier) (int, error) {
+	filters := stores.Filters(stores.NotDeleted(clauses))
+	where, vals, err := generateWhereStatement(&filters, passengerColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	query := "SELECT COALESCE(SUM(seats_requested), 0) FROM " + passengerTableName + " " + where
+
+	seats := 0
+	if err := p.db.Get(&seats, query, vals...); err != nil {
+		return 0, err
+	}
+
+	return seats, nil
+}
+
+// Where provides a generic query interface to get a single passenger
+func (p *PassengerStore) Where(clauses []stores.QueryModifier) (*models.Passenger, error) {
+	clauses = stores.NotDeleted(append(clauses[:len(clauses):len(clauses)], stores.Limit(1)))
+	where, vals, err := generateWhereStatement(&clauses, passengerColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT * FROM " + passengerTableName + " " + where
+
+	return p.getBy(query, vals...)
+}
+
+// WhereMany provides a generic query interface to get many passengers
+func (p *PassengerStore) WhereMany(clauses []stores.QueryModifier) ([]*models.Passenger, error) {
+	clauses = stores.NotDeleted(clauses)
+	where, vals, err := generateWhereStatement(&clauses, passengerColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT * FROM " + passengerTableName + " " + where
+	passengers := []*models.Passenger{}
+
+	if err := p.db.Select(&passengers, query, vals...); err != nil {
+		return nil, err
+	}
+
+	return passengers, nil
+}
+
+func (p *PassengerStore) getBy(query string, arg ...interface{}) (*models.Passenger, error) {
+	passenger := models.Passenger{}
+
+	if err := p.db.Get(&passenger, query, arg...); err != nil {
+		if err == sql.ErrNoRows {
+			err = stores.ErrNoPassengerFound
+		}
+
+		return nil, err
+	}
+
+	return &passenger, nil
+}
@@ -0,0 +1,38 @@
+package sqlite
+
+import "github.com/ucladevx/BPool/stores"
+
+const (
+	passengerTableName = "passengers"
+
+	passengerGetByIDSQL = "SELECT * FROM passengers WHERE id=? AND deleted_at IS NULL"
+
+	passengerInsertSQL = "INSERT INTO passengers (id, driver_id, passenger_id, ride_id, status, seats_requested, request_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	passengerImportSQL = "INSERT INTO passengers (id, driver_id, passenger_id, ride_id, status, seats_requested, request_id, confirm_by, created_at, updated_at, deleted_at, version) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	passengerUpdateSQL = "UPDATE passengers SET status=?, seats_requested=?, confirm_by=?, updated_at=?, version=version+1 WHERE id=? AND version=? AND deleted_at IS NULL"
+	passengerExistsSQL = "SELECT EXISTS (SELECT 1 FROM passengers WHERE id=? AND deleted_at IS NULL)"
+
+	passengerDeleteSQL = "UPDATE passengers SET deleted_at=? WHERE id=? AND deleted_at IS NULL"
+
+	// only passengers whose ride has not been deleted are restored
+	passengerRestoreSQL = "UPDATE passengers SET deleted_at=NULL WHERE id=? AND deleted_at IS NOT NULL " +
+		"AND ride_id IN (SELECT id FROM rides WHERE deleted_at IS NULL)"
+
+	passengerPurgeSQL = "DELETE FROM passengers WHERE deleted_at < ?"
+)
+
+// passengerColumns are the columns of passengers that query modifiers may use
+var passengerColumns = stores.Columns(
+	"id",
+	"driver_id",
+	"passenger_id",
+	"ride_id",
+	"status",
+	"seats_requested",
+	"request_id",
+	"confirm_by",
+	"created_at",
+	"updated_at",
+	"deleted_at",
+	"version",
+)
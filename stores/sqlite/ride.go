@@ -0,0 +1,304 @@
+package sqlite
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/id"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+// RideStore persits rides in a sqlite DB
+type RideStore struct {
+	db    queryer
+	idGen IDgen
+}
+
+// NewRideStore creates a new sqlite ride store
+func NewRideStore(db *sqlx.DB) *RideStore {
+	return &RideStore{
+		db:    db,
+		idGen: id.New,
+	}
+}
+
+// GetAll returns a page of rides in the page's sort order
+func (r *RideStore) GetAll(page pagination.Page) ([]*models.Ride, error) {
+	return r.WhereMany(stores.Paginate(nil, page))
+}
+
+// GetAllWherePassenger returns all rides that the user passed is a passenger
+func (r *RideStore) GetAllWherePassenger(passengerID string) ([]*models.Ride, error) {
+	rides := []*models.Ride{}
+
+	if err := r.db.Select(&rides, rideGetAllWherePassenger, passengerID); err != nil {
+		return nil, err
+	}
+
+	return rides, nil
+}
+
+// WhereMany provides a generic query interface to get many rides
+func (r *RideStore) WhereMany(clauses []stores.QueryModifier) ([]*models.Ride, error) {
+	clauses = stores.NotDeleted(clauses)
+	where, vals, err := generateWhereStatement(&clauses, rideColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT * FROM " + rideTableName + " " + where
+	rides := []*models.Ride{}
+
+	if err := r.db.Select(&rides, query, vals...); err != nil {
+		return nil, err
+	}
+
+	return rides, nil
+}
+
+// Count determines the number of rides in the db that fit the where clauses
+func (r *RideStore) Count(clauses []stores.QueryModifier) (int, error) {
+	filters := stores.Filters(stores.NotDeleted(clauses))
+	where, vals, err := generateWhereStatement(&filters, rideColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	query := "SELECT COUNT(*) FROM " + rideTableName + " " + where
+
+	count := 0
+	if err := r.db.Get(&count, query, vals...); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Search finds the rides that match the search in the search's sort order, the
+// query narrows the rides down and the rest of the search is done in go since
+// sqlite stores dates as text and has no distance functions
+func (r *RideStore) Search(search *models.RideSearch) ([]*models.Ride, error) {
+	where := []string{}
+	args := []interface{}{}
+
+	if near := search.Near; near != nil {
+		fromMin, fromMax := stores.LatitudeBand(near.FromLat, near.FromRadiusKm)
+		toMin, toMax := stores.LatitudeBand(near.ToLat, near.ToRadiusKm)
+		where = append(where, "start_dest_lat BETWEEN ? AND ?", "end_dest_lat BETWEEN ? AND ?")
+		args = append(args, fromMin, fromMax, toMin, toMax)
+	}
+	if search.StartCity != "" {
+		where = append(where, "lower(start_city) = lower(?)")
+		args = append(args, search.StartCity)
+	}
+	if search.EndCity != "" {
+		where = append(where, "lower(end_city) = lower(?)")
+		args = append(args, search.EndCity)
+	}
+	if search.MinSeats > 0 {
+		where = append(where, "seats - seats_taken >= ?")
+		args = append(args, search.MinSeats)
+	}
+	if search.MaxPrice != nil {
+		where = append(where, "price_per_seat <= ?")
+		args = append(args, *search.MaxPrice)
+	}
+	if len(search.Statuses) > 0 {
+		where = append(where, "status IN (?"+strings.Repeat(", ?", len(search.Statuses)-1)+")")
+		for _, status := range search.Statuses {
+			args = append(args, status)
+		}
+	}
+
+	query := rideSearchSQL
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	rides := []*models.Ride{}
+
+	if err := r.db.Select(&rides, query, args...); err != nil {
+		return nil, err
+	}
+
+	return stores.SearchRides(rides, search), nil
+}
+
+// GetByID finds a ride by ID if exits in the DB
+func (r *RideStore) GetByID(id string) (*models.Ride, error) {
+	ride := models.Ride{}
+
+	if err := r.db.Get(&ride, rideGetByIDSQL, id); err != nil {
+		if err == sql.ErrNoRows {
+			err = stores.ErrNoRideFound
+		}
+
+		return nil, err
+	}
+
+	return &ride, nil
+}
+
+// GetByIDForUpdate finds a ride by ID, it is the same as GetByID since transactions
+// begin immediately, holding SQLite's write lock on the whole db until they end
+func (r *RideStore) GetByIDForUpdate(id string) (*models.Ride, error) {
+	return r.GetByID(id)
+}
+
+// Insert persists a ride to the DB, rides without a status are scheduled
+func (r *RideStore) Insert(ride *models.Ride) error {
+	if ride.Status == "" {
+		ride.Status = models.RideScheduled
+	}
+
+	ride.ID = r.idGen()
+	ride.CreatedAt = now()
+	ride.UpdatedAt = ride.CreatedAt
+	ride.Version = 1
+
+	_, err := r.db.Exec(
+		rideInsertSQL,
+		ride.ID,
+		ride.DriverID,
+		ride.CarID,
+		ride.Seats,
+		ride.StartCity,
+		ride.EndCity,
+		ride.StartLat,
+		ride.StartLon,
+		ride.EndLat,
+		ride.EndLon,
+		ride.PricePerSeat,
+		ride.Info,
+		ride.SeriesID,
+		ride.OccurrenceDate,
+		ride.Status,
+		ride.StartDate.UTC(),
+		ride.CreatedAt,
+		ride.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return stores.ErrDuplicateOccurrence
+	}
+
+	return err
+}
+
+// Import persists a ride exactly as given, keeping its id, timestamps and version,
+// rides exported before rides had statuses are scheduled
+func (r *RideStore) Import(ride *models.Ride) error {
+	if ride.Status == "" {
+		ride.Status = models.RideScheduled
+	}
+
+	_, err := r.db.Exec(
+		rideImportSQL,
+		ride.ID,
+		ride.DriverID,
+		ride.CarID,
+		ride.Seats,
+		ride.StartCity,
+		ride.EndCity,
+		ride.StartLat,
+		ride.StartLon,
+		ride.EndLat,
+		ride.EndLon,
+		ride.PricePerSeat,
+		ride.Info,
+		ride.SeriesID,
+		ride.OccurrenceDate,
+		ride.Status,
+		nullTime(ride.CancelledAt),
+		ride.CancelledBy,
+		ride.CancelReason,
+		ride.LateCancellation,
+		ride.StartDate.UTC(),
+		ride.CreatedAt.UTC(),
+		ride.UpdatedAt.UTC(),
+		nullTime(ride.DeletedAt),
+		ride.Version,
+	)
+
+	return err
+}
+
+// Update persists the updates for the given ride if it is still at the ride's version,
+// returning stores.ErrVersionConflict if it is not
+func (r *RideStore) Update(ride *models.Ride) error {
+	updatedAt := now()
+
+	result, err := r.db.Exec(
+		rideUpdateSQL,
+		ride.CarID,
+		ride.Seats,
+		ride.StartCity,
+		ride.EndCity,
+		ride.StartLat,
+		ride.StartLon,
+		ride.EndLat,
+		ride.EndLon,
+		ride.PricePerSeat,
+		ride.Info,
+		ride.Status,
+		nullTime(ride.CancelledAt),
+		ride.CancelledBy,
+		ride.CancelReason,
+		ride.LateCancellation,
+		ride.StartDate.UTC(),
+		updatedAt,
+		ride.ID,
+		ride.Version,
+	)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		if err == nil {
+			err = missedUpdate(r.db, rideExistsSQL, ride.ID, stores.ErrNoRideFound)
+		}
+
+		return err
+	}
+
+	ride.UpdatedAt = updatedAt
+	ride.Version++
+
+	return nil
+}
+
+// Delete soft deletes the ride and its passengers, does no verification
+func (r *RideStore) Delete(id string) error {
+	deletedAt := now()
+
+	return inTx(r.db, func(tx queryer) error {
+		if _, err := tx.Exec(rideDeletePassengersSQL, deletedAt, id); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(rideDeleteSQL, deletedAt, id)
+		return err
+	})
+}
+
+// Restore undoes the deletion of a ride and the passengers deleted with it, the
+// ride's car must not be deleted
+func (r *RideStore) Restore(id string) error {
+	return inTx(r.db, func(tx queryer) error {
+		if _, err := tx.Exec(rideRestorePassengersSQL, id, id); err != nil {
+			return err
+		}
+
+		return execOne(tx, stores.ErrNoRideFound, rideRestoreSQL, id)
+	})
+}
+
+// Purge permanently deletes rides deleted before the time given
+func (r *RideStore) Purge(before time.Time) (int, error) {
+	return purge(r.db, ridePurgeSQL, before)
+}
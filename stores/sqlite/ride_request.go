@@ -0,0 +1,175 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/id"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+// RideRequestStore persists ride requests in a sqlite DB
+type RideRequestStore struct {
+	db    queryer
+	idGen IDgen
+}
+
+// NewRideRequestStore creates a new sqlite ride request store
+func NewRideRequestStore(db *sqlx.DB) *RideRequestStore {
+	return &RideRequestStore{
+		db:    db,
+		idGen: id.New,
+	}
+}
+
+// GetAll returns a page of ride requests in the page's sort order
+func (s *RideRequestStore) GetAll(page pagination.Page) ([]*models.RideRequest, error) {
+	return s.WhereMany(stores.Paginate(nil, page))
+}
+
+// WhereMany provides a generic query interface to get many ride requests
+func (s *RideRequestStore) WhereMany(clauses []stores.QueryModifier) ([]*models.RideRequest, error) {
+	clauses = stores.NotDeleted(clauses)
+	where, vals, err := generateWhereStatement(&clauses, rideRequestColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT * FROM " + rideRequestTableName + " " + where
+	all := []*models.RideRequest{}
+
+	if err := s.db.Select(&all, query, vals...); err != nil {
+		return nil, err
+	}
+
+	return all, nil
+}
+
+// GetByID finds a ride request by ID if it exists in the DB
+func (s *RideRequestStore) GetByID(id string) (*models.RideRequest, error) {
+	request := models.RideRequest{}
+
+	if err := s.db.Get(&request, rideRequestGetByIDSQL, id); err != nil {
+		if err == sql.ErrNoRows {
+			err = stores.ErrNoRideRequestFound
+		}
+
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+// Insert persists a ride request to the DB
+func (s *RideRequestStore) Insert(request *models.RideRequest) error {
+	request.ID = s.idGen()
+	request.CreatedAt = now()
+	request.UpdatedAt = request.CreatedAt
+	request.Version = 1
+
+	_, err := s.db.Exec(
+		rideRequestInsertSQL,
+		request.ID,
+		request.PassengerID,
+		request.StartCity,
+		request.EndCity,
+		request.StartLat,
+		request.StartLon,
+		request.EndLat,
+		request.EndLon,
+		request.EarliestStart.UTC(),
+		request.LatestStart.UTC(),
+		request.Seats,
+		request.MaxPrice,
+		request.Info,
+		request.Status,
+		request.CreatedAt,
+		request.UpdatedAt,
+	)
+
+	return err
+}
+
+// Import persists a ride request exactly as given, keeping its id, timestamps and version
+func (s *RideRequestStore) Import(request *models.RideRequest) error {
+	_, err := s.db.Exec(
+		rideRequestImportSQL,
+		request.ID,
+		request.PassengerID,
+		request.StartCity,
+		request.EndCity,
+		request.StartLat,
+		request.StartLon,
+		request.EndLat,
+		request.EndLon,
+		request.EarliestStart.UTC(),
+		request.LatestStart.UTC(),
+		request.Seats,
+		request.MaxPrice,
+		request.Info,
+		request.Status,
+		request.CreatedAt.UTC(),
+		request.UpdatedAt.UTC(),
+		nullTime(request.DeletedAt),
+		request.Version,
+	)
+
+	return err
+}
+
+// Update persists the updates for the given ride request if it is still at the
+// request's version, returning stores.ErrVersionConflict if it is not
+func (s *RideRequestStore) Update(request *models.RideRequest) error {
+	updatedAt := now()
+
+	result, err := s.db.Exec(
+		rideRequestUpdateSQL,
+		request.StartCity,
+		request.EndCity,
+		request.StartLat,
+		request.StartLon,
+		request.EndLat,
+		request.EndLon,
+		request.EarliestStart.UTC(),
+		request.LatestStart.UTC(),
+		request.Seats,
+		request.MaxPrice,
+		request.Info,
+		request.Status,
+		updatedAt,
+		request.ID,
+		request.Version,
+	)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		if err == nil {
+			err = missedUpdate(s.db, rideRequestExistsSQL, request.ID, stores.ErrNoRideRequestFound)
+		}
+
+		return err
+	}
+
+	request.UpdatedAt = updatedAt
+	request.Version++
+
+	return nil
+}
+
+// Delete soft deletes the ride request, the passengers offered rides for it are left alone
+func (s *RideRequestStore) Delete(id string) error {
+	_, err := s.db.Exec(rideRequestDeleteSQL, now(), id)
+	return err
+}
+
+// Purge permanently deletes ride requests deleted before the time given, the
+// passengers offered rides for them are kept but no longer linked to them
+func (s *RideRequestStore) Purge(before time.Time) (int, error) {
+	return purge(s.db, rideRequestPurgeSQL, before)
+}
@@ -0,0 +1,40 @@
+package sqlite
+
+import "github.com/ucladevx/BPool/stores"
+
+const (
+	rideRequestTableName = "ride_requests"
+
+	rideRequestGetByIDSQL = "SELECT * FROM ride_requests WHERE id=? AND deleted_at IS NULL"
+
+	rideRequestInsertSQL = "INSERT INTO ride_requests (id, passenger_id, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, earliest_start, latest_start, seats, max_price, info, status, created_at, updated_at) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	rideRequestImportSQL = "INSERT INTO ride_requests (id, passenger_id, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, earliest_start, latest_start, seats, max_price, info, status, created_at, updated_at, deleted_at, version) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	rideRequestUpdateSQL = "UPDATE ride_requests SET start_city=?, end_city=?, start_dest_lat=?, start_dest_lon=?, end_dest_lat=?, end_dest_lon=?, " +
+		"earliest_start=?, latest_start=?, seats=?, max_price=?, info=?, status=?, updated_at=?, version=version+1 " +
+		"WHERE id=? AND version=? AND deleted_at IS NULL"
+	rideRequestExistsSQL = "SELECT EXISTS (SELECT 1 FROM ride_requests WHERE id=? AND deleted_at IS NULL)"
+
+	rideRequestDeleteSQL = "UPDATE ride_requests SET deleted_at=? WHERE id=? AND deleted_at IS NULL"
+
+	// the passengers offered rides for the request are kept, their request_id is set to null
+	rideRequestPurgeSQL = "DELETE FROM ride_requests WHERE deleted_at < ?"
+)
+
+// rideRequestColumns are the columns of ride_requests that query modifiers may use
+var rideRequestColumns = stores.Columns(
+	"id",
+	"passenger_id",
+	"start_city",
+	"end_city",
+	"earliest_start",
+	"latest_start",
+	"seats",
+	"max_price",
+	"status",
+	"created_at",
+	"updated_at",
+	"deleted_at",
+	"version",
+)
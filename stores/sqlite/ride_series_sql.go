@@ -0,0 +1,51 @@
+package sqlite
+
+import "github.com/ucladevx/BPool/stores"
+
+const (
+	rideSeriesTableName = "ride_series"
+
+	rideSeriesGetByIDSQL = "SELECT * FROM ride_series WHERE id=? AND deleted_at IS NULL"
+
+	// series are active until they have been materialized through their last date
+	rideSeriesGetActiveSQL = "SELECT ride_series.* FROM ride_series JOIN cars ON cars.id = ride_series.car_id " +
+		"WHERE ride_series.deleted_at IS NULL AND cars.deleted_at IS NULL " +
+		"AND (materialized_through IS NULL OR materialized_through < ends_on) ORDER BY ride_series.id"
+
+	rideSeriesInsertSQL = "INSERT INTO ride_series (id, driver_id, car_id, seats, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, price_per_seat, info, days, departure_time, time_zone, starts_on, ends_on, created_at, updated_at) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	rideSeriesImportSQL = "INSERT INTO ride_series (id, driver_id, car_id, seats, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, price_per_seat, info, days, departure_time, time_zone, starts_on, ends_on, materialized_through, created_at, updated_at, deleted_at, version) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	rideSeriesUpdateSQL = "UPDATE ride_series SET car_id=?, seats=?, start_city=?, end_city=?, start_dest_lat=?, start_dest_lon=?, end_dest_lat=?, end_dest_lon=?, price_per_seat=?, info=?, " +
+		"days=?, departure_time=?, time_zone=?, starts_on=?, ends_on=?, updated_at=?, version=version+1 " +
+		"WHERE id=? AND version=? AND deleted_at IS NULL"
+	rideSeriesExistsSQL = "SELECT EXISTS (SELECT 1 FROM ride_series WHERE id=? AND deleted_at IS NULL)"
+
+	rideSeriesSetMaterializedThroughSQL = "UPDATE ride_series SET materialized_through=? WHERE id=? AND deleted_at IS NULL"
+
+	rideSeriesDeleteSQL = "UPDATE ride_series SET deleted_at=? WHERE id=? AND deleted_at IS NULL"
+
+	// the series' rides are kept, their series_id is set to null
+	rideSeriesPurgeSQL = "DELETE FROM ride_series WHERE deleted_at < ?"
+)
+
+// rideSeriesColumns are the columns of ride_series that query modifiers may use
+var rideSeriesColumns = stores.Columns(
+	"id",
+	"driver_id",
+	"car_id",
+	"seats",
+	"start_city",
+	"end_city",
+	"price_per_seat",
+	"days",
+	"departure_time",
+	"time_zone",
+	"starts_on",
+	"ends_on",
+	"materialized_through",
+	"created_at",
+	"updated_at",
+	"deleted_at",
+	"version",
+)
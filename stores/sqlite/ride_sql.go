@@ -0,0 +1,64 @@
+package sqlite
+
+import "github.com/ucladevx/BPool/stores"
+
+const (
+	rideTableName = "rides"
+
+	rideGetAllWherePassenger = "SELECT rides.*, passengers.status AS passenger_status FROM passengers JOIN rides ON rides.id = ride_id WHERE passenger_id=? AND passengers.deleted_at IS NULL AND rides.deleted_at IS NULL"
+
+	rideGetByIDSQL = "SELECT *, (SELECT COALESCE(SUM(seats_requested), 0) FROM passengers WHERE ride_id=rides.id AND status IN ('accepted', 'seat_offered') AND passengers.deleted_at IS NULL) AS seats_taken FROM rides WHERE rides.id=? AND rides.deleted_at IS NULL"
+
+	rideInsertSQL = "INSERT INTO rides (id, driver_id, car_id, seats, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, price_per_seat, info, series_id, occurrence_date, status, start_date, created_at, updated_at) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	rideImportSQL = "INSERT INTO rides (id, driver_id, car_id, seats, start_city, end_city, start_dest_lat, start_dest_lon, end_dest_lat, end_dest_lon, price_per_seat, info, series_id, occurrence_date, status, cancelled_at, cancelled_by, cancel_reason, late_cancellation, start_date, created_at, updated_at, deleted_at, version) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	rideUpdateSQL = "UPDATE rides SET car_id=?, seats=?, start_city=?, end_city=?, start_dest_lat=?, start_dest_lon=?, end_dest_lat=?, end_dest_lon=?, price_per_seat=?, info=?, status=?, cancelled_at=?, cancelled_by=?, cancel_reason=?, late_cancellation=?, start_date=?, updated_at=?, version=version+1 " +
+		"WHERE id=? AND version=? AND deleted_at IS NULL"
+	// rides are searched in a derived table so that seats_taken can be filtered on, it
+	// is counted the same way as rideGetByIDSQL
+	rideSearchSQL = "SELECT * FROM (SELECT *, (SELECT COALESCE(SUM(seats_requested), 0) FROM passengers WHERE ride_id=rides.id AND status IN ('accepted', 'seat_offered') AND passengers.deleted_at IS NULL) AS seats_taken " +
+		"FROM rides WHERE rides.deleted_at IS NULL) AS rides"
+
+	rideExistsSQL = "SELECT EXISTS (SELECT 1 FROM rides WHERE id=? AND deleted_at IS NULL)"
+
+	// a ride is soft deleted along with its passengers with the same deleted_at so
+	// that restoring the ride can restore them too
+	rideDeletePassengersSQL = "UPDATE passengers SET deleted_at=? WHERE ride_id=? AND deleted_at IS NULL"
+	rideDeleteSQL           = "UPDATE rides SET deleted_at=? WHERE id=? AND deleted_at IS NULL"
+
+	// only rides whose car has not been deleted are restored
+	rideRestorePassengersSQL = "UPDATE passengers SET deleted_at=NULL WHERE ride_id=? AND deleted_at=" +
+		"(SELECT rides.deleted_at FROM rides JOIN cars ON cars.id = rides.car_id WHERE rides.id=? AND cars.deleted_at IS NULL)"
+	rideRestoreSQL = "UPDATE rides SET deleted_at=NULL WHERE id=? AND deleted_at IS NOT NULL AND car_id IN (SELECT id FROM cars WHERE deleted_at IS NULL)"
+
+	ridePurgeSQL = "DELETE FROM rides WHERE deleted_at < ?"
+)
+
+// rideColumns are the columns of rides that query modifiers may use
+var rideColumns = stores.Columns(
+	"id",
+	"driver_id",
+	"car_id",
+	"seats",
+	"start_city",
+	"end_city",
+	"start_dest_lat",
+	"start_dest_lon",
+	"end_dest_lat",
+	"end_dest_lon",
+	"price_per_seat",
+	"info",
+	"series_id",
+	"occurrence_date",
+	"status",
+	"cancelled_at",
+	"cancelled_by",
+	"cancel_reason",
+	"late_cancellation",
+	"start_date",
+	"created_at",
+	"updated_at",
+	"deleted_at",
+	"version",
+)
@@ -0,0 +1,147 @@
+package sqlite_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ucladevx/BPool/mocks"
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/stores/sqlite"
+	"github.com/ucladevx/BPool/stores/storetest"
+	"github.com/ucladevx/BPool/utils/auth"
+)
+
+func TestStoreContract(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		db := sqlite.NewConnection(":memory:", mocks.Logger{})
+
+		if _, err := sqlite.NewMigrator(db, mocks.Logger{}).Up(); err != nil {
+			t.Fatal(err)
+		}
+
+		return storetest.Stores{
+			Users:      sqlite.NewUserStore(db),
+			Cars:       sqlite.NewCarStore(db),
+			Rides:      sqlite.NewRideStore(db),
+			RideSeries: sqlite.NewRideSeriesStore(db),
+			Requests:   sqlite.NewRideRequestStore(db),
+			Passengers: sqlite.NewPassengerStore(db),
+			Audit:      sqlite.NewAuditStore(db),
+			Outbox:     sqlite.NewOutboxStore(db),
+			Transactor: sqlite.NewTransactor(db),
+		}
+	})
+}
+
+func TestMigrateDown(t *testing.T) {
+	db := sqlite.NewConnection(":memory:", mocks.Logger{})
+	migrator := sqlite.NewMigrator(db, mocks.Logger{})
+
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := migrator.Down(len(sqlite.Migrations))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != len(sqlite.Migrations) {
+		t.Errorf("should have rolled back %d migrations, but rolled back %d", len(sqlite.Migrations), count)
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range statuses {
+		if s.Applied {
+			t.Errorf("migration %d should not be applied after rolling everything back", s.Version)
+		}
+	}
+}
+
+func TestConcurrentAccepts(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "bpool")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bpool.db")
+
+	// two connections to the same file, so that only sqlite's own locking serializes them
+	first := sqlite.NewConnection(path, mocks.Logger{})
+	defer first.Close()
+	second := sqlite.NewConnection(path, mocks.Logger{})
+	defer second.Close()
+
+	_, err = sqlite.NewMigrator(first, mocks.Logger{}).Up()
+	require.Nil(t, err)
+
+	driver := models.User{Email: "driver@ucla.edu", FirstName: "Joe", LastName: "Bruin", AuthLevel: services.UserLevel}
+	require.Nil(t, sqlite.NewUserStore(first).Insert(&driver))
+	car := models.Car{Make: "Toyota", Model: "Prius", Year: 2017, Color: "white", UserID: driver.ID}
+	require.Nil(t, sqlite.NewCarStore(first).Insert(&car))
+	ride := models.Ride{
+		DriverID: driver.ID, CarID: car.ID, Seats: 3, StartCity: "Los Angeles", EndCity: "San Francisco",
+		PricePerSeat: 15, StartDate: time.Now().Add(24 * time.Hour),
+	}
+	require.Nil(t, sqlite.NewRideStore(first).Insert(&ride))
+
+	var ids []string
+	for i := 0; i < 8; i++ {
+		rider := models.User{Email: fmt.Sprintf("rider%d@ucla.edu", i), FirstName: "Josie", LastName: "Bruin", AuthLevel: services.UserLevel}
+		require.Nil(t, sqlite.NewUserStore(first).Insert(&rider))
+		passenger := models.Passenger{DriverID: driver.ID, PassengerID: rider.ID, RideID: ride.ID, Status: models.PassengerInterested, SeatsRequested: 1}
+		require.Nil(t, sqlite.NewPassengerStore(first).Insert(&passenger))
+		ids = append(ids, passenger.ID)
+	}
+
+	var passengerServices []*services.PassengerService
+	for _, db := range []*sqlx.DB{first, second} {
+		transactor := sqlite.NewTransactor(db)
+		rideService := services.NewRideService(sqlite.NewRideStore(db), nil, transactor, 0, 0, mocks.Logger{})
+		passengerServices = append(passengerServices, services.NewPassengerService(sqlite.NewPassengerStore(db), rideService, transactor, mocks.Logger{}))
+	}
+
+	claims := auth.UserClaims{ID: driver.ID}
+	accepted := models.PassengerAccepted
+
+	var wg sync.WaitGroup
+	statuses := make(chan string, len(ids))
+	for i, id := range ids {
+		wg.Add(1)
+		go func(service *services.PassengerService, id string) {
+			defer wg.Done()
+			passenger, err := service.Update(&models.PassengerChangeSet{Status: &accepted}, id, &claims)
+			if assert.Nil(err, "concurrent accepts should wait for each other rather than fail") {
+				statuses <- passenger.Status
+			}
+		}(passengerServices[i%2], id)
+	}
+	wg.Wait()
+	close(statuses)
+
+	counts := map[string]int{}
+	for status := range statuses {
+		counts[status]++
+	}
+	assert.Equal(ride.Seats, counts[models.PassengerAccepted])
+	assert.Equal(len(ids)-ride.Seats, counts[models.PassengerWaitlisted])
+
+	found, err := sqlite.NewRideStore(first).GetByID(ride.ID)
+	require.Nil(t, err)
+	assert.Equal(ride.Seats, *found.SeatsTaken, "the ride should be exactly full")
+}
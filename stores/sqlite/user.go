@@ -0,0 +1,96 @@
+package sqlite
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/id"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+// UserStore persits users in a sqlite DB
+type UserStore struct {
+	db    queryer
+	idGen IDgen
+}
+
+// NewUserStore creates a new sqlite user store
+func NewUserStore(db *sqlx.DB) *UserStore {
+	return &UserStore{
+		db:    db,
+		idGen: id.New,
+	}
+}
+
+// GetAll returns a page of users in the page's sort order
+func (u *UserStore) GetAll(page pagination.Page) ([]*models.User, error) {
+	clauses := stores.Paginate(nil, page)
+	where, vals, err := generateWhereStatement(&clauses, userColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT * FROM " + userTableName + " " + where
+	users := []*models.User{}
+
+	if err := u.db.Select(&users, query, vals...); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// GetByID finds a user by ID if exits in the DB
+func (u *UserStore) GetByID(id string) (*models.User, error) {
+	return u.getBy(userGetByIDSQL, id)
+}
+
+// GetByEmail finds a user by email if exits in the DB
+func (u *UserStore) GetByEmail(email string) (*models.User, error) {
+	return u.getBy(userGetByEmailSQL, email)
+}
+
+// Insert persists a user to the DB
+func (u *UserStore) Insert(user *models.User) error {
+	user.ID = u.idGen()
+	user.AuthLevel = 0
+	user.CreatedAt = now()
+	user.UpdatedAt = user.CreatedAt
+
+	_, err := u.db.Exec(userInsertSQL, user.ID, user.FirstName, user.LastName, user.Email, user.ProfileImage, user.CreatedAt, user.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return stores.ErrUserAlreadyExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Import persists a user exactly as given, keeping its id and timestamps
+func (u *UserStore) Import(user *models.User) error {
+	_, err := u.db.Exec(userImportSQL, user.ID, user.FirstName, user.LastName, user.Email, user.ProfileImage, user.AuthLevel, user.CreatedAt.UTC(), user.UpdatedAt.UTC())
+	if isUniqueViolation(err) {
+		return stores.ErrUserAlreadyExists
+	}
+
+	return err
+}
+
+func (u *UserStore) getBy(query string, arg interface{}) (*models.User, error) {
+	var user models.User
+
+	if err := u.db.Get(&user, query, arg); err != nil {
+		if err == sql.ErrNoRows {
+			err = stores.ErrNoUserFound
+		}
+
+		return nil, err
+	}
+
+	return &user, nil
+}
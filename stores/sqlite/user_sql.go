@@ -0,0 +1,26 @@
+package sqlite
+
+import "github.com/ucladevx/BPool/stores"
+
+const (
+	userTableName = "users"
+
+	userGetByIDSQL = "SELECT * FROM users WHERE id=?"
+
+	userGetByEmailSQL = "SELECT * FROM users WHERE email=?"
+
+	userInsertSQL = "INSERT INTO users (id, first_name, last_name, email, profile_image, auth_level, created_at, updated_at) VALUES (?, ?, ?, ?, ?, 0, ?, ?)"
+	userImportSQL = "INSERT INTO users (id, first_name, last_name, email, profile_image, auth_level, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+)
+
+// userColumns are the columns of users that query modifiers may use
+var userColumns = stores.Columns(
+	"id",
+	"first_name",
+	"last_name",
+	"email",
+	"profile_image",
+	"auth_level",
+	"created_at",
+	"updated_at",
+)
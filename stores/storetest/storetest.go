@@ -0,0 +1,1923 @@
+// Package storetest is a contract test suite that every store backend must pass,
+// so that services behave the same no matter which backend they are given
+package storetest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ucladevx/BPool/models"
+	"github.com/ucladevx/BPool/services"
+	"github.com/ucladevx/BPool/stores"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+type (
+	// Stores is one of each store, all backed by the same database
+	Stores struct {
+		Users      services.UserStore
+		Cars       services.CarStore
+		Rides      services.RideStore
+		RideSeries services.RideSeriesStore
+		Requests   services.RideRequestStore
+		Passengers services.PassengerStore
+		Audit      services.AuditStore
+		Outbox     services.OutboxStore
+		Transactor services.Transactor
+	}
+
+	// Factory returns stores backed by a new, empty database
+	Factory func(t *testing.T) Stores
+)
+
+// Run certifies a backend by running the contract for every store against it
+func Run(t *testing.T, factory Factory) {
+	t.Run("Users", func(t *testing.T) { Users(t, factory) })
+	t.Run("Cars", func(t *testing.T) { Cars(t, factory) })
+	t.Run("Rides", func(t *testing.T) { Rides(t, factory) })
+	t.Run("RideSeries", func(t *testing.T) { RideSeries(t, factory) })
+	t.Run("RideRequests", func(t *testing.T) { RideRequests(t, factory) })
+	t.Run("Passengers", func(t *testing.T) { Passengers(t, factory) })
+	t.Run("Audit", func(t *testing.T) { Audit(t, factory) })
+	t.Run("Outbox", func(t *testing.T) { Outbox(t, factory) })
+	t.Run("Transactions", func(t *testing.T) { Transactions(t, factory) })
+	t.Run("Import", func(t *testing.T) { Import(t, factory) })
+}
+
+// Users verifies the UserStore contract
+func Users(t *testing.T, factory Factory) {
+	t.Run("insert and get", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		user := newUser(t, s, "joe@ucla.edu")
+		assert.NotEmpty(user.ID, "insert should set the id")
+		assert.False(user.CreatedAt.IsZero(), "insert should set created_at")
+		assert.False(user.UpdatedAt.IsZero(), "insert should set updated_at")
+		assert.Equal(0, user.AuthLevel, "users should start at the lowest auth level")
+
+		found, err := s.Users.GetByID(user.ID)
+		require.Nil(t, err)
+		assert.Equal(user.Email, found.Email)
+		assert.Equal(user.FirstName, found.FirstName)
+
+		found, err = s.Users.GetByEmail(user.Email)
+		require.Nil(t, err)
+		assert.Equal(user.ID, found.ID)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		newUser(t, s, "joe@ucla.edu")
+
+		dup := models.User{FirstName: "Joe", LastName: "Bruin", Email: "joe@ucla.edu"}
+		assert.Equal(stores.ErrUserAlreadyExists, s.Users.Insert(&dup), "emails should be unique")
+
+		_, err := s.Users.GetByID("doesnotexist")
+		assert.Equal(stores.ErrNoUserFound, err)
+
+		_, err = s.Users.GetByEmail("nobody@ucla.edu")
+		assert.Equal(stores.ErrNoUserFound, err)
+	})
+
+	t.Run("get all pages by id", func(t *testing.T) {
+		s := factory(t)
+
+		var ids []string
+		for i := 0; i < 5; i++ {
+			ids = append(ids, newUser(t, s, fmt.Sprintf("user%d@ucla.edu", i)).ID)
+		}
+
+		checkPages(t, ids, func(page pagination.Page) (interface{}, error) {
+			return s.Users.GetAll(page)
+		})
+	})
+}
+
+// Cars verifies the CarStore contract
+func Cars(t *testing.T, factory Factory) {
+	t.Run("insert and get", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		user := newUser(t, s, "joe@ucla.edu")
+		car := newCar(t, s, user)
+		assert.NotEmpty(car.ID, "insert should set the id")
+		assert.False(car.CreatedAt.IsZero(), "insert should set created_at")
+
+		found, err := s.Cars.GetByID(car.ID)
+		require.Nil(t, err)
+		assert.Equal(car.Make, found.Make)
+		assert.Equal(car.Year, found.Year)
+		assert.Equal(user.ID, found.UserID)
+
+		_, err = s.Cars.GetByID("doesnotexist")
+		assert.Equal(stores.ErrNoCarFound, err)
+	})
+
+	t.Run("count filters by query modifiers", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		joe := newUser(t, s, "joe@ucla.edu")
+		jane := newUser(t, s, "jane@ucla.edu")
+		newCar(t, s, joe)
+		newCar(t, s, joe)
+		newCar(t, s, jane)
+
+		count, err := s.Cars.GetCount([]stores.QueryModifier{stores.QueryMod("user_id", stores.EQ, joe.ID)})
+		require.Nil(t, err)
+		assert.Equal(2, count)
+
+		count, err = s.Cars.GetCount([]stores.QueryModifier{
+			stores.QueryMod("user_id", stores.EQ, joe.ID),
+			stores.Or,
+			stores.QueryMod("user_id", stores.EQ, jane.ID),
+		})
+		require.Nil(t, err)
+		assert.Equal(3, count)
+
+		count, err = s.Cars.GetCount([]stores.QueryModifier{
+			stores.QueryMod("user_id", stores.EQ, joe.ID),
+			stores.And,
+			stores.QueryMod("year", stores.GT, 2020),
+		})
+		require.Nil(t, err)
+		assert.Equal(0, count)
+	})
+
+	t.Run("get all pages by id", func(t *testing.T) {
+		s := factory(t)
+		user := newUser(t, s, "joe@ucla.edu")
+
+		var ids []string
+		for i := 0; i < 5; i++ {
+			ids = append(ids, newCar(t, s, user).ID)
+		}
+
+		checkPages(t, ids, func(page pagination.Page) (interface{}, error) {
+			return s.Cars.GetAll(page)
+		})
+	})
+
+	t.Run("remove cascades to rides and passengers", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		rider := newUser(t, s, "rider@ucla.edu")
+		car := newCar(t, s, driver)
+		ride := newRide(t, s, car)
+		passenger := newPassenger(t, s, ride, rider, models.PassengerAccepted)
+
+		require.Nil(t, s.Cars.Remove(car.ID))
+
+		_, err := s.Cars.GetByID(car.ID)
+		assert.Equal(stores.ErrNoCarFound, err)
+
+		_, err = s.Rides.GetByID(ride.ID)
+		assert.Equal(stores.ErrNoRideFound, err)
+
+		_, err = s.Passengers.GetByID(passenger.ID)
+		assert.Equal(stores.ErrNoPassengerFound, err)
+
+		count, err := s.Cars.GetCount([]stores.QueryModifier{stores.QueryMod("user_id", stores.EQ, driver.ID)})
+		require.Nil(t, err)
+		assert.Equal(0, count, "removed cars should not be counted")
+	})
+
+	t.Run("restore brings back what was removed with the car", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		rider := newUser(t, s, "rider@ucla.edu")
+		car := newCar(t, s, driver)
+		ride := newRide(t, s, car)
+		deletedEarlier := newRide(t, s, car)
+		passenger := newPassenger(t, s, ride, rider, models.PassengerAccepted)
+
+		require.Nil(t, s.Rides.Delete(deletedEarlier.ID))
+		time.Sleep(time.Millisecond)
+		require.Nil(t, s.Cars.Remove(car.ID))
+
+		require.Nil(t, s.Cars.Restore(car.ID))
+		assert.Equal(stores.ErrNoCarFound, s.Cars.Restore(car.ID), "a car that is not removed cannot be restored")
+
+		_, err := s.Cars.GetByID(car.ID)
+		assert.Nil(err)
+
+		restored, err := s.Rides.GetByID(ride.ID)
+		require.Nil(t, err)
+		assert.Equal(1, *restored.SeatsTaken)
+
+		_, err = s.Passengers.GetByID(passenger.ID)
+		assert.Nil(err)
+
+		_, err = s.Rides.GetByID(deletedEarlier.ID)
+		assert.Equal(stores.ErrNoRideFound, err, "rides deleted before the car should stay deleted")
+	})
+
+	t.Run("purge permanently deletes cars removed before the time given", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		removed := newCar(t, s, driver)
+		kept := newCar(t, s, driver)
+		ride := newRide(t, s, removed)
+
+		require.Nil(t, s.Cars.Remove(removed.ID))
+
+		count, err := s.Cars.Purge(time.Now().Add(-time.Hour))
+		require.Nil(t, err)
+		assert.Equal(0, count, "cars removed after the cut off should be kept")
+
+		count, err = s.Cars.Purge(time.Now().Add(time.Hour))
+		require.Nil(t, err)
+		assert.Equal(1, count)
+
+		assert.Equal(stores.ErrNoCarFound, s.Cars.Restore(removed.ID))
+		assert.Equal(stores.ErrNoRideFound, s.Rides.Restore(ride.ID))
+
+		_, err = s.Cars.GetByID(kept.ID)
+		assert.Nil(err)
+	})
+}
+
+// Rides verifies the RideStore contract
+func Rides(t *testing.T, factory Factory) {
+	t.Run("insert and get", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		ride := newRide(t, s, newCar(t, s, driver))
+		assert.NotEmpty(ride.ID, "insert should set the id")
+		assert.False(ride.CreatedAt.IsZero(), "insert should set created_at")
+
+		found, err := s.Rides.GetByID(ride.ID)
+		require.Nil(t, err)
+		assert.Equal(ride.DriverID, found.DriverID)
+		assert.Equal(ride.StartCity, found.StartCity)
+		assert.Equal(ride.Seats, found.Seats)
+		assert.InDelta(ride.StartLat, found.StartLat, 0.000001)
+		assert.InDelta(ride.PricePerSeat, found.PricePerSeat, 0.001)
+		assert.True(ride.StartDate.Equal(found.StartDate), "start date should round trip")
+		assert.Equal(models.RideScheduled, found.Status, "rides should be scheduled when inserted without a status")
+		require.NotNil(t, found.SeatsTaken, "get by id should count the seats taken")
+		assert.Equal(0, *found.SeatsTaken)
+
+		_, err = s.Rides.GetByID("doesnotexist")
+		assert.Equal(stores.ErrNoRideFound, err)
+	})
+
+	t.Run("seats taken counts accepted passengers", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		ride := newRide(t, s, newCar(t, s, driver))
+		newPassenger(t, s, ride, newUser(t, s, "a@ucla.edu"), models.PassengerAccepted)
+		newPassenger(t, s, ride, newUser(t, s, "b@ucla.edu"), models.PassengerAccepted)
+		newPassenger(t, s, ride, newUser(t, s, "c@ucla.edu"), models.PassengerInterested)
+		newPassenger(t, s, ride, newUser(t, s, "d@ucla.edu"), models.PassengerRejected)
+
+		found, err := s.Rides.GetByID(ride.ID)
+		require.Nil(t, err)
+		require.NotNil(t, found.SeatsTaken)
+		assert.Equal(2, *found.SeatsTaken)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		ride := newRide(t, s, newCar(t, s, driver))
+		created := ride.UpdatedAt
+
+		ride.Seats = 1
+		ride.EndCity = "San Jose"
+		ride.Info = "room for skis"
+		ride.Status = models.RideDeparted
+		require.Nil(t, s.Rides.Update(ride))
+		assert.False(ride.UpdatedAt.Before(created), "update should move updated_at forward")
+
+		found, err := s.Rides.GetByID(ride.ID)
+		require.Nil(t, err)
+		assert.Equal(1, found.Seats)
+		assert.Equal("San Jose", found.EndCity)
+		assert.Equal("room for skis", found.Info)
+		assert.Equal(models.RideDeparted, found.Status)
+
+		missing := *ride
+		missing.ID = "doesnotexist"
+		assert.Equal(stores.ErrNoRideFound, s.Rides.Update(&missing))
+	})
+
+	t.Run("update checks the version", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		ride := newRide(t, s, newCar(t, s, newUser(t, s, "driver@ucla.edu")))
+		assert.Equal(1, ride.Version, "rides should start at the first version")
+
+		first, err := s.Rides.GetByID(ride.ID)
+		require.Nil(t, err)
+		second, err := s.Rides.GetByID(ride.ID)
+		require.Nil(t, err)
+
+		first.Info = "first"
+		require.Nil(t, s.Rides.Update(first))
+		assert.Equal(2, first.Version, "update should move to the next version")
+
+		second.Info = "second"
+		assert.Equal(stores.ErrVersionConflict, s.Rides.Update(second), "a stale version should conflict")
+
+		found, err := s.Rides.GetByID(ride.ID)
+		require.Nil(t, err)
+		assert.Equal("first", found.Info, "the conflicting update should not be applied")
+		assert.Equal(2, found.Version)
+	})
+
+	t.Run("search finds rides near both points, nearest first", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		car := newCar(t, s, newUser(t, s, "driver@ucla.edu"))
+		moveRide := func(ride *models.Ride, startLat, startLon, endLat, endLon float64) {
+			ride.StartLat, ride.StartLon, ride.EndLat, ride.EndLon = startLat, startLon, endLat, endLon
+			require.Nil(t, s.Rides.Update(ride))
+		}
+
+		exact := newRide(t, s, car)
+
+		nearby := newRide(t, s, car)
+		moveRide(nearby, 34.019454, -118.491191, 37.804363, -122.271111) // Santa Monica to Oakland
+
+		wrongEnd := newRide(t, s, car)
+		moveRide(wrongEnd, exact.StartLat, exact.StartLon, 32.715738, -117.161084) // to San Diego
+
+		deleted := newRide(t, s, car)
+		require.Nil(t, s.Rides.Delete(deleted.ID))
+
+		near := models.RideSearchNear{
+			FromLat:      exact.StartLat,
+			FromLon:      exact.StartLon,
+			ToLat:        exact.EndLat,
+			ToLon:        exact.EndLon,
+			FromRadiusKm: 50,
+			ToRadiusKm:   50,
+		}
+		search := models.RideSearch{Near: &near, Sort: models.RideSortDistance, Limit: 10}
+
+		rides, err := s.Rides.Search(&search)
+		require.Nil(t, err)
+		require.Len(t, rides, 2, "only live rides within both radiuses should be found")
+		assert.Equal(exact.ID, rides[0].ID, "the nearest ride should be first")
+		assert.Equal(nearby.ID, rides[1].ID)
+		require.NotNil(t, rides[0].Distance)
+		require.NotNil(t, rides[1].Distance)
+		assert.InDelta(0, *rides[0].Distance, 0.1)
+		assert.InDelta(20, *rides[1].Distance, 3, "the distance should be the sum of the start and end distances in km")
+
+		near.ToRadiusKm = 10
+		rides, err = s.Rides.Search(&search)
+		require.Nil(t, err)
+		require.Len(t, rides, 1, "each end should use its own radius")
+		assert.Equal(exact.ID, rides[0].ID)
+
+		near.ToRadiusKm = 50
+		search.Limit = 1
+		rides, err = s.Rides.Search(&search)
+		require.Nil(t, err)
+		require.Len(t, rides, 1, "the limit should be respected")
+		assert.Equal(exact.ID, rides[0].ID)
+	})
+
+	t.Run("search filters rides and continues after the cursor", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		car := newCar(t, s, driver)
+		changeRide := func(ride *models.Ride, change func(ride *models.Ride)) {
+			change(ride)
+			require.Nil(t, s.Rides.Update(ride))
+		}
+
+		cheap := newRide(t, s, car)
+		changeRide(cheap, func(ride *models.Ride) { ride.PricePerSeat = 10 })
+
+		later := newRide(t, s, car)
+		changeRide(later, func(ride *models.Ride) {
+			ride.StartDate = ride.StartDate.Add(24 * time.Hour)
+			ride.PricePerSeat = 20
+		})
+
+		full := newRide(t, s, car)
+		changeRide(full, func(ride *models.Ride) { ride.Seats = 1 })
+		newPassenger(t, s, full, newUser(t, s, "rider@ucla.edu"), models.PassengerAccepted)
+
+		otherCity := newRide(t, s, car)
+		changeRide(otherCity, func(ride *models.Ride) { ride.StartCity = "San Diego" })
+
+		search := models.RideSearch{StartCity: "los angeles", EndCity: "SAN FRANCISCO", MinSeats: 1, Sort: models.RideSortPrice, Limit: 10}
+
+		rides, err := s.Rides.Search(&search)
+		require.Nil(t, err)
+		require.Len(t, rides, 2, "cities should match ignoring case and full rides should be left out")
+		assert.Equal(cheap.ID, rides[0].ID, "the cheapest ride should be first")
+		assert.Equal(later.ID, rides[1].ID)
+		require.NotNil(t, rides[0].SeatsTaken)
+		assert.Equal(0, *rides[0].SeatsTaken)
+
+		maxPrice := 15.0
+		search.MaxPrice = &maxPrice
+		rides, err = s.Rides.Search(&search)
+		require.Nil(t, err)
+		require.Len(t, rides, 1, "rides over the max price should be left out")
+		assert.Equal(cheap.ID, rides[0].ID)
+
+		search.MaxPrice = nil
+		search.StartAfter = later.StartDate
+		search.StartBefore = later.StartDate
+		rides, err = s.Rides.Search(&search)
+		require.Nil(t, err)
+		require.Len(t, rides, 1, "the start date window should include its ends")
+		assert.Equal(later.ID, rides[0].ID)
+
+		search = models.RideSearch{MinSeats: 0, Sort: models.RideSortDate, Limit: 2}
+		first, err := s.Rides.Search(&search)
+		require.Nil(t, err)
+		require.Len(t, first, 2, "the limit should be respected")
+
+		search.After = models.NewRideSearchCursor(models.RideSortDate, first[1])
+		second, err := s.Rides.Search(&search)
+		require.Nil(t, err)
+		require.Len(t, second, 2, "the next page should have the rest of the rides")
+		assert.Equal(later.ID, second[1].ID, "the latest ride should be last")
+
+		seen := map[string]bool{}
+		for _, ride := range append(first, second...) {
+			assert.False(seen[ride.ID], "pages should not overlap")
+			seen[ride.ID] = true
+		}
+	})
+
+	t.Run("search filters rides by status", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		car := newCar(t, s, newUser(t, s, "driver@ucla.edu"))
+		scheduled := newRide(t, s, car)
+		cancelled := newRide(t, s, car)
+		cancelled.Status = models.RideCancelled
+		require.Nil(t, s.Rides.Update(cancelled))
+
+		search := models.RideSearch{Statuses: []string{models.RideScheduled}, Sort: models.RideSortDate, Limit: 10}
+		rides, err := s.Rides.Search(&search)
+		require.Nil(t, err)
+		require.Len(t, rides, 1, "only rides with the statuses searched for should be found")
+		assert.Equal(scheduled.ID, rides[0].ID)
+
+		search.Statuses = []string{models.RideScheduled, models.RideCancelled}
+		rides, err = s.Rides.Search(&search)
+		require.Nil(t, err)
+		assert.Len(rides, 2)
+
+		filtered, err := s.Rides.WhereMany([]stores.QueryModifier{stores.QueryMod("status", stores.EQ, models.RideCancelled)})
+		require.Nil(t, err)
+		require.Len(t, filtered, 1, "rides should be filterable by status")
+		assert.Equal(cancelled.ID, filtered[0].ID)
+	})
+
+	t.Run("cancellations round trip and are counted", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		car := newCar(t, s, driver)
+		newRide(t, s, car)
+		ride := newRide(t, s, car)
+
+		ride.Cancel(driver.ID, "car broke down", time.Now().Truncate(time.Second), time.Hour*48)
+		require.Nil(t, s.Rides.Update(ride))
+
+		found, err := s.Rides.GetByID(ride.ID)
+		require.Nil(t, err)
+		assert.Equal(models.RideCancelled, found.Status)
+		require.NotNil(t, found.CancelledAt)
+		assert.True(ride.CancelledAt.Equal(*found.CancelledAt), "cancelled_at should round trip")
+		assert.Equal(driver.ID, *found.CancelledBy)
+		assert.Equal("car broke down", *found.CancelReason)
+		assert.True(found.LateCancellation)
+
+		late, err := s.Rides.Count([]stores.QueryModifier{
+			stores.QueryMod("driver_id", stores.EQ, driver.ID),
+			stores.And,
+			stores.QueryMod("late_cancellation", stores.EQ, true),
+		})
+		require.Nil(t, err)
+		assert.Equal(1, late)
+
+		all, err := s.Rides.Count([]stores.QueryModifier{stores.QueryMod("driver_id", stores.EQ, driver.ID)})
+		require.Nil(t, err)
+		assert.Equal(2, all)
+	})
+
+	t.Run("search finds rides by text with the best matches first", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		car := newCar(t, s, newUser(t, s, "driver@ucla.edu"))
+		changeRide := func(ride *models.Ride, change func(ride *models.Ride)) {
+			change(ride)
+			require.Nil(t, s.Rides.Update(ride))
+		}
+
+		inInfo := newRide(t, s, car)
+		changeRide(inInfo, func(ride *models.Ride) { ride.Info = "Stopping in Santa Barbara, room for skis" })
+
+		inCity := newRide(t, s, car)
+		changeRide(inCity, func(ride *models.Ride) { ride.EndCity = "Santa Barbara" })
+
+		newRide(t, s, car)
+
+		search := models.RideSearch{Query: "santa barbara", Sort: models.RideSortRelevance, Limit: 10}
+		rides, err := s.Rides.Search(&search)
+		require.Nil(t, err)
+		require.Len(t, rides, 2, "only rides mentioning the query should be found")
+		assert.Equal(inCity.ID, rides[0].ID, "rides to the city should rank above rides that mention it")
+		assert.Equal(inInfo.ID, rides[1].ID)
+		require.NotNil(t, rides[0].Rank)
+		require.NotNil(t, rides[1].Rank)
+		assert.True(*rides[0].Rank > *rides[1].Rank)
+
+		search.Limit = 1
+		search.After = models.NewRideSearchCursor(models.RideSortRelevance, rides[0])
+		rides, err = s.Rides.Search(&search)
+		require.Nil(t, err)
+		require.Len(t, rides, 1, "the next page should continue after the cursor")
+		assert.Equal(inInfo.ID, rides[0].ID)
+
+		rides, err = s.Rides.Search(&models.RideSearch{Query: "ski", Sort: models.RideSortDate, Limit: 10})
+		require.Nil(t, err)
+		require.Len(t, rides, 1, "words should match their plurals")
+		assert.Equal(inInfo.ID, rides[0].ID)
+	})
+
+	t.Run("where many filters by query modifiers", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		joe := newUser(t, s, "joe@ucla.edu")
+		jane := newUser(t, s, "jane@ucla.edu")
+		joeRide := newRide(t, s, newCar(t, s, joe))
+		newRide(t, s, newCar(t, s, jane))
+
+		rides, err := s.Rides.WhereMany([]stores.QueryModifier{stores.QueryMod("driver_id", stores.EQ, joe.ID)})
+		require.Nil(t, err)
+		require.Equal(t, 1, len(rides))
+		assert.Equal(joeRide.ID, rides[0].ID)
+
+		rides, err = s.Rides.WhereMany([]stores.QueryModifier{
+			stores.QueryMod("driver_id", stores.EQ, joe.ID),
+			stores.Or,
+			stores.QueryMod("driver_id", stores.EQ, jane.ID),
+		})
+		require.Nil(t, err)
+		assert.Equal(2, len(rides))
+
+		rides, err = s.Rides.WhereMany([]stores.QueryModifier{stores.QueryMod("seats", stores.GT, 10)})
+		require.Nil(t, err)
+		assert.Equal(0, len(rides), "no matches should be an empty slice")
+	})
+
+	t.Run("where many orders and pages", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		car := newCar(t, s, newUser(t, s, "driver@ucla.edu"))
+
+		var ids []string
+		for i := 0; i < 4; i++ {
+			ride := newRide(t, s, car)
+			ride.StartDate = time.Now().Add(time.Duration(4-i) * time.Hour).Truncate(time.Second)
+			if i%2 == 0 {
+				ride.StartCity = "Santa Barbara"
+			}
+			require.Nil(t, s.Rides.Update(ride))
+			ids = append([]string{ride.ID}, ids...)
+		}
+
+		rides, err := s.Rides.WhereMany([]stores.QueryModifier{
+			stores.QueryMod("start_date", stores.GT, time.Now()),
+			stores.OrderBy("start_date", stores.Asc),
+		})
+		require.Nil(t, err)
+		require.Equal(t, 4, len(rides))
+		for i, ride := range rides {
+			assert.Equal(ids[i], ride.ID)
+		}
+
+		rides, err = s.Rides.WhereMany([]stores.QueryModifier{
+			stores.OrderBy("start_date", stores.Desc),
+			stores.Offset(1),
+			stores.Limit(2),
+		})
+		require.Nil(t, err)
+		require.Equal(t, 2, len(rides))
+		assert.Equal(ids[2], rides[0].ID)
+		assert.Equal(ids[1], rides[1].ID)
+
+		rides, err = s.Rides.WhereMany([]stores.QueryModifier{stores.Offset(3)})
+		require.Nil(t, err)
+		assert.Equal(1, len(rides))
+
+		rides, err = s.Rides.WhereMany([]stores.QueryModifier{
+			stores.QueryMod("id", stores.IN, ids[:3]),
+			stores.And,
+			stores.Group(
+				stores.QueryMod("start_city", stores.ILIKE, "santa%"),
+				stores.Or,
+				stores.IsNull("info"),
+			),
+			stores.And,
+			stores.Between("seats", 1, 3),
+		})
+		require.Nil(t, err)
+		assert.Equal(1, len(rides))
+
+		_, err = s.Rides.WhereMany([]stores.QueryModifier{stores.QueryMod("id", stores.EQ, nil)})
+		assert.IsType(&stores.QueryModifierError{}, err)
+	})
+
+	t.Run("where many rejects columns it does not allow", func(t *testing.T) {
+		s := factory(t)
+
+		_, err := s.Rides.WhereMany([]stores.QueryModifier{stores.QueryMod("1=1; DROP TABLE rides; --", stores.EQ, 1)})
+		assert.IsType(t, &stores.QueryModifierError{}, err)
+
+		_, err = s.Passengers.Count([]stores.QueryModifier{stores.QueryMod("ride_id", "= ride_id OR 1 =", 1)})
+		assert.IsType(t, &stores.QueryModifierError{}, err)
+
+	})
+
+	t.Run("get all where passenger", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		rider := newUser(t, s, "rider@ucla.edu")
+		car := newCar(t, s, driver)
+		ride := newRide(t, s, car)
+		newRide(t, s, car)
+		newPassenger(t, s, ride, rider, models.PassengerInterested)
+
+		rides, err := s.Rides.GetAllWherePassenger(rider.ID)
+		require.Nil(t, err)
+		require.Equal(t, 1, len(rides))
+		assert.Equal(ride.ID, rides[0].ID)
+		require.NotNil(t, rides[0].PassengerStatus)
+		assert.Equal(models.PassengerInterested, *rides[0].PassengerStatus)
+	})
+
+	t.Run("get all pages by id", func(t *testing.T) {
+		s := factory(t)
+		car := newCar(t, s, newUser(t, s, "driver@ucla.edu"))
+
+		var ids []string
+		for i := 0; i < 5; i++ {
+			ids = append(ids, newRide(t, s, car).ID)
+		}
+
+		checkPages(t, ids, func(page pagination.Page) (interface{}, error) {
+			return s.Rides.GetAll(page)
+		})
+	})
+
+	t.Run("get all pages by start date", func(t *testing.T) {
+		s := factory(t)
+		car := newCar(t, s, newUser(t, s, "driver@ucla.edu"))
+
+		var want []string
+		for i := 0; i < 5; i++ {
+			ride := newRide(t, s, car)
+			ride.StartDate = ride.StartDate.Add(time.Duration(-i) * time.Hour)
+			require.Nil(t, s.Rides.Update(ride))
+
+			want = append([]string{ride.ID}, want...)
+		}
+
+		checkSortedPages(t, want, pagination.Sort{"start_date", "id"}, func(page pagination.Page) (interface{}, error) {
+			return s.Rides.GetAll(page)
+		})
+	})
+
+	t.Run("delete cascades to passengers", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		ride := newRide(t, s, newCar(t, s, driver))
+		passenger := newPassenger(t, s, ride, newUser(t, s, "rider@ucla.edu"), models.PassengerAccepted)
+
+		require.Nil(t, s.Rides.Delete(ride.ID))
+
+		_, err := s.Rides.GetByID(ride.ID)
+		assert.Equal(stores.ErrNoRideFound, err)
+
+		_, err = s.Passengers.GetByID(passenger.ID)
+		assert.Equal(stores.ErrNoPassengerFound, err)
+	})
+
+	t.Run("deleted rides are hidden from reads", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		rider := newUser(t, s, "rider@ucla.edu")
+		ride := newRide(t, s, newCar(t, s, driver))
+		newPassenger(t, s, ride, rider, models.PassengerAccepted)
+
+		require.Nil(t, s.Rides.Delete(ride.ID))
+
+		rides, err := s.Rides.GetAll(pagination.Page{Sort: pagination.ByID, Limit: 10})
+		require.Nil(t, err)
+		assert.Equal(0, len(rides))
+
+		rides, err = s.Rides.WhereMany([]stores.QueryModifier{stores.QueryMod("driver_id", stores.EQ, driver.ID)})
+		require.Nil(t, err)
+		assert.Equal(0, len(rides))
+
+		rides, err = s.Rides.GetAllWherePassenger(rider.ID)
+		require.Nil(t, err)
+		assert.Equal(0, len(rides))
+
+		ride.Seats = 4
+		assert.Equal(stores.ErrNoRideFound, s.Rides.Update(ride))
+	})
+
+	t.Run("restore brings back the ride and its passengers", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		car := newCar(t, s, driver)
+		ride := newRide(t, s, car)
+		passenger := newPassenger(t, s, ride, newUser(t, s, "rider@ucla.edu"), models.PassengerAccepted)
+		left := newPassenger(t, s, ride, newUser(t, s, "left@ucla.edu"), models.PassengerAccepted)
+
+		require.Nil(t, s.Passengers.Delete(left.ID))
+		time.Sleep(time.Millisecond)
+		require.Nil(t, s.Rides.Delete(ride.ID))
+
+		require.Nil(t, s.Rides.Restore(ride.ID))
+		assert.Equal(stores.ErrNoRideFound, s.Rides.Restore(ride.ID), "a ride that is not deleted cannot be restored")
+
+		restored, err := s.Rides.GetByID(ride.ID)
+		require.Nil(t, err)
+		assert.Equal(1, *restored.SeatsTaken, "passengers deleted before the ride should stay deleted")
+
+		_, err = s.Passengers.GetByID(passenger.ID)
+		assert.Nil(err)
+
+		require.Nil(t, s.Rides.Delete(ride.ID))
+		require.Nil(t, s.Cars.Remove(car.ID))
+		assert.Equal(stores.ErrNoRideFound, s.Rides.Restore(ride.ID), "rides of removed cars cannot be restored")
+	})
+
+	t.Run("purge permanently deletes rides deleted before the time given", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		ride := newRide(t, s, newCar(t, s, driver))
+		passenger := newPassenger(t, s, ride, newUser(t, s, "rider@ucla.edu"), models.PassengerAccepted)
+
+		require.Nil(t, s.Rides.Delete(ride.ID))
+
+		count, err := s.Rides.Purge(time.Now().Add(time.Hour))
+		require.Nil(t, err)
+		assert.Equal(1, count)
+
+		assert.Equal(stores.ErrNoRideFound, s.Rides.Restore(ride.ID))
+		assert.Equal(stores.ErrNoPassengerFound, s.Passengers.Restore(passenger.ID))
+	})
+}
+
+// RideSeries verifies the RideSeriesStore contract and the rides linked to series
+func RideSeries(t *testing.T, factory Factory) {
+	t.Run("insert, get and update", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		series := newRideSeries(t, s, newCar(t, s, driver))
+		assert.NotEmpty(series.ID, "insert should set the id")
+		assert.False(series.CreatedAt.IsZero(), "insert should set created_at")
+		assert.Equal(1, series.Version, "series should start at version 1")
+		assert.Nil(series.MaterializedThrough)
+
+		found, err := s.RideSeries.GetByID(series.ID)
+		require.Nil(t, err)
+		assert.Equal("MO,WE,FR", found.Days)
+		assert.Equal("08:30", found.DepartureTime)
+		assert.Equal(models.DefaultSeriesTimeZone, found.TimeZone)
+		assert.Equal(series.StartsOn, found.StartsOn)
+		assert.Equal(series.EndsOn, found.EndsOn)
+
+		found.Days = "TU,TH"
+		require.Nil(t, s.RideSeries.Update(found))
+		assert.Equal(2, found.Version, "update should bump the version")
+
+		series.Days = "SA"
+		assert.Equal(stores.ErrVersionConflict, s.RideSeries.Update(series), "a stale update should conflict")
+
+		missing := models.RideSeries{ID: "doesnotexist", Version: 1}
+		assert.Equal(stores.ErrNoRideSeriesFound, s.RideSeries.Update(&missing))
+
+		through := "2018-03-10"
+		require.Nil(t, s.RideSeries.SetMaterializedThrough(series.ID, &through))
+
+		found, err = s.RideSeries.GetByID(series.ID)
+		require.Nil(t, err)
+		assert.Equal("TU,TH", found.Days)
+		assert.Equal(2, found.Version, "materializing should not change the version")
+		if assert.NotNil(found.MaterializedThrough) {
+			assert.Equal(through, *found.MaterializedThrough)
+		}
+
+		_, err = s.RideSeries.GetByID("doesnotexist")
+		assert.Equal(stores.ErrNoRideSeriesFound, err)
+	})
+
+	t.Run("where many and get all", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		other := newUser(t, s, "other@ucla.edu")
+		car := newCar(t, s, driver)
+
+		ids := []string{newRideSeries(t, s, car).ID, newRideSeries(t, s, car).ID, newRideSeries(t, s, newCar(t, s, other)).ID}
+
+		mine, err := s.RideSeries.WhereMany([]stores.QueryModifier{stores.QueryMod("driver_id", stores.EQ, driver.ID)})
+		require.Nil(t, err)
+		assert.Len(mine, 2)
+
+		checkPages(t, ids, func(page pagination.Page) (interface{}, error) {
+			return s.RideSeries.GetAll(page)
+		})
+	})
+
+	t.Run("get active", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		car := newCar(t, s, driver)
+		pending := newRideSeries(t, s, car)
+		partly := newRideSeries(t, s, car)
+		done := newRideSeries(t, s, car)
+		deleted := newRideSeries(t, s, car)
+		withDeletedCar := newRideSeries(t, s, newCar(t, s, driver))
+
+		through := "2018-03-10"
+		require.Nil(t, s.RideSeries.SetMaterializedThrough(partly.ID, &through))
+		require.Nil(t, s.RideSeries.SetMaterializedThrough(done.ID, &done.EndsOn))
+		require.Nil(t, s.RideSeries.Delete(deleted.ID))
+		require.Nil(t, s.Cars.Remove(withDeletedCar.CarID))
+
+		active, err := s.RideSeries.GetActive()
+		require.Nil(t, err)
+
+		ids := []string{}
+		for _, series := range active {
+			ids = append(ids, series.ID)
+		}
+		assert.ElementsMatch([]string{pending.ID, partly.ID}, ids, "only live series with dates left to materialize should be active")
+	})
+
+	t.Run("occurrences", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		series := newRideSeries(t, s, newCar(t, s, driver))
+
+		ride, err := series.Occurrence("2018-03-12")
+		require.Nil(t, err)
+		require.Nil(t, s.Rides.Insert(ride))
+
+		found, err := s.Rides.GetByID(ride.ID)
+		require.Nil(t, err)
+		if assert.NotNil(found.SeriesID) && assert.NotNil(found.OccurrenceDate) {
+			assert.Equal(series.ID, *found.SeriesID)
+			assert.Equal("2018-03-12", *found.OccurrenceDate)
+		}
+
+		rides, err := s.Rides.WhereMany([]stores.QueryModifier{stores.QueryMod("series_id", stores.EQ, series.ID)})
+		require.Nil(t, err)
+		assert.Len(rides, 1)
+
+		again, err := series.Occurrence("2018-03-12")
+		require.Nil(t, err)
+		assert.Equal(stores.ErrDuplicateOccurrence, s.Rides.Insert(again), "a series should have one ride a date")
+
+		require.Nil(t, s.Rides.Delete(ride.ID))
+		again, err = series.Occurrence("2018-03-12")
+		require.Nil(t, err)
+		assert.Equal(stores.ErrDuplicateOccurrence, s.Rides.Insert(again), "a cancelled ride should still take its date")
+
+		other, err := series.Occurrence("2018-03-14")
+		require.Nil(t, err)
+		assert.Nil(s.Rides.Insert(other))
+		assert.Nil(s.Rides.Insert(newUnlinkedRide(series)), "rides without a series should not conflict")
+		assert.Nil(s.Rides.Insert(newUnlinkedRide(series)))
+	})
+
+	t.Run("delete and purge", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		series := newRideSeries(t, s, newCar(t, s, driver))
+		ride, err := series.Occurrence("2018-03-12")
+		require.Nil(t, err)
+		require.Nil(t, s.Rides.Insert(ride))
+
+		require.Nil(t, s.RideSeries.Delete(series.ID))
+
+		_, err = s.RideSeries.GetByID(series.ID)
+		assert.Equal(stores.ErrNoRideSeriesFound, err, "deleted series should be hidden")
+
+		all, err := s.RideSeries.GetAll(pagination.Page{Sort: pagination.ByID, Limit: 10})
+		require.Nil(t, err)
+		assert.Empty(all)
+
+		_, err = s.Rides.GetByID(ride.ID)
+		assert.Nil(err, "deleting a series should leave its rides alone")
+
+		count, err := s.RideSeries.Purge(time.Now().Add(time.Second))
+		require.Nil(t, err)
+		assert.Equal(1, count)
+
+		found, err := s.Rides.GetByID(ride.ID)
+		require.Nil(t, err, "purging a series should keep its rides")
+		assert.Nil(found.SeriesID, "purging a series should unlink its rides")
+	})
+
+	t.Run("import", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		car := newCar(t, s, driver)
+		created := time.Date(2018, 1, 2, 3, 4, 5, 6000, time.UTC)
+		through := "2018-03-10"
+
+		series := models.RideSeries{
+			ID:                  "series1",
+			DriverID:            driver.ID,
+			CarID:               car.ID,
+			Seats:               3,
+			StartCity:           "Los Angeles",
+			EndCity:             "San Francisco",
+			Days:                "MO",
+			DepartureTime:       "08:30",
+			TimeZone:            models.DefaultSeriesTimeZone,
+			StartsOn:            "2018-03-05",
+			EndsOn:              "2018-03-26",
+			MaterializedThrough: &through,
+			CreatedAt:           created,
+			UpdatedAt:           created,
+			Version:             3,
+		}
+		require.Nil(t, s.RideSeries.Import(&series))
+
+		found, err := s.RideSeries.GetByID(series.ID)
+		require.Nil(t, err)
+		assert.Equal(3, found.Version, "the version should be kept")
+		assert.True(created.Equal(found.CreatedAt), "created_at should be kept")
+		if assert.NotNil(found.MaterializedThrough) {
+			assert.Equal(through, *found.MaterializedThrough)
+		}
+
+		ride, err := series.Occurrence("2018-03-05")
+		require.Nil(t, err)
+		ride.ID, ride.CreatedAt, ride.UpdatedAt, ride.Version = "ride1", created, created, 1
+		require.Nil(t, s.Rides.Import(ride))
+
+		orphan := *ride
+		missing := "doesnotexist"
+		orphan.ID, orphan.SeriesID = "ride2", &missing
+		assert.NotNil(s.Rides.Import(&orphan), "a ride's series must be imported first")
+	})
+}
+
+// RideRequests verifies the RideRequestStore contract and the passengers offered rides for requests
+func RideRequests(t *testing.T, factory Factory) {
+	t.Run("insert, get and update", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		rider := newUser(t, s, "rider@ucla.edu")
+		request := newRideRequest(t, s, rider)
+		assert.NotEmpty(request.ID, "insert should set the id")
+		assert.False(request.CreatedAt.IsZero(), "insert should set created_at")
+		assert.Equal(1, request.Version, "requests should start at version 1")
+
+		found, err := s.Requests.GetByID(request.ID)
+		require.Nil(t, err)
+		assert.Equal(rider.ID, found.PassengerID)
+		assert.Equal(models.RideRequestOpen, found.Status)
+		assert.Equal(2, found.Seats)
+		assert.True(request.EarliestStart.Equal(found.EarliestStart))
+		assert.True(request.LatestStart.Equal(found.LatestStart))
+		if assert.NotNil(found.MaxPrice) {
+			assert.Equal(30.0, *found.MaxPrice)
+		}
+
+		found.Status = models.RideRequestFulfilled
+		found.MaxPrice = nil
+		require.Nil(t, s.Requests.Update(found))
+		assert.Equal(2, found.Version, "update should bump the version")
+
+		request.Seats = 1
+		assert.Equal(stores.ErrVersionConflict, s.Requests.Update(request), "a stale update should conflict")
+
+		missing := models.RideRequest{ID: "doesnotexist", Version: 1}
+		assert.Equal(stores.ErrNoRideRequestFound, s.Requests.Update(&missing))
+
+		found, err = s.Requests.GetByID(request.ID)
+		require.Nil(t, err)
+		assert.Equal(models.RideRequestFulfilled, found.Status)
+		assert.Nil(found.MaxPrice)
+
+		_, err = s.Requests.GetByID("doesnotexist")
+		assert.Equal(stores.ErrNoRideRequestFound, err)
+	})
+
+	t.Run("where many and get all", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		rider := newUser(t, s, "rider@ucla.edu")
+		ids := []string{newRideRequest(t, s, rider).ID, newRideRequest(t, s, rider).ID, newRideRequest(t, s, newUser(t, s, "other@ucla.edu")).ID}
+
+		fulfilled, err := s.Requests.GetByID(ids[0])
+		require.Nil(t, err)
+		fulfilled.Status = models.RideRequestFulfilled
+		require.Nil(t, s.Requests.Update(fulfilled))
+
+		open, err := s.Requests.WhereMany([]stores.QueryModifier{
+			stores.QueryMod("passenger_id", stores.EQ, rider.ID),
+			stores.And,
+			stores.QueryMod("status", stores.EQ, models.RideRequestOpen),
+		})
+		require.Nil(t, err)
+		if assert.Len(open, 1) {
+			assert.Equal(ids[1], open[0].ID)
+		}
+
+		checkPages(t, ids, func(page pagination.Page) (interface{}, error) {
+			return s.Requests.GetAll(page)
+		})
+	})
+
+	t.Run("offered passengers link to the request", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		rider := newUser(t, s, "rider@ucla.edu")
+		request := newRideRequest(t, s, rider)
+		ride := newRide(t, s, newCar(t, s, newUser(t, s, "driver@ucla.edu")))
+
+		offer := models.Passenger{DriverID: ride.DriverID, PassengerID: rider.ID, RideID: ride.ID, Status: models.PassengerInterested, SeatsRequested: 1, RequestID: &request.ID}
+		require.Nil(t, s.Passengers.Insert(&offer))
+
+		offers, err := s.Passengers.WhereMany([]stores.QueryModifier{stores.QueryMod("request_id", stores.EQ, request.ID)})
+		require.Nil(t, err)
+		if assert.Len(offers, 1) && assert.NotNil(offers[0].RequestID) {
+			assert.Equal(offer.ID, offers[0].ID)
+			assert.Equal(request.ID, *offers[0].RequestID)
+		}
+	})
+
+	t.Run("delete and purge", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		rider := newUser(t, s, "rider@ucla.edu")
+		request := newRideRequest(t, s, rider)
+		ride := newRide(t, s, newCar(t, s, newUser(t, s, "driver@ucla.edu")))
+		offer := models.Passenger{DriverID: ride.DriverID, PassengerID: rider.ID, RideID: ride.ID, Status: models.PassengerInterested, SeatsRequested: 1, RequestID: &request.ID}
+		require.Nil(t, s.Passengers.Insert(&offer))
+
+		require.Nil(t, s.Requests.Delete(request.ID))
+
+		_, err := s.Requests.GetByID(request.ID)
+		assert.Equal(stores.ErrNoRideRequestFound, err, "deleted requests should be hidden")
+
+		all, err := s.Requests.GetAll(pagination.Page{Sort: pagination.ByID, Limit: 10})
+		require.Nil(t, err)
+		assert.Empty(all)
+
+		_, err = s.Passengers.GetByID(offer.ID)
+		assert.Nil(err, "deleting a request should leave its offers alone")
+
+		count, err := s.Requests.Purge(time.Now().Add(time.Second))
+		require.Nil(t, err)
+		assert.Equal(1, count)
+
+		found, err := s.Passengers.GetByID(offer.ID)
+		require.Nil(t, err, "purging a request should keep its offers")
+		assert.Nil(found.RequestID, "purging a request should unlink its offers")
+	})
+
+	t.Run("import", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		rider := newUser(t, s, "rider@ucla.edu")
+		created := time.Date(2018, 1, 2, 3, 4, 5, 6000, time.UTC)
+
+		request := models.RideRequest{
+			ID:            "request1",
+			PassengerID:   rider.ID,
+			StartCity:     "Los Angeles",
+			EndCity:       "San Francisco",
+			EarliestStart: created.Add(24 * time.Hour),
+			LatestStart:   created.Add(48 * time.Hour),
+			Seats:         1,
+			Status:        models.RideRequestFulfilled,
+			CreatedAt:     created,
+			UpdatedAt:     created,
+			Version:       2,
+		}
+		require.Nil(t, s.Requests.Import(&request))
+
+		found, err := s.Requests.GetByID(request.ID)
+		require.Nil(t, err)
+		assert.Equal(2, found.Version, "the version should be kept")
+		assert.True(created.Equal(found.CreatedAt), "created_at should be kept")
+		assert.Equal(models.RideRequestFulfilled, found.Status)
+
+		ride := newRide(t, s, newCar(t, s, newUser(t, s, "driver@ucla.edu")))
+		offer := models.Passenger{ID: "passenger1", DriverID: ride.DriverID, PassengerID: rider.ID, RideID: ride.ID, Status: models.PassengerAccepted, SeatsRequested: 1, CreatedAt: created, UpdatedAt: created, Version: 1}
+		offer.RequestID = &request.ID
+		require.Nil(t, s.Passengers.Import(&offer))
+
+		orphan := offer
+		missing := "doesnotexist"
+		orphan.ID, orphan.RequestID = "passenger2", &missing
+		assert.NotNil(s.Passengers.Import(&orphan), "a passenger's request must be imported first")
+	})
+}
+
+// Passengers verifies the PassengerStore contract
+func Passengers(t *testing.T, factory Factory) {
+	t.Run("insert and get", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		rider := newUser(t, s, "rider@ucla.edu")
+		ride := newRide(t, s, newCar(t, s, driver))
+		passenger := newPassenger(t, s, ride, rider, models.PassengerInterested)
+		assert.NotEmpty(passenger.ID, "insert should set the id")
+		assert.False(passenger.CreatedAt.IsZero(), "insert should set created_at")
+
+		found, err := s.Passengers.GetByID(passenger.ID)
+		require.Nil(t, err)
+		assert.Equal(driver.ID, found.DriverID)
+		assert.Equal(rider.ID, found.PassengerID)
+		assert.Equal(ride.ID, found.RideID)
+		assert.Equal(models.PassengerInterested, found.Status)
+
+		dup := models.Passenger{DriverID: driver.ID, PassengerID: rider.ID, RideID: ride.ID, Status: models.PassengerInterested, SeatsRequested: 1}
+		assert.Equal(stores.ErrAlreadyPassenger, s.Passengers.Insert(&dup), "a user can only join a ride once")
+
+		_, err = s.Passengers.GetByID("doesnotexist")
+		assert.Equal(stores.ErrNoPassengerFound, err)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		ride := newRide(t, s, newCar(t, s, driver))
+		passenger := newPassenger(t, s, ride, newUser(t, s, "rider@ucla.edu"), models.PassengerInterested)
+
+		passenger.Status = models.PassengerAccepted
+		require.Nil(t, s.Passengers.Update(passenger))
+
+		found, err := s.Passengers.GetByID(passenger.ID)
+		require.Nil(t, err)
+		assert.Equal(models.PassengerAccepted, found.Status)
+
+		missing := *passenger
+		missing.ID = "doesnotexist"
+		assert.Equal(stores.ErrNoPassengerFound, s.Passengers.Update(&missing))
+	})
+
+	t.Run("seat offers hold a seat until they lapse", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		ride := newRide(t, s, newCar(t, s, newUser(t, s, "driver@ucla.edu")))
+		passenger := newPassenger(t, s, ride, newUser(t, s, "rider@ucla.edu"), models.PassengerWaitlisted)
+
+		confirmBy := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+		passenger.Status = models.PassengerSeatOffered
+		passenger.ConfirmBy = &confirmBy
+		require.Nil(t, s.Passengers.Update(passenger))
+
+		found, err := s.Passengers.GetByID(passenger.ID)
+		require.Nil(t, err)
+		if assert.NotNil(found.ConfirmBy) {
+			assert.WithinDuration(confirmBy, *found.ConfirmBy, time.Second)
+		}
+
+		foundRide, err := s.Rides.GetByID(ride.ID)
+		require.Nil(t, err)
+		require.NotNil(t, foundRide.SeatsTaken)
+		assert.Equal(1, *foundRide.SeatsTaken, "an offered seat should be taken")
+
+		lapsed, err := s.Passengers.WhereMany([]stores.QueryModifier{
+			stores.QueryMod("status", stores.EQ, models.PassengerSeatOffered),
+			stores.And,
+			stores.QueryMod("confirm_by", stores.LT, time.Now()),
+		})
+		require.Nil(t, err)
+		assert.Len(lapsed, 1)
+
+		found.Status = models.PassengerWithdrawn
+		found.ConfirmBy = nil
+		require.Nil(t, s.Passengers.Update(found))
+
+		found, err = s.Passengers.GetByID(passenger.ID)
+		require.Nil(t, err)
+		assert.Nil(found.ConfirmBy, "withdrawing should clear the deadline")
+	})
+
+	t.Run("seats taken add up the parties", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		ride := newRide(t, s, newCar(t, s, newUser(t, s, "driver@ucla.edu")))
+		newPassenger(t, s, ride, newUser(t, s, "a@ucla.edu"), models.PassengerAccepted)
+		party := newPassenger(t, s, ride, newUser(t, s, "b@ucla.edu"), models.PassengerInterested)
+		newPassenger(t, s, ride, newUser(t, s, "c@ucla.edu"), models.PassengerRejected)
+
+		party.Status = models.PassengerAccepted
+		party.SeatsRequested = 2
+		require.Nil(t, s.Passengers.Update(party))
+
+		found, err := s.Passengers.GetByID(party.ID)
+		require.Nil(t, err)
+		assert.Equal(2, found.SeatsRequested)
+
+		foundRide, err := s.Rides.GetByID(ride.ID)
+		require.Nil(t, err)
+		require.NotNil(t, foundRide.SeatsTaken)
+		assert.Equal(3, *foundRide.SeatsTaken, "a party should take a seat for each of its members")
+
+		seats, err := s.Passengers.SumSeats([]stores.QueryModifier{
+			stores.QueryMod("ride_id", stores.EQ, ride.ID),
+			stores.And,
+			stores.QueryMod("status", stores.EQ, models.PassengerAccepted),
+		})
+		require.Nil(t, err)
+		assert.Equal(3, seats)
+
+		none, err := s.Passengers.SumSeats([]stores.QueryModifier{stores.QueryMod("status", stores.EQ, models.PassengerWaitlisted)})
+		require.Nil(t, err)
+		assert.Equal(0, none, "no passengers should add up to no seats")
+	})
+
+	t.Run("update checks the version", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		ride := newRide(t, s, newCar(t, s, newUser(t, s, "driver@ucla.edu")))
+		passenger := newPassenger(t, s, ride, newUser(t, s, "rider@ucla.edu"), models.PassengerInterested)
+		assert.Equal(1, passenger.Version, "passengers should start at the first version")
+
+		stale := *passenger
+
+		passenger.Status = models.PassengerAccepted
+		require.Nil(t, s.Passengers.Update(passenger))
+		assert.Equal(2, passenger.Version, "update should move to the next version")
+
+		stale.Status = models.PassengerRejected
+		assert.Equal(stores.ErrVersionConflict, s.Passengers.Update(&stale), "a stale version should conflict")
+
+		found, err := s.Passengers.GetByID(passenger.ID)
+		require.Nil(t, err)
+		assert.Equal(models.PassengerAccepted, found.Status, "the conflicting update should not be applied")
+		assert.Equal(2, found.Version)
+	})
+
+	t.Run("count and where many filter by query modifiers", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		car := newCar(t, s, driver)
+		ride := newRide(t, s, car)
+		other := newRide(t, s, car)
+		newPassenger(t, s, ride, newUser(t, s, "a@ucla.edu"), models.PassengerAccepted)
+		newPassenger(t, s, ride, newUser(t, s, "b@ucla.edu"), models.PassengerInterested)
+		newPassenger(t, s, other, newUser(t, s, "c@ucla.edu"), models.PassengerAccepted)
+
+		accepted := []stores.QueryModifier{
+			stores.QueryMod("ride_id", stores.EQ, ride.ID),
+			stores.And,
+			stores.QueryMod("status", stores.EQ, models.PassengerAccepted),
+		}
+
+		count, err := s.Passengers.Count(accepted)
+		require.Nil(t, err)
+		assert.Equal(1, count)
+
+		passengers, err := s.Passengers.WhereMany([]stores.QueryModifier{stores.QueryMod("ride_id", stores.EQ, ride.ID)})
+		require.Nil(t, err)
+		assert.Equal(2, len(passengers))
+		for _, passenger := range passengers {
+			assert.Equal(ride.ID, passenger.RideID)
+		}
+	})
+
+	t.Run("get all pages by id", func(t *testing.T) {
+		s := factory(t)
+		driver := newUser(t, s, "driver@ucla.edu")
+		ride := newRide(t, s, newCar(t, s, driver))
+
+		var ids []string
+		for i := 0; i < 5; i++ {
+			rider := newUser(t, s, fmt.Sprintf("rider%d@ucla.edu", i))
+			ids = append(ids, newPassenger(t, s, ride, rider, models.PassengerInterested).ID)
+		}
+
+		checkPages(t, ids, func(page pagination.Page) (interface{}, error) {
+			return s.Passengers.GetAll(page)
+		})
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		ride := newRide(t, s, newCar(t, s, driver))
+		passenger := newPassenger(t, s, ride, newUser(t, s, "rider@ucla.edu"), models.PassengerInterested)
+
+		require.Nil(t, s.Passengers.Delete(passenger.ID))
+
+		_, err := s.Passengers.GetByID(passenger.ID)
+		assert.Equal(t, stores.ErrNoPassengerFound, err)
+
+		count, err := s.Passengers.Count([]stores.QueryModifier{stores.QueryMod("ride_id", stores.EQ, ride.ID)})
+		require.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("restore and rejoin", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		rider := newUser(t, s, "rider@ucla.edu")
+		ride := newRide(t, s, newCar(t, s, driver))
+		passenger := newPassenger(t, s, ride, rider, models.PassengerInterested)
+
+		require.Nil(t, s.Passengers.Delete(passenger.ID))
+		require.Nil(t, s.Passengers.Restore(passenger.ID))
+		assert.Equal(stores.ErrNoPassengerFound, s.Passengers.Restore(passenger.ID), "a passenger that is not deleted cannot be restored")
+
+		require.Nil(t, s.Passengers.Delete(passenger.ID))
+		rejoined := newPassenger(t, s, ride, rider, models.PassengerInterested)
+		assert.NotEqual(passenger.ID, rejoined.ID, "deleted passengers should not stop a user from joining again")
+		assert.Equal(stores.ErrAlreadyPassenger, s.Passengers.Restore(passenger.ID))
+
+		count, err := s.Passengers.Purge(time.Now().Add(time.Hour))
+		require.Nil(t, err)
+		assert.Equal(1, count)
+
+		_, err = s.Passengers.GetByID(rejoined.ID)
+		assert.Nil(err)
+	})
+}
+
+// Import verifies that every store can import records exactly as they were exported
+func Import(t *testing.T, factory Factory) {
+	t.Run("import keeps ids, timestamps and versions", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		created := time.Date(2018, 1, 2, 3, 4, 5, 6000, time.UTC)
+		updated := created.Add(time.Hour)
+
+		user := models.User{ID: "user1", FirstName: "Joe", LastName: "Bruin", Email: "joe@ucla.edu", AuthLevel: 1, CreatedAt: created, UpdatedAt: updated}
+		require.Nil(t, s.Users.Import(&user))
+		assert.Equal(stores.ErrUserAlreadyExists, s.Users.Import(&models.User{ID: "user2", Email: "joe@ucla.edu", CreatedAt: created, UpdatedAt: created}))
+
+		car := models.Car{ID: "car1", Make: "Toyota", Model: "Prius", Year: 2015, Color: "blue", UserID: user.ID, CreatedAt: created, UpdatedAt: updated}
+		require.Nil(t, s.Cars.Import(&car))
+
+		ride := models.Ride{
+			ID:           "ride1",
+			DriverID:     user.ID,
+			CarID:        car.ID,
+			Seats:        3,
+			StartCity:    "Los Angeles",
+			EndCity:      "San Francisco",
+			PricePerSeat: 25,
+			Info:         "room for skis",
+			StartDate:    created.Add(24 * time.Hour),
+			CreatedAt:    created,
+			UpdatedAt:    updated,
+			Version:      4,
+		}
+		require.Nil(t, s.Rides.Import(&ride))
+
+		passenger := models.Passenger{ID: "passenger1", DriverID: user.ID, PassengerID: user.ID, RideID: ride.ID, Status: models.PassengerAccepted, SeatsRequested: 1, CreatedAt: created, UpdatedAt: updated, Version: 2}
+		require.Nil(t, s.Passengers.Import(&passenger))
+
+		deleted := models.Passenger{ID: "passenger2", DriverID: user.ID, PassengerID: user.ID, RideID: ride.ID, Status: models.PassengerInterested, SeatsRequested: 1, CreatedAt: created, UpdatedAt: updated, DeletedAt: &updated, Version: 1}
+		require.Nil(t, s.Passengers.Import(&deleted))
+
+		foundUser, err := s.Users.GetByID(user.ID)
+		require.Nil(t, err)
+		assert.Equal(1, foundUser.AuthLevel)
+		assert.True(created.Equal(foundUser.CreatedAt), "created_at should be kept")
+		assert.True(updated.Equal(foundUser.UpdatedAt), "updated_at should be kept")
+
+		foundCar, err := s.Cars.GetByID(car.ID)
+		require.Nil(t, err)
+		assert.Equal(user.ID, foundCar.UserID)
+		assert.True(created.Equal(foundCar.CreatedAt))
+
+		foundRide, err := s.Rides.GetByID(ride.ID)
+		require.Nil(t, err)
+		assert.Equal(4, foundRide.Version, "the version should be kept")
+		assert.True(ride.StartDate.Equal(foundRide.StartDate))
+		assert.True(updated.Equal(foundRide.UpdatedAt))
+		require.NotNil(t, foundRide.SeatsTaken)
+		assert.Equal(1, *foundRide.SeatsTaken, "imported passengers should take seats")
+
+		foundPassenger, err := s.Passengers.GetByID(passenger.ID)
+		require.Nil(t, err)
+		assert.Equal(2, foundPassenger.Version)
+		assert.True(created.Equal(foundPassenger.CreatedAt))
+
+		_, err = s.Passengers.GetByID(deleted.ID)
+		assert.Equal(stores.ErrNoPassengerFound, err, "deleted_at should be kept")
+
+		rides, err := s.Rides.Search(&models.RideSearch{Query: "skis", Sort: models.RideSortRelevance, Limit: 10})
+		require.Nil(t, err)
+		assert.Len(rides, 1, "imported rides should be searchable")
+	})
+
+	t.Run("import rejects records whose parents are missing", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		now := time.Now().UTC().Truncate(time.Second)
+
+		car := models.Car{ID: "car1", Make: "Toyota", Model: "Prius", Year: 2015, Color: "blue", UserID: "nobody", CreatedAt: now, UpdatedAt: now}
+		assert.NotNil(s.Cars.Import(&car), "a car's user must be imported first")
+
+		_, err := s.Cars.GetByID(car.ID)
+		assert.Equal(stores.ErrNoCarFound, err)
+	})
+}
+
+// Audit verifies the AuditStore contract
+func Audit(t *testing.T, factory Factory) {
+	t.Run("insert and where many", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		first, err := models.NewAuditEvent("actor", models.AuditEntityRide, "ride1", models.AuditActionUpdate,
+			map[string]int{"seats": 3}, map[string]int{"seats": 2})
+		require.Nil(t, err)
+		require.Nil(t, s.Audit.Insert(first))
+		assert.NotEmpty(first.ID, "insert should set the id")
+		assert.False(first.CreatedAt.IsZero(), "insert should set created_at")
+
+		other, err := models.NewAuditEvent("actor", models.AuditEntityPassenger, "ride1", models.AuditActionUpdate,
+			map[string]string{"status": "interested"}, map[string]string{"status": "accepted"})
+		require.Nil(t, err)
+		require.Nil(t, s.Audit.Insert(other))
+
+		events, err := s.Audit.WhereMany([]stores.QueryModifier{
+			stores.QueryMod("entity", stores.EQ, models.AuditEntityRide),
+			stores.And,
+			stores.QueryMod("entity_id", stores.EQ, "ride1"),
+		})
+		require.Nil(t, err)
+		require.Len(t, events, 1, "only the ride's events should be found")
+		assert.Equal(first.ID, events[0].ID)
+		assert.Equal("actor", events[0].ActorID)
+		assert.Equal(models.AuditActionUpdate, events[0].Action)
+		assert.JSONEq(`{"seats": 3}`, events[0].Before.String(), "before should round trip")
+		assert.JSONEq(`{"seats": 2}`, events[0].After.String(), "after should round trip")
+	})
+
+	t.Run("written in transactions", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		committed, err := models.NewAuditEvent("actor", models.AuditEntityRide, "ride1", models.AuditActionUpdate, nil, nil)
+		require.Nil(t, err)
+		require.Nil(t, s.Transactor.WithTx(func(tx services.Tx) error {
+			return tx.Audit().Insert(committed)
+		}))
+
+		failed := errors.New("failed")
+		rolledBack, err := models.NewAuditEvent("actor", models.AuditEntityRide, "ride1", models.AuditActionUpdate, nil, nil)
+		require.Nil(t, err)
+		err = s.Transactor.WithTx(func(tx services.Tx) error {
+			if err := tx.Audit().Insert(rolledBack); err != nil {
+				return err
+			}
+
+			return failed
+		})
+		assert.Equal(failed, err)
+
+		events, err := s.Audit.WhereMany([]stores.QueryModifier{stores.QueryMod("entity_id", stores.EQ, "ride1")})
+		require.Nil(t, err)
+		require.Len(t, events, 1, "events written in a rolled back transaction should be discarded")
+		assert.Equal(committed.ID, events[0].ID)
+	})
+}
+
+// Outbox verifies the OutboxStore contract
+func Outbox(t *testing.T, factory Factory) {
+	t.Run("insert, get due and update", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		first := newOutboxEvent(t, s, models.EventRideCreated, "ride1")
+		assert.NotEmpty(first.ID, "insert should set the id")
+		assert.False(first.CreatedAt.IsZero(), "insert should set created_at")
+		assert.NotNil(first.NextAttemptAt, "events should be due once inserted")
+		second := newOutboxEvent(t, s, models.EventPassengerRequested, "passenger1")
+
+		soon := time.Now().Add(time.Second)
+		due, err := s.Outbox.GetDue(soon, 10)
+		require.Nil(t, err)
+		require.Len(t, due, 2)
+		assert.Equal([]string{first.ID, second.ID}, []string{due[0].ID, due[1].ID}, "events should be due oldest first")
+		assert.Equal(models.EventRideCreated, due[0].Type)
+		assert.Equal("ride1", due[0].EntityID)
+		assert.JSONEq(`{"id": "ride1"}`, due[0].Payload.String(), "the payload should round trip")
+
+		due, err = s.Outbox.GetDue(soon, 1)
+		require.Nil(t, err)
+		require.Len(t, due, 1, "no more events than the limit should be due")
+
+		delivered := time.Now()
+		first.Attempts = 1
+		first.DeliveredAt = &delivered
+		first.NextAttemptAt = nil
+		require.Nil(t, s.Outbox.Update(first))
+
+		message := "mail server down"
+		retry := time.Now().Add(time.Hour)
+		second.Attempts = 1
+		second.LastError = &message
+		second.NextAttemptAt = &retry
+		require.Nil(t, s.Outbox.Update(second))
+
+		due, err = s.Outbox.GetDue(soon, 10)
+		require.Nil(t, err)
+		assert.Empty(due, "delivered events and events to retry later should not be due")
+
+		due, err = s.Outbox.GetDue(retry.Add(time.Second), 10)
+		require.Nil(t, err)
+		require.Len(t, due, 1, "events should be due again once it is time to retry")
+		assert.Equal(second.ID, due[0].ID)
+		assert.Equal(1, due[0].Attempts)
+		if assert.NotNil(due[0].LastError) {
+			assert.Equal(message, *due[0].LastError)
+		}
+
+		missing := models.OutboxEvent{ID: "doesnotexist"}
+		assert.Equal(stores.ErrNoOutboxEventFound, s.Outbox.Update(&missing))
+	})
+
+	t.Run("claim", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		first := newOutboxEvent(t, s, models.EventRideCreated, "ride1")
+		second := newOutboxEvent(t, s, models.EventRideCreated, "ride2")
+
+		soon := time.Now().Add(time.Second)
+		lease := soon.Add(time.Hour)
+		claimed, err := s.Outbox.Claim(soon, lease, 1)
+		require.Nil(t, err)
+		require.Len(t, claimed, 1)
+		assert.Equal(first.ID, claimed[0].ID, "the oldest events should be claimed first")
+		if assert.NotNil(claimed[0].NextAttemptAt) {
+			assert.WithinDuration(lease, *claimed[0].NextAttemptAt, time.Millisecond)
+		}
+
+		claimed, err = s.Outbox.Claim(soon, lease, 10)
+		require.Nil(t, err)
+		require.Len(t, claimed, 1, "claimed events should not be claimed again")
+		assert.Equal(second.ID, claimed[0].ID)
+
+		claimed, err = s.Outbox.Claim(lease.Add(time.Second), lease.Add(time.Hour), 10)
+		require.Nil(t, err)
+		assert.Len(claimed, 2, "events should be claimed again once their lease runs out")
+	})
+
+	t.Run("purge delivered", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		delivered := newOutboxEvent(t, s, models.EventRideCreated, "ride1")
+		newOutboxEvent(t, s, models.EventRideCreated, "ride2")
+		givenUp := newOutboxEvent(t, s, models.EventRideCreated, "ride3")
+
+		at := time.Now()
+		delivered.DeliveredAt = &at
+		delivered.NextAttemptAt = nil
+		require.Nil(t, s.Outbox.Update(delivered))
+
+		message := "mail server down"
+		givenUp.Attempts = 10
+		givenUp.LastError = &message
+		givenUp.NextAttemptAt = nil
+		require.Nil(t, s.Outbox.Update(givenUp))
+
+		count, err := s.Outbox.Purge(time.Now().Add(time.Second))
+		require.Nil(t, err)
+		assert.Equal(2, count, "only delivered events and events given up on should be purged")
+
+		due, err := s.Outbox.GetDue(time.Now().Add(time.Second), 10)
+		require.Nil(t, err)
+		assert.Len(due, 1, "undelivered events should be kept")
+	})
+
+	t.Run("written in transactions", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		committed, err := models.NewOutboxEvent(models.EventRideCreated, "ride1", nil)
+		require.Nil(t, err)
+		require.Nil(t, s.Transactor.WithTx(func(tx services.Tx) error {
+			return tx.Outbox().Insert(committed)
+		}))
+
+		failed := errors.New("failed")
+		err = s.Transactor.WithTx(func(tx services.Tx) error {
+			rolledBack, err := models.NewOutboxEvent(models.EventRideCreated, "ride2", nil)
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Outbox().Insert(rolledBack); err != nil {
+				return err
+			}
+
+			return failed
+		})
+		assert.Equal(failed, err)
+
+		due, err := s.Outbox.GetDue(time.Now().Add(time.Second), 10)
+		require.Nil(t, err)
+		require.Len(t, due, 1, "events written in a rolled back transaction should be discarded")
+		assert.Equal(committed.ID, due[0].ID)
+	})
+}
+
+// Transactions verifies the Transactor contract
+func Transactions(t *testing.T, factory Factory) {
+	t.Run("commit", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		ride := newRide(t, s, newCar(t, s, driver))
+		passenger := newPassenger(t, s, ride, newUser(t, s, "rider@ucla.edu"), models.PassengerInterested)
+
+		err := s.Transactor.WithTx(func(tx services.Tx) error {
+			locked, err := tx.Rides().GetByIDForUpdate(ride.ID)
+			if err != nil {
+				return err
+			}
+			assert.Equal(ride.ID, locked.ID)
+
+			passenger.Status = models.PassengerAccepted
+			return tx.Passengers().Update(passenger)
+		})
+		require.Nil(t, err)
+
+		found, err := s.Passengers.GetByID(passenger.ID)
+		require.Nil(t, err)
+		assert.Equal(models.PassengerAccepted, found.Status, "changes should be committed")
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		assert := assert.New(t)
+		s := factory(t)
+
+		driver := newUser(t, s, "driver@ucla.edu")
+		ride := newRide(t, s, newCar(t, s, driver))
+		passenger := newPassenger(t, s, ride, newUser(t, s, "rider@ucla.edu"), models.PassengerInterested)
+		failed := errors.New("failed")
+
+		err := s.Transactor.WithTx(func(tx services.Tx) error {
+			passenger.Status = models.PassengerAccepted
+			if err := tx.Passengers().Update(passenger); err != nil {
+				return err
+			}
+
+			if err := tx.Rides().Delete(ride.ID); err != nil {
+				return err
+			}
+
+			return failed
+		})
+		assert.Equal(failed, err, "the unit of work's error should be returned")
+
+		found, err := s.Passengers.GetByID(passenger.ID)
+		require.Nil(t, err, "the cascading delete should be rolled back")
+		assert.Equal(models.PassengerInterested, found.Status, "the update should be rolled back")
+
+		_, err = s.Rides.GetByID(ride.ID)
+		assert.Nil(err, "the delete should be rolled back")
+	})
+}
+
+// checkPages walks getPage two records at a time and checks every id comes back once, in order
+func checkPages(t *testing.T, ids []string, getPage func(page pagination.Page) (interface{}, error)) {
+	want := append([]string(nil), ids...)
+	sort.Strings(want)
+
+	checkSortedPages(t, want, pagination.ByID, getPage)
+}
+
+// checkSortedPages pages through the rows two at a time, following the cursor of
+// each page's last row, and checks the rows come in the order of want
+func checkSortedPages(t *testing.T, want []string, sort pagination.Sort, getPage func(page pagination.Page) (interface{}, error)) {
+	var got []string
+	page := pagination.Page{Sort: sort, Limit: 2}
+
+	for i := 0; i <= len(want); i++ {
+		rows, err := getPage(page)
+		require.Nil(t, err)
+
+		rv := reflect.ValueOf(rows)
+		if rv.Len() == 0 {
+			break
+		}
+
+		assert.True(t, rv.Len() <= 2, "a page should respect the limit")
+		for j := 0; j < rv.Len(); j++ {
+			got = append(got, rv.Index(j).Elem().FieldByName("ID").String())
+		}
+
+		page.After, err = pagination.NewCursor(sort, rv.Index(rv.Len()-1).Interface())
+		require.Nil(t, err)
+	}
+
+	assert.Equal(t, want, got, "pages should cover every record in order")
+}
+
+func newOutboxEvent(t *testing.T, s Stores, eventType, entityID string) *models.OutboxEvent {
+	event, err := models.NewOutboxEvent(eventType, entityID, map[string]string{"id": entityID})
+	require.Nil(t, err)
+	require.Nil(t, s.Outbox.Insert(event))
+
+	return event
+}
+
+func newUser(t *testing.T, s Stores, email string) *models.User {
+	user := models.User{FirstName: "Joe", LastName: "Bruin", Email: email}
+	require.Nil(t, s.Users.Insert(&user))
+
+	return &user
+}
+
+func newCar(t *testing.T, s Stores, owner *models.User) *models.Car {
+	car := models.Car{Make: "Toyota", Model: "Prius", Year: 2015, Color: "blue", UserID: owner.ID}
+	require.Nil(t, s.Cars.Insert(&car))
+
+	return &car
+}
+
+func newRide(t *testing.T, s Stores, car *models.Car) *models.Ride {
+	ride := models.Ride{
+		DriverID:     car.UserID,
+		CarID:        car.ID,
+		Seats:        3,
+		StartCity:    "Los Angeles",
+		EndCity:      "San Francisco",
+		StartLat:     34.068921,
+		StartLon:     -118.445181,
+		EndLat:       37.774929,
+		EndLon:       -122.419416,
+		PricePerSeat: 25,
+		Info:         "leaving from Westwood",
+		StartDate:    time.Now().Add(24 * time.Hour).Truncate(time.Second),
+	}
+	require.Nil(t, s.Rides.Insert(&ride))
+
+	return &ride
+}
+
+func newRideSeries(t *testing.T, s Stores, car *models.Car) *models.RideSeries {
+	driverID, carID, days, departure := car.UserID, car.ID, "MO,WE,FR", "08:30"
+	startCity, endCity, info := "Los Angeles", "San Francisco", "leaving from Westwood"
+	startLat, startLon, endLat, endLon := 34.068921, -118.445181, 37.774929, -122.419416
+	startsOn, endsOn := "2018-03-05", "2018-06-08"
+
+	series, err := models.NewRideSeries(&models.RideSeriesChangeSet{
+		DriverID:      &driverID,
+		CarID:         &carID,
+		StartCity:     &startCity,
+		EndCity:       &endCity,
+		StartLat:      &startLat,
+		StartLon:      &startLon,
+		EndLat:        &endLat,
+		EndLon:        &endLon,
+		Info:          &info,
+		Days:          &days,
+		DepartureTime: &departure,
+		StartsOn:      &startsOn,
+		EndsOn:        &endsOn,
+	})
+	require.Nil(t, err)
+	series.Seats = 3
+	require.Nil(t, s.RideSeries.Insert(series))
+
+	return series
+}
+
+// newUnlinkedRide returns a ride given by the series' driver that is not one of its occurrences
+func newRideRequest(t *testing.T, s Stores, rider *models.User) *models.RideRequest {
+	startCity, endCity := "Los Angeles", "San Francisco"
+	startLat, startLon, endLat, endLon := 34.068921, -118.445181, 37.774929, -122.419416
+	earliest := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	latest := earliest.Add(6 * time.Hour)
+	seats, maxPrice := 2, 30.0
+
+	request, err := models.NewRideRequest(&models.RideRequestChangeSet{
+		PassengerID:   &rider.ID,
+		StartCity:     &startCity,
+		EndCity:       &endCity,
+		StartLat:      &startLat,
+		StartLon:      &startLon,
+		EndLat:        &endLat,
+		EndLon:        &endLon,
+		EarliestStart: &earliest,
+		LatestStart:   &latest,
+		Seats:         &seats,
+		MaxPrice:      &maxPrice,
+	})
+	require.Nil(t, err)
+	require.Nil(t, s.Requests.Insert(request))
+
+	return request
+}
+
+func newUnlinkedRide(series *models.RideSeries) *models.Ride {
+	return &models.Ride{
+		DriverID:  series.DriverID,
+		CarID:     series.CarID,
+		Seats:     series.Seats,
+		StartCity: series.StartCity,
+		EndCity:   series.EndCity,
+		StartLat:  series.StartLat,
+		StartLon:  series.StartLon,
+		EndLat:    series.EndLat,
+		EndLon:    series.EndLon,
+		StartDate: time.Now().Add(24 * time.Hour).Truncate(time.Second),
+	}
+}
+
+func newPassenger(t *testing.T, s Stores, ride *models.Ride, user *models.User, status string) *models.Passenger {
+	passenger := models.Passenger{DriverID: ride.DriverID, PassengerID: user.ID, RideID: ride.ID, Status: status, SeatsRequested: 1}
+	require.Nil(t, s.Passengers.Insert(&passenger))
+
+	return &passenger
+}
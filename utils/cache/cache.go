@@ -0,0 +1,29 @@
+package cache
+
+type (
+	// Cache holds values by key for a while, implementations must be safe to use
+	// from many goroutines and may drop values at any time
+	Cache interface {
+		// Get returns the value of the key, ok is false if it is not cached
+		Get(key string) (value interface{}, ok bool)
+		Set(key string, value interface{})
+		// SetIfUnchanged caches the value of the key unless a key was deleted or the
+		// cache cleared since version, so a value read before an invalidation is not
+		// cached after it
+		SetIfUnchanged(key string, value interface{}, version uint64)
+		// Version changes whenever a key is deleted or the cache is cleared
+		Version() uint64
+		Delete(key string)
+		// Clear deletes every key
+		Clear()
+		Stats() Stats
+	}
+
+	// Stats is how well a cache is working
+	Stats struct {
+		Hits      uint64 `json:"hits"`
+		Misses    uint64 `json:"misses"`
+		Evictions uint64 `json:"evictions"`
+		Size      int    `json:"size"`
+	}
+)
@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type (
+	// LRU is an in-process cache that holds up to capacity values for ttl each,
+	// evicting the least recently used value when it is full
+	LRU struct {
+		capacity int
+		ttl      time.Duration
+		now      func() time.Time
+
+		mu      sync.Mutex
+		entries map[string]*list.Element
+		order   *list.List // most recently used first
+		stats   Stats
+		version uint64
+	}
+
+	entry struct {
+		key       string
+		value     interface{}
+		expiresAt time.Time
+	}
+)
+
+// NewLRU creates an LRU cache, a ttl of 0 keeps values until they are evicted
+func NewLRU(capacity int, ttl time.Duration) *LRU {
+	return &LRU{
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// Get returns the value of the key if it is cached and has not expired
+func (c *LRU) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+
+	e := element.Value.(*entry)
+	if c.ttl > 0 && !c.now().Before(e.expiresAt) {
+		c.remove(element)
+		c.stats.Misses++
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	c.stats.Hits++
+
+	return e.value, true
+}
+
+// Set caches the value of the key, evicting the least recently used value if full
+func (c *LRU) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(key, value)
+}
+
+// SetIfUnchanged caches the value of the key unless a key was deleted or the cache
+// cleared since version
+func (c *LRU) SetIfUnchanged(key string, value interface{}, version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.version == version {
+		c.set(key, value)
+	}
+}
+
+// Version changes whenever a key is deleted or the cache is cleared, evictions
+// leave it as it is
+func (c *LRU) Version() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.version
+}
+
+func (c *LRU) set(key string, value interface{}) {
+	expiresAt := c.now().Add(c.ttl)
+
+	if element, ok := c.entries[key]; ok {
+		e := element.Value.(*entry)
+		e.value = value
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+// Delete removes the key from the cache
+func (c *LRU) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version++
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+}
+
+// Clear removes every key from the cache
+func (c *LRU) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version++
+	c.entries = map[string]*list.Element{}
+	c.order.Init()
+}
+
+// Stats returns the hits, misses and evictions so far and how many values are cached
+func (c *LRU) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.order.Len()
+
+	return stats
+}
+
+func (c *LRU) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*entry).key)
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUGetSet(t *testing.T) {
+	assert := assert.New(t)
+	c := NewLRU(2, 0)
+
+	_, ok := c.Get("a")
+	assert.False(ok, "nothing should be cached yet")
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	value, ok := c.Get("a")
+	assert.True(ok)
+	assert.Equal(1, value)
+
+	c.Set("a", 3)
+	value, _ = c.Get("a")
+	assert.Equal(3, value, "setting a cached key should replace its value")
+
+	c.Delete("a")
+	_, ok = c.Get("a")
+	assert.False(ok, "deleted keys should not be cached")
+
+	c.Clear()
+	_, ok = c.Get("b")
+	assert.False(ok, "clear should delete every key")
+
+	assert.Equal(Stats{Hits: 2, Misses: 3, Size: 0}, c.Stats())
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	assert := assert.New(t)
+	c := NewLRU(2, 0)
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Set("c", 3)
+
+	_, ok := c.Get("b")
+	assert.False(ok, "the least recently used key should be evicted")
+	_, ok = c.Get("a")
+	assert.True(ok, "getting a key should make it recently used")
+	_, ok = c.Get("c")
+	assert.True(ok)
+
+	stats := c.Stats()
+	assert.Equal(uint64(1), stats.Evictions)
+	assert.Equal(2, stats.Size)
+}
+
+func TestLRUExpires(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Date(2018, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	c := NewLRU(10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+
+	now = now.Add(59 * time.Second)
+	_, ok := c.Get("a")
+	assert.True(ok, "keys should be cached until the ttl is up")
+
+	now = now.Add(time.Second)
+	_, ok = c.Get("a")
+	assert.False(ok, "keys should expire after the ttl")
+	assert.Equal(0, c.Stats().Size, "expired keys should be removed")
+}
+
+func TestLRUSetIfUnchanged(t *testing.T) {
+	assert := assert.New(t)
+	c := NewLRU(2, 0)
+
+	version := c.Version()
+	c.SetIfUnchanged("a", 1, version)
+	value, ok := c.Get("a")
+	assert.True(ok, "values should be cached while nothing was invalidated")
+	assert.Equal(1, value)
+
+	version = c.Version()
+	c.Delete("b")
+	c.SetIfUnchanged("a", 2, version)
+	value, _ = c.Get("a")
+	assert.Equal(1, value, "values read before a delete should not be cached")
+
+	version = c.Version()
+	c.Clear()
+	c.SetIfUnchanged("a", 3, version)
+	_, ok = c.Get("a")
+	assert.False(ok, "values read before a clear should not be cached")
+}
@@ -0,0 +1,69 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidCursor occurs when a cursor was not encoded by the codec, or was changed since
+var ErrInvalidCursor = errors.New("cursor must be the next_cursor of a previous page")
+
+// Codec encodes cursors as opaque strings signed so that clients cannot change them
+type Codec struct {
+	secret []byte
+}
+
+// NewCodec creates a codec that signs cursors with the secret
+func NewCodec(secret string) *Codec {
+	return &Codec{
+		secret: []byte(secret),
+	}
+}
+
+// Encode encodes the cursor, any JSON encodable value, and its signature
+func (c *Codec) Encode(cursor interface{}) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return encode(data) + "." + encode(c.sign(data)), nil
+}
+
+// Decode verifies the signature of an encoded cursor and decodes it into cursor
+func (c *Codec) Decode(encoded string, cursor interface{}) error {
+	parts := strings.Split(encoded, ".")
+	if len(parts) != 2 {
+		return ErrInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, c.sign(data)) {
+		return ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return ErrInvalidCursor
+	}
+
+	return nil
+}
+
+func (c *Codec) sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,244 @@
+package pagination
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultLimit is the page size when none is requested
+	DefaultLimit = 15
+	// MaxLimit is the largest page that can be requested
+	MaxLimit = 100
+)
+
+var (
+	// ErrInvalidSort occurs when a list is sorted by a column it cannot be sorted by
+	ErrInvalidSort = errors.New("the list cannot be sorted by that column")
+	// ErrSortMismatch occurs when a cursor from a list with a different sort is used
+	ErrSortMismatch = errors.New("the cursor is for a list with a different sort")
+)
+
+// ByID sorts a list by id, which is the order rows were created in
+var ByID = Sort{"id"}
+
+type (
+	// Sort is the columns a list is sorted by, ascending, the last column is always
+	// id so that every row has its own place in the order
+	Sort []string
+
+	// Page is a request for Limit rows after the After cursor, or the first Limit
+	// rows if there is no cursor
+	Page struct {
+		Sort  Sort
+		After *Cursor
+		Limit int
+	}
+
+	// Cursor is the position of the last row of a page, the values of its sort columns
+	Cursor struct {
+		Sort   Sort
+		Values []interface{}
+	}
+
+	// value is a cursor value tagged with its type so it decodes to the same type
+	value struct {
+		Time   *time.Time `json:"time,omitempty"`
+		String *string    `json:"string,omitempty"`
+		Float  *float64   `json:"float,omitempty"`
+		Int    *int       `json:"int,omitempty"`
+	}
+
+	cursorJSON struct {
+		Sort   string  `json:"sort"`
+		Values []value `json:"values"`
+	}
+)
+
+// ParseSort parses comma separated columns, an empty string is sorted by id
+func ParseSort(columns string) Sort {
+	sort := Sort{}
+
+	for _, column := range strings.Split(columns, ",") {
+		if column = strings.TrimSpace(column); column != "" && column != "id" {
+			sort = append(sort, column)
+		}
+	}
+
+	return append(sort, "id")
+}
+
+// String is the comma separated columns
+func (s Sort) String() string {
+	return strings.Join(s, ",")
+}
+
+// WithDefaults fills in the sort and limit of the page when they are not given,
+// limits over MaxLimit are also replaced with DefaultLimit
+func (p Page) WithDefaults(sort Sort) Page {
+	if len(p.Sort) == 0 {
+		p.Sort = sort
+	}
+
+	if p.Limit <= 0 || p.Limit > MaxLimit {
+		p.Limit = DefaultLimit
+	}
+
+	return p
+}
+
+// Validate checks the page is sorted by the allowed columns, id is always allowed,
+// and that its cursor is for the same sort
+func (p Page) Validate(allowed ...string) error {
+	if len(p.Sort) == 0 || p.Sort[len(p.Sort)-1] != "id" {
+		return ErrInvalidSort
+	}
+
+	for _, column := range p.Sort[:len(p.Sort)-1] {
+		if !contains(allowed, column) {
+			return ErrInvalidSort
+		}
+	}
+
+	if p.After != nil && p.After.Sort.String() != p.Sort.String() {
+		return ErrSortMismatch
+	}
+
+	return nil
+}
+
+// Peek is the page with one more row, which is found to tell if there is a next page
+func (p Page) Peek() Page {
+	p.Limit++
+	return p
+}
+
+// Trim cuts rows, a pointer to a slice of models found with Peek, down to the page
+// and returns the cursor of the next page, or nil if this is the last page
+func (p Page) Trim(rows interface{}) (*Cursor, error) {
+	rv := reflect.ValueOf(rows).Elem()
+	if rv.Len() <= p.Limit {
+		return nil, nil
+	}
+
+	rv.Set(rv.Slice(0, p.Limit))
+
+	return NewCursor(p.Sort, rv.Index(p.Limit-1).Interface())
+}
+
+// NewCursor creates a cursor positioned at row, a model with a field for each
+// column of the sort
+func NewCursor(sort Sort, row interface{}) (*Cursor, error) {
+	rv := reflect.Indirect(reflect.ValueOf(row))
+	cursor := &Cursor{Sort: sort}
+
+	for _, column := range sort {
+		field, ok := fieldByColumn(rv, column)
+		if !ok {
+			return nil, fmt.Errorf("pagination: %s has no column %s", rv.Type(), column)
+		}
+
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				return nil, fmt.Errorf("pagination: column %s is null", column)
+			}
+			field = field.Elem()
+		}
+
+		cursor.Values = append(cursor.Values, field.Interface())
+	}
+
+	return cursor, nil
+}
+
+// MarshalJSON encodes the cursor's values along with their types
+func (c Cursor) MarshalJSON() ([]byte, error) {
+	encoded := cursorJSON{Sort: c.Sort.String()}
+
+	for _, v := range c.Values {
+		var tagged value
+
+		switch v := v.(type) {
+		case time.Time:
+			tagged.Time = &v
+		case string:
+			tagged.String = &v
+		case float64:
+			tagged.Float = &v
+		case int:
+			tagged.Int = &v
+		default:
+			return nil, fmt.Errorf("pagination: cannot encode a %T cursor value", v)
+		}
+
+		encoded.Values = append(encoded.Values, tagged)
+	}
+
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes the cursor's values back into their types
+func (c *Cursor) UnmarshalJSON(data []byte) error {
+	decoded := cursorJSON{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	c.Sort = ParseSort(decoded.Sort)
+	c.Values = nil
+
+	for _, tagged := range decoded.Values {
+		switch {
+		case tagged.Time != nil:
+			c.Values = append(c.Values, *tagged.Time)
+		case tagged.String != nil:
+			c.Values = append(c.Values, *tagged.String)
+		case tagged.Float != nil:
+			c.Values = append(c.Values, *tagged.Float)
+		case tagged.Int != nil:
+			c.Values = append(c.Values, *tagged.Int)
+		default:
+			return errors.New("pagination: cursor value has no type")
+		}
+	}
+
+	if len(c.Values) != len(c.Sort) {
+		return errors.New("pagination: cursor must have a value for each sort column")
+	}
+
+	return nil
+}
+
+func fieldByColumn(rv reflect.Value, column string) (reflect.Value, bool) {
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+
+	for i := 0; i < rv.NumField(); i++ {
+		// untagged fields are their lowercase name, as sqlx maps them
+		name := rv.Type().Field(i).Tag.Get("db")
+		if name == "" {
+			name = strings.ToLower(rv.Type().Field(i).Name)
+		}
+
+		if name == column {
+			return rv.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+func contains(columns []string, column string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,81 @@
+package pagination_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ucladevx/BPool/utils/pagination"
+)
+
+type row struct {
+	ID        string
+	Price     float64   `db:"price_per_seat"`
+	StartDate time.Time `db:"start_date"`
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+	codec := pagination.NewCodec("secret")
+
+	start := time.Date(2018, 3, 1, 9, 30, 0, 0, time.UTC)
+	cursor, err := pagination.NewCursor(pagination.Sort{"start_date", "price_per_seat", "id"}, &row{
+		ID:        "abc",
+		Price:     12.5,
+		StartDate: start,
+	})
+	assert.Nil(err)
+
+	encoded, err := codec.Encode(cursor)
+	assert.Nil(err)
+
+	decoded := pagination.Cursor{}
+	assert.Nil(codec.Decode(encoded, &decoded))
+	assert.Equal(pagination.Sort{"start_date", "price_per_seat", "id"}, decoded.Sort)
+	assert.Equal([]interface{}{start, 12.5, "abc"}, decoded.Values, "values should decode to the same types")
+
+	other := pagination.NewCodec("other")
+	assert.Equal(pagination.ErrInvalidCursor, other.Decode(encoded, &decoded), "a cursor signed with another secret is invalid")
+
+	tampered := "x" + encoded
+	assert.Equal(pagination.ErrInvalidCursor, codec.Decode(tampered, &decoded), "a changed cursor is invalid")
+	assert.Equal(pagination.ErrInvalidCursor, codec.Decode("garbage", &decoded))
+}
+
+func TestPageValidate(t *testing.T) {
+	tables := []struct {
+		name string
+		page pagination.Page
+		err  error
+	}{
+		{"by id", pagination.Page{Sort: pagination.ByID}, nil},
+		{"allowed column", pagination.Page{Sort: pagination.ParseSort("start_date")}, nil},
+		{"unknown column", pagination.Page{Sort: pagination.ParseSort("info")}, pagination.ErrInvalidSort},
+		{"not ending in id", pagination.Page{Sort: pagination.Sort{"start_date"}}, pagination.ErrInvalidSort},
+		{
+			"cursor for another sort",
+			pagination.Page{Sort: pagination.ByID, After: &pagination.Cursor{Sort: pagination.ParseSort("start_date")}},
+			pagination.ErrSortMismatch,
+		},
+	}
+
+	for _, table := range tables {
+		err := table.page.Validate("start_date")
+		assert.Equal(t, table.err, err, table.name)
+	}
+}
+
+func TestPageTrim(t *testing.T) {
+	assert := assert.New(t)
+	page := pagination.Page{Sort: pagination.ByID, Limit: 2}
+
+	rows := []*row{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	next, err := page.Trim(&rows)
+	assert.Nil(err)
+	assert.Equal(2, len(rows), "rows should be cut down to the limit")
+	assert.Equal([]interface{}{"b"}, next.Values, "the next page should start after the last row")
+
+	next, err = page.Trim(&rows)
+	assert.Nil(err)
+	assert.Nil(next, "there is no next page when there are no extra rows")
+}